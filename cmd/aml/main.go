@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/isoforest"
+	"AML/internal/models"
+	"AML/internal/services"
+)
+
+const usage = `Usage: aml <command> [flags]
+
+Commands:
+  auth token     Issue an API token for an actor and print the digest to add to auth.json
+  ctr export     Generate the Currency Transaction Reports due for a period and write them as a batch
+  keyring init   Generate a keyring with new signing and encryption keys for exports
+  model train    Fit the isolation forest anomaly model on stored transactions
+  sar draft      Draft a SAR narrative for a case or alerts for review and editing
+  sar export     Write a stored SAR, or one generated for a case or alerts, in a filing format, signed
+  sar verify     Check an exported SAR against its signed manifest before submission
+`
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] + " " + os.Args[2] {
+	case "auth token":
+		authToken(os.Args[3:])
+	case "ctr export":
+		ctrExport(os.Args[3:])
+	case "keyring init":
+		keyringInit(os.Args[3:])
+	case "model train":
+		modelTrain(os.Args[3:])
+	case "sar draft":
+		sarDraft(os.Args[3:])
+	case "sar export":
+		sarExport(os.Args[3:])
+	case "sar verify":
+		sarVerify(os.Args[3:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// authToken issues a random API token for an actor. Only the token's digest goes in auth.json,
+// so the token is shown once and must be handed to the actor.
+func authToken(args []string) {
+	fs := flag.NewFlagSet("auth token", flag.ExitOnError)
+	actor := fs.String("actor", "", "actor ID the token authenticates, as listed in auth.json")
+	fs.Parse(args)
+	if *actor == "" {
+		log.Fatalf("-actor is required")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Failed to generate token: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	fmt.Printf("Token for %s (shown once): %s\n", *actor, token)
+	fmt.Printf("Set \"token_sha256\": %q on actor %s in auth.json\n", config.HashAuthToken(token), *actor)
+}
+
+// ctrExport writes the CTRs due for the business days from -from to -to as a JSON or CSV batch.
+func ctrExport(args []string) {
+	fs := flag.NewFlagSet("ctr export", flag.ExitOnError)
+	driver := fs.String("driver", "sqlite3", "database/sql driver name")
+	dsn := fs.String("dsn", "aml.db", "database connection string")
+	configPath := fs.String("config", "ctr.json", "CTR threshold and cash transaction settings")
+	from := fs.String("from", "", "first business day to report, YYYY-MM-DD")
+	to := fs.String("to", "", "last business day to report, YYYY-MM-DD; defaults to -from")
+	format := fs.String("format", "json", "output format: json or csv")
+	out := fs.String("out", "ctr.out", "path to write the CTR batch")
+	fs.Parse(args)
+
+	cfg, err := config.LoadCTRConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load CTR config: %v", err)
+	}
+	if *to == "" {
+		*to = *from
+	}
+	fromDate, err := time.Parse("2006-01-02", *from)
+	if err != nil {
+		log.Fatalf("Invalid -from date %q", *from)
+	}
+	toDate, err := time.Parse("2006-01-02", *to)
+	if err != nil {
+		log.Fatalf("Invalid -to date %q", *to)
+	}
+
+	db, err := database.Open(*driver, *dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctrs, err := services.GenerateCTRs(db, cfg, fromDate, toDate)
+	if err != nil {
+		log.Fatalf("Failed to generate CTRs: %v", err)
+	}
+
+	switch *format {
+	case "json":
+		err = services.ExportCTRsToJSON(ctrs, *out)
+	case "csv":
+		err = services.ExportCTRsToCSV(ctrs, *out)
+	default:
+		log.Fatalf("Unknown format %q", *format)
+	}
+	if err != nil {
+		log.Fatalf("Failed to export CTRs: %v", err)
+	}
+	fmt.Printf("Wrote %d CTRs for %s to %s to %s\n", len(ctrs), *from, *to, *out)
+}
+
+// modelTrain fits an anomaly model on the transaction history and writes it to disk.
+func modelTrain(args []string) {
+	fs := flag.NewFlagSet("model train", flag.ExitOnError)
+	driver := fs.String("driver", "sqlite3", "database/sql driver name")
+	dsn := fs.String("dsn", "aml.db", "database connection string")
+	out := fs.String("out", "anomaly_model.json", "path to write the trained model")
+	countryRiskPath := fs.String("country-risk", "country_risk.json", "country risk table")
+	defaults := isoforest.DefaultConfig()
+	trees := fs.Int("trees", defaults.NumTrees, "number of isolation trees")
+	sampleSize := fs.Int("sample-size", defaults.SampleSize, "observations sampled per tree")
+	seed := fs.Int64("seed", defaults.Seed, "random seed")
+	contamination := fs.Float64("contamination", 0.01, "expected fraction of anomalies, sets the alert threshold")
+	fs.Parse(args)
+
+	risk, err := config.LoadCountryRisk(*countryRiskPath)
+	if err != nil {
+		log.Fatalf("Failed to load country risk: %v", err)
+	}
+
+	db, err := database.Open(*driver, *dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	history, err := services.LoadTransactionHistory(db)
+	if err != nil {
+		log.Fatalf("Failed to load transaction history: %v", err)
+	}
+	fmt.Printf("Loaded %d transactions\n", len(history))
+
+	cfg := isoforest.Config{NumTrees: *trees, SampleSize: *sampleSize, Seed: *seed}
+	model, err := services.TrainAnomalyModel(history, risk, cfg, *contamination)
+	if err != nil {
+		log.Fatalf("Failed to train anomaly model: %v", err)
+	}
+
+	if err := services.SaveAnomalyModel(model, *out); err != nil {
+		log.Fatalf("Failed to save anomaly model: %v", err)
+	}
+	fmt.Printf("Trained %d trees on %d transactions, threshold %.4f, written to %s\n",
+		len(model.Forest.Trees), model.SampleCount, model.Threshold, *out)
+}
+
+// sarSource holds the flags that pick the alerts a SAR reports on.
+type sarSource struct {
+	driver   *string
+	dsn      *string
+	caseID   *string
+	alertIDs *string
+}
+
+func addSARSourceFlags(fs *flag.FlagSet) sarSource {
+	return sarSource{
+		driver:   fs.String("driver", "sqlite3", "database/sql driver name"),
+		dsn:      fs.String("dsn", "aml.db", "database connection string"),
+		caseID:   fs.String("case", "", "case to report on"),
+		alertIDs: fs.String("alerts", "", "comma-separated alert IDs to report on, instead of a case"),
+	}
+}
+
+// load generates the SAR for the selected case or alerts, exiting if there is nothing to report.
+func (s sarSource) load() *models.SARReport {
+	if (*s.caseID == "") == (*s.alertIDs == "") {
+		log.Fatalf("Exactly one of -case or -alerts is required")
+	}
+
+	db, err := database.Open(*s.driver, *s.dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var report *models.SARReport
+	if *s.caseID != "" {
+		report, err = services.GenerateCaseSARData(*s.caseID, db)
+	} else {
+		report, err = services.GenerateSARData(strings.Split(*s.alertIDs, ","), db)
+	}
+	if err != nil {
+		log.Fatalf("Failed to generate SAR: %v", err)
+	}
+	if report == nil {
+		log.Fatalf("No suspicious transactions found to report")
+	}
+	return report
+}
+
+// loadSARForFiling returns the report of a stored SAR that is approved or filed, exiting otherwise.
+func loadSARForFiling(driver, dsn, id string) *models.SARReport {
+	db, err := database.Open(driver, dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	sar, err := services.LoadSARForFiling(db, id)
+	if err != nil {
+		log.Fatalf("Failed to load SAR: %v", err)
+	}
+	return sar.Report
+}
+
+// draftNarrative drafts a narrative for a report from the templates in configPath.
+func draftNarrative(report *models.SARReport, configPath string) string {
+	cfg, err := config.LoadNarrativeConfig(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load narrative config: %v", err)
+	}
+	generator, err := services.NewNarrativeGenerator(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure narrative templates: %v", err)
+	}
+	narrative, err := generator.Draft(report)
+	if err != nil {
+		log.Fatalf("Failed to draft narrative: %v", err)
+	}
+	return narrative
+}
+
+// sarDraft writes a drafted SAR narrative to a text file for the investigator to edit and pass
+// back to sar export with -narrative.
+func sarDraft(args []string) {
+	fs := flag.NewFlagSet("sar draft", flag.ExitOnError)
+	source := addSARSourceFlags(fs)
+	narrativePath := fs.String("narrative-config", "narrative.json", "narrative templates")
+	out := fs.String("out", "narrative.txt", "path to write the draft narrative")
+	fs.Parse(args)
+
+	report := source.load()
+	if err := os.WriteFile(*out, []byte(draftNarrative(report, *narrativePath)+"\n"), 0600); err != nil {
+		log.Fatalf("Failed to write narrative: %v", err)
+	}
+	fmt.Printf("Wrote draft narrative covering %d patterns to %s\n", len(report.Patterns), *out)
+}
+
+// sarExport writes a SAR in the chosen format, encrypted when -encrypt is set, with a SHA-256
+// manifest and a detached signature beside it. Filing formats export a stored SAR that has been
+// approved, or its snapshot once filed; the other formats may also generate a report from a case
+// or a list of alerts for review.
+func sarExport(args []string) {
+	fs := flag.NewFlagSet("sar export", flag.ExitOnError)
+	source := addSARSourceFlags(fs)
+	sarID := fs.String("sar", "", "approved or filed SAR to export; required for fincen and goaml")
+	format := fs.String("format", "json", "output format: json, fincen, goaml, html or pdf")
+	fincenPath := fs.String("fincen", "fincen.json", "FinCEN filer configuration")
+	goamlPath := fs.String("goaml", "goaml.json", "goAML reporting-entity profile")
+	narrativeFile := fs.String("narrative", "", "reviewed narrative text; drafted from -narrative-config when empty")
+	narrativePath := fs.String("narrative-config", "narrative.json", "narrative templates")
+	keyringPath := fs.String("keyring", "keyring.json", "keys that sign and encrypt the export")
+	encrypt := fs.Bool("encrypt", false, "encrypt the export with the keyring's encryption key")
+	out := fs.String("out", "sar.out", "path to write the SAR")
+	fs.Parse(args)
+
+	keyring, err := config.LoadKeyring(*keyringPath)
+	if err != nil {
+		log.Fatalf("Failed to load keyring: %v", err)
+	}
+	var report *models.SARReport
+	if *sarID != "" {
+		if *source.caseID != "" || *source.alertIDs != "" || *narrativeFile != "" {
+			log.Fatalf("-sar exports the SAR as approved; -case, -alerts and -narrative don't apply")
+		}
+		report = loadSARForFiling(*source.driver, *source.dsn, *sarID)
+	} else {
+		if *format == "fincen" || *format == "goaml" {
+			log.Fatalf("-sar is required for %s exports: only approved or filed SARs are filed", *format)
+		}
+		report = source.load()
+		if *narrativeFile != "" {
+			text, err := os.ReadFile(*narrativeFile)
+			if err != nil {
+				log.Fatalf("Failed to read narrative: %v", err)
+			}
+			report.Narrative = strings.TrimSpace(string(text))
+		} else {
+			report.Narrative = draftNarrative(report, *narrativePath)
+		}
+	}
+
+	var data []byte
+	var buf bytes.Buffer
+	switch *format {
+	case "json":
+		data, err = services.MarshalSARJSON(report)
+	case "fincen":
+		cfg, cfgErr := config.LoadFinCENConfig(*fincenPath)
+		if cfgErr != nil {
+			log.Fatalf("Failed to load FinCEN config: %v", cfgErr)
+		}
+		exporter, expErr := services.NewFinCENSARExporter(cfg)
+		if expErr != nil {
+			log.Fatalf("Failed to configure FinCEN export: %v", expErr)
+		}
+		data, err = exporter.BuildBatch([]*models.SARReport{report}, time.Now())
+	case "goaml":
+		cfg, cfgErr := config.LoadGoAMLConfig(*goamlPath)
+		if cfgErr != nil {
+			log.Fatalf("Failed to load goAML config: %v", cfgErr)
+		}
+		exporter, expErr := services.NewGoAMLExporter(cfg)
+		if expErr != nil {
+			log.Fatalf("Failed to configure goAML export: %v", expErr)
+		}
+		data, err = exporter.BuildReport(report, time.Now())
+	case "html":
+		err = services.RenderSARHTML(&buf, report, time.Now())
+		data = buf.Bytes()
+	case "pdf":
+		err = services.RenderSARPDF(&buf, report, time.Now())
+		data = buf.Bytes()
+	default:
+		log.Fatalf("Unknown format %q", *format)
+	}
+
+	var verr *services.SARValidationError
+	if errors.As(err, &verr) {
+		fmt.Fprintln(os.Stderr, "SAR is not ready to file:")
+		for _, p := range verr.Problems {
+			fmt.Fprintf(os.Stderr, "  - %s\n", p)
+		}
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf("Failed to export SAR: %v", err)
+	}
+	manifest, err := services.WriteSignedExport(keyring, *out, *format, data, *encrypt, time.Now())
+	if err != nil {
+		log.Fatalf("Failed to write SAR: %v", err)
+	}
+	fmt.Printf("Wrote %s SAR covering %d transactions to %s\n", *format, report.TotalTransactionCount, *out)
+	if manifest.Encryption != nil {
+		fmt.Printf("Encrypted with %s key %s\n", manifest.Encryption.Algorithm, manifest.Encryption.KeyID)
+	}
+	fmt.Printf("Signed manifest with key %s: %s, %s\n", manifest.SigningKeyID,
+		services.ExportManifestPath(*out), services.ExportSignaturePath(*out))
+}
+
+// sarVerify checks an exported SAR against its manifest and signature before it is submitted.
+// Encrypted exports are also decrypted and checked, and written to -out when it is set.
+func sarVerify(args []string) {
+	fs := flag.NewFlagSet("sar verify", flag.ExitOnError)
+	in := fs.String("in", "sar.out", "exported SAR to verify")
+	keyringPath := fs.String("keyring", "keyring.json", "keys that verify and decrypt the export")
+	out := fs.String("out", "", "path to write the decrypted SAR; not written when empty")
+	fs.Parse(args)
+
+	keyring, err := config.LoadKeyring(*keyringPath)
+	if err != nil {
+		log.Fatalf("Failed to load keyring: %v", err)
+	}
+	manifest, content, err := services.VerifyExport(keyring, *in)
+	if errors.Is(err, services.ErrExportInvalid) {
+		fmt.Fprintf(os.Stderr, "%s FAILED verification: %v\n", *in, err)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf("Failed to verify SAR: %v", err)
+	}
+
+	fmt.Printf("%s OK: %s export created %s, sha256 %s, signed by %s\n", *in, manifest.Format,
+		manifest.CreatedAt.Format(time.RFC3339), manifest.SHA256, manifest.SigningKeyID)
+	if manifest.Encryption != nil {
+		if content == nil {
+			fmt.Printf("Encrypted with key %s, which is not on the keyring; contents not checked\n", manifest.Encryption.KeyID)
+			if *out != "" {
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Printf("Decrypted with key %s, plaintext sha256 %s\n", manifest.Encryption.KeyID, manifest.Encryption.PlaintextSHA256)
+	}
+	if *out != "" {
+		if err := os.WriteFile(*out, content, 0600); err != nil {
+			log.Fatalf("Failed to write SAR: %v", err)
+		}
+		fmt.Printf("Wrote verified SAR to %s\n", *out)
+	}
+}
+
+// keyringInit writes a new keyring with a fresh signing key and encryption key. It never
+// overwrites an existing file, since that would lose the keys to earlier exports.
+func keyringInit(args []string) {
+	fs := flag.NewFlagSet("keyring init", flag.ExitOnError)
+	signingKeyID := fs.String("signing-key-id", "sign-"+time.Now().Format("20060102"), "ID of the new signing key")
+	encryptionKeyID := fs.String("encryption-key-id", "enc-"+time.Now().Format("20060102"), "ID of the new encryption key")
+	out := fs.String("out", "keyring.json", "path to write the keyring")
+	fs.Parse(args)
+
+	keyring, err := services.NewKeyring(*signingKeyID, *encryptionKeyID)
+	if err != nil {
+		log.Fatalf("Failed to generate keys: %v", err)
+	}
+	data, err := json.MarshalIndent(keyring, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal keyring: %v", err)
+	}
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Fatalf("Failed to create keyring: %v", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		log.Fatalf("Failed to write keyring: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("Failed to write keyring: %v", err)
+	}
+	fmt.Printf("Wrote keyring with signing key %s and encryption key %s to %s\n", *signingKeyID, *encryptionKeyID, *out)
+}
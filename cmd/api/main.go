@@ -1,13 +1,15 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
-	"anti-money-laundering-system/internal/config"
-	"anti-money-laundering-system/internal/handlers"
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/handlers"
+	"AML/internal/services"
 )
 
 func main() {
@@ -19,10 +21,192 @@ func main() {
 	}
 	fmt.Printf("Loaded %d rules\n", len(rules))
 
-	// Placeholder for database connection
-	var db *sql.DB
+	detectorConfigs, err := config.LoadDetectorConfigs("detectors.json")
+	if err != nil {
+		log.Fatalf("Failed to load detectors: %v", err)
+	}
+	detectors, err := services.BuildDetectors(detectorConfigs)
+	if err != nil {
+		log.Fatalf("Failed to build detectors: %v", err)
+	}
+	fmt.Printf("Enabled %d detectors\n", len(detectors))
+
+	countryRisk, err := config.LoadCountryRisk("country_risk.json")
+	if err != nil {
+		log.Fatalf("Failed to load country risk: %v", err)
+	}
+
+	workflows, err := config.LoadWorkflowConfig("workflows.json")
+	if err != nil {
+		log.Fatalf("Failed to load workflows: %v", err)
+	}
+	if err := services.ConfigureAlertWorkflows(workflows); err != nil {
+		log.Fatalf("Failed to configure workflows: %v", err)
+	}
+	fmt.Printf("Loaded %d alert workflows\n", len(workflows.Workflows))
+
+	dispositions, err := config.LoadDispositionConfig("dispositions.json")
+	if err != nil {
+		log.Fatalf("Failed to load disposition codes: %v", err)
+	}
+	if err := services.ConfigureDispositionCodes(dispositions); err != nil {
+		log.Fatalf("Failed to configure disposition codes: %v", err)
+	}
+	analytics, err := services.NewDispositionAnalytics(dispositions)
+	if err != nil {
+		log.Fatalf("Failed to configure disposition analytics: %v", err)
+	}
+
+	dedup, err := config.LoadDedupConfig("dedup.json")
+	if err != nil {
+		log.Fatalf("Failed to load dedup config: %v", err)
+	}
+	var dedupWindow time.Duration
+	if dedup.Enabled {
+		dedupWindow, _ = dedup.GetWindow()
+	}
+
+	assignment, err := config.LoadAssignmentConfig("assignment.json")
+	if err != nil {
+		log.Fatalf("Failed to load assignment config: %v", err)
+	}
+	var assigner *services.Assigner
+	if assignment.Enabled {
+		assigner, err = services.NewAssigner(assignment.Strategy)
+		if err != nil {
+			log.Fatalf("Failed to configure assignment: %v", err)
+		}
+	}
+
+	scoring, err := config.LoadScoringConfig("scoring.json")
+	if err != nil {
+		log.Fatalf("Failed to load scoring config: %v", err)
+	}
+	var scorer *services.RiskScorer
+	if scoring.Enabled {
+		scorer, err = services.NewRiskScorer(scoring)
+		if err != nil {
+			log.Fatalf("Failed to configure risk scoring: %v", err)
+		}
+	}
+
+	processor := &services.TransactionProcessor{
+		Orchestrator: services.NewOrchestrator(detectors),
+		Detection: services.DetectionContext{
+			Clock:       services.SystemClock{},
+			Rules:       rules,
+			CountryRisk: countryRisk,
+		},
+		DedupWindow:   dedupWindow,
+		AttachToCases: true,
+		Scorer:        scorer,
+		Assigner:      assigner,
+		Correlate:     dedup.Correlate,
+	}
 
-	http.HandleFunc("/transactions", handlers.TransactionHandler(db))
+	slaConfig, err := config.LoadSLAConfig("sla.json")
+	if err != nil {
+		log.Fatalf("Failed to load SLA config: %v", err)
+	}
+	monitor, err := services.NewSLAMonitor(slaConfig, assigner)
+	if err != nil {
+		log.Fatalf("Failed to configure SLA monitor: %v", err)
+	}
 
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	sarConfig, err := config.LoadSARConfig("sar.json")
+	if err != nil {
+		log.Fatalf("Failed to load SAR config: %v", err)
+	}
+	narrativeConfig, err := config.LoadNarrativeConfig("narrative.json")
+	if err != nil {
+		log.Fatalf("Failed to load narrative config: %v", err)
+	}
+	narratives, err := services.NewNarrativeGenerator(narrativeConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure SAR narratives: %v", err)
+	}
+	sarLifecycle, err := services.NewSARLifecycle(sarConfig, narratives)
+	if err != nil {
+		log.Fatalf("Failed to configure SAR lifecycle: %v", err)
+	}
+
+	auth, err := config.LoadAuthConfig("auth.json")
+	if err != nil {
+		log.Fatalf("Failed to load auth config: %v", err)
+	}
+
+	// Placeholder for database connection, opened with database.Open so that every query is
+	// rebound for the driver's placeholder dialect.
+	var db *database.DB
+
+	// The scheduler runs outside any request, so a panic there would take the server down; it is
+	// only started once there is a connection to check against.
+	if slaConfig.Enabled && db != nil {
+		interval, _ := slaConfig.GetCheckInterval()
+		go runSLAChecks(db, monitor, interval)
+	} else if slaConfig.Enabled {
+		log.Printf("SLA checks are not scheduled: no database connection")
+	}
+
+	http.HandleFunc("/transactions", handlers.TransactionHandler(db, processor))
+	http.HandleFunc("/alerts", handlers.ListAlertsHandler(db))
+	http.HandleFunc("/alerts/sla", handlers.AlertDeadlinesHandler(db, monitor))
+	http.HandleFunc("/alerts/{id}", handlers.GetAlertHandler(db))
+	http.HandleFunc("/alerts/{id}/transitions", handlers.TransitionAlertHandler(db))
+	http.HandleFunc("/alerts/{id}/history", handlers.AlertHistoryHandler(db))
+	http.HandleFunc("/alerts/{id}/claim", handlers.ClaimAlertHandler(db))
+	http.HandleFunc("/alerts/{id}/release", handlers.ReleaseAlertHandler(db))
+	http.HandleFunc("/alerts/{id}/assign", handlers.AssignAlertHandler(db, assigner))
+	http.HandleFunc("/teams", handlers.TeamsHandler(db))
+	http.HandleFunc("/teams/{id}/queue", handlers.TeamQueueHandler(db))
+	http.HandleFunc("/investigators", handlers.InvestigatorsHandler(db))
+	http.HandleFunc("/investigators/{id}/queue", handlers.InvestigatorQueueHandler(db))
+	http.HandleFunc("/workload", handlers.WorkloadHandler(db))
+	http.HandleFunc("/cases", handlers.CasesHandler(db))
+	http.HandleFunc("/cases/{id}", handlers.GetCaseHandler(db))
+	http.HandleFunc("/cases/{id}/alerts", handlers.AttachCaseAlertsHandler(db))
+	http.HandleFunc("/cases/{id}/assign", handlers.AssignCaseHandler(db))
+	http.HandleFunc("/cases/{id}/notes", handlers.AddCaseNoteHandler(db))
+	http.HandleFunc("/cases/{id}/attachments", handlers.AddCaseAttachmentHandler(db))
+	http.HandleFunc("/cases/{id}/merge", handlers.MergeCasesHandler(db))
+	http.HandleFunc("/cases/{id}/split", handlers.SplitCaseHandler(db))
+	http.HandleFunc("/cases/{id}/close", handlers.CloseCaseHandler(db))
+	http.HandleFunc("/sars", handlers.SARsHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/continuing-reviews", handlers.ContinuingReviewsHandler(db))
+	http.HandleFunc("/sars/{id}", handlers.GetSARHandler(db))
+	http.HandleFunc("/sars/{id}/narrative", handlers.SARNarrativeHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/{id}/submit", handlers.SubmitSARHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/{id}/approve", handlers.ApproveSARHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/{id}/reject", handlers.RejectSARHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/{id}/file", handlers.FileSARHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/{id}/amend", handlers.AmendSARHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/{id}/continue", handlers.ContinueSARHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/{id}/continuing-review", handlers.CloseContinuingReviewHandler(db, sarLifecycle))
+	http.HandleFunc("/sars/{id}/snapshot", handlers.SARSnapshotHandler(db))
+	http.HandleFunc("/sla/breaches", handlers.SLABreachesHandler(db))
+	http.HandleFunc("/suppressions", handlers.SuppressionsHandler(db))
+	http.HandleFunc("/suppressions/{id}/revoke", handlers.RevokeSuppressionHandler(db))
+	http.HandleFunc("/suppressions/{id}/audit", handlers.SuppressionAuditHandler(db))
+	http.HandleFunc("/dispositions", handlers.DispositionCodesHandler())
+	http.HandleFunc("/analytics/false-positives", handlers.FalsePositiveReportHandler(db, analytics))
+	http.HandleFunc("/analytics/tuning", handlers.ThresholdTuningHandler(db, analytics, rules))
+
+	log.Fatal(http.ListenAndServe(":8080", handlers.Authenticate(auth, http.DefaultServeMux)))
+}
+
+// runSLAChecks looks for SLA breaches every interval. Each breach is acted on in its own
+// database transaction, so one that fails is retried on the next tick without holding back the
+// rest.
+func runSLAChecks(db *database.DB, monitor *services.SLAMonitor, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		breaches, err := monitor.Check(db, time.Now())
+		if err != nil {
+			log.Printf("SLA check failed: %v", err)
+		}
+		if len(breaches) > 0 {
+			log.Printf("Recorded %d SLA breaches", len(breaches))
+		}
+	}
 }
@@ -0,0 +1,72 @@
+package config
+
+import "fmt"
+
+const (
+	// AnomalyMethodZScore scores amounts by their distance from the mean in standard deviations.
+	AnomalyMethodZScore = "zscore"
+	// AnomalyMethodLogZScore scores the natural log of amounts, which suits right-skewed spending.
+	AnomalyMethodLogZScore = "log_zscore"
+	// AnomalyMethodMAD scores amounts against the median and median absolute deviation.
+	AnomalyMethodMAD = "mad"
+)
+
+const (
+	// SegmentTransactionType compares a transaction only with history of the same transaction type.
+	SegmentTransactionType = "transaction_type"
+	// SegmentDayOfWeek compares a transaction only with history from the same day of the week.
+	SegmentDayOfWeek = "day_of_week"
+)
+
+// AnomalyConfig defines how amount anomalies are scored.
+type AnomalyConfig struct {
+	Method     string  `json:"method"`
+	Threshold  float64 `json:"threshold"`
+	MinHistory int     `json:"min_history"`
+	// SegmentBy narrows the baseline to history matching the transaction on each listed dimension.
+	SegmentBy []string `json:"segment_by"`
+	// MinRelativeScale is the smallest spread allowed, as a fraction of the baseline centre,
+	// so that near-constant history yields a finite score.
+	MinRelativeScale float64 `json:"min_relative_scale"`
+}
+
+// DefaultAnomalyConfig returns the configuration matching the original z-score detector.
+func DefaultAnomalyConfig() AnomalyConfig {
+	return AnomalyConfig{
+		Method:           AnomalyMethodZScore,
+		Threshold:        3.0,
+		MinHistory:       10,
+		MinRelativeScale: 0.05,
+	}
+}
+
+// Validate checks the anomaly configuration for unsupported or out-of-range values.
+func (c AnomalyConfig) Validate() error {
+	switch c.Method {
+	case AnomalyMethodZScore, AnomalyMethodLogZScore, AnomalyMethodMAD:
+	default:
+		return fmt.Errorf("unknown anomaly method '%s'", c.Method)
+	}
+	if c.Threshold <= 0 {
+		return fmt.Errorf("threshold must be > 0")
+	}
+	if c.MinHistory < 2 {
+		return fmt.Errorf("min_history must be at least 2")
+	}
+	if c.MinRelativeScale <= 0 {
+		return fmt.Errorf("min_relative_scale must be > 0")
+	}
+	seen := make(map[string]bool)
+	for _, segment := range c.SegmentBy {
+		switch segment {
+		case SegmentTransactionType, SegmentDayOfWeek:
+		default:
+			return fmt.Errorf("unknown segment_by value '%s'", segment)
+		}
+		if seen[segment] {
+			return fmt.Errorf("duplicate segment_by value '%s'", segment)
+		}
+		seen[segment] = true
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Assignment strategy names.
+const (
+	AssignmentRoundRobin  = "round_robin"
+	AssignmentLeastLoaded = "least_loaded"
+	AssignmentSkillBased  = "skill_based"
+)
+
+// AssignmentConfig controls how new alerts are assigned to investigators.
+type AssignmentConfig struct {
+	// Enabled turns automatic assignment on; when off new alerts wait in their team queue to be
+	// claimed.
+	Enabled bool `json:"enabled"`
+	// Strategy picks the investigator among those eligible for an alert.
+	Strategy string `json:"strategy"`
+}
+
+// DefaultAssignmentConfig assigns each new alert to the eligible investigator with the fewest
+// open alerts.
+func DefaultAssignmentConfig() AssignmentConfig {
+	return AssignmentConfig{
+		Enabled:  true,
+		Strategy: AssignmentLeastLoaded,
+	}
+}
+
+// LoadAssignmentConfig loads alert assignment settings from a JSON file.
+// Fields omitted from the file keep their default values.
+func LoadAssignmentConfig(filepath string) (AssignmentConfig, error) {
+	cfg := DefaultAssignmentConfig()
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read assignment config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse assignment config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("assignment config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the assignment configuration.
+func (c AssignmentConfig) Validate() error {
+	switch c.Strategy {
+	case AssignmentRoundRobin, AssignmentLeastLoaded, AssignmentSkillBased:
+		return nil
+	default:
+		return fmt.Errorf("unknown strategy '%s'", c.Strategy)
+	}
+}
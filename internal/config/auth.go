@@ -0,0 +1,87 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// AuthConfig lists the actors allowed to call the API, how each is authenticated and the roles
+// they act with. Roles are only ever taken from here, never from the request.
+type AuthConfig struct {
+	Actors []AuthActor `json:"actors"`
+	// TrustedProxyHeader, when set, names a header carrying the actor ID established by an
+	// authenticating reverse proxy, used instead of API tokens. Only set it when the API can be
+	// reached solely through that proxy and the proxy overwrites the header on every request.
+	TrustedProxyHeader string `json:"trusted_proxy_header,omitempty"`
+}
+
+// AuthActor is a user or system allowed to call the API.
+type AuthActor struct {
+	// ID is recorded as the actor of every change they make; investigators use their
+	// investigator ID.
+	ID    string   `json:"id"`
+	Roles []string `json:"roles"`
+	// TokenSHA256 is the hex SHA-256 digest of the actor's API token. The token itself is not
+	// stored; `aml auth token` issues one.
+	TokenSHA256 string `json:"token_sha256,omitempty"`
+}
+
+// LoadAuthConfig loads API authentication settings from a JSON file.
+func LoadAuthConfig(filepath string) (AuthConfig, error) {
+	var cfg AuthConfig
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read auth config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse auth config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("auth config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that actor IDs and token digests are well formed and unique.
+func (c AuthConfig) Validate() error {
+	ids := make(map[string]bool)
+	tokens := make(map[string]bool)
+	for _, a := range c.Actors {
+		if strings.TrimSpace(a.ID) == "" {
+			return fmt.Errorf("actor id is required")
+		}
+		if ids[a.ID] {
+			return fmt.Errorf("duplicate actor '%s'", a.ID)
+		}
+		ids[a.ID] = true
+		if a.TokenSHA256 == "" {
+			continue
+		}
+		if b, err := hex.DecodeString(a.TokenSHA256); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("actor '%s': token_sha256 must be a hex SHA-256 digest", a.ID)
+		}
+		if tokens[strings.ToLower(a.TokenSHA256)] {
+			return fmt.Errorf("actor '%s': token_sha256 is shared with another actor", a.ID)
+		}
+		tokens[strings.ToLower(a.TokenSHA256)] = true
+	}
+	if c.TrustedProxyHeader != "" && http.CanonicalHeaderKey(c.TrustedProxyHeader) == "Authorization" {
+		return fmt.Errorf("trusted_proxy_header must not be Authorization")
+	}
+	return nil
+}
+
+// HashAuthToken returns the digest an API token is stored as.
+func HashAuthToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// CountryRisk assigns a risk weight between 0 and 1 to ISO country codes.
+type CountryRisk struct {
+	// Default applies to countries not listed explicitly.
+	Default   float64            `json:"default"`
+	Countries map[string]float64 `json:"countries"`
+}
+
+// Score returns the risk weight for a country code.
+func (c CountryRisk) Score(country string) float64 {
+	if risk, ok := c.Countries[strings.ToUpper(country)]; ok {
+		return risk
+	}
+	return c.Default
+}
+
+// LoadCountryRisk loads and validates a country risk table from a JSON file.
+func LoadCountryRisk(filepath string) (CountryRisk, error) {
+	var risk CountryRisk
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return risk, fmt.Errorf("failed to read country risk file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &risk); err != nil {
+		return risk, fmt.Errorf("failed to parse country risk file: %w", err)
+	}
+
+	if risk.Default < 0 || risk.Default > 1 {
+		return risk, fmt.Errorf("country risk validation failed: default must be between 0 and 1")
+	}
+	normalised := make(map[string]float64, len(risk.Countries))
+	for country, value := range risk.Countries {
+		if value < 0 || value > 1 {
+			return risk, fmt.Errorf("country risk validation failed: risk for '%s' must be between 0 and 1", country)
+		}
+		normalised[strings.ToUpper(country)] = value
+	}
+	risk.Countries = normalised
+
+	return risk, nil
+}
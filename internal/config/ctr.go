@@ -0,0 +1,159 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// CTRConfig controls which cash transactions are aggregated for Currency Transaction Reports and
+// when a day's total must be reported.
+type CTRConfig struct {
+	// Threshold is the daily cash-in or cash-out total a person must exceed to be reported.
+	Threshold float64 `json:"threshold"`
+	// Currency is the currency totals are kept and compared in.
+	Currency string `json:"currency"`
+	// ExchangeRates converts other currencies to Currency: one unit of the keyed currency is worth
+	// this many units of Currency.
+	ExchangeRates map[string]float64 `json:"exchange_rates"`
+	// CashInTypes and CashOutTypes are the transaction types counted as cash received from and
+	// paid out to a customer. Cash in and cash out are totalled separately.
+	CashInTypes  []string `json:"cash_in_types"`
+	CashOutTypes []string `json:"cash_out_types"`
+	// Statuses are the transaction statuses that count towards the totals.
+	Statuses []string `json:"statuses"`
+	// TimeZone is the IANA time zone the business-day calendar is kept in.
+	TimeZone string `json:"time_zone"`
+	// Cutoff is the local time, as HH:MM, that ends a business day; transactions at or after it
+	// count towards the next business day. Empty means midnight.
+	Cutoff string `json:"cutoff,omitempty"`
+	// ClosedWeekdays and Holidays (YYYY-MM-DD) are not business days. Transactions on them count
+	// towards the next business day.
+	ClosedWeekdays []string `json:"closed_weekdays"`
+	Holidays       []string `json:"holidays"`
+}
+
+// DefaultCTRConfig reports people whose cash in or cash out exceeds 10,000 USD in a business day
+// ending at midnight in New York, with weekend activity counted on the following Monday.
+func DefaultCTRConfig() CTRConfig {
+	return CTRConfig{
+		Threshold:      10000,
+		Currency:       "USD",
+		CashInTypes:    []string{"CASH", "CASH_DEPOSIT"},
+		CashOutTypes:   []string{"CASH_WITHDRAWAL"},
+		Statuses:       []string{"COMPLETED"},
+		TimeZone:       "America/New_York",
+		ClosedWeekdays: []string{"Saturday", "Sunday"},
+	}
+}
+
+// LoadCTRConfig loads CTR settings from a JSON file.
+// Fields omitted from the file keep their default values.
+func LoadCTRConfig(filepath string) (CTRConfig, error) {
+	cfg := DefaultCTRConfig()
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read CTR config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse CTR config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("CTR config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the threshold, currencies and business-day calendar, and that some cash is
+// counted.
+func (c CTRConfig) Validate() error {
+	if c.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	if !currencyCodePattern.MatchString(c.Currency) {
+		return fmt.Errorf("invalid currency '%s'", c.Currency)
+	}
+	for currency, rate := range c.ExchangeRates {
+		if !currencyCodePattern.MatchString(currency) || rate <= 0 {
+			return fmt.Errorf("invalid exchange rate for '%s'", currency)
+		}
+	}
+	if len(c.CashInTypes) == 0 && len(c.CashOutTypes) == 0 {
+		return fmt.Errorf("cash_in_types or cash_out_types is required")
+	}
+	seen := make(map[string]bool)
+	for _, t := range append(append([]string{}, c.CashInTypes...), c.CashOutTypes...) {
+		if seen[t] {
+			return fmt.Errorf("transaction type '%s' is listed more than once", t)
+		}
+		seen[t] = true
+	}
+	if len(c.Statuses) == 0 {
+		return fmt.Errorf("statuses is required")
+	}
+	if _, err := c.Location(); err != nil {
+		return err
+	}
+	if _, _, err := c.CutoffTime(); err != nil {
+		return err
+	}
+	closed, err := c.ClosedWeekdaySet()
+	if err != nil {
+		return err
+	}
+	if len(closed) == 7 {
+		return fmt.Errorf("closed_weekdays must leave at least one business day")
+	}
+	for _, h := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", h); err != nil {
+			return fmt.Errorf("invalid holiday '%s'", h)
+		}
+	}
+	return nil
+}
+
+// CutoffTime returns the local hour and minute that end a business day; both are zero for
+// midnight.
+func (c CTRConfig) CutoffTime() (hour, minute int, err error) {
+	if c.Cutoff == "" {
+		return 0, 0, nil
+	}
+	t, err := time.Parse("15:04", c.Cutoff)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid cutoff '%s'", c.Cutoff)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// ClosedWeekdaySet returns the weekdays that are not business days.
+func (c CTRConfig) ClosedWeekdaySet() (map[time.Weekday]bool, error) {
+	closed := make(map[time.Weekday]bool)
+	for _, name := range c.ClosedWeekdays {
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(name, d.String()) {
+				closed[d] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid closed weekday '%s'", name)
+		}
+	}
+	return closed, nil
+}
+
+// Location returns the time zone business days are counted in.
+func (c CTRConfig) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time_zone '%s': %w", c.TimeZone, err)
+	}
+	return loc, nil
+}
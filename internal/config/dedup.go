@@ -0,0 +1,64 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// DedupConfig controls how repeated findings for the same account and rule are merged.
+type DedupConfig struct {
+	// Enabled turns deduplication on; when off every finding raises its own alert.
+	Enabled bool `json:"enabled"`
+	// Window is how long after an alert is raised new findings are appended to it instead of
+	// raising a fresh alert, as long as the alert is still open.
+	Window string `json:"window"`
+	// Correlate merges the findings raised together for one transaction into a single composite
+	// alert. Each finding is still checked against suppressions and kept on the alert.
+	Correlate bool `json:"correlate"`
+}
+
+// DefaultDedupConfig merges repeat findings into an open alert raised within the last day.
+func DefaultDedupConfig() DedupConfig {
+	return DedupConfig{
+		Enabled: true,
+		Window:  "24h",
+	}
+}
+
+// LoadDedupConfig loads alert deduplication settings from a JSON file.
+// Fields omitted from the file keep their default values.
+func LoadDedupConfig(filepath string) (DedupConfig, error) {
+	cfg := DefaultDedupConfig()
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read dedup config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse dedup config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("dedup config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the deduplication configuration.
+func (c DedupConfig) Validate() error {
+	_, err := c.GetWindow()
+	return err
+}
+
+// GetWindow returns the parsed deduplication window.
+func (c DedupConfig) GetWindow() (time.Duration, error) {
+	d, err := time.ParseDuration(c.Window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window '%s'", c.Window)
+	}
+	return d, nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// DetectorConfig enables a registered detector and carries its detector-specific parameters.
+type DetectorConfig struct {
+	DetectorID string          `json:"detector_id"`
+	Enabled    bool            `json:"enabled"`
+	Params     json.RawMessage `json:"params,omitempty"`
+}
+
+// StructuringConfig defines the smurfing pattern searched for by the structuring detector.
+type StructuringConfig struct {
+	TimeWindow    string  `json:"time_window"`
+	ThresholdLow  float64 `json:"threshold_low"`
+	ThresholdHigh float64 `json:"threshold_high"`
+	MinCount      int     `json:"min_count"`
+}
+
+// DefaultStructuringConfig looks for three deposits just under $10,000 within a day.
+func DefaultStructuringConfig() StructuringConfig {
+	return StructuringConfig{
+		TimeWindow:    "24h",
+		ThresholdLow:  8000,
+		ThresholdHigh: 9999.99,
+		MinCount:      3,
+	}
+}
+
+// Validate checks the structuring configuration for out-of-range values.
+func (c StructuringConfig) Validate() error {
+	if _, err := c.GetTimeWindow(); err != nil {
+		return err
+	}
+	if c.ThresholdLow < 0 || c.ThresholdHigh <= c.ThresholdLow {
+		return fmt.Errorf("threshold_high must be greater than threshold_low")
+	}
+	if c.MinCount < 2 {
+		return fmt.Errorf("min_count must be at least 2")
+	}
+	return nil
+}
+
+// GetTimeWindow returns the parsed time window.
+func (c StructuringConfig) GetTimeWindow() (time.Duration, error) {
+	d, err := time.ParseDuration(c.TimeWindow)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid time_window '%s'", c.TimeWindow)
+	}
+	return d, nil
+}
+
+// LoadDetectorConfigs loads the detector list from a JSON file.
+func LoadDetectorConfigs(filepath string) ([]DetectorConfig, error) {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read detector config file: %w", err)
+	}
+
+	var detectors []DetectorConfig
+	if err := json.Unmarshal(data, &detectors); err != nil {
+		return nil, fmt.Errorf("failed to parse detector config file: %w", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, d := range detectors {
+		if d.DetectorID == "" {
+			return nil, fmt.Errorf("detector config validation failed: detector_id is required")
+		}
+		if ids[d.DetectorID] {
+			return nil, fmt.Errorf("detector config validation failed: duplicate detector_id: '%s'", d.DetectorID)
+		}
+		ids[d.DetectorID] = true
+	}
+
+	return detectors, nil
+}
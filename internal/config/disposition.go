@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// Disposition outcomes say whether an alert turned out to be suspicious.
+const (
+	DispositionFalsePositive = "FALSE_POSITIVE"
+	DispositionTruePositive  = "TRUE_POSITIVE"
+)
+
+// DispositionCode is a reason an analyst may give for closing an alert.
+type DispositionCode struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	// Outcome is DispositionFalsePositive or DispositionTruePositive.
+	Outcome string `json:"outcome"`
+	// Statuses limits the final statuses the code may close an alert to; any when empty.
+	Statuses []string `json:"statuses,omitempty"`
+}
+
+// DispositionConfig lists the disposition codes and how false-positive analytics and threshold
+// tuning slice them.
+type DispositionConfig struct {
+	Codes []DispositionCode `json:"codes"`
+	// ThresholdBands are the upper edges of the bands alerts are grouped in by how far the observed
+	// value exceeded the rule threshold, as a ratio of actual to threshold value.
+	ThresholdBands []float64 `json:"threshold_bands"`
+	// TuningSteps are the multipliers of the current threshold the recommender tries.
+	TuningSteps []float64 `json:"tuning_steps"`
+	// MaxTruePositiveLoss is the largest share of true positives a recommended threshold may drop.
+	MaxTruePositiveLoss float64 `json:"max_true_positive_loss"`
+}
+
+// DefaultDispositionConfig returns a small catalogue of false- and true-positive codes.
+func DefaultDispositionConfig() DispositionConfig {
+	return DispositionConfig{
+		Codes: []DispositionCode{
+			{Code: "FP_KNOWN_CUSTOMER", Description: "Activity is expected for a known customer", Outcome: DispositionFalsePositive},
+			{Code: "FP_DATA_ERROR", Description: "Alert was raised on incorrect data", Outcome: DispositionFalsePositive},
+			{Code: "FP_RULE_TOO_SENSITIVE", Description: "Rule fired on ordinary activity", Outcome: DispositionFalsePositive},
+			{Code: "TP_SAR_FILED", Description: "Suspicious activity reported", Outcome: DispositionTruePositive, Statuses: []string{"CLOSED"}},
+			{Code: "TP_NO_SAR", Description: "Unusual activity that did not warrant a report", Outcome: DispositionTruePositive, Statuses: []string{"CLOSED"}},
+		},
+		ThresholdBands:      []float64{1.1, 1.25, 1.5, 2},
+		TuningSteps:         []float64{1.1, 1.2, 1.25, 1.5, 2},
+		MaxTruePositiveLoss: 0.05,
+	}
+}
+
+// LoadDispositionConfig loads disposition codes and tuning settings from a JSON file.
+// Fields omitted from the file keep their default values.
+func LoadDispositionConfig(filepath string) (DispositionConfig, error) {
+	cfg := DefaultDispositionConfig()
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read disposition config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse disposition config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("disposition config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that codes are unique and have a known outcome, and that bands and steps are
+// increasing.
+func (c DispositionConfig) Validate() error {
+	if len(c.Codes) == 0 {
+		return fmt.Errorf("at least one disposition code is required")
+	}
+	seen := make(map[string]bool, len(c.Codes))
+	for _, d := range c.Codes {
+		if d.Code == "" {
+			return fmt.Errorf("disposition code is required")
+		}
+		if seen[d.Code] {
+			return fmt.Errorf("duplicate disposition code '%s'", d.Code)
+		}
+		seen[d.Code] = true
+		if d.Outcome != DispositionFalsePositive && d.Outcome != DispositionTruePositive {
+			return fmt.Errorf("disposition code '%s': unknown outcome '%s'", d.Code, d.Outcome)
+		}
+	}
+
+	if !sort.Float64sAreSorted(c.ThresholdBands) || (len(c.ThresholdBands) > 0 && c.ThresholdBands[0] <= 1) {
+		return fmt.Errorf("threshold_bands must be increasing ratios above 1")
+	}
+	if !sort.Float64sAreSorted(c.TuningSteps) || (len(c.TuningSteps) > 0 && c.TuningSteps[0] <= 1) {
+		return fmt.Errorf("tuning_steps must be increasing multipliers above 1")
+	}
+	if c.MaxTruePositiveLoss < 0 || c.MaxTruePositiveLoss > 1 {
+		return fmt.Errorf("max_true_positive_loss must be between 0 and 1")
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// FinCENInstitution identifies the institution filing SARs and where the activity took place.
+type FinCENInstitution struct {
+	Name string `json:"name"`
+	// TIN is the institution's EIN.
+	TIN     string `json:"tin"`
+	Address string `json:"address"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	ZIP     string `json:"zip"`
+	Country string `json:"country"`
+	// RegulatorCode is the FinCEN code of the institution's primary federal regulator.
+	RegulatorCode string `json:"regulator_code"`
+}
+
+// FinCENClassification is the suspicious activity category and subcategory reported for an alert
+// type.
+type FinCENClassification struct {
+	TypeID    int `json:"type_id"`
+	SubtypeID int `json:"subtype_id"`
+	// OtherText describes the activity when the subcategory is an "other" code.
+	OtherText string `json:"other_text,omitempty"`
+}
+
+// FinCENConfig holds the filer details and code mappings used to export SARs as FinCEN BSA XML.
+type FinCENConfig struct {
+	// TransmitterControlCode is the TCC FinCEN assigned to the transmitter of the batch.
+	TransmitterControlCode string            `json:"transmitter_control_code"`
+	Filer                  FinCENInstitution `json:"filer"`
+	ContactOffice          string            `json:"contact_office"`
+	ContactPhone           string            `json:"contact_phone"`
+	// Classifications maps alert types to the activity classification reported for them.
+	Classifications map[string]FinCENClassification `json:"classifications"`
+	// DefaultClassification applies to alert types without a mapping.
+	DefaultClassification FinCENClassification `json:"default_classification"`
+}
+
+// LoadFinCENConfig loads FinCEN filer details from a JSON file.
+func LoadFinCENConfig(filepath string) (FinCENConfig, error) {
+	var cfg FinCENConfig
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read FinCEN config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse FinCEN config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("FinCEN config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that the filer is identified and every classification has codes.
+func (c FinCENConfig) Validate() error {
+	if c.TransmitterControlCode == "" {
+		return fmt.Errorf("transmitter_control_code is required")
+	}
+	if c.Filer.Name == "" || c.Filer.TIN == "" {
+		return fmt.Errorf("filer name and tin are required")
+	}
+	if c.ContactOffice == "" {
+		return fmt.Errorf("contact_office is required")
+	}
+	if c.DefaultClassification.TypeID <= 0 || c.DefaultClassification.SubtypeID <= 0 {
+		return fmt.Errorf("default_classification requires type_id and subtype_id")
+	}
+	for alertType, cl := range c.Classifications {
+		if cl.TypeID <= 0 || cl.SubtypeID <= 0 {
+			return fmt.Errorf("classification for '%s' requires type_id and subtype_id", alertType)
+		}
+	}
+	return nil
+}
+
+// ClassificationFor returns the activity classification reported for an alert type.
+func (c FinCENConfig) ClassificationFor(alertType string) FinCENClassification {
+	if cl, ok := c.Classifications[alertType]; ok {
+		return cl
+	}
+	return c.DefaultClassification
+}
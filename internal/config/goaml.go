@@ -0,0 +1,138 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// GoAMLPerson is the reporting person named on goAML reports.
+type GoAMLPerson struct {
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Email      string `json:"email"`
+	Occupation string `json:"occupation"`
+}
+
+// GoAMLLocation is the reporting entity's address.
+type GoAMLLocation struct {
+	AddressType string `json:"address_type"`
+	Address     string `json:"address"`
+	City        string `json:"city"`
+	CountryCode string `json:"country_code"`
+}
+
+// GoAMLConfig is the reporting-entity profile and code mappings used to export SARs as goAML
+// reports.
+type GoAMLConfig struct {
+	// RentityID is the reporting entity's ID registered with the financial intelligence unit.
+	RentityID     int    `json:"rentity_id"`
+	RentityBranch string `json:"rentity_branch,omitempty"`
+	// InstitutionName and SWIFT identify the entity as the institution holding the subject's
+	// accounts.
+	InstitutionName string `json:"institution_name"`
+	SWIFT           string `json:"swift"`
+	// ReportCode is the report type, e.g. STR or SAR.
+	ReportCode string `json:"report_code"`
+	// EntityReferencePrefix starts the entity's own reference for each report.
+	EntityReferencePrefix string        `json:"entity_reference_prefix"`
+	CurrencyCodeLocal     string        `json:"currency_code_local"`
+	ReportingPerson       GoAMLPerson   `json:"reporting_person"`
+	Location              GoAMLLocation `json:"location"`
+	// ExchangeRates converts foreign-currency transactions to the local currency: one unit of the
+	// keyed currency is worth this many local units.
+	ExchangeRates map[string]float64 `json:"exchange_rates"`
+	// TransmodeCodes maps transaction types to goAML transmission mode codes.
+	TransmodeCodes       map[string]string `json:"transmode_codes"`
+	DefaultTransmodeCode string            `json:"default_transmode_code"`
+	// InboundTypes are the transaction types that bring funds into the subject's account, such as
+	// cash deposits. They are reported from the counterparty to the subject's account; all other
+	// types from the subject's account to the counterparty.
+	InboundTypes []string `json:"inbound_types"`
+	// FundsCode is the funds type reported for both sides of each transaction.
+	FundsCode string `json:"funds_code"`
+	// Indicators maps alert types to the FIU's report indicator codes.
+	Indicators        map[string][]string `json:"indicators"`
+	DefaultIndicators []string            `json:"default_indicators"`
+}
+
+// LoadGoAMLConfig loads the goAML reporting-entity profile from a JSON file.
+func LoadGoAMLConfig(filepath string) (GoAMLConfig, error) {
+	var cfg GoAMLConfig
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read goAML config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse goAML config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("goAML config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that the reporting entity and person are identified and the codes are usable.
+func (c GoAMLConfig) Validate() error {
+	if c.RentityID <= 0 {
+		return fmt.Errorf("rentity_id is required")
+	}
+	if c.InstitutionName == "" || c.SWIFT == "" {
+		return fmt.Errorf("institution_name and swift are required")
+	}
+	if c.ReportCode == "" {
+		return fmt.Errorf("report_code is required")
+	}
+	if !currencyCodePattern.MatchString(c.CurrencyCodeLocal) {
+		return fmt.Errorf("invalid currency_code_local '%s'", c.CurrencyCodeLocal)
+	}
+	if c.ReportingPerson.FirstName == "" || c.ReportingPerson.LastName == "" {
+		return fmt.Errorf("reporting_person first_name and last_name are required")
+	}
+	for currency, rate := range c.ExchangeRates {
+		if !currencyCodePattern.MatchString(currency) || rate <= 0 {
+			return fmt.Errorf("invalid exchange rate for '%s'", currency)
+		}
+	}
+	if c.DefaultTransmodeCode == "" || c.FundsCode == "" {
+		return fmt.Errorf("default_transmode_code and funds_code are required")
+	}
+	if len(c.DefaultIndicators) == 0 {
+		return fmt.Errorf("default_indicators is required")
+	}
+	return nil
+}
+
+// TransmodeCodeFor returns the transmission mode code for a transaction type.
+func (c GoAMLConfig) TransmodeCodeFor(transactionType string) string {
+	if code, ok := c.TransmodeCodes[strings.ToUpper(transactionType)]; ok {
+		return code
+	}
+	return c.DefaultTransmodeCode
+}
+
+// IsInbound reports whether a transaction type brings funds into the subject's account.
+func (c GoAMLConfig) IsInbound(transactionType string) bool {
+	for _, t := range c.InboundTypes {
+		if strings.EqualFold(t, transactionType) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndicatorsFor returns the report indicators for an alert type.
+func (c GoAMLConfig) IndicatorsFor(alertType string) []string {
+	if codes, ok := c.Indicators[alertType]; ok {
+		return codes
+	}
+	return c.DefaultIndicators
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// KeyringConfig holds the keys used to sign and encrypt export files. Keys are base64 encoded
+// and looked up by ID, so old keys can stay on the keyring to verify and decrypt earlier exports
+// after new ones take over.
+type KeyringConfig struct {
+	// SigningKeyID names the key in SigningKeys that signs new exports.
+	SigningKeyID string `json:"signing_key_id"`
+	// SigningKeys are 32-byte Ed25519 seeds.
+	SigningKeys map[string]string `json:"signing_keys"`
+	// PublicKeys are Ed25519 public keys that verify exports signed elsewhere. Public keys for
+	// SigningKeys are derived and need not be listed.
+	PublicKeys map[string]string `json:"public_keys,omitempty"`
+	// EncryptionKeyID names the key in EncryptionKeys that encrypts new exports.
+	EncryptionKeyID string `json:"encryption_key_id,omitempty"`
+	// EncryptionKeys are 32-byte AES-256 key-encryption keys.
+	EncryptionKeys map[string]string `json:"encryption_keys,omitempty"`
+}
+
+// LoadKeyring loads a keyring from a JSON file. The file holds private keys, so it is refused if
+// anyone other than its owner can read or write it.
+func LoadKeyring(filepath string) (KeyringConfig, error) {
+	var cfg KeyringConfig
+
+	info, err := os.Stat(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read keyring file: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return cfg, fmt.Errorf("keyring file %s must only be accessible by its owner, has permissions %o", filepath, info.Mode().Perm())
+	}
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read keyring file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse keyring file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("keyring validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that every key decodes to the right size and that the active keys exist.
+func (c KeyringConfig) Validate() error {
+	for id, key := range c.SigningKeys {
+		if _, err := decodeKey(key, ed25519.SeedSize); err != nil {
+			return fmt.Errorf("invalid signing key '%s': %w", id, err)
+		}
+	}
+	for id, key := range c.PublicKeys {
+		if _, err := decodeKey(key, ed25519.PublicKeySize); err != nil {
+			return fmt.Errorf("invalid public key '%s': %w", id, err)
+		}
+	}
+	for id, key := range c.EncryptionKeys {
+		if _, err := decodeKey(key, 32); err != nil {
+			return fmt.Errorf("invalid encryption key '%s': %w", id, err)
+		}
+	}
+	if c.SigningKeyID != "" {
+		if _, ok := c.SigningKeys[c.SigningKeyID]; !ok {
+			return fmt.Errorf("signing_key_id '%s' is not in signing_keys", c.SigningKeyID)
+		}
+	}
+	if c.EncryptionKeyID != "" {
+		if _, ok := c.EncryptionKeys[c.EncryptionKeyID]; !ok {
+			return fmt.Errorf("encryption_key_id '%s' is not in encryption_keys", c.EncryptionKeyID)
+		}
+	}
+	return nil
+}
+
+// SigningKey returns the Ed25519 private key with the given ID.
+func (c KeyringConfig) SigningKey(id string) (ed25519.PrivateKey, error) {
+	encoded, ok := c.SigningKeys[id]
+	if !ok {
+		return nil, fmt.Errorf("no signing key '%s' on the keyring", id)
+	}
+	seed, err := decodeKey(encoded, ed25519.SeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key '%s': %w", id, err)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// PublicKey returns the Ed25519 public key with the given ID, from PublicKeys or derived from
+// the signing key of that ID.
+func (c KeyringConfig) PublicKey(id string) (ed25519.PublicKey, error) {
+	if encoded, ok := c.PublicKeys[id]; ok {
+		key, err := decodeKey(encoded, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key '%s': %w", id, err)
+		}
+		return ed25519.PublicKey(key), nil
+	}
+	private, err := c.SigningKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("no public key '%s' on the keyring", id)
+	}
+	return private.Public().(ed25519.PublicKey), nil
+}
+
+// EncryptionKey returns the key-encryption key with the given ID.
+func (c KeyringConfig) EncryptionKey(id string) ([]byte, error) {
+	encoded, ok := c.EncryptionKeys[id]
+	if !ok {
+		return nil, fmt.Errorf("no encryption key '%s' on the keyring", id)
+	}
+	key, err := decodeKey(encoded, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key '%s': %w", id, err)
+	}
+	return key, nil
+}
+
+func decodeKey(encoded string, size int) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("not valid base64")
+	}
+	if len(key) != size {
+		return nil, fmt.Errorf("must be %d bytes, got %d", size, len(key))
+	}
+	return key, nil
+}
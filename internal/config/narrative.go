@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+)
+
+// NarrativeConfig holds the text/template sources used to draft SAR narratives. The introduction
+// and conclusion describe the whole report; each pattern gets a paragraph from the template for
+// its alert type.
+type NarrativeConfig struct {
+	Introduction string `json:"introduction"`
+	// Templates maps alert types to the paragraph drafted for their pattern.
+	Templates map[string]string `json:"templates"`
+	// DefaultTemplate drafts patterns whose alert type has no template.
+	DefaultTemplate string `json:"default_template"`
+	Conclusion      string `json:"conclusion"`
+	// ReportingThreshold is the currency transaction reporting threshold quoted in narratives.
+	ReportingThreshold float64 `json:"reporting_threshold"`
+}
+
+// DefaultNarrativeConfig drafts a who/what/when/where/why narrative for the built-in alert types.
+func DefaultNarrativeConfig() NarrativeConfig {
+	return NarrativeConfig{
+		Introduction: "This report concerns {{.Subject}}{{if .Associates}}, together with {{.Associates}}{{end}}. " +
+			"Between {{.Start}} and {{.End}}, {{.Count}} transaction(s) totalling {{.Total}} were identified as suspicious. " +
+			"{{if .PriorReference}}This report continues report {{.PriorReference}}; " +
+			"since {{.SeriesStart}}, {{.CumulativeCount}} transaction(s) totalling {{.CumulativeTotal}} have been reported.{{end}}",
+		Templates: map[string]string{
+			"STRUCTURING_PATTERN": "Between {{.Start}} and {{.End}}, {{.Subject}} conducted {{.Count}} {{.Types}} transaction(s) " +
+				"totalling {{.Total}}, each below the {{.Threshold}} reporting threshold. The largest was {{.Largest}}. " +
+				"The amounts and timing suggest the activity was structured to avoid reporting requirements.",
+			"THRESHOLD_VIOLATION": "Between {{.Start}} and {{.End}}, {{.Subject}} conducted {{.Count}} {{.Types}} transaction(s) " +
+				"totalling {{.Total}} from {{.Origins}} to {{.Destinations}}, each at or above the {{.Threshold}} reporting threshold.",
+			"GEOGRAPHIC_RISK": "Between {{.Start}} and {{.End}}, {{.Subject}} sent {{.Count}} {{.Types}} transaction(s) " +
+				"totalling {{.Total}} to {{.Destinations}}, which are rated high risk.",
+			"CONTINUING_ACTIVITY": "Between {{.Start}} and {{.End}}, after the prior report, {{.Subject}} conducted a further " +
+				"{{.Count}} {{.Types}} transaction(s) totalling {{.Total}}.",
+		},
+		DefaultTemplate: "Between {{.Start}} and {{.End}}, {{.Count}} {{.Types}} transaction(s) by {{.Subject}} " +
+			"totalling {{.Total}} were flagged as {{.Pattern}}.",
+		Conclusion:         "The activity is inconsistent with the customer's expected activity and has no apparent lawful purpose.",
+		ReportingThreshold: 10000,
+	}
+}
+
+// LoadNarrativeConfig loads SAR narrative templates from a JSON file.
+// Fields omitted from the file keep their default values.
+func LoadNarrativeConfig(filepath string) (NarrativeConfig, error) {
+	cfg := DefaultNarrativeConfig()
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read narrative config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse narrative config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("narrative config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that a default template is set and every template parses.
+func (c NarrativeConfig) Validate() error {
+	if c.DefaultTemplate == "" {
+		return fmt.Errorf("default_template is required")
+	}
+	if c.ReportingThreshold <= 0 {
+		return fmt.Errorf("reporting_threshold must be positive")
+	}
+	sources := map[string]string{
+		"introduction":     c.Introduction,
+		"default_template": c.DefaultTemplate,
+		"conclusion":       c.Conclusion,
+	}
+	for alertType, src := range c.Templates {
+		sources["template for '"+alertType+"'"] = src
+	}
+	for name, src := range sources {
+		if _, err := template.New(name).Parse(src); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
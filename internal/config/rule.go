@@ -14,6 +14,8 @@ type Rule struct {
 	ThresholdValue float64 `json:"threshold_value"`
 	TimeWindow     string  `json:"time_window"`
 	Enabled        bool    `json:"enabled"`
+	// MinHistory is the number of profiled transactions required before a behavioural rule applies.
+	MinHistory int `json:"min_history,omitempty"`
 }
 
 // GetTimeWindow returns the parsed time duration for the rule.
@@ -50,6 +52,9 @@ func validateRules(rules []Rule) error {
 		if _, err := time.ParseDuration(rule.TimeWindow); err != nil {
 			return fmt.Errorf("invalid time_window for rule '%s'", rule.RuleID)
 		}
+		if rule.MinHistory < 0 {
+			return fmt.Errorf("min_history must be >= 0 for rule '%s'", rule.RuleID)
+		}
 		if _, exists := ruleIDs[rule.RuleID]; exists {
 			return fmt.Errorf("duplicate rule_id: '%s'", rule.RuleID)
 		}
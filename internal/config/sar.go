@@ -0,0 +1,64 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// SARConfig controls who may move a SAR through review and filing.
+type SARConfig struct {
+	// ApproverRoles may approve a SAR under review or send it back to draft. The approver must
+	// also be someone other than the SAR's preparer and submitter.
+	ApproverRoles []string `json:"approver_roles"`
+	// FilerRoles may record an approved SAR as filed with the regulator.
+	FilerRoles []string `json:"filer_roles"`
+	// ContinuingReviewDays is how long after filing a SAR's subject is due a review for
+	// continuing activity.
+	ContinuingReviewDays int `json:"continuing_review_days"`
+}
+
+// DefaultSARConfig lets supervisors and MLROs approve SARs and only MLROs file them, and
+// schedules continuing-activity reviews 90 days after filing.
+func DefaultSARConfig() SARConfig {
+	return SARConfig{
+		ApproverRoles:        []string{"supervisor", "mlro"},
+		FilerRoles:           []string{"mlro"},
+		ContinuingReviewDays: 90,
+	}
+}
+
+// LoadSARConfig loads SAR lifecycle settings from a JSON file.
+// Fields omitted from the file keep their default values.
+func LoadSARConfig(filepath string) (SARConfig, error) {
+	cfg := DefaultSARConfig()
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read SAR config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse SAR config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("SAR config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that someone is able to approve and file SARs and that reviews are scheduled.
+func (c SARConfig) Validate() error {
+	if len(c.ApproverRoles) == 0 {
+		return fmt.Errorf("approver_roles is required")
+	}
+	if len(c.FilerRoles) == 0 {
+		return fmt.Errorf("filer_roles is required")
+	}
+	if c.ContinuingReviewDays <= 0 {
+		return fmt.Errorf("continuing_review_days must be positive")
+	}
+	return nil
+}
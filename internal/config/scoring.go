@@ -0,0 +1,158 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// Risk scoring factor names.
+const (
+	ScoreFactorAlertType        = "alert_type"
+	ScoreFactorAmountVsBaseline = "amount_vs_baseline"
+	ScoreFactorCountryRisk      = "country_risk"
+	ScoreFactorCustomerRisk     = "customer_risk"
+	ScoreFactorPriorAlerts      = "prior_alerts"
+	ScoreFactorRulesFired       = "rules_fired"
+)
+
+// ScoringConfig configures the weighted risk scoring model. Each factor yields a risk between 0
+// and 1; the score is their weighted average scaled to 0–100.
+type ScoringConfig struct {
+	// Enabled replaces the legacy score with the model's score and breakdown.
+	Enabled bool `json:"enabled"`
+	// Weights gives each factor's relative weight. A weight of zero leaves the factor out.
+	Weights map[string]float64 `json:"weights"`
+	// AlertTypeRisk is the inherent risk of each alert type.
+	AlertTypeRisk        map[string]float64 `json:"alert_type_risk"`
+	DefaultAlertTypeRisk float64            `json:"default_alert_type_risk"`
+	// AmountRatioCap is the multiple of the account's mean amount at which the amount factor
+	// reaches full risk. Amounts at or below the mean score zero.
+	AmountRatioCap float64 `json:"amount_ratio_cap"`
+	// AmountReference stands in for the mean amount of accounts with no history.
+	AmountReference float64 `json:"amount_reference"`
+	// CustomerRisk maps KYC risk ratings to risk.
+	CustomerRisk        map[string]float64 `json:"customer_risk"`
+	DefaultCustomerRisk float64            `json:"default_customer_risk"`
+	// PriorAlertsLookback is how far back earlier alerts on the account are counted.
+	PriorAlertsLookback string `json:"prior_alerts_lookback"`
+	// PriorAlertsCap is the number of earlier alerts at which that factor reaches full risk.
+	PriorAlertsCap int `json:"prior_alerts_cap"`
+	// RulesFiredCap is the number of findings on one transaction at which that factor reaches
+	// full risk.
+	RulesFiredCap int `json:"rules_fired_cap"`
+}
+
+// DefaultScoringConfig returns the baseline scoring model.
+func DefaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		Enabled: true,
+		Weights: map[string]float64{
+			ScoreFactorAlertType:        0.30,
+			ScoreFactorAmountVsBaseline: 0.20,
+			ScoreFactorCountryRisk:      0.15,
+			ScoreFactorCustomerRisk:     0.15,
+			ScoreFactorPriorAlerts:      0.10,
+			ScoreFactorRulesFired:       0.10,
+		},
+		AlertTypeRisk: map[string]float64{
+			"THRESHOLD_VIOLATION":          0.5,
+			"GEOGRAPHIC_RISK":              0.6,
+			"BEHAVIORAL_DEVIATION":         0.5,
+			"ANOMALY_DETECTED":             0.7,
+			"VELOCITY_ANOMALY":             0.7,
+			"DORMANT_ACCOUNT_REACTIVATION": 0.7,
+			"STRUCTURING_PATTERN":          1.0,
+		},
+		DefaultAlertTypeRisk: 0.5,
+		AmountRatioCap:       10,
+		AmountReference:      10000,
+		CustomerRisk: map[string]float64{
+			"LOW":    0.1,
+			"MEDIUM": 0.5,
+			"HIGH":   1.0,
+		},
+		DefaultCustomerRisk: 0.5,
+		PriorAlertsLookback: "2160h",
+		PriorAlertsCap:      5,
+		RulesFiredCap:       3,
+	}
+}
+
+// LoadScoringConfig loads the risk scoring model from a JSON file.
+// Fields omitted from the file keep their default values.
+func LoadScoringConfig(filepath string) (ScoringConfig, error) {
+	cfg := DefaultScoringConfig()
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read scoring config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse scoring config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("scoring config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the scoring configuration.
+func (c ScoringConfig) Validate() error {
+	var total float64
+	for name, weight := range c.Weights {
+		switch name {
+		case ScoreFactorAlertType, ScoreFactorAmountVsBaseline, ScoreFactorCountryRisk,
+			ScoreFactorCustomerRisk, ScoreFactorPriorAlerts, ScoreFactorRulesFired:
+		default:
+			return fmt.Errorf("unknown factor '%s'", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("weight for '%s' must not be negative", name)
+		}
+		total += weight
+	}
+	if total <= 0 {
+		return fmt.Errorf("at least one factor must have a positive weight")
+	}
+
+	for name, risk := range c.AlertTypeRisk {
+		if risk < 0 || risk > 1 {
+			return fmt.Errorf("alert_type_risk for '%s' must be between 0 and 1", name)
+		}
+	}
+	for name, risk := range c.CustomerRisk {
+		if risk < 0 || risk > 1 {
+			return fmt.Errorf("customer_risk for '%s' must be between 0 and 1", name)
+		}
+	}
+	if c.DefaultAlertTypeRisk < 0 || c.DefaultAlertTypeRisk > 1 || c.DefaultCustomerRisk < 0 || c.DefaultCustomerRisk > 1 {
+		return fmt.Errorf("default risks must be between 0 and 1")
+	}
+	if c.AmountRatioCap <= 1 {
+		return fmt.Errorf("amount_ratio_cap must be greater than 1")
+	}
+	if c.AmountReference <= 0 {
+		return fmt.Errorf("amount_reference must be positive")
+	}
+	if _, err := c.GetPriorAlertsLookback(); err != nil {
+		return err
+	}
+	if c.PriorAlertsCap <= 0 || c.RulesFiredCap <= 0 {
+		return fmt.Errorf("prior_alerts_cap and rules_fired_cap must be positive")
+	}
+	return nil
+}
+
+// GetPriorAlertsLookback returns the parsed prior alert lookback.
+func (c ScoringConfig) GetPriorAlertsLookback() (time.Duration, error) {
+	d, err := time.ParseDuration(c.PriorAlertsLookback)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid prior_alerts_lookback '%s'", c.PriorAlertsLookback)
+	}
+	return d, nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// SLAPolicy sets a deadline for one stage of work on alerts of a given priority.
+type SLAPolicy struct {
+	// Name identifies the stage, e.g. "triage" or "decision".
+	Name string `json:"name"`
+	// Priority is the alert priority the policy applies to: MEDIUM, HIGH or CRITICAL.
+	Priority string `json:"priority"`
+	// Within is how long after an alert is raised the stage must be complete.
+	Within string `json:"within"`
+	// WarnBefore is how long before the deadline an alert counts as approaching it.
+	WarnBefore string `json:"warn_before,omitempty"`
+	// PendingStatuses are the statuses in which the stage is still outstanding. When empty the
+	// stage is outstanding until the alert reaches a final status.
+	PendingStatuses []string `json:"pending_statuses,omitempty"`
+	// EscalateTo is the status an overdue alert is moved to through its workflow, if any.
+	EscalateTo string `json:"escalate_to,omitempty"`
+	// Reassign hands an overdue alert to another eligible investigator.
+	Reassign bool `json:"reassign,omitempty"`
+}
+
+// SLAConfig holds the SLA policies and how often they are checked.
+type SLAConfig struct {
+	// Enabled turns the breach scheduler on. Deadlines are reported either way.
+	Enabled bool `json:"enabled"`
+	// CheckInterval is how often the scheduler looks for breaches.
+	CheckInterval string `json:"check_interval"`
+	// ActorRoles are the roles the scheduler acts with when escalating alerts.
+	ActorRoles []string    `json:"actor_roles,omitempty"`
+	Policies   []SLAPolicy `json:"policies"`
+}
+
+// DefaultSLAConfig checks hourly for CRITICAL alerts not triaged within a day and alerts of any
+// priority without a decision after 30 days.
+func DefaultSLAConfig() SLAConfig {
+	policies := []SLAPolicy{
+		{Name: "triage", Priority: "CRITICAL", Within: "24h", WarnBefore: "4h", PendingStatuses: []string{"OPEN"}, Reassign: true},
+	}
+	for _, p := range []string{"MEDIUM", "HIGH", "CRITICAL"} {
+		policies = append(policies, SLAPolicy{Name: "decision", Priority: p, Within: "720h", WarnBefore: "72h"})
+	}
+	return SLAConfig{
+		Enabled:       true,
+		CheckInterval: "1h",
+		Policies:      policies,
+	}
+}
+
+// LoadSLAConfig loads SLA policies from a JSON file.
+// Fields omitted from the file keep their default values.
+func LoadSLAConfig(filepath string) (SLAConfig, error) {
+	cfg := DefaultSLAConfig()
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read SLA config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse SLA config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("SLA config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the SLA configuration.
+func (c SLAConfig) Validate() error {
+	if _, err := c.GetCheckInterval(); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, p := range c.Policies {
+		if p.Name == "" {
+			return fmt.Errorf("policy name is required")
+		}
+		switch p.Priority {
+		case "MEDIUM", "HIGH", "CRITICAL":
+		default:
+			return fmt.Errorf("policy '%s': invalid priority '%s'", p.Name, p.Priority)
+		}
+		key := p.Priority + "/" + p.Name
+		if seen[key] {
+			return fmt.Errorf("duplicate policy '%s' for priority %s", p.Name, p.Priority)
+		}
+		seen[key] = true
+
+		within, err := p.GetWithin()
+		if err != nil {
+			return fmt.Errorf("policy '%s': %w", p.Name, err)
+		}
+		warn, err := p.GetWarnBefore()
+		if err != nil {
+			return fmt.Errorf("policy '%s': %w", p.Name, err)
+		}
+		if warn >= within {
+			return fmt.Errorf("policy '%s': warn_before must be shorter than within", p.Name)
+		}
+	}
+	return nil
+}
+
+// GetCheckInterval returns the parsed scheduler interval.
+func (c SLAConfig) GetCheckInterval() (time.Duration, error) {
+	d, err := time.ParseDuration(c.CheckInterval)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid check_interval '%s'", c.CheckInterval)
+	}
+	return d, nil
+}
+
+// GetWithin returns the parsed deadline.
+func (p SLAPolicy) GetWithin() (time.Duration, error) {
+	d, err := time.ParseDuration(p.Within)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid within '%s'", p.Within)
+	}
+	return d, nil
+}
+
+// GetWarnBefore returns the parsed warning period, zero when unset.
+func (p SLAPolicy) GetWarnBefore() (time.Duration, error) {
+	if p.WarnBefore == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(p.WarnBefore)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid warn_before '%s'", p.WarnBefore)
+	}
+	return d, nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// VelocityConfig defines how transaction frequency is compared with an account's own history.
+type VelocityConfig struct {
+	// Windows are the look-back periods whose transaction counts are tested, e.g. "1h", "24h", "168h".
+	Windows []string `json:"windows"`
+	// Alpha is the Poisson tail probability below which a count is treated as a burst.
+	Alpha float64 `json:"alpha"`
+	// MinCount is the smallest count in a window that may be flagged, so one-off pairs are ignored.
+	MinCount int `json:"min_count"`
+	// MinHistory is the number of inter-transaction gaps required before a rate baseline is trusted.
+	MinHistory int `json:"min_history"`
+	// DormancyPeriod is the silence after which a new transaction counts as a reactivation.
+	DormancyPeriod string `json:"dormancy_period"`
+}
+
+// DefaultVelocityConfig returns the standard 1h/24h/7d velocity configuration.
+func DefaultVelocityConfig() VelocityConfig {
+	return VelocityConfig{
+		Windows:        []string{"1h", "24h", "168h"},
+		Alpha:          0.001,
+		MinCount:       3,
+		MinHistory:     10,
+		DormancyPeriod: "2160h",
+	}
+}
+
+// Validate checks the velocity configuration for out-of-range values.
+func (c VelocityConfig) Validate() error {
+	if len(c.Windows) == 0 {
+		return fmt.Errorf("at least one window is required")
+	}
+	if _, err := c.GetWindows(); err != nil {
+		return err
+	}
+	if c.Alpha <= 0 || c.Alpha >= 1 {
+		return fmt.Errorf("alpha must be between 0 and 1")
+	}
+	if c.MinCount < 1 {
+		return fmt.Errorf("min_count must be at least 1")
+	}
+	if c.MinHistory < 1 {
+		return fmt.Errorf("min_history must be at least 1")
+	}
+	if _, err := c.GetDormancyPeriod(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetWindows returns the parsed look-back windows.
+func (c VelocityConfig) GetWindows() ([]time.Duration, error) {
+	windows := make([]time.Duration, len(c.Windows))
+	for i, w := range c.Windows {
+		d, err := time.ParseDuration(w)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid window '%s'", w)
+		}
+		windows[i] = d
+	}
+	return windows, nil
+}
+
+// GetDormancyPeriod returns the parsed dormancy period.
+func (c VelocityConfig) GetDormancyPeriod() (time.Duration, error) {
+	d, err := time.ParseDuration(c.DormancyPeriod)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid dormancy_period '%s'", c.DormancyPeriod)
+	}
+	return d, nil
+}
+
+// Lookback returns the longest configured window, i.e. how much recent history velocity detection needs.
+func (c VelocityConfig) Lookback() time.Duration {
+	windows, err := c.GetWindows()
+	if err != nil {
+		return 0
+	}
+	var longest time.Duration
+	for _, w := range windows {
+		if w > longest {
+			longest = w
+		}
+	}
+	return longest
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// WorkflowTransition allows alerts to move between two states.
+type WorkflowTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Roles lists the roles allowed to make the transition; any role may when empty.
+	Roles []string `json:"roles,omitempty"`
+	// RequiredFields names the fields that must accompany the transition, e.g. disposition_code.
+	RequiredFields []string `json:"required_fields,omitempty"`
+}
+
+// Workflow is the alert state machine for a set of alert types.
+type Workflow struct {
+	Name string `json:"name"`
+	// AlertTypes lists the alert types governed by this workflow.
+	AlertTypes   []string `json:"alert_types,omitempty"`
+	InitialState string   `json:"initial_state"`
+	States       []string `json:"states"`
+	// FinalStates are the states in which an alert no longer needs work, such as CLOSED.
+	FinalStates []string             `json:"final_states"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// WorkflowConfig holds every alert workflow and names the one used for unlisted alert types.
+type WorkflowConfig struct {
+	DefaultWorkflow string     `json:"default_workflow"`
+	Workflows       []Workflow `json:"workflows"`
+}
+
+// DefaultWorkflowConfig returns the original fixed workflow:
+// OPEN → INVESTIGATING → ESCALATED → CLOSED, with INVESTIGATING → FALSE_POSITIVE.
+func DefaultWorkflowConfig() WorkflowConfig {
+	return WorkflowConfig{
+		DefaultWorkflow: "standard",
+		Workflows: []Workflow{{
+			Name:         "standard",
+			InitialState: "OPEN",
+			States:       []string{"OPEN", "INVESTIGATING", "ESCALATED", "CLOSED", "FALSE_POSITIVE"},
+			FinalStates:  []string{"CLOSED", "FALSE_POSITIVE"},
+			Transitions: []WorkflowTransition{
+				{From: "OPEN", To: "INVESTIGATING"},
+				{From: "INVESTIGATING", To: "ESCALATED"},
+				{From: "INVESTIGATING", To: "FALSE_POSITIVE"},
+				{From: "ESCALATED", To: "CLOSED"},
+			},
+		}},
+	}
+}
+
+// Validate checks that every workflow is a well-formed state machine and that each alert type
+// maps to at most one workflow.
+func (c WorkflowConfig) Validate() error {
+	if len(c.Workflows) == 0 {
+		return fmt.Errorf("at least one workflow is required")
+	}
+
+	names := make(map[string]bool)
+	alertTypes := make(map[string]string)
+	for _, w := range c.Workflows {
+		if w.Name == "" {
+			return fmt.Errorf("workflow name is required")
+		}
+		if names[w.Name] {
+			return fmt.Errorf("duplicate workflow name: '%s'", w.Name)
+		}
+		names[w.Name] = true
+
+		for _, t := range w.AlertTypes {
+			if other, exists := alertTypes[t]; exists {
+				return fmt.Errorf("alert type '%s' is assigned to both '%s' and '%s'", t, other, w.Name)
+			}
+			alertTypes[t] = w.Name
+		}
+
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("workflow '%s': %w", w.Name, err)
+		}
+	}
+
+	if !names[c.DefaultWorkflow] {
+		return fmt.Errorf("default_workflow '%s' is not defined", c.DefaultWorkflow)
+	}
+	return nil
+}
+
+// Validate checks a single workflow: states are unique, transitions connect known states and
+// every state can be reached from the initial state.
+func (w Workflow) Validate() error {
+	states := make(map[string]bool)
+	for _, s := range w.States {
+		if s == "" {
+			return fmt.Errorf("state names must not be empty")
+		}
+		if states[s] {
+			return fmt.Errorf("duplicate state: '%s'", s)
+		}
+		states[s] = true
+	}
+	if !states[w.InitialState] {
+		return fmt.Errorf("initial_state '%s' is not a declared state", w.InitialState)
+	}
+	for _, s := range w.FinalStates {
+		if !states[s] {
+			return fmt.Errorf("final state '%s' is not a declared state", s)
+		}
+		if s == w.InitialState {
+			return fmt.Errorf("initial_state '%s' cannot be final", s)
+		}
+	}
+
+	seen := make(map[[2]string]bool)
+	next := make(map[string][]string)
+	for _, t := range w.Transitions {
+		if !states[t.From] || !states[t.To] {
+			return fmt.Errorf("transition %s → %s references an undeclared state", t.From, t.To)
+		}
+		if t.From == t.To {
+			return fmt.Errorf("transition %s → %s does not change state", t.From, t.To)
+		}
+		key := [2]string{t.From, t.To}
+		if seen[key] {
+			return fmt.Errorf("duplicate transition %s → %s", t.From, t.To)
+		}
+		seen[key] = true
+		for _, role := range t.Roles {
+			if role == "" {
+				return fmt.Errorf("transition %s → %s has an empty role", t.From, t.To)
+			}
+		}
+		for _, field := range t.RequiredFields {
+			if field == "" {
+				return fmt.Errorf("transition %s → %s has an empty required field", t.From, t.To)
+			}
+		}
+		next[t.From] = append(next[t.From], t.To)
+	}
+
+	reached := map[string]bool{w.InitialState: true}
+	queue := []string{w.InitialState}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, to := range next[s] {
+			if !reached[to] {
+				reached[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	for _, s := range w.States {
+		if !reached[s] {
+			return fmt.Errorf("state '%s' cannot be reached from '%s'", s, w.InitialState)
+		}
+	}
+	return nil
+}
+
+// LoadWorkflowConfig loads the alert workflows from a JSON file.
+func LoadWorkflowConfig(filepath string) (WorkflowConfig, error) {
+	var cfg WorkflowConfig
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read workflow config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse workflow config file: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("workflow config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
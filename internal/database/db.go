@@ -0,0 +1,90 @@
+package database
+
+import "database/sql"
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx, so repository functions can run
+// standalone or as part of a larger database transaction.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// DB is a connection whose queries are rebound from ? placeholders to its dialect's, as are the
+// queries of every transaction begun on it. Handlers and jobs take a DB rather than a *sql.DB so
+// that no service call can reach the driver unbound.
+type DB struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// Open opens a database with the given driver, bound to the driver's dialect.
+func Open(driverName, dataSourceName string) (*DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(db, DialectFor(driverName)), nil
+}
+
+// Wrap binds an open connection to a dialect.
+func Wrap(db *sql.DB, d Dialect) *DB {
+	return &DB{db: db, dialect: d}
+}
+
+// Dialect returns the placeholder style queries are rebound to.
+func (d *DB) Dialect() Dialect {
+	return d.dialect
+}
+
+func (d *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.db.Exec(d.dialect.Rebind(query), args...)
+}
+
+func (d *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.Query(d.dialect.Rebind(query), args...)
+}
+
+func (d *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.db.QueryRow(d.dialect.Rebind(query), args...)
+}
+
+// Begin starts a transaction bound to the same dialect.
+func (d *DB) Begin() (*Tx, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx: tx, dialect: d.dialect}, nil
+}
+
+// Close closes the underlying connection.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Tx is a transaction whose queries are rebound to its connection's dialect.
+type Tx struct {
+	tx      *sql.Tx
+	dialect Dialect
+}
+
+func (t *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.Exec(t.dialect.Rebind(query), args...)
+}
+
+func (t *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.Query(t.dialect.Rebind(query), args...)
+}
+
+func (t *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.tx.QueryRow(t.dialect.Rebind(query), args...)
+}
+
+func (t *Tx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *Tx) Rollback() error {
+	return t.tx.Rollback()
+}
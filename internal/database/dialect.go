@@ -0,0 +1,81 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect is the placeholder style a database driver expects. Queries in this codebase are
+// written with ? placeholders and rebound for the driver they run on.
+type Dialect int
+
+const (
+	// Question is for drivers that take ? placeholders, such as SQLite and MySQL.
+	Question Dialect = iota
+	// Dollar is for drivers that take numbered $1, $2, ... placeholders, such as Postgres.
+	Dollar
+)
+
+// MaxInParams is the most values bound into a single IN list. Longer lists are fetched in chunks
+// so queries stay within the drivers' parameter limits.
+const MaxInParams = 500
+
+// DialectFor returns the dialect for a database/sql driver name.
+func DialectFor(driverName string) Dialect {
+	switch driverName {
+	case "postgres", "pgx", "cloudsqlpostgres":
+		return Dollar
+	}
+	return Question
+}
+
+// Rebind rewrites the ? placeholders in a query for the dialect. Question marks inside quoted
+// strings and identifiers are left alone.
+func (d Dialect) Rebind(query string) string {
+	if d == Question || !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Placeholders returns an IN list of n ? placeholders, e.g. (?,?,?).
+func Placeholders(n int) string {
+	if n <= 0 {
+		return "()"
+	}
+	return "(?" + strings.Repeat(",?", n-1) + ")"
+}
+
+// Chunks splits values into slices of at most size, for binding into IN lists.
+func Chunks(values []string, size int) [][]string {
+	var chunks [][]string
+	for len(values) > size {
+		chunks = append(chunks, values[:size:size])
+		values = values[size:]
+	}
+	if len(values) > 0 {
+		chunks = append(chunks, values)
+	}
+	return chunks
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+func TestDialect(t *testing.T) {
+	// Test Case 1: Dollar dialects number placeholders outside quotes
+	t.Run("rebind", func(t *testing.T) {
+		tests := []struct {
+			dialect Dialect
+			query   string
+			want    string
+		}{
+			{Question, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = ? AND b = ?"},
+			{Dollar, "SELECT * FROM t WHERE a = ? AND b IN (?,?)", "SELECT * FROM t WHERE a = $1 AND b IN ($2,$3)"},
+			{Dollar, `SELECT '?' AS q, "col?" FROM t WHERE a = ?`, `SELECT '?' AS q, "col?" FROM t WHERE a = $1`},
+			{Dollar, "SELECT 1", "SELECT 1"},
+		}
+		for _, tt := range tests {
+			if got := tt.dialect.Rebind(tt.query); got != tt.want {
+				t.Errorf("Rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		}
+	})
+
+	// Test Case 2: Drivers map to their dialect
+	t.Run("drivers", func(t *testing.T) {
+		if DialectFor("postgres") != Dollar || DialectFor("pgx") != Dollar || DialectFor("sqlite3") != Question {
+			t.Errorf("Unexpected dialects for driver names")
+		}
+		db, err := Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer db.Close()
+		if db.Dialect() != Question {
+			t.Errorf("Expected SQLite to use ? placeholders")
+		}
+	})
+
+	// Test Case 3: Long IN lists are queried in chunks
+	t.Run("query_in", func(t *testing.T) {
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer db.Close()
+		if _, err := db.Exec(`CREATE TABLE items (id TEXT PRIMARY KEY, kind TEXT)`); err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+		ids := make([]string, 2*MaxInParams+10)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("item-%d", i)
+			if _, err := db.Exec(`INSERT INTO items (id, kind) VALUES (?, ?)`, ids[i], []string{"a", "b"}[i%2]); err != nil {
+				t.Fatalf("failed to insert item: %v", err)
+			}
+		}
+		if chunks := Chunks(ids, MaxInParams); len(chunks) != 3 || len(chunks[2]) != 10 {
+			t.Fatalf("Expected 3 chunks, the last of 10, got %d", len(chunks))
+		}
+
+		count := 0
+		err = QueryIn(Wrap(db, Dollar), `SELECT id FROM items WHERE id IN %s AND kind = ?`, ids, func(rows *sql.Rows) error {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			if !strings.HasPrefix(id, "item-") {
+				return fmt.Errorf("unexpected id %s", id)
+			}
+			count++
+			return nil
+		}, "a")
+		if err != nil {
+			t.Fatalf("QueryIn failed: %v", err)
+		}
+		if count != MaxInParams+5 {
+			t.Errorf("Expected %d rows, got %d", MaxInParams+5, count)
+		}
+	})
+
+	// Test Case 4: Transactions are rebound like their connection
+	t.Run("transactions", func(t *testing.T) {
+		raw, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		raw.SetMaxOpenConns(1)
+		db := Wrap(raw, Dollar)
+		defer db.Close()
+		if _, err := db.Exec(`CREATE TABLE items (id TEXT PRIMARY KEY, kind TEXT)`); err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatalf("Begin failed: %v", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO items (id, kind) VALUES (?, ?)`, "item-1", "a"); err != nil {
+			t.Fatalf("failed to insert item: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+		var kind string
+		if err := db.QueryRow(`SELECT kind FROM items WHERE id = ?`, "item-1").Scan(&kind); err != nil || kind != "a" {
+			t.Errorf("Expected the committed item, got %q, %v", kind, err)
+		}
+	})
+}
@@ -0,0 +1,19 @@
+ALTER TABLE transactions ADD COLUMN counterparty_id VARCHAR(255);
+
+CREATE INDEX idx_transactions_counterparty_id ON transactions(counterparty_id);
+
+CREATE TABLE account_profiles (
+    account_id VARCHAR(255) PRIMARY KEY,
+    transaction_count BIGINT NOT NULL DEFAULT 0,
+    amount_mean DOUBLE PRECISION NOT NULL DEFAULT 0,
+    amount_m2 DOUBLE PRECISION NOT NULL DEFAULT 0,
+    log_amount_mean DOUBLE PRECISION NOT NULL DEFAULT 0,
+    log_amount_m2 DOUBLE PRECISION NOT NULL DEFAULT 0,
+    counterparties TEXT,
+    countries TEXT,
+    transaction_types TEXT,
+    active_hours TEXT,
+    first_seen TIMESTAMP WITH TIME ZONE,
+    last_seen TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,3 @@
+ALTER TABLE account_profiles ADD COLUMN gap_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE account_profiles ADD COLUMN gap_mean_hours DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE account_profiles ADD COLUMN gap_m2 DOUBLE PRECISION NOT NULL DEFAULT 0;
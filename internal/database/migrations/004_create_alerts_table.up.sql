@@ -0,0 +1,19 @@
+CREATE TABLE alerts (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES transactions(transaction_id),
+    account_id VARCHAR(255) NOT NULL,
+    alert_type VARCHAR(50) NOT NULL,
+    priority SMALLINT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    assigned_to VARCHAR(255) NOT NULL DEFAULT '',
+    rule_details TEXT,
+    transition_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_alerts_status ON alerts(status);
+CREATE INDEX idx_alerts_account_id ON alerts(account_id);
+CREATE INDEX idx_alerts_assigned_to ON alerts(assigned_to);
+CREATE INDEX idx_alerts_created_at_id ON alerts(created_at, id);
+CREATE INDEX idx_alerts_score_id ON alerts(score, id);
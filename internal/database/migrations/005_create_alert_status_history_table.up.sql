@@ -0,0 +1,22 @@
+CREATE TABLE alert_status_history (
+    id UUID PRIMARY KEY,
+    alert_id UUID NOT NULL REFERENCES alerts(id),
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    comment TEXT NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_alert_status_history_alert_id ON alert_status_history(alert_id, changed_at);
+
+-- History is append-only: reject any attempt to rewrite or remove it.
+CREATE FUNCTION reject_alert_status_history_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'alert_status_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER alert_status_history_append_only
+    BEFORE UPDATE OR DELETE ON alert_status_history
+    FOR EACH ROW EXECUTE FUNCTION reject_alert_status_history_change();
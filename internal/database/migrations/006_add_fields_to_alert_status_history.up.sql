@@ -0,0 +1 @@
+ALTER TABLE alert_status_history ADD COLUMN fields TEXT;
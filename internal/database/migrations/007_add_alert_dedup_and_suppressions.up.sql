@@ -0,0 +1,54 @@
+ALTER TABLE alerts ADD COLUMN dedup_key VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_alerts_dedup ON alerts(account_id, dedup_key, created_at);
+
+CREATE TABLE alert_evidence (
+    id UUID PRIMARY KEY,
+    alert_id UUID NOT NULL REFERENCES alerts(id),
+    transaction_id UUID NOT NULL REFERENCES transactions(transaction_id),
+    details TEXT,
+    observed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_alert_evidence_alert_id ON alert_evidence(alert_id, observed_at);
+
+CREATE TABLE alert_suppressions (
+    id UUID PRIMARY KEY,
+    account_id VARCHAR(255) NOT NULL,
+    rule_key VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_alert_suppressions_lookup ON alert_suppressions(account_id, rule_key, expires_at);
+
+CREATE TABLE alert_suppression_audit (
+    id UUID PRIMARY KEY,
+    suppression_id UUID NOT NULL REFERENCES alert_suppressions(id),
+    action VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_alert_suppression_audit_suppression_id ON alert_suppression_audit(suppression_id, created_at);
+
+-- Evidence and the suppression audit trail are append-only.
+CREATE FUNCTION reject_append_only_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER alert_evidence_append_only
+    BEFORE UPDATE OR DELETE ON alert_evidence
+    FOR EACH ROW EXECUTE FUNCTION reject_append_only_change();
+
+CREATE TRIGGER alert_suppression_audit_append_only
+    BEFORE UPDATE OR DELETE ON alert_suppression_audit
+    FOR EACH ROW EXECUTE FUNCTION reject_append_only_change();
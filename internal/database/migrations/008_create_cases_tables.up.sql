@@ -0,0 +1,44 @@
+CREATE TABLE cases (
+    id UUID PRIMARY KEY,
+    subject_account_id VARCHAR(255) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    assigned_to VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    close_reason TEXT NOT NULL DEFAULT '',
+    merged_into UUID REFERENCES cases(id)
+);
+
+CREATE INDEX idx_cases_subject_status ON cases(subject_account_id, status);
+CREATE INDEX idx_cases_assigned_to ON cases(assigned_to);
+
+ALTER TABLE alerts ADD COLUMN case_id UUID REFERENCES cases(id);
+
+CREATE INDEX idx_alerts_case_id ON alerts(case_id);
+
+CREATE TABLE case_notes (
+    id UUID PRIMARY KEY,
+    case_id UUID NOT NULL REFERENCES cases(id),
+    author VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_case_notes_case_id ON case_notes(case_id, created_at);
+
+CREATE TABLE case_attachments (
+    id UUID PRIMARY KEY,
+    case_id UUID NOT NULL REFERENCES cases(id),
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    uri TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL DEFAULT '',
+    uploaded_by VARCHAR(255) NOT NULL,
+    uploaded_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_case_attachments_case_id ON case_attachments(case_id);
@@ -0,0 +1,35 @@
+CREATE TABLE teams (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    alert_types TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE investigators (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    team_id UUID REFERENCES teams(id),
+    skills TEXT NOT NULL DEFAULT '[]',
+    max_open_alerts INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_assigned_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_investigators_team_id ON investigators(team_id);
+
+CREATE TABLE alert_assignments (
+    id UUID PRIMARY KEY,
+    alert_id UUID NOT NULL REFERENCES alerts(id),
+    investigator_id VARCHAR(255) NOT NULL REFERENCES investigators(id),
+    action VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    strategy VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_alert_assignments_alert_id ON alert_assignments(alert_id, created_at);
+
+CREATE TRIGGER alert_assignments_append_only
+    BEFORE UPDATE OR DELETE ON alert_assignments
+    FOR EACH ROW EXECUTE FUNCTION reject_append_only_change();
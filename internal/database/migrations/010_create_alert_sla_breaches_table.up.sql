@@ -0,0 +1,17 @@
+CREATE TABLE alert_sla_breaches (
+    id UUID PRIMARY KEY,
+    alert_id UUID NOT NULL REFERENCES alerts(id),
+    policy VARCHAR(100) NOT NULL,
+    priority VARCHAR(50) NOT NULL,
+    deadline TIMESTAMP WITH TIME ZONE NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    actions TEXT NOT NULL DEFAULT '[]',
+    detail TEXT NOT NULL DEFAULT '',
+    UNIQUE (alert_id, policy)
+);
+
+CREATE INDEX idx_alert_sla_breaches_detected_at ON alert_sla_breaches(detected_at);
+
+CREATE TRIGGER alert_sla_breaches_append_only
+    BEFORE UPDATE OR DELETE ON alert_sla_breaches
+    FOR EACH ROW EXECUTE FUNCTION reject_append_only_change();
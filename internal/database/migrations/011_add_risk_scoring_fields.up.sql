@@ -0,0 +1,12 @@
+ALTER TABLE alerts ADD COLUMN score_breakdown TEXT;
+
+-- accounts holds KYC data that is normally loaded from the core banking system. Create it for
+-- installs that don't have it yet; a NULL risk_rating means the rating is unknown.
+CREATE TABLE IF NOT EXISTS accounts (
+    account_id VARCHAR(255) PRIMARY KEY,
+    holder_name VARCHAR(255),
+    address TEXT,
+    date_of_birth VARCHAR(10)
+);
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS risk_rating VARCHAR(20);
@@ -0,0 +1 @@
+ALTER TABLE alerts ADD COLUMN findings TEXT;
@@ -0,0 +1,3 @@
+ALTER TABLE alerts ADD COLUMN disposition_code VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_alerts_disposition ON alerts(disposition_code, transition_at);
@@ -0,0 +1,61 @@
+CREATE TABLE sars (
+    id UUID PRIMARY KEY,
+    case_id UUID REFERENCES cases(id),
+    status VARCHAR(50) NOT NULL,
+    filing_type VARCHAR(50) NOT NULL,
+    prior_sar_id UUID REFERENCES sars(id),
+    report TEXT NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    submitted_by VARCHAR(255) NOT NULL DEFAULT '',
+    submitted_at TIMESTAMP WITH TIME ZONE,
+    approved_by VARCHAR(255) NOT NULL DEFAULT '',
+    approved_at TIMESTAMP WITH TIME ZONE,
+    filed_by VARCHAR(255) NOT NULL DEFAULT '',
+    filed_at TIMESTAMP WITH TIME ZONE,
+    filing_reference VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_sars_status ON sars(status, updated_at);
+CREATE INDEX idx_sars_case_id ON sars(case_id);
+CREATE INDEX idx_sars_prior_sar_id ON sars(prior_sar_id);
+
+CREATE TABLE sar_alerts (
+    sar_id UUID NOT NULL REFERENCES sars(id),
+    alert_id UUID NOT NULL REFERENCES alerts(id),
+    PRIMARY KEY (sar_id, alert_id)
+);
+
+CREATE INDEX idx_sar_alerts_alert_id ON sar_alerts(alert_id);
+
+CREATE TABLE sar_events (
+    id UUID PRIMARY KEY,
+    sar_id UUID NOT NULL REFERENCES sars(id),
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_sar_events_sar_id ON sar_events(sar_id, created_at);
+
+-- A SAR's report is frozen when it is filed.
+CREATE TABLE sar_snapshots (
+    sar_id UUID PRIMARY KEY REFERENCES sars(id),
+    report TEXT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Events and snapshots are append-only: reject any attempt to rewrite or remove them.
+
+CREATE TRIGGER sar_events_append_only
+    BEFORE UPDATE OR DELETE ON sar_events
+    FOR EACH ROW EXECUTE FUNCTION reject_append_only_change();
+
+CREATE TRIGGER sar_snapshots_append_only
+    BEFORE UPDATE OR DELETE ON sar_snapshots
+    FOR EACH ROW EXECUTE FUNCTION reject_append_only_change();
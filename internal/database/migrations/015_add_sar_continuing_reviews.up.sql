@@ -0,0 +1,3 @@
+ALTER TABLE sars ADD COLUMN review_due_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_sars_review_due_at ON sars(review_due_at);
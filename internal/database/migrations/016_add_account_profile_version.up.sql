@@ -0,0 +1,3 @@
+-- version guards profile updates against concurrent ingests of the same account; rows saved
+-- before it existed count as their first version.
+ALTER TABLE account_profiles ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
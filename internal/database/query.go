@@ -0,0 +1,55 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// QueryIn runs a query over a list of values that may be too long for one IN list. The query's
+// %s marks where the IN list goes; it is run once per chunk of at most MaxInParams values, with
+// the chunk bound first and args after it. scan is called for each row as it is read, so callers
+// can aggregate without holding every row. Rows arrive in order within a chunk only, so callers
+// that need a global order must sort what they keep.
+func QueryIn(db DBTX, query string, values []string, scan func(rows *sql.Rows) error, args ...interface{}) error {
+	if !strings.Contains(query, "%s") {
+		return fmt.Errorf("query has no IN list placeholder")
+	}
+	for _, chunk := range Chunks(values, MaxInParams) {
+		chunkArgs := make([]interface{}, 0, len(chunk)+len(args))
+		for _, v := range chunk {
+			chunkArgs = append(chunkArgs, v)
+		}
+		chunkArgs = append(chunkArgs, args...)
+
+		if err := queryChunk(db, strings.Replace(query, "%s", Placeholders(len(chunk)), 1), chunkArgs, scan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func queryChunk(db DBTX, query string, args []interface{}, scan func(rows *sql.Rows) error) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// IsUndefinedTable reports whether err is the driver's error for a query on a table that does
+// not exist: SQLite's "no such table" or Postgres' undefined_table (42P01).
+func IsUndefinedTable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "no such table") || strings.Contains(msg, "42P01") ||
+		(strings.Contains(msg, "relation ") && strings.Contains(msg, " does not exist"))
+}
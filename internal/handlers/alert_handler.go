@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/models"
+	"AML/internal/services"
+)
+
+// alertDetailResponse is the body returned for a single alert.
+type alertDetailResponse struct {
+	Alert       *models.Alert            `json:"alert"`
+	Transaction *models.Transaction      `json:"transaction"`
+	Evidence    []models.AlertEvidence   `json:"evidence"`
+	Assignments []models.AlertAssignment `json:"assignments"`
+}
+
+// ListAlertsHandler lists alerts with filtering, sorting and cursor pagination.
+func ListAlertsHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filter, err := parseAlertFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		page, err := services.ListAlerts(db, filter)
+		if errors.Is(err, services.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to list alerts", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, page)
+	}
+}
+
+// GetAlertHandler returns one alert together with the transaction that raised it, its evidence and
+// its assignment log.
+func GetAlertHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		alert, err := services.GetAlert(db, r.PathValue("id"))
+		if errors.Is(err, services.ErrAlertNotFound) {
+			http.Error(w, "Alert not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load alert", http.StatusInternalServerError)
+			return
+		}
+
+		tx, err := services.GetTransaction(db, alert.TransactionID)
+		if err != nil && !errors.Is(err, services.ErrTransactionNotFound) {
+			http.Error(w, "Failed to load transaction", http.StatusInternalServerError)
+			return
+		}
+
+		evidence, err := services.ListAlertEvidence(db, alert.ID)
+		if err != nil {
+			http.Error(w, "Failed to load alert evidence", http.StatusInternalServerError)
+			return
+		}
+
+		assignments, err := services.ListAlertAssignments(db, alert.ID)
+		if err != nil {
+			http.Error(w, "Failed to load alert assignments", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, alertDetailResponse{Alert: alert, Transaction: tx, Evidence: evidence, Assignments: assignments})
+	}
+}
+
+// alertTransitionRequest is the body accepted by TransitionAlertHandler.
+type alertTransitionRequest struct {
+	Status     string            `json:"status"`
+	Comment    string            `json:"comment"`
+	AssignedTo string            `json:"assigned_to"`
+	Fields     map[string]string `json:"fields"`
+}
+
+// alertTransitionResponse is the body returned after a status change.
+type alertTransitionResponse struct {
+	Alert  *models.Alert             `json:"alert"`
+	Change *models.AlertStatusChange `json:"change"`
+}
+
+// TransitionAlertHandler moves an alert to a new status and records the change in its history.
+// The change is made as the authenticated caller, with their configured roles.
+func TransitionAlertHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body alertTransitionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		dbTx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Failed to transition alert", http.StatusInternalServerError)
+			return
+		}
+		defer dbTx.Rollback()
+
+		alert, change, err := services.ApplyAlertTransition(dbTx, services.AlertTransitionRequest{
+			AlertID:   r.PathValue("id"),
+			NewStatus: body.Status,
+			Actor:     requestActor(r),
+			Comment:   body.Comment,
+			AssignTo:  body.AssignedTo,
+			Roles:     requestRoles(r),
+			Fields:    body.Fields,
+		})
+		switch {
+		case errors.Is(err, services.ErrActorRequired):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case errors.Is(err, services.ErrTransitionForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, services.ErrCommentRequired), errors.Is(err, services.ErrMissingTransitionField), errors.Is(err, services.ErrInvalidDisposition),
+			errors.Is(err, services.ErrInvalidInvestigator):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, services.ErrAlertNotFound):
+			http.Error(w, "Alert not found", http.StatusNotFound)
+			return
+		case errors.Is(err, services.ErrInvestigatorNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrConcurrentTransition),
+			errors.Is(err, services.ErrAlertAlreadyAssigned), errors.Is(err, services.ErrAlertClosed):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, "Failed to transition alert", http.StatusInternalServerError)
+			return
+		}
+
+		if err := dbTx.Commit(); err != nil {
+			http.Error(w, "Failed to transition alert", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, alertTransitionResponse{Alert: alert, Change: change})
+	}
+}
+
+// AlertHistoryHandler returns an alert's status history, oldest first.
+func AlertHistoryHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.PathValue("id")
+		if _, err := services.GetAlert(db, id); err != nil {
+			if errors.Is(err, services.ErrAlertNotFound) {
+				http.Error(w, "Alert not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Failed to load alert", http.StatusInternalServerError)
+			return
+		}
+
+		history, err := services.ListAlertStatusHistory(db, id)
+		if err != nil {
+			http.Error(w, "Failed to load alert history", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"history": history})
+	}
+}
+
+// parseAlertFilter builds an alert filter from query parameters. List parameters accept
+// comma-separated values.
+func parseAlertFilter(q url.Values) (services.AlertFilter, error) {
+	filter := services.AlertFilter{
+		Statuses:   splitParam(q.Get("status")),
+		AlertTypes: splitParam(q.Get("type")),
+		AccountID:  q.Get("account_id"),
+		AssignedTo: q.Get("assigned_to"),
+		CaseID:     q.Get("case_id"),
+		SortBy:     q.Get("sort"),
+		Cursor:     q.Get("cursor"),
+	}
+
+	for _, p := range splitParam(q.Get("priority")) {
+		priority, err := models.ParsePriorityLevel(p)
+		if err != nil {
+			return filter, err
+		}
+		filter.Priorities = append(filter.Priorities, priority)
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimeParam(q, "created_from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTimeParam(q, "created_to"); err != nil {
+		return filter, err
+	}
+	if filter.MinScore, err = parseFloatParam(q, "min_score"); err != nil {
+		return filter, err
+	}
+	if filter.MaxScore, err = parseFloatParam(q, "max_score"); err != nil {
+		return filter, err
+	}
+
+	switch strings.ToLower(q.Get("order")) {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	switch filter.SortBy {
+	case "", services.AlertSortCreatedAt, services.AlertSortScore, services.AlertSortPriority:
+	default:
+		return filter, fmt.Errorf("sort must be one of created_at, score, priority")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// splitParam splits a comma-separated query value, dropping empty entries.
+func splitParam(v string) []string {
+	var values []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// parseTimeParam parses an RFC 3339 query parameter, returning the zero time when absent.
+func parseTimeParam(q url.Values, name string) (time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return t, nil
+}
+
+// parseFloatParam parses a numeric query parameter, returning nil when absent.
+func parseFloatParam(q url.Values, name string) (*float64, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+	return &f, nil
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/services"
+)
+
+// DispositionCodesHandler lists the disposition codes alerts may be closed with.
+func DispositionCodesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"codes": services.DispositionCodes()})
+	}
+}
+
+// FalsePositiveReportHandler reports false-positive rates per rule, detector and threshold band
+// for alerts dispositioned between the from and to query parameters, optionally broken down by
+// period (day, week or month).
+func FalsePositiveReportHandler(db *database.DB, analytics *services.DispositionAnalytics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, ok := parseReportRange(w, r)
+		if !ok {
+			return
+		}
+		report, err := analytics.FalsePositiveReport(db, from, to, r.URL.Query().Get("period"))
+		if errors.Is(err, services.ErrInvalidReportPeriod) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to build false-positive report", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// ThresholdTuningHandler recommends rule thresholds from the alerts dispositioned between the from
+// and to query parameters.
+func ThresholdTuningHandler(db *database.DB, analytics *services.DispositionAnalytics, rules []config.Rule) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, ok := parseReportRange(w, r)
+		if !ok {
+			return
+		}
+		recommendations, err := analytics.RecommendThresholds(db, rules, from, to)
+		if err != nil {
+			http.Error(w, "Failed to recommend thresholds", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"recommendations": recommendations})
+	}
+}
+
+// parseReportRange reads the from and to query parameters, writing a 400 response if either is
+// malformed.
+func parseReportRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	q := r.URL.Query()
+	from, err := parseTimeParam(q, "from")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := parseTimeParam(q, "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/models"
+	"AML/internal/services"
+)
+
+// createTeamRequest is the body accepted when creating a team.
+type createTeamRequest struct {
+	Name       string   `json:"name"`
+	AlertTypes []string `json:"alert_types"`
+}
+
+// createInvestigatorRequest is the body accepted when registering an investigator.
+type createInvestigatorRequest struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	TeamID        string   `json:"team_id"`
+	Skills        []string `json:"skills"`
+	MaxOpenAlerts int      `json:"max_open_alerts"`
+	Active        *bool    `json:"active"`
+}
+
+// assignAlertRequest is the body accepted by AssignAlertHandler.
+type assignAlertRequest struct {
+	InvestigatorID string `json:"investigator_id"`
+}
+
+// TeamsHandler lists teams (GET) and creates them (POST).
+func TeamsHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			teams, err := services.ListTeams(db)
+			if err != nil {
+				http.Error(w, "Failed to list teams", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"teams": teams})
+
+		case http.MethodPost:
+			var body createTeamRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			runAssignmentUpdate(w, db, http.StatusCreated, "Failed to create team", func(tx database.DBTX) (interface{}, error) {
+				return services.CreateTeam(tx, body.Name, body.AlertTypes, time.Now())
+			})
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// InvestigatorsHandler lists investigators (GET, optionally by team_id) and registers them (POST).
+// New investigators are active unless the body says otherwise.
+func InvestigatorsHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			investigators, err := services.ListInvestigators(db, r.URL.Query().Get("team_id"))
+			if err != nil {
+				http.Error(w, "Failed to list investigators", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"investigators": investigators})
+
+		case http.MethodPost:
+			var body createInvestigatorRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			investigator := &models.Investigator{
+				ID:            body.ID,
+				Name:          body.Name,
+				TeamID:        body.TeamID,
+				Skills:        body.Skills,
+				MaxOpenAlerts: body.MaxOpenAlerts,
+				Active:        body.Active == nil || *body.Active,
+			}
+			runAssignmentUpdate(w, db, http.StatusCreated, "Failed to create investigator", func(tx database.DBTX) (interface{}, error) {
+				if err := services.CreateInvestigator(tx, investigator, time.Now()); err != nil {
+					return nil, err
+				}
+				return investigator, nil
+			})
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// TeamQueueHandler returns a team's unassigned open alerts in priority order. An optional limit
+// query parameter caps the number returned.
+func TeamQueueHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var limit int
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid limit: "+v, http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		alerts, err := services.TeamQueue(db, r.PathValue("id"), limit)
+		if errors.Is(err, services.ErrTeamNotFound) {
+			http.Error(w, "Team not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load queue", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"alerts": alerts})
+	}
+}
+
+// InvestigatorQueueHandler returns the open alerts assigned to an investigator in priority order.
+func InvestigatorQueueHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		alerts, err := services.InvestigatorQueue(db, r.PathValue("id"))
+		if errors.Is(err, services.ErrInvestigatorNotFound) {
+			http.Error(w, "Investigator not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load queue", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"alerts": alerts})
+	}
+}
+
+// WorkloadHandler returns each investigator's open alert count, broken down by priority.
+func WorkloadHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		workloads, err := services.ListWorkloads(db)
+		if err != nil {
+			http.Error(w, "Failed to load workloads", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"workloads": workloads})
+	}
+}
+
+// ClaimAlertHandler assigns an unassigned alert to the authenticated investigator.
+func ClaimAlertHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+		runAssignmentUpdate(w, db, http.StatusOK, "Failed to claim alert", func(tx database.DBTX) (interface{}, error) {
+			return services.ClaimAlert(tx, r.PathValue("id"), requestActor(r), time.Now())
+		})
+	}
+}
+
+// ReleaseAlertHandler returns an alert held by the authenticated investigator to the queue.
+func ReleaseAlertHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+		runAssignmentUpdate(w, db, http.StatusOK, "Failed to release alert", func(tx database.DBTX) (interface{}, error) {
+			return services.ReleaseAlert(tx, r.PathValue("id"), requestActor(r), time.Now())
+		})
+	}
+}
+
+// AssignAlertHandler assigns an alert to the investigator named in the body, or, when none is
+// named, to the one chosen by the configured assignment strategy.
+func AssignAlertHandler(db *database.DB, assigner *services.Assigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body assignAlertRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		actor := requestActor(r)
+		if actor == "" {
+			http.Error(w, services.ErrActorRequired.Error(), http.StatusUnauthorized)
+			return
+		}
+		if body.InvestigatorID == "" && assigner == nil {
+			http.Error(w, "investigator_id is required when automatic assignment is disabled", http.StatusBadRequest)
+			return
+		}
+
+		runAssignmentUpdate(w, db, http.StatusOK, "Failed to assign alert", func(tx database.DBTX) (interface{}, error) {
+			if body.InvestigatorID == "" {
+				return assigner.Assign(tx, r.PathValue("id"), actor, time.Now())
+			}
+			return services.AssignAlert(tx, r.PathValue("id"), body.InvestigatorID, actor, time.Now())
+		})
+	}
+}
+
+// runAssignmentUpdate runs a team, investigator or assignment change in a database transaction and
+// writes its result, mapping assignment errors to HTTP statuses. failure is the message used for
+// unexpected errors.
+func runAssignmentUpdate(w http.ResponseWriter, db *database.DB, status int, failure string, update func(tx database.DBTX) (interface{}, error)) {
+	dbTx, err := db.Begin()
+	if err != nil {
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	result, err := update(dbTx)
+	switch {
+	case errors.Is(err, services.ErrActorRequired):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, services.ErrInvalidInvestigator):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrAlertNotFound), errors.Is(err, services.ErrTeamNotFound),
+		errors.Is(err, services.ErrInvestigatorNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrAlertAlreadyAssigned), errors.Is(err, services.ErrAlertNotAssigned),
+		errors.Is(err, services.ErrAlertClosed), errors.Is(err, services.ErrNoEligibleInvestigator):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, status, result)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"AML/internal/config"
+)
+
+// authActor is the authenticated caller of a request, with the roles configured for them.
+type authActor struct {
+	id    string
+	roles []string
+}
+
+type actorContextKey struct{}
+
+// Authenticate identifies the caller of every request before passing it to next. Callers
+// present an API token as "Authorization: Bearer <token>", or, when the config names a trusted
+// proxy header, are identified by that header. Their roles come from the config. Requests from
+// unknown callers are rejected with 401 Unauthorized.
+func Authenticate(cfg config.AuthConfig, next http.Handler) http.Handler {
+	byToken := make(map[string]authActor)
+	byID := make(map[string]authActor)
+	for _, a := range cfg.Actors {
+		actor := authActor{id: a.ID, roles: a.Roles}
+		byID[a.ID] = actor
+		if a.TokenSHA256 != "" {
+			byToken[strings.ToLower(a.TokenSHA256)] = actor
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var actor authActor
+		var ok bool
+		if cfg.TrustedProxyHeader != "" {
+			actor, ok = byID[r.Header.Get(cfg.TrustedProxyHeader)]
+		} else if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+			actor, ok = byToken[config.HashAuthToken(strings.TrimSpace(token))]
+		}
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="aml"`)
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorContextKey{}, actor)))
+	})
+}
+
+// requestActor returns the ID of the request's authenticated caller, or "" outside Authenticate.
+func requestActor(r *http.Request) string {
+	actor, _ := r.Context().Value(actorContextKey{}).(authActor)
+	return actor.id
+}
+
+// requestRoles returns the configured roles of the request's authenticated caller.
+func requestRoles(r *http.Request) []string {
+	actor, _ := r.Context().Value(actorContextKey{}).(authActor)
+	return actor.roles
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/models"
+	"AML/internal/services"
+)
+
+// createCaseRequest is the body accepted when opening a case.
+type createCaseRequest struct {
+	SubjectAccountID string   `json:"subject_account_id"`
+	Title            string   `json:"title"`
+	AssignedTo       string   `json:"assigned_to"`
+	AlertIDs         []string `json:"alert_ids"`
+}
+
+// caseAlertsRequest is the body accepted when attaching alerts to a case.
+type caseAlertsRequest struct {
+	AlertIDs []string `json:"alert_ids"`
+}
+
+// assignCaseRequest is the body accepted when assigning a case.
+type assignCaseRequest struct {
+	AssignedTo string `json:"assigned_to"`
+}
+
+// caseNoteRequest is the body accepted when adding a note to a case.
+type caseNoteRequest struct {
+	Body string `json:"body"`
+}
+
+// caseAttachmentRequest is the body accepted when attaching a document to a case.
+type caseAttachmentRequest struct {
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	URI         string `json:"uri"`
+	SizeBytes   int64  `json:"size_bytes"`
+	SHA256      string `json:"sha256"`
+}
+
+// mergeCasesRequest is the body accepted when merging cases into the case named in the path.
+type mergeCasesRequest struct {
+	SourceCaseIDs []string `json:"source_case_ids"`
+}
+
+// splitCaseRequest is the body accepted when splitting alerts out of a case.
+type splitCaseRequest struct {
+	AlertIDs   []string `json:"alert_ids"`
+	Title      string   `json:"title"`
+	AssignedTo string   `json:"assigned_to"`
+}
+
+// closeCaseRequest is the body accepted when closing a case.
+type closeCaseRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CasesHandler lists cases (GET) and opens them (POST) as the authenticated analyst.
+func CasesHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			cases, err := services.ListCases(db, services.CaseFilter{
+				Status:           q.Get("status"),
+				SubjectAccountID: q.Get("account_id"),
+				AssignedTo:       q.Get("assigned_to"),
+			})
+			if err != nil {
+				http.Error(w, "Failed to list cases", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"cases": cases})
+
+		case http.MethodPost:
+			var body createCaseRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			runCaseUpdate(w, db, http.StatusCreated, "Failed to create case", func(tx database.DBTX) (interface{}, error) {
+				return services.CreateCase(tx, body.SubjectAccountID, body.Title, body.AssignedTo, body.AlertIDs, requestActor(r), time.Now())
+			})
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// GetCaseHandler returns a case with its alerts, notes and attachments.
+func GetCaseHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		detail, err := services.GetCaseDetail(db, r.PathValue("id"))
+		if errors.Is(err, services.ErrCaseNotFound) {
+			http.Error(w, "Case not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load case", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, detail)
+	}
+}
+
+// AttachCaseAlertsHandler moves alerts into an open case.
+func AttachCaseAlertsHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body caseAlertsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		id := r.PathValue("id")
+		runCaseUpdate(w, db, http.StatusOK, "Failed to attach alerts", func(tx database.DBTX) (interface{}, error) {
+			if err := services.AttachAlertsToCase(tx, id, body.AlertIDs, requestActor(r), time.Now()); err != nil {
+				return nil, err
+			}
+			return services.GetCaseDetail(tx, id)
+		})
+	}
+}
+
+// AssignCaseHandler changes a case's assignee.
+func AssignCaseHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body assignCaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		runCaseUpdate(w, db, http.StatusOK, "Failed to assign case", func(tx database.DBTX) (interface{}, error) {
+			return services.AssignCase(tx, r.PathValue("id"), body.AssignedTo, requestActor(r), time.Now())
+		})
+	}
+}
+
+// AddCaseNoteHandler appends a note to a case.
+func AddCaseNoteHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body caseNoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		id := r.PathValue("id")
+		runCaseUpdate(w, db, http.StatusCreated, "Failed to add note", func(tx database.DBTX) (interface{}, error) {
+			if _, err := services.GetCase(tx, id); err != nil {
+				return nil, err
+			}
+			return services.AddCaseNote(tx, id, requestActor(r), body.Body, time.Now())
+		})
+	}
+}
+
+// AddCaseAttachmentHandler records a document attached to a case. The document itself is stored
+// elsewhere; the case keeps its location and checksum.
+func AddCaseAttachmentHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body caseAttachmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		attachment := &models.CaseAttachment{
+			CaseID:      r.PathValue("id"),
+			FileName:    body.FileName,
+			ContentType: body.ContentType,
+			URI:         body.URI,
+			SizeBytes:   body.SizeBytes,
+			SHA256:      body.SHA256,
+			UploadedBy:  requestActor(r),
+		}
+		runCaseUpdate(w, db, http.StatusCreated, "Failed to add attachment", func(tx database.DBTX) (interface{}, error) {
+			if err := services.AddCaseAttachment(tx, attachment, time.Now()); err != nil {
+				return nil, err
+			}
+			return attachment, nil
+		})
+	}
+}
+
+// MergeCasesHandler merges the listed source cases into the case named in the path.
+func MergeCasesHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body mergeCasesRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		id := r.PathValue("id")
+		runCaseUpdate(w, db, http.StatusOK, "Failed to merge cases", func(tx database.DBTX) (interface{}, error) {
+			if _, err := services.MergeCases(tx, id, body.SourceCaseIDs, requestActor(r), time.Now()); err != nil {
+				return nil, err
+			}
+			return services.GetCaseDetail(tx, id)
+		})
+	}
+}
+
+// SplitCaseHandler moves alerts out of a case into a new case and returns the new case.
+func SplitCaseHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body splitCaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		runCaseUpdate(w, db, http.StatusCreated, "Failed to split case", func(tx database.DBTX) (interface{}, error) {
+			split, err := services.SplitCase(tx, r.PathValue("id"), body.AlertIDs, body.Title, body.AssignedTo, requestActor(r), time.Now())
+			if err != nil {
+				return nil, err
+			}
+			return services.GetCaseDetail(tx, split.ID)
+		})
+	}
+}
+
+// CloseCaseHandler closes an open case. A reason is required.
+func CloseCaseHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body closeCaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		runCaseUpdate(w, db, http.StatusOK, "Failed to close case", func(tx database.DBTX) (interface{}, error) {
+			return services.CloseCase(tx, r.PathValue("id"), body.Reason, requestActor(r), time.Now())
+		})
+	}
+}
+
+// runCaseUpdate runs a case change in a database transaction and writes its result, mapping
+// case errors to HTTP statuses. failure is the message used for unexpected errors.
+func runCaseUpdate(w http.ResponseWriter, db *database.DB, status int, failure string, update func(tx database.DBTX) (interface{}, error)) {
+	dbTx, err := db.Begin()
+	if err != nil {
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	result, err := update(dbTx)
+	switch {
+	case errors.Is(err, services.ErrActorRequired):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, services.ErrInvalidCase), errors.Is(err, services.ErrCommentRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrCaseNotFound), errors.Is(err, services.ErrAlertNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrCaseNotOpen):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, status, result)
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/services"
+)
+
+// createSARRequest is the body accepted when drafting a SAR, from a case or from alerts.
+type createSARRequest struct {
+	CaseID   string   `json:"case_id"`
+	AlertIDs []string `json:"alert_ids"`
+}
+
+// sarNarrativeRequest is the body accepted when editing a draft SAR's narrative.
+type sarNarrativeRequest struct {
+	Narrative string `json:"narrative"`
+}
+
+// sarReviewRequest is the body accepted by the submit, approve, reject, amend and
+// continuing-review steps.
+type sarReviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+// fileSARRequest is the body accepted when recording a SAR as filed.
+type fileSARRequest struct {
+	FilingReference string `json:"filing_reference"`
+}
+
+// SARsHandler lists SARs (GET) and drafts them (POST) with the authenticated caller as preparer.
+func SARsHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			sars, err := services.ListSARs(db, services.SARFilter{
+				Status:  q.Get("status"),
+				CaseID:  q.Get("case_id"),
+				AlertID: q.Get("alert_id"),
+			})
+			if err != nil {
+				http.Error(w, "Failed to list SARs", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"sars": sars})
+
+		case http.MethodPost:
+			var body createSARRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			runSARUpdate(w, db, http.StatusCreated, "Failed to create SAR", func(tx database.DBTX) (interface{}, error) {
+				return lifecycle.Create(tx, body.CaseID, body.AlertIDs, requestActor(r), time.Now())
+			})
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// GetSARHandler returns a SAR with its lifecycle history.
+func GetSARHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		detail, err := services.GetSARDetail(db, r.PathValue("id"))
+		if errors.Is(err, services.ErrSARNotFound) {
+			http.Error(w, "SAR not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load SAR", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, detail)
+	}
+}
+
+// SARSnapshotHandler returns the report of a filed SAR exactly as it was filed.
+func SARSnapshotHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		snapshot, err := services.GetSARSnapshot(db, r.PathValue("id"))
+		if errors.Is(err, services.ErrSARNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load SAR snapshot", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, snapshot)
+	}
+}
+
+// SARNarrativeHandler replaces the narrative of a draft SAR.
+func SARNarrativeHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body sarNarrativeRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		runSARUpdate(w, db, http.StatusOK, "Failed to update SAR narrative", func(tx database.DBTX) (interface{}, error) {
+			return lifecycle.UpdateNarrative(tx, r.PathValue("id"), body.Narrative, requestActor(r), time.Now())
+		})
+	}
+}
+
+// SubmitSARHandler sends a draft SAR for review.
+func SubmitSARHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return sarReviewHandler(db, "Failed to submit SAR", func(tx database.DBTX, r *http.Request, comment string) (interface{}, error) {
+		return lifecycle.Submit(tx, r.PathValue("id"), requestActor(r), comment, time.Now())
+	})
+}
+
+// ApproveSARHandler approves a SAR under review as the authenticated caller, who must hold an
+// approver role in auth.json and must not be the preparer.
+func ApproveSARHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return sarReviewHandler(db, "Failed to approve SAR", func(tx database.DBTX, r *http.Request, comment string) (interface{}, error) {
+		return lifecycle.Approve(tx, r.PathValue("id"), requestActor(r), requestRoles(r), comment, time.Now())
+	})
+}
+
+// RejectSARHandler returns a SAR under review to draft.
+func RejectSARHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return sarReviewHandler(db, "Failed to reject SAR", func(tx database.DBTX, r *http.Request, comment string) (interface{}, error) {
+		return lifecycle.Reject(tx, r.PathValue("id"), requestActor(r), requestRoles(r), comment, time.Now())
+	})
+}
+
+// AmendSARHandler supersedes a filed SAR and returns the new draft amendment.
+func AmendSARHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return sarReviewHandler(db, "Failed to amend SAR", func(tx database.DBTX, r *http.Request, comment string) (interface{}, error) {
+		return lifecycle.Amend(tx, r.PathValue("id"), requestActor(r), comment, time.Now())
+	})
+}
+
+// ContinueSARHandler drafts a continuing-activity SAR following a filed one.
+func ContinueSARHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return sarReviewHandler(db, "Failed to continue SAR", func(tx database.DBTX, r *http.Request, comment string) (interface{}, error) {
+		return lifecycle.Continue(tx, r.PathValue("id"), requestActor(r), time.Now())
+	})
+}
+
+// CloseContinuingReviewHandler records that a filed SAR's continuing-activity review found
+// nothing further to report.
+func CloseContinuingReviewHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return sarReviewHandler(db, "Failed to close continuing-activity review", func(tx database.DBTX, r *http.Request, comment string) (interface{}, error) {
+		return lifecycle.CloseContinuingReview(tx, r.PathValue("id"), requestActor(r), requestRoles(r), comment, time.Now())
+	})
+}
+
+// ContinuingReviewsHandler lists the filed SARs due a review for continuing activity.
+func ContinuingReviewsHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		sars, err := services.ListDueContinuingReviews(db, time.Now())
+		if err != nil {
+			http.Error(w, "Failed to list continuing-activity reviews", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"sars": sars})
+	}
+}
+
+// FileSARHandler records an approved SAR as filed under the regulator's reference.
+func FileSARHandler(db *database.DB, lifecycle *services.SARLifecycle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body fileSARRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		runSARUpdate(w, db, http.StatusOK, "Failed to file SAR", func(tx database.DBTX) (interface{}, error) {
+			return lifecycle.File(tx, r.PathValue("id"), requestActor(r), requestRoles(r), body.FilingReference, time.Now())
+		})
+	}
+}
+
+// sarReviewHandler accepts a POST with an optional comment and runs one lifecycle step.
+func sarReviewHandler(db *database.DB, failure string, step func(tx database.DBTX, r *http.Request, comment string) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body sarReviewRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+		runSARUpdate(w, db, http.StatusOK, failure, func(tx database.DBTX) (interface{}, error) {
+			return step(tx, r, body.Comment)
+		})
+	}
+}
+
+// runSARUpdate runs a SAR change in a database transaction, committing it only if the change
+// succeeds, and maps lifecycle errors to HTTP statuses.
+func runSARUpdate(w http.ResponseWriter, db *database.DB, status int, failure string, update func(tx database.DBTX) (interface{}, error)) {
+	dbTx, err := db.Begin()
+	if err != nil {
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	result, err := update(dbTx)
+	switch {
+	case errors.Is(err, services.ErrActorRequired):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, services.ErrInvalidSAR), errors.Is(err, services.ErrSARInvalid), errors.Is(err, services.ErrCommentRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrTransitionForbidden), errors.Is(err, services.ErrFourEyes):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, services.ErrSARNotFound), errors.Is(err, services.ErrCaseNotFound), errors.Is(err, services.ErrAlertNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrSARLocked), errors.Is(err, services.ErrInvalidSARTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, status, result)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/models"
+	"AML/internal/services"
+)
+
+// AlertDeadlinesHandler lists open alerts approaching or past an SLA deadline, soonest first.
+// The state query parameter narrows the list to ON_TRACK, APPROACHING or BREACHED stages.
+func AlertDeadlinesHandler(db *database.DB, monitor *services.SLAMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		state := strings.ToUpper(r.URL.Query().Get("state"))
+		switch state {
+		case "", models.SLAStateOnTrack, models.SLAStateApproaching, models.SLAStateBreached:
+		default:
+			http.Error(w, "invalid state: "+state, http.StatusBadRequest)
+			return
+		}
+
+		deadlines, err := monitor.ListDeadlines(db, state, time.Now())
+		if err != nil {
+			http.Error(w, "Failed to list deadlines", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"deadlines": deadlines})
+	}
+}
+
+// SLABreachesHandler lists recorded SLA breaches, optionally for one alert_id.
+func SLABreachesHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		breaches, err := services.ListSLABreaches(db, r.URL.Query().Get("alert_id"))
+		if err != nil {
+			http.Error(w, "Failed to list SLA breaches", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"breaches": breaches})
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/services"
+)
+
+// createSuppressionRequest is the body accepted when creating a suppression.
+type createSuppressionRequest struct {
+	AccountID string    `json:"account_id"`
+	RuleKey   string    `json:"rule_key"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// revokeSuppressionRequest is the body accepted when revoking a suppression.
+type revokeSuppressionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// SuppressionsHandler lists suppressions (GET) and creates them (POST) as the authenticated
+// analyst.
+func SuppressionsHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			var activeAt time.Time
+			if r.URL.Query().Get("active") == "true" {
+				activeAt = time.Now()
+			}
+			suppressions, err := services.ListSuppressions(db, r.URL.Query().Get("account_id"), activeAt)
+			if err != nil {
+				http.Error(w, "Failed to list suppressions", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"suppressions": suppressions})
+
+		case http.MethodPost:
+			var body createSuppressionRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			dbTx, err := db.Begin()
+			if err != nil {
+				http.Error(w, "Failed to create suppression", http.StatusInternalServerError)
+				return
+			}
+			defer dbTx.Rollback()
+
+			s, err := services.CreateSuppression(dbTx, body.AccountID, body.RuleKey, body.Reason, requestActor(r), body.ExpiresAt, time.Now())
+			switch {
+			case errors.Is(err, services.ErrActorRequired):
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			case errors.Is(err, services.ErrInvalidSuppression):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			case err != nil:
+				http.Error(w, "Failed to create suppression", http.StatusInternalServerError)
+				return
+			}
+
+			if err := dbTx.Commit(); err != nil {
+				http.Error(w, "Failed to create suppression", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, s)
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// RevokeSuppressionHandler ends a suppression before it expires. A comment is required.
+func RevokeSuppressionHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body revokeSuppressionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		dbTx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Failed to revoke suppression", http.StatusInternalServerError)
+			return
+		}
+		defer dbTx.Rollback()
+
+		s, err := services.RevokeSuppression(dbTx, r.PathValue("id"), requestActor(r), body.Comment, time.Now())
+		switch {
+		case errors.Is(err, services.ErrActorRequired):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case errors.Is(err, services.ErrCommentRequired):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, services.ErrSuppressionNotFound):
+			http.Error(w, "Suppression not found", http.StatusNotFound)
+			return
+		case errors.Is(err, services.ErrInvalidSuppression):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, "Failed to revoke suppression", http.StatusInternalServerError)
+			return
+		}
+
+		if err := dbTx.Commit(); err != nil {
+			http.Error(w, "Failed to revoke suppression", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, s)
+	}
+}
+
+// SuppressionAuditHandler returns a suppression's audit trail, including every alert it muted.
+func SuppressionAuditHandler(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.PathValue("id")
+		s, err := services.GetSuppression(db, id)
+		if errors.Is(err, services.ErrSuppressionNotFound) {
+			http.Error(w, "Suppression not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load suppression", http.StatusInternalServerError)
+			return
+		}
+
+		audit, err := services.ListSuppressionAudit(db, id)
+		if err != nil {
+			http.Error(w, "Failed to load suppression audit", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"suppression": s, "audit": audit})
+	}
+}
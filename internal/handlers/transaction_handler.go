@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"regexp"
 	"strings"
@@ -11,11 +11,14 @@ import (
 
 	"github.com/google/uuid"
 
+	"AML/internal/database"
 	"AML/internal/models"
+	"AML/internal/services"
 )
 
-// TransactionHandler handles the creation of new transactions.
-func TransactionHandler(db *sql.DB) http.HandlerFunc {
+// TransactionHandler handles the creation of new transactions. Each transaction is run through
+// the processor's detectors and any resulting alerts are filed with it.
+func TransactionHandler(db *database.DB, processor *services.TransactionProcessor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -37,17 +40,54 @@ func TransactionHandler(db *sql.DB) http.HandlerFunc {
 			t.TransactionID = uuid.New().String()
 		}
 
-		if err := insertTransaction(db, &t); err != nil {
+		t.Timestamp = time.Now()
+
+		dbTx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
+			return
+		}
+		defer dbTx.Rollback()
+
+		if err := insertTransaction(dbTx, &t); err != nil {
+			http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
+			return
+		}
+
+		result, err := processor.Process(dbTx, t)
+		if err != nil {
+			http.Error(w, "Failed to process transaction", http.StatusInternalServerError)
+			return
+		}
+		if result.DetectionErr != nil {
+			log.Printf("Detection incomplete for transaction %s: %v", t.TransactionID, result.DetectionErr)
+		}
+
+		if err := dbTx.Commit(); err != nil {
 			http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{"transaction_id": t.TransactionID})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"transaction_id":         t.TransactionID,
+			"alert_ids":              alertIDs(result.Alerts),
+			"deduplicated_alert_ids": alertIDs(result.Deduplicated),
+			"suppressed_count":       len(result.Suppressed),
+		})
 	}
 }
 
+// alertIDs returns the IDs of the given alerts.
+func alertIDs(alerts []*models.Alert) []string {
+	ids := make([]string, len(alerts))
+	for i, alert := range alerts {
+		ids[i] = alert.ID
+	}
+	return ids
+}
+
 // validateTransaction validates the transaction data.
 func validateTransaction(t *models.Transaction) error {
 	if t.AccountID == "" || t.Currency == "" || t.SourceCountry == "" || t.DestinationCountry == "" || t.TransactionType == "" || t.Status == "" {
@@ -74,17 +114,11 @@ func isValidCurrency(currency string) bool {
 }
 
 // insertTransaction inserts a new transaction into the database.
-func insertTransaction(db *sql.DB, t *models.Transaction) error {
+func insertTransaction(db database.DBTX, t *models.Transaction) error {
 	query := `
-		INSERT INTO transactions (transaction_id, account_id, amount, currency, "timestamp", source_country, destination_country, transaction_type, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO transactions (transaction_id, account_id, amount, currency, "timestamp", source_country, destination_country, transaction_type, status, counterparty_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	stmt, err := db.Prepare(query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(t.TransactionID, t.AccountID, t.Amount, t.Currency, time.Now(), t.SourceCountry, t.DestinationCountry, t.TransactionType, t.Status)
+	_, err := db.Exec(query, t.TransactionID, t.AccountID, t.Amount, t.Currency, t.Timestamp, t.SourceCountry, t.DestinationCountry, t.TransactionType, t.Status, t.CounterpartyID)
 	return err
 }
@@ -0,0 +1,248 @@
+// Package isoforest implements an isolation forest for unsupervised outlier scoring.
+//
+// Points that can be separated from the rest of the data with few random splits are
+// anomalous. The anomaly score follows Liu, Ting and Zhou (2008): values near 1 are
+// anomalies, values well below 0.5 are normal.
+//
+// Unlike the original algorithm, a point that falls outside the range a tree or node was
+// grown on is treated as isolated at that node, since a single cut would separate it.
+// Without this, values far beyond the training data score no higher than its edges.
+package isoforest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// eulerGamma is the Euler–Mascheroni constant used to approximate harmonic numbers.
+const eulerGamma = 0.5772156649
+
+// Config controls how a forest is grown.
+type Config struct {
+	NumTrees   int   `json:"num_trees"`
+	SampleSize int   `json:"sample_size"`
+	Seed       int64 `json:"seed"`
+}
+
+// DefaultConfig returns the parameters recommended by the original paper.
+func DefaultConfig() Config {
+	return Config{NumTrees: 100, SampleSize: 256, Seed: 1}
+}
+
+// Node is a split or leaf in an isolation tree. Leaves have no children.
+type Node struct {
+	Feature int     `json:"feature,omitempty"`
+	Split   float64 `json:"split,omitempty"`
+	// Min and Max are the range of the split feature within the node's sample.
+	Min   float64 `json:"min,omitempty"`
+	Max   float64 `json:"max,omitempty"`
+	Size  int     `json:"size"`
+	Left  *Node   `json:"left,omitempty"`
+	Right *Node   `json:"right,omitempty"`
+}
+
+// Tree is an isolation tree with the bounding box of the sample it was grown on.
+type Tree struct {
+	Root  *Node     `json:"root"`
+	Lower []float64 `json:"lower"`
+	Upper []float64 `json:"upper"`
+}
+
+// IsLeaf reports whether the node has no children.
+func (n *Node) IsLeaf() bool {
+	return n.Left == nil && n.Right == nil
+}
+
+// Forest is a trained isolation forest. It serialises to JSON as-is.
+type Forest struct {
+	Trees        []*Tree `json:"trees"`
+	SampleSize   int     `json:"sample_size"`
+	FeatureCount int     `json:"feature_count"`
+}
+
+// Train grows a forest over data, where each row is one observation's feature vector.
+func Train(data [][]float64, cfg Config) (*Forest, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("at least 2 observations are required to train an isolation forest")
+	}
+	if cfg.NumTrees < 1 {
+		return nil, fmt.Errorf("num_trees must be at least 1")
+	}
+	if cfg.SampleSize < 2 {
+		return nil, fmt.Errorf("sample_size must be at least 2")
+	}
+
+	featureCount := len(data[0])
+	if featureCount == 0 {
+		return nil, fmt.Errorf("observations must have at least one feature")
+	}
+	for i, row := range data {
+		if len(row) != featureCount {
+			return nil, fmt.Errorf("observation %d has %d features, expected %d", i, len(row), featureCount)
+		}
+	}
+
+	sampleSize := cfg.SampleSize
+	if sampleSize > len(data) {
+		sampleSize = len(data)
+	}
+	heightLimit := int(math.Ceil(math.Log2(float64(sampleSize))))
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	forest := &Forest{SampleSize: sampleSize, FeatureCount: featureCount}
+	for t := 0; t < cfg.NumTrees; t++ {
+		sample := make([][]float64, sampleSize)
+		for i, idx := range rng.Perm(len(data))[:sampleSize] {
+			sample[i] = data[idx]
+		}
+		lower, upper := bounds(sample)
+		forest.Trees = append(forest.Trees, &Tree{
+			Root:  grow(sample, 0, heightLimit, rng),
+			Lower: lower,
+			Upper: upper,
+		})
+	}
+	return forest, nil
+}
+
+// grow builds an isolation tree by splitting on a random feature at a random value.
+func grow(sample [][]float64, depth, heightLimit int, rng *rand.Rand) *Node {
+	node := &Node{Size: len(sample)}
+	if depth >= heightLimit || len(sample) <= 1 {
+		return node
+	}
+
+	// Only features that vary within the sample can separate points.
+	mins, maxs := bounds(sample)
+	var candidates []int
+	for f := range mins {
+		if maxs[f] > mins[f] {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(candidates) == 0 {
+		return node
+	}
+
+	feature := candidates[rng.Intn(len(candidates))]
+	split := mins[feature] + rng.Float64()*(maxs[feature]-mins[feature])
+
+	var left, right [][]float64
+	for _, row := range sample {
+		if row[feature] < split {
+			left = append(left, row)
+		} else {
+			right = append(right, row)
+		}
+	}
+
+	node.Feature = feature
+	node.Split = split
+	node.Min = mins[feature]
+	node.Max = maxs[feature]
+	node.Left = grow(left, depth+1, heightLimit, rng)
+	node.Right = grow(right, depth+1, heightLimit, rng)
+	return node
+}
+
+// Score returns the anomaly score of x in [0, 1].
+func (f *Forest) Score(x []float64) float64 {
+	if len(f.Trees) == 0 {
+		return 0
+	}
+	var total float64
+	for _, tree := range f.Trees {
+		total += tree.pathLength(x, nil)
+	}
+	return math.Pow(2, -(total/float64(len(f.Trees)))/averagePathLength(f.SampleSize))
+}
+
+// Contributions attributes the isolation of x to its features. Every split on x's path
+// credits its feature with log2(parent size / child size), i.e. how much of the remaining
+// sample that feature cut away. The result is normalised to sum to 1.
+func (f *Forest) Contributions(x []float64) []float64 {
+	contributions := make([]float64, f.FeatureCount)
+	for _, tree := range f.Trees {
+		tree.pathLength(x, contributions)
+	}
+
+	var total float64
+	for _, c := range contributions {
+		total += c
+	}
+	if total > 0 {
+		for i := range contributions {
+			contributions[i] /= total
+		}
+	}
+	return contributions
+}
+
+// pathLength walks x down the tree and returns its adjusted path length. When credit is
+// non-nil, each split's isolation gain is added to the split feature's entry.
+func (t *Tree) pathLength(x []float64, credit []float64) float64 {
+	// A point outside the tree's sample on any feature is cut off by the first split.
+	isolated := false
+	for f := range t.Lower {
+		if x[f] < t.Lower[f] || x[f] > t.Upper[f] {
+			isolated = true
+			if credit != nil {
+				credit[f] += math.Log2(float64(t.Root.Size))
+			}
+		}
+	}
+	if isolated {
+		return 1
+	}
+
+	node := t.Root
+	depth := 0.0
+	for !node.IsLeaf() {
+		if x[node.Feature] < node.Min || x[node.Feature] > node.Max {
+			if credit != nil {
+				credit[node.Feature] += math.Log2(float64(node.Size))
+			}
+			return depth + 1
+		}
+		next := node.Right
+		if x[node.Feature] < node.Split {
+			next = node.Left
+		}
+		if credit != nil && next.Size > 0 {
+			credit[node.Feature] += math.Log2(float64(node.Size) / float64(next.Size))
+		}
+		node = next
+		depth++
+	}
+	return depth + averagePathLength(node.Size)
+}
+
+// bounds returns the per-feature minimum and maximum of a sample.
+func bounds(sample [][]float64) (lower, upper []float64) {
+	featureCount := len(sample[0])
+	lower = make([]float64, featureCount)
+	upper = make([]float64, featureCount)
+	for f := 0; f < featureCount; f++ {
+		lower[f], upper[f] = math.Inf(1), math.Inf(-1)
+		for _, row := range sample {
+			lower[f] = math.Min(lower[f], row[f])
+			upper[f] = math.Max(upper[f], row[f])
+		}
+	}
+	return lower, upper
+}
+
+// averagePathLength is c(n), the average path length of an unsuccessful search in a
+// binary search tree of n points, used to normalise path lengths.
+func averagePathLength(n int) float64 {
+	switch {
+	case n <= 1:
+		return 0
+	case n == 2:
+		return 1
+	default:
+		harmonic := math.Log(float64(n-1)) + eulerGamma
+		return 2*harmonic - 2*float64(n-1)/float64(n)
+	}
+}
@@ -0,0 +1,87 @@
+package isoforest
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+// clusteredData returns points around (10, 10) plus optional extra rows.
+func clusteredData(n int) [][]float64 {
+	rng := rand.New(rand.NewSource(7))
+	data := make([][]float64, n)
+	for i := range data {
+		data[i] = []float64{10 + rng.NormFloat64(), 10 + rng.NormFloat64()}
+	}
+	return data
+}
+
+func TestForestScore(t *testing.T) {
+	forest, err := Train(clusteredData(500), DefaultConfig())
+	if err != nil {
+		t.Fatalf("Train failed: %v", err)
+	}
+
+	// Test Case 1: Outliers score higher than inliers
+	t.Run("outlier_scores_higher", func(t *testing.T) {
+		inlier := forest.Score([]float64{10, 10})
+		outlier := forest.Score([]float64{10, 40})
+		if outlier <= inlier {
+			t.Errorf("Expected outlier score %f > inlier score %f", outlier, inlier)
+		}
+		if outlier < 0.6 {
+			t.Errorf("Expected outlier score above 0.6, got %f", outlier)
+		}
+		if inlier > 0.5 {
+			t.Errorf("Expected inlier score below 0.5, got %f", inlier)
+		}
+	})
+
+	// Test Case 2: Contributions point at the deviating feature
+	t.Run("contributions_identify_feature", func(t *testing.T) {
+		contributions := forest.Contributions([]float64{10, 40})
+		if contributions[1] <= contributions[0] {
+			t.Errorf("Expected feature 1 to dominate, got %v", contributions)
+		}
+		sum := contributions[0] + contributions[1]
+		if sum < 0.999 || sum > 1.001 {
+			t.Errorf("Expected contributions to sum to 1, got %f", sum)
+		}
+	})
+
+	// Test Case 3: Training is deterministic for a seed and survives JSON
+	t.Run("deterministic_and_serialisable", func(t *testing.T) {
+		again, err := Train(clusteredData(500), DefaultConfig())
+		if err != nil {
+			t.Fatalf("Train failed: %v", err)
+		}
+		x := []float64{12, 7}
+		if forest.Score(x) != again.Score(x) {
+			t.Errorf("Expected identical scores for identical seeds")
+		}
+
+		data, err := json.Marshal(forest)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var decoded Forest
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if decoded.Score(x) != forest.Score(x) {
+			t.Errorf("Expected decoded forest to score identically")
+		}
+	})
+}
+
+func TestTrainValidation(t *testing.T) {
+	if _, err := Train([][]float64{{1}}, DefaultConfig()); err == nil {
+		t.Errorf("Expected error for a single observation")
+	}
+	if _, err := Train([][]float64{{1, 2}, {1}}, DefaultConfig()); err == nil {
+		t.Errorf("Expected error for ragged observations")
+	}
+	if _, err := Train(clusteredData(10), Config{NumTrees: 0, SampleSize: 8}); err == nil {
+		t.Errorf("Expected error for zero trees")
+	}
+}
@@ -6,4 +6,6 @@ type Account struct {
 	HolderName  string `db:"holder_name"`
 	Address     string `db:"address"`
 	DateOfBirth string `db:"date_of_birth"`
+	// RiskRating is the customer's KYC risk rating, e.g. LOW, MEDIUM or HIGH.
+	RiskRating string `db:"risk_rating"`
 }
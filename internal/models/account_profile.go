@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// CountMap counts how often each categorical value (country, counterparty, type) was seen.
+type CountMap map[string]int64
+
+// Value implements the driver.Valuer interface.
+func (c CountMap) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *CountMap) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+	bytes, err := scanBytes(value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal CountMap value: %w", err)
+	}
+	if *c == nil {
+		*c = make(CountMap)
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// HourHistogram counts transactions per hour of the day (UTC).
+type HourHistogram [24]int64
+
+// Value implements the driver.Valuer interface.
+func (h HourHistogram) Value() (driver.Value, error) {
+	bytes, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (h *HourHistogram) Scan(value interface{}) error {
+	if value == nil {
+		*h = HourHistogram{}
+		return nil
+	}
+	bytes, err := scanBytes(value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal HourHistogram value: %w", err)
+	}
+	return json.Unmarshal(bytes, h)
+}
+
+// AccountProfile holds rolling behavioural statistics for an account, updated
+// incrementally as transactions are ingested.
+type AccountProfile struct {
+	AccountID        string `json:"account_id" db:"account_id"`
+	TransactionCount int64  `json:"transaction_count" db:"transaction_count"`
+	// AmountMean and AmountM2 are Welford accumulators over transaction amounts.
+	AmountMean float64 `json:"amount_mean" db:"amount_mean"`
+	AmountM2   float64 `json:"amount_m2" db:"amount_m2"`
+	// LogAmountMean and LogAmountM2 are Welford accumulators over log(1+amount).
+	LogAmountMean    float64       `json:"log_amount_mean" db:"log_amount_mean"`
+	LogAmountM2      float64       `json:"log_amount_m2" db:"log_amount_m2"`
+	Counterparties   CountMap      `json:"counterparties" db:"counterparties"`
+	Countries        CountMap      `json:"countries" db:"countries"`
+	TransactionTypes CountMap      `json:"transaction_types" db:"transaction_types"`
+	ActiveHours      HourHistogram `json:"active_hours" db:"active_hours"`
+	// GapCount, GapMeanHours and GapM2 are Welford accumulators over the hours between
+	// consecutive transactions, the baseline for velocity detection.
+	GapCount     int64     `json:"gap_count" db:"gap_count"`
+	GapMeanHours float64   `json:"gap_mean_hours" db:"gap_mean_hours"`
+	GapM2        float64   `json:"gap_m2" db:"gap_m2"`
+	FirstSeen    time.Time `json:"first_seen" db:"first_seen"`
+	LastSeen     time.Time `json:"last_seen" db:"last_seen"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	// Version counts the saves of the stored profile; zero means it has not been stored yet.
+	Version int64 `json:"version" db:"version"`
+}
+
+// AmountStdDev returns the population standard deviation of amounts seen so far.
+func (p *AccountProfile) AmountStdDev() float64 {
+	if p.TransactionCount == 0 {
+		return 0
+	}
+	return math.Sqrt(p.AmountM2 / float64(p.TransactionCount))
+}
+
+// LogAmountStdDev returns the population standard deviation of log amounts seen so far.
+func (p *AccountProfile) LogAmountStdDev() float64 {
+	if p.TransactionCount == 0 {
+		return 0
+	}
+	return math.Sqrt(p.LogAmountM2 / float64(p.TransactionCount))
+}
+
+// RatePerHour returns the account's historical transaction rate derived from the mean gap.
+func (p *AccountProfile) RatePerHour() float64 {
+	if p.GapCount == 0 || p.GapMeanHours <= 0 {
+		return 0
+	}
+	return 1 / p.GapMeanHours
+}
+
+// scanBytes normalises the text or blob representations drivers return for JSON columns.
+func scanBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
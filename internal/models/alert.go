@@ -4,17 +4,23 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type JSONMap map[string]interface{}
 
-// Value implements the driver.Valuer interface.
+// Value implements the driver.Valuer interface. JSON is passed as a string so it lands
+// as text rather than binary in every driver.
 func (j JSONMap) Value() (driver.Value, error) {
 	if j == nil {
 		return nil, nil
 	}
-	return json.Marshal(j)
+	bytes, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
 }
 
 // Scan implements the sql.Scanner interface.
@@ -23,8 +29,8 @@ func (j *JSONMap) Scan(value interface{}) error {
 		*j = nil
 		return nil
 	}
-	bytes, ok := value.([]byte)
-	if !ok {
+	bytes, err := scanBytes(value)
+	if err != nil {
 		return fmt.Errorf("failed to unmarshal JSONMap value: %v", value)
 	}
 	// Ensure the map is initialized before unmarshalling
@@ -51,11 +57,27 @@ func (p PriorityLevel) String() string {
 	return [...]string{"MEDIUM", "HIGH", "CRITICAL"}[p]
 }
 
+// ParsePriorityLevel parses the string representation of a PriorityLevel.
+func ParsePriorityLevel(s string) (PriorityLevel, error) {
+	switch strings.ToUpper(s) {
+	case "MEDIUM":
+		return Medium, nil
+	case "HIGH":
+		return High, nil
+	case "CRITICAL":
+		return Critical, nil
+	default:
+		return 0, fmt.Errorf("invalid priority level: %s", s)
+	}
+}
+
 const (
 	// StatusOpen is for newly created alerts.
 	StatusOpen = "OPEN"
 	// StatusInvestigating is for alerts under investigation.
 	StatusInvestigating = "INVESTIGATING"
+	// StatusPendingInfo is for alerts waiting on information from the customer or another team.
+	StatusPendingInfo = "PENDING_INFO"
 	// StatusEscalated is for alerts that have been escalated.
 	StatusEscalated = "ESCALATED"
 	// StatusClosed is for alerts that have been closed.
@@ -68,6 +90,7 @@ const (
 type Alert struct {
 	ID            string                 `json:"id"`
 	TransactionID string                 `json:"transaction_id"`
+	AccountID     string                 `json:"account_id"`
 	AlertType     string                 `json:"alert_type"`
 	Priority      PriorityLevel          `json:"priority"`
 	Score         float64                `json:"score"`
@@ -76,4 +99,16 @@ type Alert struct {
 	AssignedTo    string                 `json:"assigned_to"`
 	RuleDetails   JSONMap                `json:"rule_details" gorm:"type:text"`
 	TransitionAt  time.Time              `json:"transition_at"`
+	// DedupKey identifies the rule or detector that raised the alert, for deduplication.
+	DedupKey string `json:"dedup_key,omitempty"`
+	// CaseID is the case the alert is being investigated under, if any.
+	CaseID string `json:"case_id,omitempty"`
+	// ScoreBreakdown explains Score when it was computed by the risk scoring model.
+	ScoreBreakdown ScoreBreakdown `json:"score_breakdown,omitempty" gorm:"type:text"`
+	// Findings lists the findings merged into a composite alert; it is empty for an alert raised
+	// by a single finding.
+	Findings AlertFindings `json:"findings,omitempty" gorm:"type:text"`
+	// DispositionCode is the reason given when the alert was closed; it is cleared if the alert is
+	// reopened.
+	DispositionCode string `json:"disposition_code,omitempty"`
 }
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// AlertEvidence is one finding attached to an alert. The finding that raised the alert is its
+// first piece of evidence; later duplicate findings are appended rather than raising new alerts.
+type AlertEvidence struct {
+	ID            string    `json:"id"`
+	AlertID       string    `json:"alert_id"`
+	TransactionID string    `json:"transaction_id"`
+	Details       JSONMap   `json:"details"`
+	ObservedAt    time.Time `json:"observed_at"`
+}
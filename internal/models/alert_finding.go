@@ -0,0 +1,46 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// AlertFinding is one detector finding that contributed to a composite alert. It keeps the
+// details, priority and score the finding would have raised on its own.
+type AlertFinding struct {
+	AlertType      string         `json:"alert_type"`
+	DedupKey       string         `json:"dedup_key"`
+	Priority       PriorityLevel  `json:"priority"`
+	Score          float64        `json:"score"`
+	RuleDetails    JSONMap        `json:"rule_details"`
+	ScoreBreakdown ScoreBreakdown `json:"score_breakdown,omitempty"`
+}
+
+// AlertFindings lists the findings behind a composite alert.
+type AlertFindings []AlertFinding
+
+// Value implements the driver.Valuer interface.
+func (f AlertFindings) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (f *AlertFindings) Scan(value interface{}) error {
+	if value == nil {
+		*f = nil
+		return nil
+	}
+	bytes, err := scanBytes(value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal AlertFindings value: %w", err)
+	}
+	return json.Unmarshal(bytes, f)
+}
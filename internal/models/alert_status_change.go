@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AlertStatusChange is one entry in an alert's append-only status history.
+type AlertStatusChange struct {
+	ID         string `json:"id"`
+	AlertID    string `json:"alert_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Actor      string `json:"actor"`
+	Comment    string `json:"comment"`
+	// Fields holds the extra values the workflow required for the change, such as a disposition code.
+	Fields    JSONMap   `json:"fields,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+const (
+	// CaseStatusOpen is for cases under investigation.
+	CaseStatusOpen = "OPEN"
+	// CaseStatusClosed is for cases whose investigation has finished.
+	CaseStatusClosed = "CLOSED"
+	// CaseStatusMerged is for cases folded into another case.
+	CaseStatusMerged = "MERGED"
+)
+
+// Case groups the alerts raised for one subject so they are investigated together.
+type Case struct {
+	ID               string    `json:"id"`
+	SubjectAccountID string    `json:"subject_account_id"`
+	Title            string    `json:"title"`
+	Status           string    `json:"status"`
+	AssignedTo       string    `json:"assigned_to"`
+	CreatedBy        string    `json:"created_by"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	// ClosedAt is zero while the case is open.
+	ClosedAt    time.Time `json:"closed_at,omitempty"`
+	CloseReason string    `json:"close_reason,omitempty"`
+	// MergedInto is the case this one was merged into, when its status is MERGED.
+	MergedInto string `json:"merged_into,omitempty"`
+}
+
+// CaseNote is an investigator's note, or a record of an action taken, on a case.
+type CaseNote struct {
+	ID        string    `json:"id"`
+	CaseID    string    `json:"case_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CaseAttachment describes a document attached to a case. The document itself is held in
+// external storage at URI.
+type CaseAttachment struct {
+	ID          string    `json:"id"`
+	CaseID      string    `json:"case_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	URI         string    `json:"uri"`
+	SizeBytes   int64     `json:"size_bytes"`
+	SHA256      string    `json:"sha256,omitempty"`
+	UploadedBy  string    `json:"uploaded_by"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// CTR is a Currency Transaction Report: one person's cash transactions on one business day, across
+// all of their accounts, where the cash in or the cash out exceeded the reporting threshold.
+type CTR struct {
+	ID string `json:"id"`
+	// BusinessDate is the business day the transactions count towards, as YYYY-MM-DD.
+	BusinessDate string `json:"business_date"`
+	PersonName   string `json:"person_name"`
+	// PersonAddress and PersonDateOfBirth are empty when the accounts have no holder on record.
+	PersonAddress     string   `json:"person_address"`
+	PersonDateOfBirth string   `json:"person_date_of_birth"`
+	AccountIDs        []string `json:"account_ids"`
+	// CashIn and CashOut are the day's totals in Currency.
+	CashIn   float64 `json:"cash_in"`
+	CashOut  float64 `json:"cash_out"`
+	Currency string  `json:"currency"`
+	// Transactions are the day's cash transactions in time order, including those in the
+	// direction that stayed under the threshold.
+	Transactions []Transaction `json:"transactions"`
+}
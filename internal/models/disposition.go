@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Reporting periods for false-positive analytics.
+const (
+	ReportPeriodDay   = "day"
+	ReportPeriodWeek  = "week"
+	ReportPeriodMonth = "month"
+)
+
+// FalsePositiveRate is the share of dispositioned findings closed as false positives for one
+// rule, detector or threshold band in one period.
+type FalsePositiveRate struct {
+	Key string `json:"key"`
+	// Band is the threshold band, for rates broken down by how far the rule threshold was exceeded.
+	Band string `json:"band,omitempty"`
+	// Period is the day, ISO week or month the findings were dispositioned in; empty for the whole
+	// reporting range.
+	Period         string  `json:"period,omitempty"`
+	Dispositioned  int     `json:"dispositioned"`
+	FalsePositives int     `json:"false_positives"`
+	Rate           float64 `json:"rate"`
+}
+
+// FalsePositiveReport breaks down the false-positive rate of dispositioned alerts.
+type FalsePositiveReport struct {
+	From            time.Time           `json:"from,omitempty"`
+	To              time.Time           `json:"to,omitempty"`
+	Period          string              `json:"period,omitempty"`
+	ByRule          []FalsePositiveRate `json:"by_rule"`
+	ByDetector      []FalsePositiveRate `json:"by_detector"`
+	ByThresholdBand []FalsePositiveRate `json:"by_threshold_band"`
+}
+
+// ThresholdRecommendation suggests a new rule threshold and what it would have removed from the
+// dispositioned alerts the rule raised.
+type ThresholdRecommendation struct {
+	RuleID             string  `json:"rule_id"`
+	CurrentThreshold   float64 `json:"current_threshold"`
+	SuggestedThreshold float64 `json:"suggested_threshold"`
+	FalsePositives     int     `json:"false_positives"`
+	TruePositives      int     `json:"true_positives"`
+	// RemovedFalsePositives and RemovedTruePositives count the findings at or below the suggested
+	// threshold, which it would not have raised.
+	RemovedFalsePositives     int     `json:"removed_false_positives"`
+	RemovedTruePositives      int     `json:"removed_true_positives"`
+	RemovedFalsePositiveShare float64 `json:"removed_false_positive_share"`
+	RemovedTruePositiveShare  float64 `json:"removed_true_positive_share"`
+	Summary                   string  `json:"summary"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// StringList is a list of strings stored as a JSON array.
+type StringList []string
+
+// Value implements the driver.Valuer interface.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	bytes, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	bytes, err := scanBytes(value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal StringList value: %w", err)
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// Contains reports whether the list holds s.
+func (l StringList) Contains(s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// AssignmentActionAssigned is recorded when an alert is given to an investigator by a
+	// supervisor or an assignment strategy.
+	AssignmentActionAssigned = "ASSIGNED"
+	// AssignmentActionClaimed is recorded when an investigator takes an alert from a queue.
+	AssignmentActionClaimed = "CLAIMED"
+	// AssignmentActionReleased is recorded when an investigator hands an alert back to the queue.
+	AssignmentActionReleased = "RELEASED"
+)
+
+// Team is a group of investigators sharing a work queue.
+type Team struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// AlertTypes are the alert types routed to the team's queue; empty means every type.
+	AlertTypes StringList `json:"alert_types"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Handles reports whether alerts of the given type are routed to the team.
+func (t Team) Handles(alertType string) bool {
+	return len(t.AlertTypes) == 0 || t.AlertTypes.Contains(alertType)
+}
+
+// Investigator is an analyst who works alerts.
+type Investigator struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	TeamID string `json:"team_id,omitempty"`
+	// Skills are the alert types the investigator is trained to work.
+	Skills StringList `json:"skills"`
+	// MaxOpenAlerts caps automatic assignment; zero means no cap.
+	MaxOpenAlerts int  `json:"max_open_alerts"`
+	Active        bool `json:"active"`
+	// LastAssignedAt is when an alert was last assigned to or claimed by the investigator.
+	LastAssignedAt time.Time `json:"last_assigned_at,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// AlertAssignment is one entry in an alert's append-only assignment log.
+type AlertAssignment struct {
+	ID             string `json:"id"`
+	AlertID        string `json:"alert_id"`
+	InvestigatorID string `json:"investigator_id"`
+	Action         string `json:"action"`
+	Actor          string `json:"actor"`
+	// Strategy names the assignment strategy for automatic assignments.
+	Strategy  string    `json:"strategy,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InvestigatorWorkload summarises the open alerts assigned to an investigator.
+type InvestigatorWorkload struct {
+	Investigator Investigator `json:"investigator"`
+	OpenAlerts   int          `json:"open_alerts"`
+	// ByPriority counts open alerts per priority level name.
+	ByPriority map[string]int `json:"by_priority"`
+}
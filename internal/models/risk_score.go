@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ScoreFactor is one factor's part in an alert's risk score.
+type ScoreFactor struct {
+	Name string `json:"name"`
+	// Weight is the factor's share of the score; the weights of a breakdown sum to 1.
+	Weight float64 `json:"weight"`
+	// Value is the factor's normalised risk between 0 and 1.
+	Value float64 `json:"value"`
+	// Contribution is the number of score points the factor added: Weight × Value × 100.
+	Contribution float64 `json:"contribution"`
+	// Reason states the evidence behind the value in plain words.
+	Reason string `json:"reason"`
+}
+
+// ScoreBreakdown explains a risk score factor by factor.
+type ScoreBreakdown []ScoreFactor
+
+// Value implements the driver.Valuer interface.
+func (b ScoreBreakdown) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (b *ScoreBreakdown) Scan(value interface{}) error {
+	if value == nil {
+		*b = nil
+		return nil
+	}
+	bytes, err := scanBytes(value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal ScoreBreakdown value: %w", err)
+	}
+	return json.Unmarshal(bytes, b)
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// SARStatusDraft is for SARs being prepared; only drafts can be edited.
+	SARStatusDraft = "DRAFT"
+	// SARStatusUnderReview is for SARs submitted for four-eyes review.
+	SARStatusUnderReview = "UNDER_REVIEW"
+	// SARStatusApproved is for SARs approved and ready to file.
+	SARStatusApproved = "APPROVED"
+	// SARStatusFiled is for SARs filed with the regulator; their content is frozen in a snapshot.
+	SARStatusFiled = "FILED"
+	// SARStatusAmended is for filed SARs superseded by an amendment.
+	SARStatusAmended = "AMENDED"
+)
+
+const (
+	// SARFilingInitial is a first report of the activity.
+	SARFilingInitial = "INITIAL"
+	// SARFilingAmendment corrects a SAR already filed.
+	SARFilingAmendment = "AMENDMENT"
+	// SARFilingContinuing reports activity that continued after a SAR was filed.
+	SARFilingContinuing = "CONTINUING"
+)
+
+// SAR is a stored Suspicious Activity Report moving through preparation, review and filing.
+type SAR struct {
+	ID string `json:"id"`
+	// CaseID and AlertIDs link the SAR back to the investigation it reports on.
+	CaseID   string   `json:"case_id,omitempty"`
+	AlertIDs []string `json:"alert_ids"`
+	Status   string   `json:"status"`
+	// FilingType is INITIAL, AMENDMENT or CONTINUING. Amendments and continuing reports name the
+	// SAR they follow in PriorSARID.
+	FilingType string     `json:"filing_type"`
+	PriorSARID string     `json:"prior_sar_id,omitempty"`
+	Report     *SARReport `json:"report"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	// The review and filing fields are empty until each step happens.
+	SubmittedBy     string    `json:"submitted_by,omitempty"`
+	SubmittedAt     time.Time `json:"submitted_at,omitempty"`
+	ApprovedBy      string    `json:"approved_by,omitempty"`
+	ApprovedAt      time.Time `json:"approved_at,omitempty"`
+	FiledBy         string    `json:"filed_by,omitempty"`
+	FiledAt         time.Time `json:"filed_at,omitempty"`
+	FilingReference string    `json:"filing_reference,omitempty"`
+	// ReviewDueAt is when a filed SAR's subject is due a review for continuing activity. It is
+	// cleared once the review finds nothing further to report.
+	ReviewDueAt time.Time `json:"review_due_at,omitempty"`
+}
+
+// SAREvent records one step in a SAR's lifecycle.
+type SAREvent struct {
+	ID         string    `json:"id"`
+	SARID      string    `json:"sar_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"`
+	Comment    string    `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SARSnapshot is the report exactly as filed. SHA256 is the hex digest of Report and lets the
+// snapshot be checked for tampering.
+type SARSnapshot struct {
+	SARID     string          `json:"sar_id"`
+	Report    json.RawMessage `json:"report"`
+	SHA256    string          `json:"sha256"`
+	CreatedBy string          `json:"created_by"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -42,7 +42,51 @@ type SuspiciousActivityPattern struct {
 	TransactionCount   int           `json:"transaction_count"`
 }
 
+// SAR subject roles.
+const (
+	// SARSubjectPrimary is the account holder a report is principally about.
+	SARSubjectPrimary = "PRIMARY"
+	// SARSubjectCoConspirator is another account holder whose transactions are part of the activity.
+	SARSubjectCoConspirator = "CO_CONSPIRATOR"
+	// SARSubjectBeneficiary is an account holder who received funds from the activity.
+	SARSubjectBeneficiary = "BENEFICIARY"
+)
+
+// SARSubject is a person named in a report, with the accounts of theirs the activity involved.
+type SARSubject struct {
+	Role        string   `json:"role"`
+	Name        string   `json:"name"`
+	Address     string   `json:"address"`
+	DateOfBirth string   `json:"date_of_birth"`
+	AccountIDs  []string `json:"account_ids"`
+}
+
+// SARSubjects lists a report's subjects, primary subject first.
+type SARSubjects []SARSubject
+
+// Value implements the driver.Valuer interface.
+func (s SARSubjects) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *SARSubjects) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal SARSubjects value: %v", value)
+	}
+	return json.Unmarshal(bytes, s)
+}
+
 // SARReport represents a complete Suspicious Activity Report, aggregating multiple alerts.
+// SubjectName, SubjectAddress and SubjectDateOfBirth describe the primary subject.
 type SARReport struct {
 	SubjectName           string                               `json:"subject_name"`
 	SubjectAddress        string                               `json:"subject_address"`
@@ -52,4 +96,45 @@ type SARReport struct {
 	TotalSuspiciousAmount float64                              `json:"total_suspicious_amount"`
 	TotalTransactionCount int                                  `json:"total_transaction_count"`
 	Patterns              SARPatterns `json:"patterns" gorm:"type:text"`
+	// Subjects names everyone involved, primary subject first. Reports drafted before subjects
+	// were recorded leave it empty and name only the primary subject.
+	Subjects SARSubjects `json:"subjects,omitempty" gorm:"type:text"`
+	// Narrative is the reviewed narrative text filed with the report; empty until drafted.
+	Narrative string `json:"narrative,omitempty"`
+	// Continuation is set on continuing-activity reports only.
+	Continuation *SARContinuation `json:"continuation,omitempty" gorm:"type:text"`
+	// Amendment is set on reports that correct a filed report only.
+	Amendment *SARAmendment `json:"amendment,omitempty" gorm:"type:text"`
+}
+
+// SARAmendment links an amendment to the filed report it corrects.
+type SARAmendment struct {
+	PriorSARID           string `json:"prior_sar_id"`
+	PriorFilingReference string `json:"prior_filing_reference"`
+}
+
+// SARContinuation links a continuing-activity report to the filed report it follows. The
+// cumulative totals cover the whole series of reports, this one included.
+type SARContinuation struct {
+	PriorSARID           string `json:"prior_sar_id"`
+	PriorFilingReference string `json:"prior_filing_reference"`
+	// ReportNumber is the report's position in the series; the initial report is 1.
+	ReportNumber               int       `json:"report_number"`
+	SeriesStartDate            time.Time `json:"series_start_date"`
+	CumulativeAmount           float64   `json:"cumulative_amount"`
+	CumulativeTransactionCount int       `json:"cumulative_transaction_count"`
+}
+
+// Value implements the driver.Valuer interface.
+func (c SARContinuation) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *SARContinuation) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal SARContinuation value: %v", value)
+	}
+	return json.Unmarshal(bytes, c)
 }
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	// SLAStateOnTrack is for stages well within their deadline.
+	SLAStateOnTrack = "ON_TRACK"
+	// SLAStateApproaching is for stages inside their policy's warning period.
+	SLAStateApproaching = "APPROACHING"
+	// SLAStateBreached is for stages past their deadline.
+	SLAStateBreached = "BREACHED"
+)
+
+// SLA breach actions.
+const (
+	SLAActionEscalated        = "ESCALATED"
+	SLAActionEscalationFailed = "ESCALATION_FAILED"
+	SLAActionReassigned       = "REASSIGNED"
+	SLAActionReassignFailed   = "REASSIGN_FAILED"
+)
+
+// AlertDeadline is an outstanding SLA stage for an alert.
+type AlertDeadline struct {
+	Alert    Alert     `json:"alert"`
+	Policy   string    `json:"policy"`
+	Deadline time.Time `json:"deadline"`
+	State    string    `json:"state"`
+}
+
+// SLABreach records that an alert missed an SLA deadline and what was done about it.
+type SLABreach struct {
+	ID         string    `json:"id"`
+	AlertID    string    `json:"alert_id"`
+	Policy     string    `json:"policy"`
+	Priority   string    `json:"priority"`
+	Deadline   time.Time `json:"deadline"`
+	DetectedAt time.Time `json:"detected_at"`
+	// Actions lists the escalation and reassignment outcomes, e.g. ESCALATED.
+	Actions StringList `json:"actions"`
+	// Detail explains failed actions.
+	Detail string `json:"detail,omitempty"`
+}
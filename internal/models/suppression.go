@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	// SuppressionActionCreated records that an analyst created a suppression.
+	SuppressionActionCreated = "CREATED"
+	// SuppressionActionRevoked records that an analyst revoked a suppression before it expired.
+	SuppressionActionRevoked = "REVOKED"
+	// SuppressionActionApplied records that a suppression muted an alert.
+	SuppressionActionApplied = "APPLIED"
+)
+
+// Suppression mutes alerts for one account and rule until it expires or is revoked.
+type Suppression struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"account_id"`
+	RuleKey   string    `json:"rule_key"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// RevokedAt is zero while the suppression has not been revoked.
+	RevokedAt time.Time `json:"revoked_at,omitempty"`
+	RevokedBy string    `json:"revoked_by,omitempty"`
+}
+
+// ActiveAt reports whether the suppression mutes alerts at the given time.
+func (s *Suppression) ActiveAt(t time.Time) bool {
+	return s.RevokedAt.IsZero() && t.Before(s.ExpiresAt)
+}
+
+// SuppressionAuditEntry is one append-only record of what happened to a suppression.
+type SuppressionAuditEntry struct {
+	ID            string    `json:"id"`
+	SuppressionID string    `json:"suppression_id"`
+	Action        string    `json:"action"`
+	Actor         string    `json:"actor"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	Comment       string    `json:"comment,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
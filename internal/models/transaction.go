@@ -15,4 +15,5 @@ type Transaction struct {
 	DestinationCountry string    `db:"destination_country" json:"destination_country"`
 	TransactionType    string    `db:"transaction_type" json:"transaction_type"`
 	Status             string    `db:"status" json:"status"`
+	CounterpartyID     string    `db:"counterparty_id" json:"counterparty_id,omitempty"`
 }
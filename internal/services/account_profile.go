@@ -0,0 +1,229 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// maxProfileSaveAttempts bounds how often a profile update is reapplied after losing a race with a
+// concurrent update of the same account.
+const maxProfileSaveAttempts = 5
+
+// ErrProfileConflict is returned by SaveAccountProfile when the stored profile changed after it was
+// loaded.
+var ErrProfileConflict = fmt.Errorf("account profile was updated concurrently")
+
+// maxTrackedCounterparties bounds the counterparty map so long-lived accounts do not grow without limit.
+const maxTrackedCounterparties = 500
+
+// NewAccountProfile returns an empty profile for an account.
+func NewAccountProfile(accountID string) *models.AccountProfile {
+	return &models.AccountProfile{
+		AccountID:        accountID,
+		Counterparties:   make(models.CountMap),
+		Countries:        make(models.CountMap),
+		TransactionTypes: make(models.CountMap),
+	}
+}
+
+// UpdateAccountProfile folds a transaction into the profile's rolling statistics.
+func UpdateAccountProfile(profile *models.AccountProfile, tx models.Transaction) {
+	if profile.Counterparties == nil {
+		profile.Counterparties = make(models.CountMap)
+	}
+	if profile.Countries == nil {
+		profile.Countries = make(models.CountMap)
+	}
+	if profile.TransactionTypes == nil {
+		profile.TransactionTypes = make(models.CountMap)
+	}
+
+	// Welford's online update for mean and sum of squared deviations.
+	profile.TransactionCount++
+	n := float64(profile.TransactionCount)
+	delta := tx.Amount - profile.AmountMean
+	profile.AmountMean += delta / n
+	profile.AmountM2 += delta * (tx.Amount - profile.AmountMean)
+
+	logAmt := logAmount(tx.Amount)
+	logDelta := logAmt - profile.LogAmountMean
+	profile.LogAmountMean += logDelta / n
+	profile.LogAmountM2 += logDelta * (logAmt - profile.LogAmountMean)
+
+	if tx.CounterpartyID != "" {
+		profile.Counterparties[tx.CounterpartyID]++
+		if len(profile.Counterparties) > maxTrackedCounterparties {
+			evictRarest(profile.Counterparties, tx.CounterpartyID)
+		}
+	}
+	for _, country := range transactionCountries(tx) {
+		profile.Countries[country]++
+	}
+	if tx.TransactionType != "" {
+		profile.TransactionTypes[strings.ToLower(tx.TransactionType)]++
+	}
+	profile.ActiveHours[tx.Timestamp.UTC().Hour()]++
+
+	// Only in-order arrivals extend the inter-transaction gap baseline.
+	if profile.TransactionCount > 1 && tx.Timestamp.After(profile.LastSeen) {
+		gap := tx.Timestamp.Sub(profile.LastSeen).Hours()
+		profile.GapCount++
+		gapDelta := gap - profile.GapMeanHours
+		profile.GapMeanHours += gapDelta / float64(profile.GapCount)
+		profile.GapM2 += gapDelta * (gap - profile.GapMeanHours)
+	}
+
+	if profile.FirstSeen.IsZero() || tx.Timestamp.Before(profile.FirstSeen) {
+		profile.FirstSeen = tx.Timestamp
+	}
+	if tx.Timestamp.After(profile.LastSeen) {
+		profile.LastSeen = tx.Timestamp
+	}
+	profile.UpdatedAt = time.Now()
+}
+
+// HourShare returns the fraction of the account's transactions that happened in the given UTC hour.
+func HourShare(profile *models.AccountProfile, hour int) float64 {
+	if profile.TransactionCount == 0 {
+		return 0
+	}
+	return float64(profile.ActiveHours[hour]) / float64(profile.TransactionCount)
+}
+
+// transactionCountries returns the distinct non-empty countries a transaction touches.
+func transactionCountries(tx models.Transaction) []string {
+	var countries []string
+	if tx.SourceCountry != "" {
+		countries = append(countries, strings.ToUpper(tx.SourceCountry))
+	}
+	if tx.DestinationCountry != "" && !strings.EqualFold(tx.DestinationCountry, tx.SourceCountry) {
+		countries = append(countries, strings.ToUpper(tx.DestinationCountry))
+	}
+	return countries
+}
+
+// evictRarest removes the least frequently seen entry, never evicting keep.
+func evictRarest(counts models.CountMap, keep string) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		if k != keep {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] == counts[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return counts[keys[i]] < counts[keys[j]]
+	})
+	if len(keys) > 0 {
+		delete(counts, keys[0])
+	}
+}
+
+// LoadAccountProfile fetches the stored profile for an account, returning an empty profile if none exists.
+func LoadAccountProfile(db database.DBTX, accountID string) (*models.AccountProfile, error) {
+	query := `
+		SELECT account_id, transaction_count, amount_mean, amount_m2, log_amount_mean, log_amount_m2,
+			counterparties, countries, transaction_types, active_hours, gap_count, gap_mean_hours, gap_m2,
+			first_seen, last_seen, updated_at, version
+		FROM account_profiles
+		WHERE account_id = ?
+	`
+	profile := NewAccountProfile(accountID)
+	err := db.QueryRow(query, accountID).Scan(
+		&profile.AccountID, &profile.TransactionCount, &profile.AmountMean, &profile.AmountM2,
+		&profile.LogAmountMean, &profile.LogAmountM2, &profile.Counterparties, &profile.Countries,
+		&profile.TransactionTypes, &profile.ActiveHours, &profile.GapCount, &profile.GapMeanHours, &profile.GapM2,
+		&profile.FirstSeen, &profile.LastSeen, &profile.UpdatedAt, &profile.Version,
+	)
+	if err == sql.ErrNoRows {
+		return NewAccountProfile(accountID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account profile for %s: %w", accountID, err)
+	}
+	return profile, nil
+}
+
+// SaveAccountProfile stores a profile loaded by LoadAccountProfile. A profile that was not stored
+// is inserted; a stored one is only overwritten if nobody else saved it since it was loaded.
+// Otherwise ErrProfileConflict is returned and the profile must be reloaded. On success the
+// profile's version is advanced.
+func SaveAccountProfile(db database.DBTX, profile *models.AccountProfile) error {
+	args := []interface{}{
+		profile.TransactionCount, profile.AmountMean, profile.AmountM2,
+		profile.LogAmountMean, profile.LogAmountM2, profile.Counterparties, profile.Countries,
+		profile.TransactionTypes, profile.ActiveHours, profile.GapCount, profile.GapMeanHours, profile.GapM2,
+		profile.FirstSeen.UTC(), profile.LastSeen.UTC(), profile.UpdatedAt.UTC(),
+	}
+	var query string
+	if profile.Version == 0 {
+		query = `
+			INSERT INTO account_profiles (transaction_count, amount_mean, amount_m2, log_amount_mean, log_amount_m2,
+				counterparties, countries, transaction_types, active_hours, gap_count, gap_mean_hours, gap_m2,
+				first_seen, last_seen, updated_at, account_id, version)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+			ON CONFLICT (account_id) DO NOTHING
+		`
+		args = append(args, profile.AccountID)
+	} else {
+		query = `
+			UPDATE account_profiles
+			SET transaction_count = ?, amount_mean = ?, amount_m2 = ?, log_amount_mean = ?, log_amount_m2 = ?,
+				counterparties = ?, countries = ?, transaction_types = ?, active_hours = ?,
+				gap_count = ?, gap_mean_hours = ?, gap_m2 = ?, first_seen = ?, last_seen = ?, updated_at = ?,
+				version = version + 1
+			WHERE account_id = ? AND version = ?
+		`
+		args = append(args, profile.AccountID, profile.Version)
+	}
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to save account profile for %s: %w", profile.AccountID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save account profile for %s: %w", profile.AccountID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrProfileConflict, profile.AccountID)
+	}
+	profile.Version++
+	return nil
+}
+
+// RecordTransactionInProfile loads the account's profile, folds in the transaction and persists it.
+func RecordTransactionInProfile(db database.DBTX, tx models.Transaction) (*models.AccountProfile, error) {
+	profile, err := LoadAccountProfile(db, tx.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return recordInProfile(db, profile, tx)
+}
+
+// recordInProfile folds the transaction into a loaded profile and persists it. If another update
+// of the account was saved in the meantime, the stored profile is reloaded and the transaction
+// folded into that instead, so concurrent ingests of one account never overwrite each other.
+func recordInProfile(db database.DBTX, profile *models.AccountProfile, tx models.Transaction) (*models.AccountProfile, error) {
+	for attempt := 1; ; attempt++ {
+		UpdateAccountProfile(profile, tx)
+		err := SaveAccountProfile(db, profile)
+		if err == nil {
+			return profile, nil
+		}
+		if !errors.Is(err, ErrProfileConflict) || attempt == maxProfileSaveAttempts {
+			return nil, err
+		}
+		if profile, err = LoadAccountProfile(db, tx.AccountID); err != nil {
+			return nil, err
+		}
+	}
+}
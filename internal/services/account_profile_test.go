@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"AML/internal/models"
+)
+
+func TestUpdateAccountProfile(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	txs := []models.Transaction{
+		{AccountID: "acc-1", Amount: 100.00, Timestamp: base, SourceCountry: "US", DestinationCountry: "US", TransactionType: "transfer", CounterpartyID: "cp-1"},
+		{AccountID: "acc-1", Amount: 250.00, Timestamp: base.Add(2 * time.Hour), SourceCountry: "US", DestinationCountry: "CA", TransactionType: "transfer", CounterpartyID: "cp-2"},
+		{AccountID: "acc-1", Amount: 75.00, Timestamp: base.Add(-time.Hour), SourceCountry: "US", DestinationCountry: "US", TransactionType: "Purchase", CounterpartyID: "cp-1"},
+		{AccountID: "acc-1", Amount: 1200.00, Timestamp: base.Add(26 * time.Hour), SourceCountry: "US", DestinationCountry: "US", TransactionType: "transfer"},
+	}
+
+	profile := NewAccountProfile("acc-1")
+	for _, tx := range txs {
+		UpdateAccountProfile(profile, tx)
+	}
+
+	// Test Case 1: Welford statistics match a batch computation
+	t.Run("rolling_statistics_match_batch", func(t *testing.T) {
+		amounts := make([]float64, len(txs))
+		logAmounts := make([]float64, len(txs))
+		for i, tx := range txs {
+			amounts[i] = tx.Amount
+			logAmounts[i] = logAmount(tx.Amount)
+		}
+		wantMean := mean(amounts)
+		if math.Abs(profile.AmountMean-wantMean) > 1e-9 {
+			t.Errorf("Expected mean %f, got %f", wantMean, profile.AmountMean)
+		}
+		if want := stdDev(amounts, wantMean); math.Abs(profile.AmountStdDev()-want) > 1e-9 {
+			t.Errorf("Expected stddev %f, got %f", want, profile.AmountStdDev())
+		}
+		logMean := mean(logAmounts)
+		if want := stdDev(logAmounts, logMean); math.Abs(profile.LogAmountStdDev()-want) > 1e-9 {
+			t.Errorf("Expected log stddev %f, got %f", want, profile.LogAmountStdDev())
+		}
+	})
+
+	// Test Case 2: Categorical behaviour and activity window
+	t.Run("categorical_counts", func(t *testing.T) {
+		if profile.TransactionCount != 4 {
+			t.Errorf("Expected 4 transactions, got %d", profile.TransactionCount)
+		}
+		if profile.Counterparties["cp-1"] != 2 || profile.Counterparties["cp-2"] != 1 {
+			t.Errorf("Unexpected counterparties: %v", profile.Counterparties)
+		}
+		if profile.Countries["US"] != 4 || profile.Countries["CA"] != 1 {
+			t.Errorf("Unexpected countries: %v", profile.Countries)
+		}
+		if profile.TransactionTypes["transfer"] != 3 || profile.TransactionTypes["purchase"] != 1 {
+			t.Errorf("Unexpected transaction types: %v", profile.TransactionTypes)
+		}
+		if profile.ActiveHours[9] != 1 || profile.ActiveHours[11] != 2 || profile.ActiveHours[8] != 1 {
+			t.Errorf("Unexpected active hours: %v", profile.ActiveHours)
+		}
+		if !profile.FirstSeen.Equal(base.Add(-time.Hour)) || !profile.LastSeen.Equal(base.Add(26*time.Hour)) {
+			t.Errorf("Unexpected activity window %s - %s", profile.FirstSeen, profile.LastSeen)
+		}
+		if share := HourShare(profile, 11); share != 0.5 {
+			t.Errorf("Expected hour 11 share 0.5, got %f", share)
+		}
+	})
+}
+
+func TestAccountProfilePersistence(t *testing.T) {
+	db := newTestDB(t)
+
+	// Test Case 1: Missing profile loads as empty
+	t.Run("missing_profile_is_empty", func(t *testing.T) {
+		profile, err := LoadAccountProfile(db, "acc-new")
+		if err != nil {
+			t.Fatalf("LoadAccountProfile failed: %v", err)
+		}
+		if profile.TransactionCount != 0 || profile.AccountID != "acc-new" {
+			t.Errorf("Expected empty profile for acc-new, got %+v", profile)
+		}
+	})
+
+	// Test Case 2: Recorded transactions survive a round trip
+	t.Run("round_trip", func(t *testing.T) {
+		ts := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			tx := models.Transaction{AccountID: "acc-2", Amount: 100.00 * float64(i+1), Timestamp: ts.Add(time.Duration(i) * time.Minute), SourceCountry: "GB", DestinationCountry: "FR", TransactionType: "transfer", CounterpartyID: "cp-9"}
+			if _, err := RecordTransactionInProfile(db, tx); err != nil {
+				t.Fatalf("RecordTransactionInProfile failed: %v", err)
+			}
+		}
+
+		profile, err := LoadAccountProfile(db, "acc-2")
+		if err != nil {
+			t.Fatalf("LoadAccountProfile failed: %v", err)
+		}
+		if profile.TransactionCount != 3 {
+			t.Errorf("Expected 3 transactions, got %d", profile.TransactionCount)
+		}
+		if profile.AmountMean != 200.00 {
+			t.Errorf("Expected mean 200, got %f", profile.AmountMean)
+		}
+		if profile.Countries["FR"] != 3 || profile.Counterparties["cp-9"] != 3 {
+			t.Errorf("Unexpected categorical counts: %v %v", profile.Countries, profile.Counterparties)
+		}
+		if profile.ActiveHours[14] != 3 {
+			t.Errorf("Expected 3 transactions at 14:00, got %d", profile.ActiveHours[14])
+		}
+		if !profile.LastSeen.Equal(ts.Add(2 * time.Minute)) {
+			t.Errorf("Expected last seen %s, got %s", ts.Add(2*time.Minute), profile.LastSeen)
+		}
+	})
+
+	// Test Case 3: A profile saved by a concurrent ingest is not overwritten
+	t.Run("concurrent_update", func(t *testing.T) {
+		ts := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+		txs := []models.Transaction{
+			{AccountID: "acc-3", Amount: 100.00, Timestamp: ts, TransactionType: "transfer"},
+			{AccountID: "acc-3", Amount: 200.00, Timestamp: ts.Add(time.Minute), TransactionType: "transfer"},
+			{AccountID: "acc-3", Amount: 300.00, Timestamp: ts.Add(2 * time.Minute), TransactionType: "transfer"},
+		}
+
+		// Both ingests load the profile before either saves it.
+		first, err := LoadAccountProfile(db, "acc-3")
+		if err != nil {
+			t.Fatalf("LoadAccountProfile failed: %v", err)
+		}
+		second, err := LoadAccountProfile(db, "acc-3")
+		if err != nil {
+			t.Fatalf("LoadAccountProfile failed: %v", err)
+		}
+		if _, err := recordInProfile(db, first, txs[0]); err != nil {
+			t.Fatalf("recordInProfile failed: %v", err)
+		}
+
+		stale := *second
+		UpdateAccountProfile(&stale, txs[1])
+		if err := SaveAccountProfile(db, &stale); !errors.Is(err, ErrProfileConflict) {
+			t.Errorf("Expected ErrProfileConflict saving a stale profile, got %v", err)
+		}
+
+		if _, err := recordInProfile(db, second, txs[1]); err != nil {
+			t.Fatalf("recordInProfile failed: %v", err)
+		}
+		if _, err := RecordTransactionInProfile(db, txs[2]); err != nil {
+			t.Fatalf("RecordTransactionInProfile failed: %v", err)
+		}
+
+		profile, err := LoadAccountProfile(db, "acc-3")
+		if err != nil {
+			t.Fatalf("LoadAccountProfile failed: %v", err)
+		}
+		if profile.TransactionCount != 3 || profile.AmountMean != 200.00 {
+			t.Errorf("Expected 3 transactions averaging 200, got %d averaging %f", profile.TransactionCount, profile.AmountMean)
+		}
+		if profile.Version != 3 {
+			t.Errorf("Expected version 3, got %d", profile.Version)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"github.com/google/uuid"
+
+	"AML/internal/models"
+)
+
+// CorrelateAlerts merges alerts raised for the same transaction and account into one composite
+// alert per group, keeping the order in which each group first appears. Alerts without a
+// concurrent partner are returned unchanged.
+func CorrelateAlerts(alerts []*models.Alert) []*models.Alert {
+	var order []string
+	groups := make(map[string][]*models.Alert)
+	for _, alert := range alerts {
+		key := alert.TransactionID + "/" + alert.AccountID
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], alert)
+	}
+
+	correlated := make([]*models.Alert, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		if len(group) == 1 {
+			correlated = append(correlated, group[0])
+			continue
+		}
+		correlated = append(correlated, compositeAlert(group))
+	}
+	return correlated
+}
+
+// compositeAlert builds one alert from concurrent alerts. The alert with the highest priority,
+// then the highest score, is the primary: the composite takes its type, status and details, so
+// the primary's workflow, SLAs and dedup key apply. Every alert is kept as a finding with its own
+// details and dedup key.
+//
+// The combined score treats the findings as independent signals, 100 × (1 − Π(1 − sᵢ/100)), so
+// it is never below the highest finding score and grows with each corroborating finding.
+func compositeAlert(group []*models.Alert) *models.Alert {
+	primary := group[0]
+	remaining := 1.0
+	findings := make(models.AlertFindings, 0, len(group))
+	for _, alert := range group {
+		if alert.Priority > primary.Priority || (alert.Priority == primary.Priority && alert.Score > primary.Score) {
+			primary = alert
+		}
+		remaining *= 1 - clamp01(alert.Score/100)
+		findings = append(findings, models.AlertFinding{
+			AlertType:      alert.AlertType,
+			DedupKey:       alert.DedupKey,
+			Priority:       alert.Priority,
+			Score:          alert.Score,
+			RuleDetails:    alert.RuleDetails,
+			ScoreBreakdown: alert.ScoreBreakdown,
+		})
+	}
+
+	return &models.Alert{
+		ID:            uuid.New().String(),
+		TransactionID: primary.TransactionID,
+		AccountID:     primary.AccountID,
+		AlertType:     primary.AlertType,
+		Priority:      primary.Priority,
+		Score:         round2(100 * (1 - remaining)),
+		CreatedAt:     primary.CreatedAt,
+		Status:        primary.Status,
+		RuleDetails:   primary.RuleDetails,
+		DedupKey:      primary.DedupKey,
+		Findings:      findings,
+	}
+}
+
+// alertDedupKeys returns the dedup keys an alert covers: its own, then those of its other
+// findings.
+func alertDedupKeys(alert *models.Alert) []string {
+	keys := []string{alert.DedupKey}
+	seen := map[string]bool{alert.DedupKey: true}
+	for _, f := range alert.Findings {
+		if !seen[f.DedupKey] {
+			seen[f.DedupKey] = true
+			keys = append(keys, f.DedupKey)
+		}
+	}
+	return keys
+}
+
+// alertEvidence returns the details recorded as evidence for an alert: those of each finding
+// for a composite alert, otherwise the alert's own.
+func alertEvidence(alert *models.Alert) []map[string]interface{} {
+	if len(alert.Findings) == 0 {
+		return []map[string]interface{}{alert.RuleDetails}
+	}
+	evidence := make([]map[string]interface{}, 0, len(alert.Findings))
+	for _, f := range alert.Findings {
+		evidence = append(evidence, f.RuleDetails)
+	}
+	return evidence
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"AML/internal/models"
+)
+
+func TestAlertCorrelation(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ruleFinding := Finding{DetectorID: DetectorThresholdRules, AlertType: AlertTypeThresholdViolation, RuleID: "large_single_transaction"}
+	structuringFinding := Finding{DetectorID: "structuring", AlertType: AlertTypeStructuringPattern, Details: map[string]interface{}{"count": 4}}
+	newProcessor := func() *TransactionProcessor {
+		return &TransactionProcessor{
+			Orchestrator: NewOrchestrator([]Detector{stubDetector{id: "stub", findings: []Finding{ruleFinding, structuringFinding}}}),
+			Detection:    DetectionContext{Clock: fixedClock(now)},
+			DedupWindow:  24 * time.Hour,
+			Correlate:    true,
+		}
+	}
+	tx := func(id string) models.Transaction {
+		return models.Transaction{TransactionID: id, AccountID: "acc-1", Amount: 500, Timestamp: now}
+	}
+
+	// Test Case 1: Concurrent alerts merge with the highest priority and a combined score
+	t.Run("composite", func(t *testing.T) {
+		alerts := []*models.Alert{
+			{ID: "a", TransactionID: "tx-1", AccountID: "acc-1", AlertType: AlertTypeThresholdViolation, Priority: models.High, Score: 50, DedupKey: "rule-b", RuleDetails: models.JSONMap{"rule_id": "rule-b"}},
+			{ID: "b", TransactionID: "tx-1", AccountID: "acc-1", AlertType: AlertTypeStructuringPattern, Priority: models.Critical, Score: 40, DedupKey: "rule-a", RuleDetails: models.JSONMap{"rule_id": "rule-a"}},
+			{ID: "c", TransactionID: "tx-2", AccountID: "acc-1", AlertType: AlertTypeThresholdViolation, Priority: models.Medium, Score: 10, DedupKey: "rule-b"},
+		}
+		correlated := CorrelateAlerts(alerts)
+		if len(correlated) != 2 || correlated[1].ID != "c" {
+			t.Fatalf("Expected one composite alert and the lone tx-2 alert, got %+v", correlated)
+		}
+
+		composite := correlated[0]
+		if composite.Priority != models.Critical || composite.AlertType != AlertTypeStructuringPattern {
+			t.Errorf("Expected the critical structuring alert as primary, got %s %s", composite.Priority, composite.AlertType)
+		}
+		// 100 × (1 − 0.5 × 0.6)
+		if composite.Score != 70 {
+			t.Errorf("Expected combined score 70, got %.2f", composite.Score)
+		}
+		if composite.DedupKey != "rule-a" {
+			t.Errorf("Expected the primary finding's dedup key, got %q", composite.DedupKey)
+		}
+		if keys := alertDedupKeys(composite); len(keys) != 2 || keys[0] != "rule-a" || keys[1] != "rule-b" {
+			t.Errorf("Expected the composite to cover both finding keys, got %v", keys)
+		}
+		if len(composite.Findings) != 2 || composite.Findings[0].RuleDetails["rule_id"] != "rule-b" || composite.Findings[1].RuleDetails["rule_id"] != "rule-a" {
+			t.Errorf("Expected each finding's own details, got %+v", composite.Findings)
+		}
+	})
+
+	// Test Case 2: The processor stores one composite alert with evidence per finding and merges repeats
+	t.Run("processor", func(t *testing.T) {
+		db := newTestDB(t)
+		p := newProcessor()
+
+		result, err := p.Process(db, tx("tx-1"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if len(result.Alerts) != 1 {
+			t.Fatalf("Expected 1 composite alert, got %d", len(result.Alerts))
+		}
+		stored, err := GetAlert(db, result.Alerts[0].ID)
+		if err != nil {
+			t.Fatalf("GetAlert failed: %v", err)
+		}
+		if len(stored.Findings) != 2 || stored.Findings[1].RuleDetails["detector_id"] != "structuring" {
+			t.Errorf("Expected both findings to be stored, got %+v", stored.Findings)
+		}
+		evidence, err := ListAlertEvidence(db, stored.ID)
+		if err != nil {
+			t.Fatalf("ListAlertEvidence failed: %v", err)
+		}
+		if len(evidence) != 2 {
+			t.Errorf("Expected evidence for each finding, got %d", len(evidence))
+		}
+
+		result, err = p.Process(db, tx("tx-2"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if len(result.Alerts) != 0 || len(result.Deduplicated) != 1 || result.Deduplicated[0].ID != stored.ID {
+			t.Errorf("Expected the repeat composite to be merged, got %+v", result)
+		}
+	})
+
+	// Test Case 3: Suppressed findings are dropped before the rest are correlated
+	t.Run("suppressed_finding", func(t *testing.T) {
+		db := newTestDB(t)
+		p := newProcessor()
+		if _, err := CreateSuppression(db, "acc-1", ruleFinding.RuleID, "Known payroll", "analyst-1", now.Add(time.Hour), now.Add(-time.Minute)); err != nil {
+			t.Fatalf("CreateSuppression failed: %v", err)
+		}
+
+		result, err := p.Process(db, tx("tx-1"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if len(result.Suppressed) != 1 || len(result.Alerts) != 1 {
+			t.Fatalf("Expected one suppressed finding and one alert, got %+v", result)
+		}
+		alert := result.Alerts[0]
+		if alert.AlertType != AlertTypeStructuringPattern || len(alert.Findings) != 0 {
+			t.Errorf("Expected a plain structuring alert, got %+v", alert)
+		}
+	})
+
+	// Test Case 4: A repeat of any one finding merges into the open composite alert
+	t.Run("finding_repeat", func(t *testing.T) {
+		db := newTestDB(t)
+		result, err := newProcessor().Process(db, tx("tx-1"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		composite := result.Alerts[0]
+		if composite.DedupKey != "structuring/STRUCTURING_PATTERN" {
+			t.Errorf("Expected the structuring finding's key on the composite, got %q", composite.DedupKey)
+		}
+
+		// The rule finding alone, and with a finding the composite lacks, both match its rule finding.
+		velocityFinding := Finding{DetectorID: "velocity", AlertType: AlertTypeVelocityAnomaly, Details: map[string]interface{}{"window": "1h"}}
+		for i, findings := range [][]Finding{{ruleFinding}, {ruleFinding, velocityFinding}} {
+			p := newProcessor()
+			p.Orchestrator = NewOrchestrator([]Detector{stubDetector{id: "stub", findings: findings}})
+			result, err := p.Process(db, tx(fmt.Sprintf("tx-%d", i+2)))
+			if err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+			if len(result.Alerts) != 0 || len(result.Deduplicated) != 1 || result.Deduplicated[0].ID != composite.ID {
+				t.Errorf("Expected repeat %d to be merged into the composite, got %+v", i+1, result)
+			}
+		}
+	})
+}
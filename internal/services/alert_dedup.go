@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// dedupKey identifies the rule behind a finding: its rule ID when it has one, otherwise the
+// detector and alert type, since one detector may raise several kinds of alert.
+func dedupKey(finding Finding) string {
+	if finding.RuleID != "" {
+		return finding.RuleID
+	}
+	return finding.DetectorID + "/" + finding.AlertType
+}
+
+// FindOpenDuplicate returns the most recent alert for the account raised at or after since that is
+// still open and covers the dedup key, as its own key or one of its findings', or nil when there
+// is none.
+func FindOpenDuplicate(db database.DBTX, accountID, key string, since time.Time) (*models.Alert, error) {
+	query := `
+		SELECT ` + alertSelectColumns + `
+		FROM alerts
+		WHERE account_id = ? AND created_at >= ?
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := db.Query(query, accountID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate alerts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		}
+		if IsFinalAlertStatus(alert.AlertType, alert.Status) {
+			continue
+		}
+		for _, k := range alertDedupKeys(alert) {
+			if k == key {
+				return alert, nil
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+	}
+	return nil, nil
+}
+
+// AddAlertEvidence appends a finding's details to an alert's evidence.
+func AddAlertEvidence(db database.DBTX, alertID, transactionID string, details map[string]interface{}, observedAt time.Time) (*models.AlertEvidence, error) {
+	evidence := &models.AlertEvidence{
+		ID:            uuid.New().String(),
+		AlertID:       alertID,
+		TransactionID: transactionID,
+		Details:       details,
+		ObservedAt:    observedAt,
+	}
+	query := `
+		INSERT INTO alert_evidence (id, alert_id, transaction_id, details, observed_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, evidence.ID, evidence.AlertID, evidence.TransactionID, evidence.Details, evidence.ObservedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to add evidence to alert %s: %w", alertID, err)
+	}
+	return evidence, nil
+}
+
+// ListAlertEvidence returns an alert's evidence, oldest first.
+func ListAlertEvidence(db database.DBTX, alertID string) ([]models.AlertEvidence, error) {
+	query := `
+		SELECT id, alert_id, transaction_id, details, observed_at
+		FROM alert_evidence
+		WHERE alert_id = ?
+		ORDER BY observed_at ASC, id ASC
+	`
+	rows, err := db.Query(query, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evidence for alert %s: %w", alertID, err)
+	}
+	defer rows.Close()
+
+	evidence := []models.AlertEvidence{}
+	for rows.Next() {
+		var e models.AlertEvidence
+		if err := rows.Scan(&e.ID, &e.AlertID, &e.TransactionID, &e.Details, &e.ObservedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan evidence row: %w", err)
+		}
+		evidence = append(evidence, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating evidence rows: %w", err)
+	}
+
+	return evidence, nil
+}
+
+// mergeDuplicate folds a new alert into an existing open one: the new finding becomes evidence
+// and the existing alert is raised to the higher priority and score of the two.
+func mergeDuplicate(db database.DBTX, existing, duplicate *models.Alert) error {
+	for _, details := range alertEvidence(duplicate) {
+		if _, err := AddAlertEvidence(db, existing.ID, duplicate.TransactionID, details, duplicate.CreatedAt); err != nil {
+			return err
+		}
+	}
+	if duplicate.Priority <= existing.Priority && duplicate.Score <= existing.Score {
+		return nil
+	}
+	if duplicate.Priority > existing.Priority {
+		existing.Priority = duplicate.Priority
+	}
+	if duplicate.Score > existing.Score {
+		existing.Score = duplicate.Score
+		existing.ScoreBreakdown = duplicate.ScoreBreakdown
+	}
+	return UpdateAlert(db, existing)
+}
@@ -18,6 +18,12 @@ const (
 	AlertTypeStructuringPattern = "STRUCTURING_PATTERN"
 	// AlertTypeGeographicRisk is for geographic risk alerts.
 	AlertTypeGeographicRisk = "GEOGRAPHIC_RISK"
+	// AlertTypeBehavioralDeviation is for departures from an account's behavioural profile.
+	AlertTypeBehavioralDeviation = "BEHAVIORAL_DEVIATION"
+	// AlertTypeVelocityAnomaly is for bursts of transactions well above the account's usual rate.
+	AlertTypeVelocityAnomaly = "VELOCITY_ANOMALY"
+	// AlertTypeDormantReactivation is for activity on an account after a long dormant period.
+	AlertTypeDormantReactivation = "DORMANT_ACCOUNT_REACTIVATION"
 )
 
 // GenerateAlert creates a new alert for a suspicious transaction.
@@ -35,11 +41,12 @@ func GenerateAlert(tx models.Transaction, alertType string, ruleDetails map[stri
 	alert := &models.Alert{
 		ID:            uuid.New().String(),
 		TransactionID: tx.TransactionID,
+		AccountID:     tx.AccountID,
 		AlertType:     alertType,
 		Priority:      priority,
 		Score:         score,
 		CreatedAt:     time.Now(),
-		Status:        "OPEN",
+		Status:        InitialAlertStatus(alertType),
 		AssignedTo:    "", // Initially unassigned
 		RuleDetails:   ruleDetails,
 	}
@@ -70,9 +77,9 @@ func CalculateRiskScore(amount float64, priority models.PriorityLevel) (float64,
 
 func getPriorityForAlertType(alertType string) (models.PriorityLevel, error) {
 	switch alertType {
-	case AlertTypeThresholdViolation, AlertTypeGeographicRisk:
+	case AlertTypeThresholdViolation, AlertTypeGeographicRisk, AlertTypeBehavioralDeviation:
 		return models.Medium, nil
-	case AlertTypeAnomalyDetected:
+	case AlertTypeAnomalyDetected, AlertTypeVelocityAnomaly, AlertTypeDormantReactivation:
 		return models.High, nil
 	case AlertTypeStructuringPattern:
 		return models.Critical, nil
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+var (
+	// ErrCommentRequired is returned when a status change has no reason comment.
+	ErrCommentRequired = fmt.Errorf("a comment is required to change alert status")
+	// ErrActorRequired is returned when a status change does not say who made it.
+	ErrActorRequired = fmt.Errorf("an actor is required to change alert status")
+	// ErrConcurrentTransition is returned when the alert's status changed while a transition was applied.
+	ErrConcurrentTransition = fmt.Errorf("alert status changed concurrently")
+)
+
+// AlertTransitionRequest describes a requested alert status change.
+type AlertTransitionRequest struct {
+	AlertID   string
+	NewStatus string
+	Actor     string
+	Comment   string
+	// AssignTo optionally assigns the alert to an active investigator before the change, as
+	// AssignAlert does.
+	AssignTo string
+	// Roles held by the actor, checked against the workflow.
+	Roles []string
+	// Fields supplies values the workflow requires for the change.
+	Fields map[string]string
+}
+
+// ApplyAlertTransition moves an alert to a new status through the workflow and records the change
+// in the alert's status history. A requested assignee is assigned first through AssignAlert, so the
+// investigator is checked and the assignment logged. Run it inside a database transaction so the
+// alert, its assignment and its history are updated together.
+func ApplyAlertTransition(db database.DBTX, req AlertTransitionRequest) (*models.Alert, *models.AlertStatusChange, error) {
+	if strings.TrimSpace(req.Actor) == "" {
+		return nil, nil, ErrActorRequired
+	}
+	if strings.TrimSpace(req.Comment) == "" {
+		return nil, nil, ErrCommentRequired
+	}
+
+	alert, err := GetAlert(db, req.AlertID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.AssignTo != "" && req.AssignTo != alert.AssignedTo {
+		if alert, err = AssignAlert(db, req.AlertID, req.AssignTo, req.Actor, time.Now()); err != nil {
+			return nil, nil, err
+		}
+	}
+	fromStatus := alert.Status
+
+	input := TransitionInput{Roles: req.Roles, Fields: req.Fields}
+	if err := TransitionAlertStatusWithInput(alert, req.NewStatus, "", input); err != nil {
+		return nil, nil, err
+	}
+
+	// Only apply the change if nobody else moved the alert since it was read.
+	query := `
+		UPDATE alerts
+		SET status = ?, transition_at = ?, disposition_code = ?
+		WHERE id = ? AND status = ?
+	`
+	res, err := db.Exec(query, alert.Status, alert.TransitionAt.UTC(), alert.DispositionCode, alert.ID, fromStatus)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update alert %s: %w", alert.ID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrConcurrentTransition, alert.ID)
+	}
+
+	change := &models.AlertStatusChange{
+		ID:         uuid.New().String(),
+		AlertID:    alert.ID,
+		FromStatus: fromStatus,
+		ToStatus:   alert.Status,
+		Actor:      req.Actor,
+		Comment:    req.Comment,
+		ChangedAt:  alert.TransitionAt,
+	}
+	if len(req.Fields) > 0 {
+		change.Fields = make(models.JSONMap, len(req.Fields))
+		for k, v := range req.Fields {
+			change.Fields[k] = v
+		}
+	}
+	if err := insertAlertStatusChange(db, change); err != nil {
+		return nil, nil, err
+	}
+	return alert, change, nil
+}
+
+// insertAlertStatusChange appends a row to the alert status history.
+func insertAlertStatusChange(db database.DBTX, change *models.AlertStatusChange) error {
+	query := `
+		INSERT INTO alert_status_history (id, alert_id, from_status, to_status, actor, comment, fields, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, change.ID, change.AlertID, change.FromStatus, change.ToStatus, change.Actor, change.Comment, change.Fields, change.ChangedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record status change for alert %s: %w", change.AlertID, err)
+	}
+	return nil
+}
+
+// ListAlertStatusHistory returns an alert's status changes, oldest first.
+func ListAlertStatusHistory(db database.DBTX, alertID string) ([]models.AlertStatusChange, error) {
+	query := `
+		SELECT id, alert_id, from_status, to_status, actor, comment, fields, changed_at
+		FROM alert_status_history
+		WHERE alert_id = ?
+		ORDER BY changed_at ASC, id ASC
+	`
+	rows, err := db.Query(query, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history for alert %s: %w", alertID, err)
+	}
+	defer rows.Close()
+
+	history := []models.AlertStatusChange{}
+	for rows.Next() {
+		var change models.AlertStatusChange
+		err := rows.Scan(&change.ID, &change.AlertID, &change.FromStatus, &change.ToStatus, &change.Actor, &change.Comment, &change.Fields, &change.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status history row: %w", err)
+		}
+		history = append(history, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status history rows: %w", err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"AML/internal/models"
+)
+
+func TestApplyAlertTransition(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Test Case 1: Each change is appended to the history with its actor and comment
+	t.Run("records_history", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		if err := CreateInvestigator(db, &models.Investigator{ID: "analyst-1", Name: "Ann Analyst", Active: true}, base); err != nil {
+			t.Fatalf("CreateInvestigator failed: %v", err)
+		}
+
+		steps := []AlertTransitionRequest{
+			{AlertID: "alert-0", NewStatus: models.StatusInvestigating, Actor: "analyst-1", Comment: "Picking up", AssignTo: "analyst-1"},
+			{AlertID: "alert-0", NewStatus: models.StatusEscalated, Actor: "analyst-2", Comment: "Needs MLRO review", Fields: map[string]string{"escalation_reason": "cash intensive"}},
+		}
+		for _, step := range steps {
+			if _, _, err := ApplyAlertTransition(db, step); err != nil {
+				t.Fatalf("ApplyAlertTransition failed: %v", err)
+			}
+		}
+
+		alert, _ := GetAlert(db, "alert-0")
+		if alert.Status != models.StatusEscalated || alert.AssignedTo != "analyst-1" {
+			t.Errorf("Unexpected alert after transitions: %+v", alert)
+		}
+		assignments, err := ListAlertAssignments(db, "alert-0")
+		if err != nil {
+			t.Fatalf("ListAlertAssignments failed: %v", err)
+		}
+		if len(assignments) != 1 || assignments[0].InvestigatorID != "analyst-1" || assignments[0].Action != models.AssignmentActionAssigned {
+			t.Errorf("Expected the assignment to be logged, got %+v", assignments)
+		}
+
+		history, err := ListAlertStatusHistory(db, "alert-0")
+		if err != nil {
+			t.Fatalf("ListAlertStatusHistory failed: %v", err)
+		}
+		if len(history) != 2 {
+			t.Fatalf("Expected 2 history entries, got %d", len(history))
+		}
+		if history[0].FromStatus != models.StatusOpen || history[0].ToStatus != models.StatusInvestigating || history[0].Actor != "analyst-1" {
+			t.Errorf("Unexpected first entry: %+v", history[0])
+		}
+		if history[1].FromStatus != models.StatusInvestigating || history[1].Actor != "analyst-2" || history[1].Comment != "Needs MLRO review" ||
+			history[1].Fields["escalation_reason"] != "cash intensive" {
+			t.Errorf("Unexpected second entry: %+v", history[1])
+		}
+	})
+
+	// Test Case 2: Rejected changes leave no trace
+	t.Run("rejected_changes", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+
+		_, _, err := ApplyAlertTransition(db, AlertTransitionRequest{AlertID: "alert-0", NewStatus: models.StatusInvestigating, Actor: "analyst-1"})
+		if !errors.Is(err, ErrCommentRequired) {
+			t.Errorf("Expected ErrCommentRequired, got %v", err)
+		}
+		_, _, err = ApplyAlertTransition(db, AlertTransitionRequest{AlertID: "alert-0", NewStatus: models.StatusClosed, Actor: "analyst-1", Comment: "Done"})
+		if !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("Expected ErrInvalidTransition, got %v", err)
+		}
+		_, _, err = ApplyAlertTransition(db, AlertTransitionRequest{AlertID: "alert-0", NewStatus: models.StatusInvestigating, Actor: "analyst-1", Comment: "Picking up", AssignTo: "someone"})
+		if !errors.Is(err, ErrInvestigatorNotFound) {
+			t.Errorf("Expected ErrInvestigatorNotFound assigning to an unknown investigator, got %v", err)
+		}
+
+		history, _ := ListAlertStatusHistory(db, "alert-0")
+		if len(history) != 0 {
+			t.Errorf("Expected no history, got %+v", history)
+		}
+		alert, _ := GetAlert(db, "alert-0")
+		if alert.Status != models.StatusOpen {
+			t.Errorf("Expected alert to stay OPEN, got %s", alert.Status)
+		}
+	})
+}
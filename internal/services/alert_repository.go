@@ -0,0 +1,326 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+var (
+	// ErrAlertNotFound is returned when no alert exists with the requested ID.
+	ErrAlertNotFound = fmt.Errorf("alert not found")
+	// ErrTransactionNotFound is returned when no transaction exists with the requested ID.
+	ErrTransactionNotFound = fmt.Errorf("transaction not found")
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+	ErrInvalidCursor = fmt.Errorf("invalid cursor")
+)
+
+const (
+	// AlertSortCreatedAt orders alerts by creation time.
+	AlertSortCreatedAt = "created_at"
+	// AlertSortScore orders alerts by risk score.
+	AlertSortScore = "score"
+	// AlertSortPriority orders alerts by priority.
+	AlertSortPriority = "priority"
+)
+
+const (
+	defaultAlertPageSize = 50
+	maxAlertPageSize     = 500
+)
+
+// alertColumns lists the columns written when an alert is created.
+const alertColumns = `id, transaction_id, account_id, alert_type, priority, score, created_at, status, assigned_to, rule_details, transition_at, dedup_key, score_breakdown, findings, disposition_code`
+
+// alertSelectColumns is the column list matching scanAlert.
+const alertSelectColumns = alertColumns + `, case_id`
+
+// AlertFilter narrows and orders an alert listing. Zero values leave a field unfiltered.
+type AlertFilter struct {
+	Statuses    []string
+	Priorities  []models.PriorityLevel
+	AlertTypes  []string
+	AccountID   string
+	AssignedTo  string
+	CaseID      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	MinScore    *float64
+	MaxScore    *float64
+	SortBy      string
+	Descending  bool
+	Cursor      string
+	Limit       int
+}
+
+// AlertPage is one page of an alert listing.
+type AlertPage struct {
+	Alerts     []models.Alert `json:"alerts"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// alertCursor records the sort key of the last alert on a page.
+type alertCursor struct {
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Score     float64   `json:"score,omitempty"`
+	Priority  int       `json:"priority,omitempty"`
+	ID        string    `json:"id"`
+}
+
+// SaveAlert inserts a new alert.
+func SaveAlert(db database.DBTX, alert *models.Alert) error {
+	query := `
+		INSERT INTO alerts (` + alertColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query,
+		alert.ID, alert.TransactionID, alert.AccountID, alert.AlertType, int(alert.Priority), alert.Score,
+		alert.CreatedAt.UTC(), alert.Status, alert.AssignedTo, alert.RuleDetails, alert.TransitionAt.UTC(),
+		alert.DedupKey, alert.ScoreBreakdown, alert.Findings, alert.DispositionCode,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert alert %s: %w", alert.ID, err)
+	}
+	return nil
+}
+
+// UpdateAlert persists the mutable fields of an existing alert.
+func UpdateAlert(db database.DBTX, alert *models.Alert) error {
+	query := `
+		UPDATE alerts
+		SET alert_type = ?, priority = ?, score = ?, status = ?, assigned_to = ?, rule_details = ?, transition_at = ?,
+			score_breakdown = ?, disposition_code = ?
+		WHERE id = ?
+	`
+	res, err := db.Exec(query,
+		alert.AlertType, int(alert.Priority), alert.Score, alert.Status, alert.AssignedTo, alert.RuleDetails,
+		alert.TransitionAt.UTC(), alert.ScoreBreakdown, alert.DispositionCode, alert.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update alert %s: %w", alert.ID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, alert.ID)
+	}
+	return nil
+}
+
+// GetAlert fetches a single alert by ID.
+func GetAlert(db database.DBTX, id string) (*models.Alert, error) {
+	query := `SELECT ` + alertSelectColumns + ` FROM alerts WHERE id = ?`
+	alert, err := scanAlert(db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrAlertNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert %s: %w", id, err)
+	}
+	return alert, nil
+}
+
+// GetTransaction fetches a single transaction by ID.
+func GetTransaction(db database.DBTX, id string) (*models.Transaction, error) {
+	query := `
+		SELECT transaction_id, account_id, amount, currency, timestamp,
+			source_country, destination_country, transaction_type, status, COALESCE(counterparty_id, '')
+		FROM transactions
+		WHERE transaction_id = ?
+	`
+	var tx models.Transaction
+	err := db.QueryRow(query, id).Scan(
+		&tx.TransactionID, &tx.AccountID, &tx.Amount, &tx.Currency, &tx.Timestamp,
+		&tx.SourceCountry, &tx.DestinationCountry, &tx.TransactionType, &tx.Status, &tx.CounterpartyID,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction %s: %w", id, err)
+	}
+	return &tx, nil
+}
+
+// ListAlerts returns one page of alerts matching the filter, using keyset pagination so
+// pages stay stable while new alerts arrive.
+func ListAlerts(db database.DBTX, filter AlertFilter) (*AlertPage, error) {
+	sortColumn, err := alertSortColumn(filter.SortBy)
+	if err != nil {
+		return nil, err
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAlertPageSize
+	}
+	if limit > maxAlertPageSize {
+		limit = maxAlertPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	addIn := func(column string, values []interface{}) {
+		if len(values) == 0 {
+			return
+		}
+		conditions = append(conditions, column+" IN (?"+strings.Repeat(",?", len(values)-1)+")")
+		args = append(args, values...)
+	}
+
+	statuses := make([]interface{}, len(filter.Statuses))
+	for i, s := range filter.Statuses {
+		statuses[i] = s
+	}
+	addIn("status", statuses)
+	priorities := make([]interface{}, len(filter.Priorities))
+	for i, p := range filter.Priorities {
+		priorities[i] = int(p)
+	}
+	addIn("priority", priorities)
+	alertTypes := make([]interface{}, len(filter.AlertTypes))
+	for i, t := range filter.AlertTypes {
+		alertTypes[i] = t
+	}
+	addIn("alert_type", alertTypes)
+
+	if filter.AccountID != "" {
+		conditions = append(conditions, "account_id = ?")
+		args = append(args, filter.AccountID)
+	}
+	if filter.AssignedTo != "" {
+		conditions = append(conditions, "assigned_to = ?")
+		args = append(args, filter.AssignedTo)
+	}
+	if filter.CaseID != "" {
+		conditions = append(conditions, "case_id = ?")
+		args = append(args, filter.CaseID)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.CreatedFrom.UTC())
+	}
+	if !filter.CreatedTo.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.CreatedTo.UTC())
+	}
+	if filter.MinScore != nil {
+		conditions = append(conditions, "score >= ?")
+		args = append(args, *filter.MinScore)
+	}
+	if filter.MaxScore != nil {
+		conditions = append(conditions, "score <= ?")
+		args = append(args, *filter.MaxScore)
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.Cursor != "" {
+		cursor, err := decodeAlertCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		switch sortColumn {
+		case AlertSortScore:
+			value = cursor.Score
+		case AlertSortPriority:
+			value = cursor.Priority
+		default:
+			value = cursor.CreatedAt.UTC()
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", sortColumn, comparison, sortColumn, comparison))
+		args = append(args, value, value, cursor.ID)
+	}
+
+	query := `SELECT ` + alertSelectColumns + ` FROM alerts`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// Fetch one extra row to learn whether another page follows.
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %d", sortColumn, direction, direction, limit+1)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	page := &AlertPage{Alerts: []models.Alert{}}
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		}
+		page.Alerts = append(page.Alerts, *alert)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+	}
+
+	if len(page.Alerts) > limit {
+		page.Alerts = page.Alerts[:limit]
+		last := page.Alerts[limit-1]
+		page.NextCursor = encodeAlertCursor(alertCursor{
+			CreatedAt: last.CreatedAt,
+			Score:     last.Score,
+			Priority:  int(last.Priority),
+			ID:        last.ID,
+		})
+	}
+	return page, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAlert(row rowScanner) (*models.Alert, error) {
+	var alert models.Alert
+	var priority int
+	var caseID sql.NullString
+	err := row.Scan(
+		&alert.ID, &alert.TransactionID, &alert.AccountID, &alert.AlertType, &priority, &alert.Score,
+		&alert.CreatedAt, &alert.Status, &alert.AssignedTo, &alert.RuleDetails, &alert.TransitionAt, &alert.DedupKey, &alert.ScoreBreakdown, &alert.Findings, &alert.DispositionCode, &caseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	alert.Priority = models.PriorityLevel(priority)
+	alert.CaseID = caseID.String
+	return &alert, nil
+}
+
+func alertSortColumn(sortBy string) (string, error) {
+	switch sortBy {
+	case "", AlertSortCreatedAt:
+		return AlertSortCreatedAt, nil
+	case AlertSortScore, AlertSortPriority:
+		return sortBy, nil
+	default:
+		return "", fmt.Errorf("unsupported sort field: %s", sortBy)
+	}
+}
+
+func encodeAlertCursor(c alertCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeAlertCursor(s string) (alertCursor, error) {
+	var c alertCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
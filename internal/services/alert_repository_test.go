@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+func seedAlerts(t *testing.T, db database.DBTX, base time.Time) []*models.Alert {
+	t.Helper()
+	var alerts []*models.Alert
+	for i := 0; i < 5; i++ {
+		alert := &models.Alert{
+			ID:            fmt.Sprintf("alert-%d", i),
+			TransactionID: fmt.Sprintf("tx-%d", i),
+			AccountID:     []string{"acc-1", "acc-2"}[i%2],
+			AlertType:     []string{AlertTypeThresholdViolation, AlertTypeStructuringPattern}[i%2],
+			Priority:      []models.PriorityLevel{models.Medium, models.Critical}[i%2],
+			Score:         float64(i) * 20,
+			CreatedAt:     base.Add(time.Duration(i) * time.Hour),
+			Status:        models.StatusOpen,
+			RuleDetails:   models.JSONMap{"rule_id": fmt.Sprintf("rule-%d", i)},
+		}
+		if err := SaveAlert(db, alert); err != nil {
+			t.Fatalf("SaveAlert failed: %v", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+func TestAlertRepository(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Test Case 1: Alerts round-trip with their rule details
+	t.Run("save_and_get", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+
+		alert, err := GetAlert(db, "alert-3")
+		if err != nil {
+			t.Fatalf("GetAlert failed: %v", err)
+		}
+		if alert.AccountID != "acc-2" || alert.Priority != models.Critical || alert.Score != 60 {
+			t.Errorf("Unexpected alert: %+v", alert)
+		}
+		if !alert.CreatedAt.Equal(base.Add(3 * time.Hour)) {
+			t.Errorf("Expected created_at %v, got %v", base.Add(3*time.Hour), alert.CreatedAt)
+		}
+		if alert.RuleDetails["rule_id"] != "rule-3" {
+			t.Errorf("Expected decoded rule details, got %v", alert.RuleDetails)
+		}
+
+		if _, err := GetAlert(db, "missing"); !errors.Is(err, ErrAlertNotFound) {
+			t.Errorf("Expected ErrAlertNotFound, got %v", err)
+		}
+	})
+
+	// Test Case 2: Updates persist status and assignee
+	t.Run("update", func(t *testing.T) {
+		db := newTestDB(t)
+		alerts := seedAlerts(t, db, base)
+
+		alert := alerts[0]
+		alert.Status = models.StatusInvestigating
+		alert.AssignedTo = "inv-1"
+		if err := UpdateAlert(db, alert); err != nil {
+			t.Fatalf("UpdateAlert failed: %v", err)
+		}
+		got, _ := GetAlert(db, alert.ID)
+		if got.Status != models.StatusInvestigating || got.AssignedTo != "inv-1" {
+			t.Errorf("Expected update to persist, got %+v", got)
+		}
+
+		if err := UpdateAlert(db, &models.Alert{ID: "missing"}); !errors.Is(err, ErrAlertNotFound) {
+			t.Errorf("Expected ErrAlertNotFound, got %v", err)
+		}
+	})
+
+	// Test Case 3: Filters combine
+	t.Run("filters", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+
+		minScore := 10.0
+		page, err := ListAlerts(db, AlertFilter{
+			AccountID:   "acc-1",
+			Priorities:  []models.PriorityLevel{models.Medium},
+			MinScore:    &minScore,
+			CreatedFrom: base,
+			CreatedTo:   base.Add(4 * time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("ListAlerts failed: %v", err)
+		}
+		if len(page.Alerts) != 1 || page.Alerts[0].ID != "alert-2" {
+			t.Errorf("Expected only alert-2, got %+v", page.Alerts)
+		}
+	})
+
+	// Test Case 4: Cursor pagination walks every alert once in sort order
+	t.Run("cursor_pagination", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+
+		var ids []string
+		filter := AlertFilter{SortBy: AlertSortScore, Descending: true, Limit: 2}
+		for {
+			page, err := ListAlerts(db, filter)
+			if err != nil {
+				t.Fatalf("ListAlerts failed: %v", err)
+			}
+			for _, a := range page.Alerts {
+				ids = append(ids, a.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		want := []string{"alert-4", "alert-3", "alert-2", "alert-1", "alert-0"}
+		if fmt.Sprint(ids) != fmt.Sprint(want) {
+			t.Errorf("Expected %v, got %v", want, ids)
+		}
+
+		if _, err := ListAlerts(db, AlertFilter{Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+	})
+}
@@ -2,45 +2,214 @@ package services
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"AML/internal/config"
+	"AML/internal/database"
 	"AML/internal/models"
 )
 
 var (
 	// ErrInvalidTransition is returned for invalid status transitions.
 	ErrInvalidTransition = fmt.Errorf("invalid status transition")
+	// ErrTransitionForbidden is returned when none of the actor's roles may make a transition.
+	ErrTransitionForbidden = fmt.Errorf("status transition not permitted for role")
+	// ErrMissingTransitionField is returned when a transition lacks a field the workflow requires.
+	ErrMissingTransitionField = fmt.Errorf("missing required transition field")
 )
 
-var allowedTransitions = map[string][]string{
-	models.StatusOpen:          {models.StatusInvestigating},
-	models.StatusInvestigating: {models.StatusEscalated, models.StatusFalsePositive},
-	models.StatusEscalated:     {models.StatusClosed},
-	models.StatusClosed:        {},
-	models.StatusFalsePositive: {},
+// alertWorkflow indexes a configured workflow for transition lookups.
+type alertWorkflow struct {
+	name         string
+	initialState string
+	transitions  map[string]map[string]config.WorkflowTransition
+	finalStates  map[string]bool
+}
+
+// alertWorkflowSet resolves the workflow governing each alert type.
+type alertWorkflowSet struct {
+	byAlertType map[string]*alertWorkflow
+	fallback    *alertWorkflow
+}
+
+// alertWorkflows is the active workflow set. It starts as the built-in default and is replaced
+// once at startup by ConfigureAlertWorkflows.
+var alertWorkflows = mustCompileAlertWorkflows(config.DefaultWorkflowConfig())
+
+// ConfigureAlertWorkflows validates and installs the alert workflows. It must be called before
+// alerts are processed, as the active set is not guarded for concurrent replacement.
+func ConfigureAlertWorkflows(cfg config.WorkflowConfig) error {
+	set, err := compileAlertWorkflows(cfg)
+	if err != nil {
+		return err
+	}
+	alertWorkflows = set
+	return nil
+}
+
+func compileAlertWorkflows(cfg config.WorkflowConfig) (*alertWorkflowSet, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workflow config: %w", err)
+	}
+
+	set := &alertWorkflowSet{byAlertType: make(map[string]*alertWorkflow)}
+	for _, w := range cfg.Workflows {
+		compiled := &alertWorkflow{
+			name:         w.Name,
+			initialState: w.InitialState,
+			transitions:  make(map[string]map[string]config.WorkflowTransition),
+			finalStates:  make(map[string]bool),
+		}
+		for _, s := range w.States {
+			compiled.transitions[s] = make(map[string]config.WorkflowTransition)
+		}
+		for _, t := range w.Transitions {
+			compiled.transitions[t.From][t.To] = t
+		}
+		for _, s := range w.FinalStates {
+			compiled.finalStates[s] = true
+		}
+
+		for _, alertType := range w.AlertTypes {
+			set.byAlertType[alertType] = compiled
+		}
+		if w.Name == cfg.DefaultWorkflow {
+			set.fallback = compiled
+		}
+	}
+	return set, nil
+}
+
+func mustCompileAlertWorkflows(cfg config.WorkflowConfig) *alertWorkflowSet {
+	set, err := compileAlertWorkflows(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// forAlertType returns the workflow governing an alert type.
+func (s *alertWorkflowSet) forAlertType(alertType string) *alertWorkflow {
+	if w, ok := s.byAlertType[alertType]; ok {
+		return w
+	}
+	return s.fallback
+}
+
+// InitialAlertStatus returns the status a new alert of the given type starts in.
+func InitialAlertStatus(alertType string) string {
+	return alertWorkflows.forAlertType(alertType).initialState
+}
+
+// IsFinalAlertStatus reports whether an alert of the given type needs no further work in status.
+func IsFinalAlertStatus(alertType, status string) bool {
+	return alertWorkflows.forAlertType(alertType).finalStates[status]
+}
+
+// openAlertCondition returns a SQL condition, with its arguments, matching alerts that are not in
+// a final status of their alert type's workflow.
+func (s *alertWorkflowSet) openAlertCondition() (string, []interface{}) {
+	var typed []string
+	for alertType := range s.byAlertType {
+		typed = append(typed, alertType)
+	}
+	sort.Strings(typed)
+
+	var clauses []string
+	var args []interface{}
+	addClause := func(typeCond string, types []string, w *alertWorkflow) {
+		var parts []string
+		if len(types) > 0 {
+			parts = append(parts, "alert_type "+typeCond+" "+database.Placeholders(len(types)))
+			for _, t := range types {
+				args = append(args, t)
+			}
+		}
+		var finals []string
+		for status := range w.finalStates {
+			finals = append(finals, status)
+		}
+		sort.Strings(finals)
+		if len(finals) > 0 {
+			parts = append(parts, "status NOT IN "+database.Placeholders(len(finals)))
+			for _, f := range finals {
+				args = append(args, f)
+			}
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "1 = 1")
+		}
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	var workflows []*alertWorkflow
+	byWorkflow := make(map[*alertWorkflow][]string)
+	for _, alertType := range typed {
+		w := s.byAlertType[alertType]
+		if _, seen := byWorkflow[w]; !seen {
+			workflows = append(workflows, w)
+		}
+		byWorkflow[w] = append(byWorkflow[w], alertType)
+	}
+	for _, w := range workflows {
+		addClause("IN", byWorkflow[w], w)
+	}
+	addClause("NOT IN", typed, s.fallback)
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+// TransitionInput carries what the workflow may require of the person changing an alert.
+type TransitionInput struct {
+	// Roles held by the actor making the change.
+	Roles []string
+	// Fields supplied with the change, such as a disposition code.
+	Fields map[string]string
 }
 
 // TransitionAlertStatus updates the status of an alert after validation.
 func TransitionAlertStatus(alert *models.Alert, newStatus string, investigatorID string) error {
+	return TransitionAlertStatusWithInput(alert, newStatus, investigatorID, TransitionInput{})
+}
+
+// TransitionAlertStatusWithInput updates the status of an alert after checking the transition,
+// the actor's roles and the mandatory fields against the alert type's workflow. A disposition
+// code supplied when closing the alert must be in the configured catalogue; the alert keeps it
+// until it is reopened.
+func TransitionAlertStatusWithInput(alert *models.Alert, newStatus string, investigatorID string, input TransitionInput) error {
+	workflow := alertWorkflows.forAlertType(alert.AlertType)
+
 	currentStatus := alert.Status
-	validTransitions, ok := allowedTransitions[currentStatus]
+	validTransitions, ok := workflow.transitions[currentStatus]
 	if !ok {
 		return fmt.Errorf("unknown status: %s", currentStatus)
 	}
 
-	isValid := false
-	for _, status := range validTransitions {
-		if status == newStatus {
-			isValid = true
-			break
+	transition, ok := validTransitions[newStatus]
+	if !ok {
+		return fmt.Errorf("%w: cannot transition from %s to %s", ErrInvalidTransition, currentStatus, newStatus)
+	}
+
+	if len(transition.Roles) > 0 && !hasAnyRole(input.Roles, transition.Roles) {
+		return fmt.Errorf("%w: %s → %s requires one of %v", ErrTransitionForbidden, currentStatus, newStatus, transition.Roles)
+	}
+
+	for _, field := range transition.RequiredFields {
+		if input.Fields[field] == "" {
+			return fmt.Errorf("%w: %s → %s requires %s", ErrMissingTransitionField, currentStatus, newStatus, field)
 		}
 	}
 
-	if !isValid {
-		return fmt.Errorf("%w: cannot transition from %s to %s", ErrInvalidTransition, currentStatus, newStatus)
+	code := input.Fields[dispositionCodeField]
+	if code != "" {
+		if err := checkDisposition(code, newStatus, workflow.finalStates[newStatus]); err != nil {
+			return err
+		}
 	}
 
 	alert.Status = newStatus
+	alert.DispositionCode = code
 	alert.TransitionAt = time.Now()
 	if investigatorID != "" {
 		alert.AssignedTo = investigatorID
@@ -48,3 +217,14 @@ func TransitionAlertStatus(alert *models.Alert, newStatus string, investigatorID
 
 	return nil
 }
+
+func hasAnyRole(held, allowed []string) bool {
+	for _, h := range held {
+		for _, a := range allowed {
+			if h == a {
+				return true
+			}
+		}
+	}
+	return false
+}
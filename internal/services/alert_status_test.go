@@ -1,7 +1,10 @@
 package services
 
 import (
+	"AML/internal/config"
 	"AML/internal/models"
+	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -69,3 +72,128 @@ func TestTransitionAlertStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestConfiguredAlertWorkflows(t *testing.T) {
+	cfg := config.WorkflowConfig{
+		DefaultWorkflow: "standard",
+		Workflows: []config.Workflow{
+			{
+				Name:         "standard",
+				InitialState: models.StatusOpen,
+				States:       []string{models.StatusOpen, models.StatusInvestigating, models.StatusPendingInfo, models.StatusClosed},
+				FinalStates:  []string{models.StatusClosed},
+				Transitions: []config.WorkflowTransition{
+					{From: models.StatusOpen, To: models.StatusInvestigating},
+					{From: models.StatusInvestigating, To: models.StatusPendingInfo},
+					{From: models.StatusPendingInfo, To: models.StatusInvestigating},
+					{From: models.StatusInvestigating, To: models.StatusClosed, RequiredFields: []string{"disposition_code"}},
+					{From: models.StatusClosed, To: models.StatusOpen, Roles: []string{"supervisor"}},
+				},
+			},
+			{
+				Name:         "triage",
+				AlertTypes:   []string{AlertTypeGeographicRisk},
+				InitialState: "NEW",
+				States:       []string{"NEW", models.StatusClosed},
+				FinalStates:  []string{models.StatusClosed},
+				Transitions:  []config.WorkflowTransition{{From: "NEW", To: models.StatusClosed}},
+			},
+		},
+	}
+	if err := ConfigureAlertWorkflows(cfg); err != nil {
+		t.Fatalf("ConfigureAlertWorkflows failed: %v", err)
+	}
+	defer ConfigureAlertWorkflows(config.DefaultWorkflowConfig())
+
+	// Test Case 1: Required fields are enforced
+	t.Run("required_fields", func(t *testing.T) {
+		alert := &models.Alert{Status: models.StatusInvestigating}
+		err := TransitionAlertStatus(alert, models.StatusClosed, "")
+		if !errors.Is(err, ErrMissingTransitionField) {
+			t.Errorf("Expected ErrMissingTransitionField, got %v", err)
+		}
+		err = TransitionAlertStatusWithInput(alert, models.StatusClosed, "", TransitionInput{Fields: map[string]string{"disposition_code": "FP_KNOWN_CUSTOMER"}})
+		if err != nil || alert.Status != models.StatusClosed {
+			t.Errorf("Expected close to succeed, got status %s and error %v", alert.Status, err)
+		}
+	})
+
+	// Test Case 2: Roles are enforced
+	t.Run("roles", func(t *testing.T) {
+		alert := &models.Alert{Status: models.StatusClosed}
+		err := TransitionAlertStatusWithInput(alert, models.StatusOpen, "", TransitionInput{Roles: []string{"analyst"}})
+		if !errors.Is(err, ErrTransitionForbidden) {
+			t.Errorf("Expected ErrTransitionForbidden, got %v", err)
+		}
+		err = TransitionAlertStatusWithInput(alert, models.StatusOpen, "", TransitionInput{Roles: []string{"analyst", "supervisor"}})
+		if err != nil || alert.Status != models.StatusOpen {
+			t.Errorf("Expected reopen to succeed, got status %s and error %v", alert.Status, err)
+		}
+	})
+
+	// Test Case 3: Alert types use their own workflow
+	t.Run("per_alert_type", func(t *testing.T) {
+		if got := InitialAlertStatus(AlertTypeGeographicRisk); got != "NEW" {
+			t.Errorf("Expected initial status NEW, got %s", got)
+		}
+		if got := InitialAlertStatus(AlertTypeStructuringPattern); got != models.StatusOpen {
+			t.Errorf("Expected initial status OPEN, got %s", got)
+		}
+		alert := &models.Alert{AlertType: AlertTypeGeographicRisk, Status: "NEW"}
+		if err := TransitionAlertStatus(alert, models.StatusClosed, ""); err != nil {
+			t.Errorf("Expected NEW → CLOSED to be allowed, got %v", err)
+		}
+		if !IsFinalAlertStatus(AlertTypeGeographicRisk, models.StatusClosed) {
+			t.Errorf("Expected CLOSED to be final")
+		}
+	})
+}
+
+func TestWorkflowConfigValidate(t *testing.T) {
+	if err := config.DefaultWorkflowConfig().Validate(); err != nil {
+		t.Fatalf("Default workflow config is invalid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(cfg *config.WorkflowConfig)
+	}{
+		{"unknown_default", func(cfg *config.WorkflowConfig) { cfg.DefaultWorkflow = "missing" }},
+		{"undeclared_state", func(cfg *config.WorkflowConfig) {
+			cfg.Workflows[0].Transitions = append(cfg.Workflows[0].Transitions, config.WorkflowTransition{From: models.StatusClosed, To: "ARCHIVED"})
+		}},
+		{"unreachable_state", func(cfg *config.WorkflowConfig) {
+			cfg.Workflows[0].States = append(cfg.Workflows[0].States, models.StatusPendingInfo)
+		}},
+		{"duplicate_transition", func(cfg *config.WorkflowConfig) {
+			cfg.Workflows[0].Transitions = append(cfg.Workflows[0].Transitions, cfg.Workflows[0].Transitions[0])
+		}},
+		{"alert_type_in_two_workflows", func(cfg *config.WorkflowConfig) {
+			second := cfg.Workflows[0]
+			second.Name = "other"
+			second.AlertTypes = []string{AlertTypeStructuringPattern}
+			cfg.Workflows[0].AlertTypes = []string{AlertTypeStructuringPattern}
+			cfg.Workflows = append(cfg.Workflows, second)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultWorkflowConfig()
+			tt.mutate(&cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("Expected validation error")
+			}
+			if err := ConfigureAlertWorkflows(cfg); err == nil {
+				t.Errorf("Expected ConfigureAlertWorkflows to reject the config")
+			}
+		})
+	}
+
+	cfg, err := config.LoadWorkflowConfig(filepath.Join("..", "..", "workflows.json"))
+	if err != nil {
+		t.Fatalf("Shipped workflows.json is invalid: %v", err)
+	}
+	if len(cfg.Workflows) == 0 {
+		t.Errorf("Expected workflows in workflows.json")
+	}
+}
@@ -150,6 +150,7 @@ func TestAMLEndToEndFlow(t *testing.T) {
 	detected, matchingTxs := services.DetectStructuring(
 		"ACC123",
 		acc123Transactions,
+		time.Now(),
 		structuringTimeWindow,
 		1.0, // Assuming a low threshold for individual transactions for structuring detection
 		structuringRule.ThresholdValue, // Using the overall threshold value as the upper bound for individual txns to be part of the pattern
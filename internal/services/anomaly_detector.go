@@ -1,53 +1,255 @@
 package services
 
 import (
+	"AML/internal/config"
 	"AML/internal/models"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 )
 
+// madConsistency scales the median absolute deviation so it estimates the standard deviation of normal data.
+const madConsistency = 1.4826
+
+// AnomalyResult explains how an amount anomaly score was reached.
+type AnomalyResult struct {
+	IsAnomaly bool    `json:"is_anomaly"`
+	Score     float64 `json:"score"`
+	Threshold float64 `json:"threshold"`
+	Method    string  `json:"method"`
+	// Value is the transaction amount in the method's space (e.g. log amount for log_zscore).
+	Value  float64 `json:"value"`
+	Center float64 `json:"center"`
+	Scale  float64 `json:"scale"`
+	// ScaleFloored is true when the observed spread was below the configured minimum.
+	ScaleFloored bool   `json:"scale_floored"`
+	Baseline     string `json:"baseline"`
+	SampleSize   int    `json:"sample_size"`
+	Explanation  string `json:"explanation"`
+}
+
+// Details returns the result as alert rule details.
+func (r *AnomalyResult) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"anomaly_score": r.Score,
+		"threshold":     r.Threshold,
+		"method":        r.Method,
+		"value":         r.Value,
+		"center":        r.Center,
+		"scale":         r.Scale,
+		"scale_floored": r.ScaleFloored,
+		"baseline":      r.Baseline,
+		"sample_size":   r.SampleSize,
+		"explanation":   r.Explanation,
+	}
+}
+
 // DetectAmountAnomaly checks for anomalous transaction amounts.
 func DetectAmountAnomaly(currentTx models.Transaction, history []models.Transaction) (isAnomaly bool, zScore float64, err error) {
-	if len(history) < 10 {
-		return false, 0, fmt.Errorf("insufficient transaction history for anomaly detection (requires at least 10 transactions)")
+	result, err := DetectAmountAnomalyWithConfig(currentTx, history, config.DefaultAnomalyConfig())
+	if err != nil {
+		return false, 0, err
 	}
+	return result.IsAnomaly, result.Score, nil
+}
 
-	mean := calculateMean(history)
-	stdDev := calculateStdDev(history, mean)
+// DetectAmountAnomalyWithConfig scores a transaction amount against its history using the configured method.
+func DetectAmountAnomalyWithConfig(currentTx models.Transaction, history []models.Transaction, cfg config.AnomalyConfig) (*AnomalyResult, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid anomaly config: %w", err)
+	}
+	if len(history) < cfg.MinHistory {
+		return nil, fmt.Errorf("%w for anomaly detection (requires at least %d transactions)", ErrInsufficientHistory, cfg.MinHistory)
+	}
 
-	if stdDev == 0 {
-		// If all historical amounts are identical, any different amount is an anomaly.
-		if currentTx.Amount != mean {
-			return true, math.Inf(1), nil // Infinite z-score
+	baseline := "all history"
+	sample := history
+	if len(cfg.SegmentBy) > 0 {
+		segmented, description := segmentHistory(currentTx, history, cfg.SegmentBy)
+		if len(segmented) >= cfg.MinHistory {
+			sample = segmented
+			baseline = description
+		} else {
+			baseline = fmt.Sprintf("all history (%s had only %d transactions)", description, len(segmented))
 		}
-		return false, 0, nil
 	}
 
-	zScore = (currentTx.Amount - mean) / stdDev
-	isAnomaly = math.Abs(zScore) > 3
+	amounts := make([]float64, len(sample))
+	for i, tx := range sample {
+		amounts[i] = tx.Amount
+	}
+	value := currentTx.Amount
+	if cfg.Method == config.AnomalyMethodLogZScore {
+		for i := range amounts {
+			amounts[i] = logAmount(amounts[i])
+		}
+		value = logAmount(value)
+	}
 
-	return isAnomaly, zScore, nil
+	var center, scale float64
+	switch cfg.Method {
+	case config.AnomalyMethodMAD:
+		center = median(amounts)
+		deviations := make([]float64, len(amounts))
+		for i, a := range amounts {
+			deviations[i] = math.Abs(a - center)
+		}
+		scale = madConsistency * median(deviations)
+	default:
+		center = mean(amounts)
+		scale = stdDev(amounts, center)
+	}
+
+	return scoreAmount(value, center, scale, len(sample), baseline, cfg), nil
 }
 
-// calculateMean calculates the mean of transaction amounts.
-func calculateMean(transactions []models.Transaction) float64 {
+// DetectAmountAnomalyFromProfile scores a transaction amount against the account's stored
+// rolling statistics, so no raw history has to be loaded. Only the mean-based methods are
+// supported, since the median cannot be maintained incrementally.
+func DetectAmountAnomalyFromProfile(currentTx models.Transaction, profile *models.AccountProfile, cfg config.AnomalyConfig) (*AnomalyResult, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid anomaly config: %w", err)
+	}
+	if cfg.Method == config.AnomalyMethodMAD || len(cfg.SegmentBy) > 0 {
+		return nil, fmt.Errorf("anomaly method %s with segment_by %v requires raw transaction history", cfg.Method, cfg.SegmentBy)
+	}
+	if profile == nil || profile.TransactionCount < int64(cfg.MinHistory) {
+		return nil, fmt.Errorf("%w for anomaly detection (requires at least %d transactions)", ErrInsufficientHistory, cfg.MinHistory)
+	}
+
+	value, center, scale := currentTx.Amount, profile.AmountMean, profile.AmountStdDev()
+	if cfg.Method == config.AnomalyMethodLogZScore {
+		value, center, scale = logAmount(currentTx.Amount), profile.LogAmountMean, profile.LogAmountStdDev()
+	}
+
+	return scoreAmount(value, center, scale, int(profile.TransactionCount), "account profile", cfg), nil
+}
+
+// scoreAmount turns a baseline centre and spread into an explained anomaly result.
+func scoreAmount(value, center, scale float64, sampleSize int, baseline string, cfg config.AnomalyConfig) *AnomalyResult {
+	// Log amounts are already relative, so the floor applies directly in log space.
+	floor := cfg.MinRelativeScale
+	if cfg.Method != config.AnomalyMethodLogZScore {
+		floor = cfg.MinRelativeScale * math.Abs(center)
+	}
+	floored := false
+	if scale < floor {
+		scale = floor
+		floored = true
+	}
+
+	result := &AnomalyResult{
+		Threshold:    cfg.Threshold,
+		Method:       cfg.Method,
+		Value:        value,
+		Center:       center,
+		Scale:        scale,
+		ScaleFloored: floored,
+		Baseline:     baseline,
+		SampleSize:   sampleSize,
+	}
+	if scale > 0 {
+		result.Score = (value - center) / scale
+	}
+	result.IsAnomaly = math.Abs(result.Score) > cfg.Threshold
+	result.Explanation = explainAnomaly(result)
+
+	return result
+}
+
+// segmentHistory keeps the history that matches the current transaction on every segment dimension.
+func segmentHistory(currentTx models.Transaction, history []models.Transaction, segmentBy []string) ([]models.Transaction, string) {
+	var parts []string
+	for _, segment := range segmentBy {
+		switch segment {
+		case config.SegmentTransactionType:
+			parts = append(parts, "transaction_type="+currentTx.TransactionType)
+		case config.SegmentDayOfWeek:
+			parts = append(parts, "day_of_week="+currentTx.Timestamp.Weekday().String())
+		}
+	}
+
+	var segmented []models.Transaction
+	for _, tx := range history {
+		if matchesSegments(currentTx, tx, segmentBy) {
+			segmented = append(segmented, tx)
+		}
+	}
+	return segmented, strings.Join(parts, ",")
+}
+
+func matchesSegments(currentTx, tx models.Transaction, segmentBy []string) bool {
+	for _, segment := range segmentBy {
+		switch segment {
+		case config.SegmentTransactionType:
+			if !strings.EqualFold(tx.TransactionType, currentTx.TransactionType) {
+				return false
+			}
+		case config.SegmentDayOfWeek:
+			if tx.Timestamp.Weekday() != currentTx.Timestamp.Weekday() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func explainAnomaly(r *AnomalyResult) string {
+	direction := "above"
+	if r.Score < 0 {
+		direction = "below"
+	}
+	verdict := "within"
+	if r.IsAnomaly {
+		verdict = "outside"
+	}
+	spread := "standard deviations"
+	if r.Method == config.AnomalyMethodMAD {
+		spread = "scaled median absolute deviations"
+	}
+	explanation := fmt.Sprintf("%s score %.2f: value %.2f is %.2f %s %s the baseline center %.2f (%s, %d transactions), %s the threshold of %.2f",
+		r.Method, r.Score, r.Value, math.Abs(r.Score), spread, direction, r.Center, r.Baseline, r.SampleSize, verdict, r.Threshold)
+	if r.ScaleFloored {
+		explanation += "; spread was raised to the configured minimum because history is nearly constant"
+	}
+	return explanation
+}
+
+// logAmount maps an amount into log space, keeping zero amounts finite.
+func logAmount(amount float64) float64 {
+	return math.Log1p(math.Max(amount, 0))
+}
+
+// mean calculates the arithmetic mean of values.
+func mean(values []float64) float64 {
 	var sum float64
-	for _, tx := range transactions {
-		sum += tx.Amount
+	for _, v := range values {
+		sum += v
 	}
-	return sum / float64(len(transactions))
+	return sum / float64(len(values))
 }
 
-// calculateStdDev calculates the standard deviation of transaction amounts.
-func calculateStdDev(transactions []models.Transaction, mean float64) float64 {
-	if len(transactions) == 0 {
+// stdDev calculates the population standard deviation of values.
+func stdDev(values []float64, mean float64) float64 {
+	if len(values) == 0 {
 		return 0
 	}
 
 	var sumOfSquares float64
-	for _, tx := range transactions {
-		sumOfSquares += math.Pow(tx.Amount-mean, 2)
+	for _, v := range values {
+		sumOfSquares += math.Pow(v-mean, 2)
+	}
+	return math.Sqrt(sumOfSquares / float64(len(values)))
+}
+
+// median returns the median of values without modifying the input.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
 	}
-	variance := sumOfSquares / float64(len(transactions))
-	return math.Sqrt(variance)
+	return (sorted[n/2-1] + sorted[n/2]) / 2
 }
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"AML/internal/config"
 	"AML/internal/models"
+	"math"
 	"testing"
 	"time"
 )
@@ -77,3 +79,186 @@ func TestDetectAmountAnomaly(t *testing.T) {
 		}
 	})
 }
+
+func TestDetectAmountAnomalyWithConfig(t *testing.T) {
+	// Test Case 1: A single huge outlier in history does not mask later anomalies under MAD
+	t.Run("mad_resists_outlier_in_history", func(t *testing.T) {
+		var history []models.Transaction
+		for i := 0; i < 19; i++ {
+			history = append(history, models.Transaction{Amount: 100.00 + float64(i%5), Timestamp: time.Now()})
+		}
+		history = append(history, models.Transaction{Amount: 1000000.00, Timestamp: time.Now()})
+		currentTx := models.Transaction{Amount: 5000.00, Timestamp: time.Now()}
+
+		zCfg := config.DefaultAnomalyConfig()
+		zResult, err := DetectAmountAnomalyWithConfig(currentTx, history, zCfg)
+		if err != nil {
+			t.Fatalf("DetectAmountAnomalyWithConfig failed: %v", err)
+		}
+		if zResult.IsAnomaly {
+			t.Errorf("Expected the z-score baseline to be masked by the outlier (score: %f)", zResult.Score)
+		}
+
+		madCfg := config.DefaultAnomalyConfig()
+		madCfg.Method = config.AnomalyMethodMAD
+		madResult, err := DetectAmountAnomalyWithConfig(currentTx, history, madCfg)
+		if err != nil {
+			t.Fatalf("DetectAmountAnomalyWithConfig failed: %v", err)
+		}
+		if !madResult.IsAnomaly {
+			t.Errorf("Expected MAD to flag the transaction, got score %f", madResult.Score)
+		}
+		if madResult.Center != 102.00 {
+			t.Errorf("Expected median center 102.00, got %f", madResult.Center)
+		}
+	})
+
+	// Test Case 2: Constant history yields a finite score and an explanation
+	t.Run("constant_history_is_finite", func(t *testing.T) {
+		history := make([]models.Transaction, 10)
+		for i := range history {
+			history[i] = models.Transaction{Amount: 100.00, Timestamp: time.Now()}
+		}
+		currentTx := models.Transaction{Amount: 200.00, Timestamp: time.Now()}
+		for _, method := range []string{config.AnomalyMethodZScore, config.AnomalyMethodLogZScore, config.AnomalyMethodMAD} {
+			cfg := config.DefaultAnomalyConfig()
+			cfg.Method = method
+			result, err := DetectAmountAnomalyWithConfig(currentTx, history, cfg)
+			if err != nil {
+				t.Fatalf("%s: DetectAmountAnomalyWithConfig failed: %v", method, err)
+			}
+			if math.IsInf(result.Score, 0) || math.IsNaN(result.Score) {
+				t.Errorf("%s: Expected finite score, got %f", method, result.Score)
+			}
+			if !result.ScaleFloored {
+				t.Errorf("%s: Expected scale to be floored for constant history", method)
+			}
+			if !result.IsAnomaly {
+				t.Errorf("%s: Expected anomaly for doubled amount, got score %f", method, result.Score)
+			}
+			if result.Explanation == "" {
+				t.Errorf("%s: Expected explanation to be set", method)
+			}
+		}
+	})
+
+	// Test Case 3: Transaction type baseline
+	t.Run("segment_by_transaction_type", func(t *testing.T) {
+		var history []models.Transaction
+		for i := 0; i < 10; i++ {
+			history = append(history,
+				models.Transaction{Amount: 50.00 + float64(i), TransactionType: "purchase", Timestamp: time.Now()},
+				models.Transaction{Amount: 5000.00 + float64(i*10), TransactionType: "payroll", Timestamp: time.Now()},
+			)
+		}
+		currentTx := models.Transaction{Amount: 5100.00, TransactionType: "payroll", Timestamp: time.Now()}
+
+		cfg := config.DefaultAnomalyConfig()
+		cfg.SegmentBy = []string{config.SegmentTransactionType}
+		result, err := DetectAmountAnomalyWithConfig(currentTx, history, cfg)
+		if err != nil {
+			t.Fatalf("DetectAmountAnomalyWithConfig failed: %v", err)
+		}
+		if result.SampleSize != 10 {
+			t.Errorf("Expected 10 payroll transactions in baseline, got %d", result.SampleSize)
+		}
+		if result.Baseline != "transaction_type=payroll" {
+			t.Errorf("Expected transaction type baseline, got %q", result.Baseline)
+		}
+	})
+
+	// Test Case 4: Day of week baseline falls back to all history when too sparse
+	t.Run("segment_by_day_of_week_fallback", func(t *testing.T) {
+		monday := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+		var history []models.Transaction
+		for i := 0; i < 10; i++ {
+			history = append(history, models.Transaction{Amount: 100.00, Timestamp: monday.AddDate(0, 0, -7*i-1)})
+		}
+		currentTx := models.Transaction{Amount: 100.00, Timestamp: monday}
+
+		cfg := config.DefaultAnomalyConfig()
+		cfg.SegmentBy = []string{config.SegmentDayOfWeek}
+		result, err := DetectAmountAnomalyWithConfig(currentTx, history, cfg)
+		if err != nil {
+			t.Fatalf("DetectAmountAnomalyWithConfig failed: %v", err)
+		}
+		if result.SampleSize != 10 {
+			t.Errorf("Expected fallback to all 10 transactions, got %d", result.SampleSize)
+		}
+	})
+
+	// Test Case 5: Configurable minimum history and cutoff
+	t.Run("configurable_min_history_and_threshold", func(t *testing.T) {
+		history := []models.Transaction{
+			{Amount: 100.00}, {Amount: 110.00}, {Amount: 90.00}, {Amount: 105.00}, {Amount: 95.00},
+		}
+		currentTx := models.Transaction{Amount: 120.00}
+
+		cfg := config.DefaultAnomalyConfig()
+		if _, err := DetectAmountAnomalyWithConfig(currentTx, history, cfg); err == nil {
+			t.Errorf("Expected error for insufficient history with default min_history")
+		}
+
+		cfg.MinHistory = 5
+		cfg.Threshold = 2.0
+		result, err := DetectAmountAnomalyWithConfig(currentTx, history, cfg)
+		if err != nil {
+			t.Fatalf("DetectAmountAnomalyWithConfig failed: %v", err)
+		}
+		if !result.IsAnomaly {
+			t.Errorf("Expected anomaly with threshold 2.0, got score %f", result.Score)
+		}
+	})
+
+	// Test Case 6: Invalid configuration
+	t.Run("invalid_config", func(t *testing.T) {
+		history := make([]models.Transaction, 10)
+		cfg := config.DefaultAnomalyConfig()
+		cfg.Method = "percentile"
+		if _, err := DetectAmountAnomalyWithConfig(models.Transaction{Amount: 1}, history, cfg); err == nil {
+			t.Errorf("Expected error for unknown method")
+		}
+	})
+}
+
+func TestDetectAmountAnomalyFromProfile(t *testing.T) {
+	profile := NewAccountProfile("acc-1")
+	var history []models.Transaction
+	for i := 0; i < 20; i++ {
+		tx := models.Transaction{Amount: 100.00 + float64(i%7)*10, Timestamp: time.Now()}
+		history = append(history, tx)
+		UpdateAccountProfile(profile, tx)
+	}
+	currentTx := models.Transaction{Amount: 400.00, Timestamp: time.Now()}
+
+	// Test Case 1: Profile scoring agrees with history scoring
+	t.Run("matches_history_scoring", func(t *testing.T) {
+		for _, method := range []string{config.AnomalyMethodZScore, config.AnomalyMethodLogZScore} {
+			cfg := config.DefaultAnomalyConfig()
+			cfg.Method = method
+			fromProfile, err := DetectAmountAnomalyFromProfile(currentTx, profile, cfg)
+			if err != nil {
+				t.Fatalf("%s: DetectAmountAnomalyFromProfile failed: %v", method, err)
+			}
+			fromHistory, err := DetectAmountAnomalyWithConfig(currentTx, history, cfg)
+			if err != nil {
+				t.Fatalf("%s: DetectAmountAnomalyWithConfig failed: %v", method, err)
+			}
+			if math.Abs(fromProfile.Score-fromHistory.Score) > 1e-6 {
+				t.Errorf("%s: Expected profile score %f to match history score %f", method, fromProfile.Score, fromHistory.Score)
+			}
+			if !fromProfile.IsAnomaly {
+				t.Errorf("%s: Expected anomaly, got score %f", method, fromProfile.Score)
+			}
+		}
+	})
+
+	// Test Case 2: MAD needs raw history
+	t.Run("mad_unsupported", func(t *testing.T) {
+		cfg := config.DefaultAnomalyConfig()
+		cfg.Method = config.AnomalyMethodMAD
+		if _, err := DetectAmountAnomalyFromProfile(currentTx, profile, cfg); err == nil {
+			t.Errorf("Expected error for MAD on profile")
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/isoforest"
+	"AML/internal/models"
+)
+
+// topFeatureCount is how many contributing features are reported with a model alert.
+const topFeatureCount = 3
+
+// AnomalyModel is a trained isolation forest together with the metadata needed to apply it.
+type AnomalyModel struct {
+	TrainedAt    time.Time `json:"trained_at"`
+	SampleCount  int       `json:"sample_count"`
+	FeatureNames []string  `json:"feature_names"`
+	// Threshold is the anomaly score above which a transaction is flagged.
+	Threshold float64           `json:"threshold"`
+	Config    isoforest.Config  `json:"config"`
+	Forest    *isoforest.Forest `json:"forest"`
+}
+
+// FeatureContribution explains how much one feature drove a model score.
+type FeatureContribution struct {
+	Feature      string  `json:"feature"`
+	Value        float64 `json:"value"`
+	Contribution float64 `json:"contribution"`
+}
+
+// ModelScore is the result of applying an AnomalyModel to a transaction.
+type ModelScore struct {
+	Score       float64               `json:"anomaly_score"`
+	Threshold   float64               `json:"threshold"`
+	IsAnomaly   bool                  `json:"is_anomaly"`
+	TopFeatures []FeatureContribution `json:"top_features"`
+}
+
+// TrainAnomalyModel fits an isolation forest on stored transactions. The flagging threshold is
+// set so that roughly the given contamination fraction of the training data scores above it.
+func TrainAnomalyModel(transactions []models.Transaction, risk config.CountryRisk, cfg isoforest.Config, contamination float64) (*AnomalyModel, error) {
+	if contamination <= 0 || contamination >= 1 {
+		return nil, fmt.Errorf("contamination must be between 0 and 1")
+	}
+
+	matrix := extractFeatureMatrix(transactions, risk)
+	forest, err := isoforest.Train(matrix, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to train isolation forest: %w", err)
+	}
+
+	scores := make([]float64, len(matrix))
+	for i, row := range matrix {
+		scores[i] = forest.Score(row)
+	}
+	sort.Float64s(scores)
+	cutoff := int(float64(len(scores)) * (1 - contamination))
+	if cutoff >= len(scores) {
+		cutoff = len(scores) - 1
+	}
+
+	return &AnomalyModel{
+		TrainedAt:    time.Now().UTC(),
+		SampleCount:  len(matrix),
+		FeatureNames: append([]string(nil), TransactionFeatureNames...),
+		Threshold:    scores[cutoff],
+		Config:       cfg,
+		Forest:       forest,
+	}, nil
+}
+
+// Score applies the model to a transaction, given the account's earlier transactions.
+func (m *AnomalyModel) Score(tx models.Transaction, history []models.Transaction, risk config.CountryRisk) (*ModelScore, error) {
+	if m.Forest == nil {
+		return nil, fmt.Errorf("anomaly model has no trained forest")
+	}
+	features := ExtractTransactionFeatures(tx, history, risk)
+	if len(features) != m.Forest.FeatureCount || len(m.FeatureNames) != m.Forest.FeatureCount {
+		return nil, fmt.Errorf("anomaly model expects %d features, transaction has %d", m.Forest.FeatureCount, len(features))
+	}
+
+	score := m.Forest.Score(features)
+	contributions := m.Forest.Contributions(features)
+
+	ranked := make([]FeatureContribution, len(features))
+	for i := range features {
+		ranked[i] = FeatureContribution{Feature: m.FeatureNames[i], Value: features[i], Contribution: contributions[i]}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Contribution > ranked[j].Contribution })
+	if len(ranked) > topFeatureCount {
+		ranked = ranked[:topFeatureCount]
+	}
+
+	return &ModelScore{
+		Score:       score,
+		Threshold:   m.Threshold,
+		IsAnomaly:   score > m.Threshold,
+		TopFeatures: ranked,
+	}, nil
+}
+
+// Details returns the score as alert rule details.
+func (s *ModelScore) Details() map[string]interface{} {
+	topFeatures := make([]map[string]interface{}, len(s.TopFeatures))
+	for i, f := range s.TopFeatures {
+		topFeatures[i] = map[string]interface{}{
+			"feature":      f.Feature,
+			"value":        f.Value,
+			"contribution": f.Contribution,
+		}
+	}
+	return map[string]interface{}{
+		"detector":      "isolation_forest",
+		"anomaly_score": s.Score,
+		"threshold":     s.Threshold,
+		"top_features":  topFeatures,
+	}
+}
+
+// SaveAnomalyModel writes a model to a JSON file.
+func SaveAnomalyModel(model *AnomalyModel, filepath string) error {
+	data, err := json.Marshal(model)
+	if err != nil {
+		return fmt.Errorf("failed to marshal anomaly model: %w", err)
+	}
+	if err := os.WriteFile(filepath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write anomaly model to file: %w", err)
+	}
+	return nil
+}
+
+// LoadAnomalyModel reads a model written by SaveAnomalyModel.
+func LoadAnomalyModel(filepath string) (*AnomalyModel, error) {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anomaly model file: %w", err)
+	}
+	var model AnomalyModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse anomaly model file: %w", err)
+	}
+	if model.Forest == nil {
+		return nil, fmt.Errorf("anomaly model file has no trained forest")
+	}
+	return &model, nil
+}
+
+// LoadTransactionHistory fetches every stored transaction, ordered by account and time.
+func LoadTransactionHistory(db database.DBTX) ([]models.Transaction, error) {
+	query := `
+		SELECT transaction_id, account_id, amount, currency, timestamp,
+			source_country, destination_country, transaction_type, status
+		FROM transactions
+		ORDER BY account_id, timestamp ASC
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var tx models.Transaction
+		err := rows.Scan(
+			&tx.TransactionID, &tx.AccountID, &tx.Amount, &tx.Currency, &tx.Timestamp,
+			&tx.SourceCountry, &tx.DestinationCountry, &tx.TransactionType, &tx.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
+	}
+
+	return transactions, nil
+}
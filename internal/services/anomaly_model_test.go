@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/isoforest"
+	"AML/internal/models"
+)
+
+func TestAnomalyModel(t *testing.T) {
+	db := newTestDB(t)
+	risk := config.CountryRisk{Default: 0.1, Countries: map[string]float64{"KP": 1.0}}
+
+	// Store 30 days of ordinary daytime activity for 10 accounts.
+	rng := rand.New(rand.NewSource(3))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for a := 0; a < 10; a++ {
+		for d := 0; d < 30; d++ {
+			ts := start.Add(time.Duration(d)*24*time.Hour + time.Duration(9+rng.Intn(8))*time.Hour)
+			_, err := db.Exec(`INSERT INTO transactions VALUES (?, ?, ?, 'USD', ?, 'US', 'US', 'purchase', 'completed', '')`,
+				fmt.Sprintf("tx-%d-%d", a, d), fmt.Sprintf("acc-%d", a), 50+rng.Float64()*100, ts)
+			if err != nil {
+				t.Fatalf("failed to insert transaction: %v", err)
+			}
+		}
+	}
+
+	history, err := LoadTransactionHistory(db)
+	if err != nil {
+		t.Fatalf("LoadTransactionHistory failed: %v", err)
+	}
+	if len(history) != 300 {
+		t.Fatalf("Expected 300 transactions, got %d", len(history))
+	}
+
+	model, err := TrainAnomalyModel(history, risk, isoforest.DefaultConfig(), 0.01)
+	if err != nil {
+		t.Fatalf("TrainAnomalyModel failed: %v", err)
+	}
+
+	// Round-trip through disk as the CLI does.
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := SaveAnomalyModel(model, path); err != nil {
+		t.Fatalf("SaveAnomalyModel failed: %v", err)
+	}
+	loaded, err := LoadAnomalyModel(path)
+	if err != nil {
+		t.Fatalf("LoadAnomalyModel failed: %v", err)
+	}
+	if loaded.Threshold != model.Threshold || loaded.SampleCount != 300 {
+		t.Errorf("Loaded model metadata mismatch: %+v", loaded)
+	}
+
+	// Detect through the registered detector, as transaction processing does.
+	detector, err := newIsolationForestDetector(json.RawMessage(fmt.Sprintf(`{"model_path": %q}`, path)))
+	if err != nil {
+		t.Fatalf("newIsolationForestDetector failed: %v", err)
+	}
+	dctx := &DetectionContext{History: SliceHistory(history), CountryRisk: risk}
+
+	// Test Case 1: Ordinary transaction raises no alert
+	t.Run("normal_transaction", func(t *testing.T) {
+		tx := models.Transaction{TransactionID: "n1", AccountID: "acc-1", Amount: 100, Timestamp: start.Add(31*24*time.Hour + 12*time.Hour), SourceCountry: "US", DestinationCountry: "US"}
+		findings, err := detector.Detect(tx, dctx)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if len(findings) != 0 {
+			t.Errorf("Expected no finding, got score %v", findings[0].Details["anomaly_score"])
+		}
+	})
+
+	// Test Case 2: Large night-time transfer to a high-risk country
+	t.Run("anomalous_transaction", func(t *testing.T) {
+		tx := models.Transaction{TransactionID: "a1", AccountID: "acc-1", Amount: 250000, Timestamp: start.Add(31*24*time.Hour + 3*time.Hour), SourceCountry: "US", DestinationCountry: "KP"}
+		findings, err := detector.Detect(tx, dctx)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if len(findings) != 1 {
+			t.Fatalf("Expected a finding for an anomalous transaction, got %d", len(findings))
+		}
+		if findings[0].AlertType != AlertTypeAnomalyDetected {
+			t.Errorf("Expected %s, got %s", AlertTypeAnomalyDetected, findings[0].AlertType)
+		}
+		topFeatures, ok := findings[0].Details["top_features"].([]map[string]interface{})
+		if !ok || len(topFeatures) != topFeatureCount {
+			t.Fatalf("Expected %d top features, got %v", topFeatureCount, findings[0].Details["top_features"])
+		}
+		if score := findings[0].Details["anomaly_score"].(float64); score <= loaded.Threshold {
+			t.Errorf("Expected score above threshold %f, got %f", loaded.Threshold, score)
+		}
+	})
+}
@@ -0,0 +1,625 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+var (
+	// ErrTeamNotFound is returned when no team exists with the requested ID.
+	ErrTeamNotFound = fmt.Errorf("team not found")
+	// ErrInvestigatorNotFound is returned when no investigator exists with the requested ID.
+	ErrInvestigatorNotFound = fmt.Errorf("investigator not found")
+	// ErrInvalidInvestigator is returned when a team or investigator request is incomplete, or the
+	// investigator cannot take work.
+	ErrInvalidInvestigator = fmt.Errorf("invalid investigator request")
+	// ErrAlertAlreadyAssigned is returned when claiming an alert someone else holds.
+	ErrAlertAlreadyAssigned = fmt.Errorf("alert is already assigned")
+	// ErrAlertNotAssigned is returned when releasing an alert the investigator does not hold.
+	ErrAlertNotAssigned = fmt.Errorf("alert is not assigned to the investigator")
+	// ErrAlertClosed is returned when assigning an alert in a final status.
+	ErrAlertClosed = fmt.Errorf("alert is closed")
+	// ErrNoEligibleInvestigator is returned when automatic assignment finds nobody to take an
+	// alert. The alert stays in its team queue.
+	ErrNoEligibleInvestigator = fmt.Errorf("no eligible investigator")
+)
+
+// assignmentSystemActor is the actor recorded for automatic assignments.
+const assignmentSystemActor = "system"
+
+// AssignmentStrategy picks the investigator for an alert from the eligible candidates, or returns
+// nil when none suits. Candidates carry each investigator's current open workload.
+type AssignmentStrategy interface {
+	Name() string
+	Choose(alert *models.Alert, candidates []models.InvestigatorWorkload) *models.Investigator
+}
+
+// NewAssignmentStrategy returns the built-in strategy with the given name.
+func NewAssignmentStrategy(name string) (AssignmentStrategy, error) {
+	switch name {
+	case config.AssignmentRoundRobin:
+		return roundRobinStrategy{}, nil
+	case config.AssignmentLeastLoaded:
+		return leastLoadedStrategy{}, nil
+	case config.AssignmentSkillBased:
+		return skillBasedStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown assignment strategy: %s", name)
+	}
+}
+
+// roundRobinStrategy rotates through candidates, picking whoever was assigned work longest ago.
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Name() string { return config.AssignmentRoundRobin }
+
+func (roundRobinStrategy) Choose(alert *models.Alert, candidates []models.InvestigatorWorkload) *models.Investigator {
+	var best *models.Investigator
+	for i := range candidates {
+		c := &candidates[i].Investigator
+		if best == nil || assignedEarlier(c, best) {
+			best = c
+		}
+	}
+	return best
+}
+
+// leastLoadedStrategy picks the candidate with the fewest open alerts, rotating among ties.
+type leastLoadedStrategy struct{}
+
+func (leastLoadedStrategy) Name() string { return config.AssignmentLeastLoaded }
+
+func (leastLoadedStrategy) Choose(alert *models.Alert, candidates []models.InvestigatorWorkload) *models.Investigator {
+	var best *models.InvestigatorWorkload
+	for i := range candidates {
+		c := &candidates[i]
+		if best == nil || c.OpenAlerts < best.OpenAlerts ||
+			(c.OpenAlerts == best.OpenAlerts && assignedEarlier(&c.Investigator, &best.Investigator)) {
+			best = c
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &best.Investigator
+}
+
+// skillBasedStrategy picks the least loaded candidate whose skills include the alert type.
+type skillBasedStrategy struct{}
+
+func (skillBasedStrategy) Name() string { return config.AssignmentSkillBased }
+
+func (skillBasedStrategy) Choose(alert *models.Alert, candidates []models.InvestigatorWorkload) *models.Investigator {
+	var skilled []models.InvestigatorWorkload
+	for _, c := range candidates {
+		if c.Investigator.Skills.Contains(alert.AlertType) {
+			skilled = append(skilled, c)
+		}
+	}
+	return leastLoadedStrategy{}.Choose(alert, skilled)
+}
+
+// assignedEarlier orders investigators by when they last received work, then by ID.
+func assignedEarlier(a, b *models.Investigator) bool {
+	if !a.LastAssignedAt.Equal(b.LastAssignedAt) {
+		return a.LastAssignedAt.Before(b.LastAssignedAt)
+	}
+	return a.ID < b.ID
+}
+
+// Assigner assigns alerts automatically using a strategy.
+type Assigner struct {
+	Strategy AssignmentStrategy
+}
+
+// NewAssigner builds an assigner for the named strategy.
+func NewAssigner(strategy string) (*Assigner, error) {
+	s, err := NewAssignmentStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+	return &Assigner{Strategy: s}, nil
+}
+
+// Assign gives an unassigned open alert to the investigator chosen by the strategy among the
+// active investigators whose team handles the alert type and who are below their workload cap.
+func (a *Assigner) Assign(db database.DBTX, alertID, actor string, now time.Time) (*models.Alert, error) {
+	alert, err := GetAlert(db, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert.AssignedTo != "" {
+		return nil, fmt.Errorf("%w: alert %s is assigned to %s", ErrAlertAlreadyAssigned, alertID, alert.AssignedTo)
+	}
+	if err := a.assign(db, alert, actor, now); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+func (a *Assigner) assign(db database.DBTX, alert *models.Alert, actor string, now time.Time) error {
+	return a.assignExcluding(db, alert, "", actor, now)
+}
+
+// assignExcluding assigns an alert as assign does, never to the excluded investigator.
+func (a *Assigner) assignExcluding(db database.DBTX, alert *models.Alert, exclude, actor string, now time.Time) error {
+	if IsFinalAlertStatus(alert.AlertType, alert.Status) {
+		return fmt.Errorf("%w: alert %s is %s", ErrAlertClosed, alert.ID, alert.Status)
+	}
+	eligible, err := eligibleInvestigators(db, alert.AlertType)
+	if err != nil {
+		return err
+	}
+	var candidates []models.InvestigatorWorkload
+	for _, c := range eligible {
+		if c.Investigator.ID != exclude {
+			candidates = append(candidates, c)
+		}
+	}
+	investigator := a.Strategy.Choose(alert, candidates)
+	if investigator == nil {
+		return fmt.Errorf("%w for %s alert %s", ErrNoEligibleInvestigator, alert.AlertType, alert.ID)
+	}
+	return setAlertAssignee(db, alert, investigator.ID, models.AssignmentActionAssigned, actor, a.Strategy.Name(), now)
+}
+
+// CreateTeam adds a team. alertTypes lists the alert types routed to its queue; empty means all.
+func CreateTeam(db database.DBTX, name string, alertTypes []string, now time.Time) (*models.Team, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidInvestigator)
+	}
+	team := &models.Team{
+		ID:         uuid.New().String(),
+		Name:       name,
+		AlertTypes: alertTypes,
+		CreatedAt:  now,
+	}
+	query := `INSERT INTO teams (id, name, alert_types, created_at) VALUES (?, ?, ?, ?)`
+	if _, err := db.Exec(query, team.ID, team.Name, team.AlertTypes, team.CreatedAt.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to insert team: %w", err)
+	}
+	return team, nil
+}
+
+// GetTeam fetches a single team by ID.
+func GetTeam(db database.DBTX, id string) (*models.Team, error) {
+	var team models.Team
+	err := db.QueryRow(`SELECT id, name, alert_types, created_at FROM teams WHERE id = ?`, id).
+		Scan(&team.ID, &team.Name, &team.AlertTypes, &team.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrTeamNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team %s: %w", id, err)
+	}
+	return &team, nil
+}
+
+// ListTeams returns every team ordered by name.
+func ListTeams(db database.DBTX) ([]models.Team, error) {
+	rows, err := db.Query(`SELECT id, name, alert_types, created_at FROM teams ORDER BY name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query teams: %w", err)
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var team models.Team
+		if err := rows.Scan(&team.ID, &team.Name, &team.AlertTypes, &team.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team row: %w", err)
+		}
+		teams = append(teams, team)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating team rows: %w", err)
+	}
+
+	return teams, nil
+}
+
+// CreateInvestigator registers an investigator. The ID is the actor ID the investigator is
+// authenticated as.
+func CreateInvestigator(db database.DBTX, investigator *models.Investigator, now time.Time) error {
+	if strings.TrimSpace(investigator.ID) == "" || strings.TrimSpace(investigator.Name) == "" {
+		return fmt.Errorf("%w: id and name are required", ErrInvalidInvestigator)
+	}
+	if investigator.MaxOpenAlerts < 0 {
+		return fmt.Errorf("%w: max_open_alerts must not be negative", ErrInvalidInvestigator)
+	}
+	if investigator.TeamID != "" {
+		if _, err := GetTeam(db, investigator.TeamID); err != nil {
+			return err
+		}
+	}
+	if _, err := GetInvestigator(db, investigator.ID); err == nil {
+		return fmt.Errorf("%w: investigator %s already exists", ErrInvalidInvestigator, investigator.ID)
+	}
+
+	investigator.CreatedAt = now
+	var teamID sql.NullString
+	if investigator.TeamID != "" {
+		teamID = sql.NullString{String: investigator.TeamID, Valid: true}
+	}
+	query := `
+		INSERT INTO investigators (id, name, team_id, skills, max_open_alerts, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, investigator.ID, investigator.Name, teamID, investigator.Skills,
+		investigator.MaxOpenAlerts, investigator.Active, investigator.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to insert investigator: %w", err)
+	}
+	return nil
+}
+
+// investigatorColumns is the column list matching scanInvestigator.
+const investigatorColumns = `id, name, team_id, skills, max_open_alerts, active, last_assigned_at, created_at`
+
+// GetInvestigator fetches a single investigator by ID.
+func GetInvestigator(db database.DBTX, id string) (*models.Investigator, error) {
+	query := `SELECT ` + investigatorColumns + ` FROM investigators WHERE id = ?`
+	investigator, err := scanInvestigator(db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrInvestigatorNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query investigator %s: %w", id, err)
+	}
+	return investigator, nil
+}
+
+// ListInvestigators returns investigators ordered by ID, optionally only those in one team.
+func ListInvestigators(db database.DBTX, teamID string) ([]models.Investigator, error) {
+	query := `SELECT ` + investigatorColumns + ` FROM investigators`
+	var args []interface{}
+	if teamID != "" {
+		query += ` WHERE team_id = ?`
+		args = append(args, teamID)
+	}
+	query += ` ORDER BY id ASC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query investigators: %w", err)
+	}
+	defer rows.Close()
+
+	investigators := []models.Investigator{}
+	for rows.Next() {
+		investigator, err := scanInvestigator(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan investigator row: %w", err)
+		}
+		investigators = append(investigators, *investigator)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating investigator rows: %w", err)
+	}
+
+	return investigators, nil
+}
+
+// AssignAlert gives an open alert to a specific investigator, replacing any current assignee.
+func AssignAlert(db database.DBTX, alertID, investigatorID, actor string, now time.Time) (*models.Alert, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if _, err := activeInvestigator(db, investigatorID); err != nil {
+		return nil, err
+	}
+	alert, err := GetAlert(db, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if IsFinalAlertStatus(alert.AlertType, alert.Status) {
+		return nil, fmt.Errorf("%w: alert %s is %s", ErrAlertClosed, alertID, alert.Status)
+	}
+	if err := setAlertAssignee(db, alert, investigatorID, models.AssignmentActionAssigned, actor, "", now); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ClaimAlert lets an investigator take an unassigned open alert. Claiming an alert the
+// investigator already holds is a no-op.
+func ClaimAlert(db database.DBTX, alertID, investigatorID string, now time.Time) (*models.Alert, error) {
+	if strings.TrimSpace(investigatorID) == "" {
+		return nil, ErrActorRequired
+	}
+	if _, err := activeInvestigator(db, investigatorID); err != nil {
+		return nil, err
+	}
+	alert, err := GetAlert(db, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert.AssignedTo == investigatorID {
+		return alert, nil
+	}
+	if alert.AssignedTo != "" {
+		return nil, fmt.Errorf("%w: alert %s is assigned to %s", ErrAlertAlreadyAssigned, alertID, alert.AssignedTo)
+	}
+	if IsFinalAlertStatus(alert.AlertType, alert.Status) {
+		return nil, fmt.Errorf("%w: alert %s is %s", ErrAlertClosed, alertID, alert.Status)
+	}
+	if err := setAlertAssignee(db, alert, investigatorID, models.AssignmentActionClaimed, investigatorID, "", now); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ReleaseAlert returns an alert the investigator holds to the unassigned queue.
+func ReleaseAlert(db database.DBTX, alertID, investigatorID string, now time.Time) (*models.Alert, error) {
+	if strings.TrimSpace(investigatorID) == "" {
+		return nil, ErrActorRequired
+	}
+	alert, err := GetAlert(db, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert.AssignedTo != investigatorID {
+		return nil, fmt.Errorf("%w: alert %s is not held by %s", ErrAlertNotAssigned, alertID, investigatorID)
+	}
+	if err := setAlertAssignee(db, alert, "", models.AssignmentActionReleased, investigatorID, "", now); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ListAlertAssignments returns an alert's assignment log, oldest first.
+func ListAlertAssignments(db database.DBTX, alertID string) ([]models.AlertAssignment, error) {
+	query := `
+		SELECT id, alert_id, investigator_id, action, actor, strategy, created_at
+		FROM alert_assignments
+		WHERE alert_id = ?
+		ORDER BY created_at ASC, id ASC
+	`
+	rows, err := db.Query(query, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assignments for alert %s: %w", alertID, err)
+	}
+	defer rows.Close()
+
+	assignments := []models.AlertAssignment{}
+	for rows.Next() {
+		var a models.AlertAssignment
+		if err := rows.Scan(&a.ID, &a.AlertID, &a.InvestigatorID, &a.Action, &a.Actor, &a.Strategy, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment row: %w", err)
+		}
+		assignments = append(assignments, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating assignment rows: %w", err)
+	}
+
+	return assignments, nil
+}
+
+// TeamQueue returns the unassigned open alerts routed to a team, highest priority and score
+// first and oldest first within those. A limit of zero returns every alert.
+func TeamQueue(db database.DBTX, teamID string, limit int) ([]models.Alert, error) {
+	team, err := GetTeam(db, teamID)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + alertSelectColumns + ` FROM alerts WHERE assigned_to = ''`
+	var args []interface{}
+	if len(team.AlertTypes) > 0 {
+		query += ` AND alert_type IN (?` + strings.Repeat(",?", len(team.AlertTypes)-1) + `)`
+		for _, t := range team.AlertTypes {
+			args = append(args, t)
+		}
+	}
+	return queryQueue(db, query, args, limit)
+}
+
+// InvestigatorQueue returns the open alerts assigned to an investigator in queue order.
+func InvestigatorQueue(db database.DBTX, investigatorID string) ([]models.Alert, error) {
+	if _, err := GetInvestigator(db, investigatorID); err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + alertSelectColumns + ` FROM alerts WHERE assigned_to = ?`
+	return queryQueue(db, query, []interface{}{investigatorID}, 0)
+}
+
+// ListWorkloads returns the open workload of every investigator, ordered by ID.
+func ListWorkloads(db database.DBTX) ([]models.InvestigatorWorkload, error) {
+	investigators, err := ListInvestigators(db, "")
+	if err != nil {
+		return nil, err
+	}
+	return workloadsFor(db, investigators)
+}
+
+// eligibleInvestigators returns the active investigators whose team handles the alert type and
+// who are below their open alert cap, with their workloads.
+func eligibleInvestigators(db database.DBTX, alertType string) ([]models.InvestigatorWorkload, error) {
+	investigators, err := ListInvestigators(db, "")
+	if err != nil {
+		return nil, err
+	}
+	teams, err := ListTeams(db)
+	if err != nil {
+		return nil, err
+	}
+	teamsByID := make(map[string]models.Team, len(teams))
+	for _, team := range teams {
+		teamsByID[team.ID] = team
+	}
+
+	var active []models.Investigator
+	for _, investigator := range investigators {
+		if !investigator.Active {
+			continue
+		}
+		if team, ok := teamsByID[investigator.TeamID]; ok && !team.Handles(alertType) {
+			continue
+		}
+		active = append(active, investigator)
+	}
+
+	workloads, err := workloadsFor(db, active)
+	if err != nil {
+		return nil, err
+	}
+	var eligible []models.InvestigatorWorkload
+	for _, w := range workloads {
+		if w.Investigator.MaxOpenAlerts > 0 && w.OpenAlerts >= w.Investigator.MaxOpenAlerts {
+			continue
+		}
+		eligible = append(eligible, w)
+	}
+	return eligible, nil
+}
+
+// workloadsFor counts the open alerts assigned to each of the given investigators.
+func workloadsFor(db database.DBTX, investigators []models.Investigator) ([]models.InvestigatorWorkload, error) {
+	rows, err := db.Query(`SELECT assigned_to, alert_type, status, priority FROM alerts WHERE assigned_to <> ''`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assigned alerts: %w", err)
+	}
+	defer rows.Close()
+
+	workloads := make([]models.InvestigatorWorkload, len(investigators))
+	index := make(map[string]int, len(investigators))
+	for i, investigator := range investigators {
+		workloads[i] = models.InvestigatorWorkload{Investigator: investigator, ByPriority: map[string]int{}}
+		index[investigator.ID] = i
+	}
+
+	for rows.Next() {
+		var assignedTo, alertType, status string
+		var priority int
+		if err := rows.Scan(&assignedTo, &alertType, &status, &priority); err != nil {
+			return nil, fmt.Errorf("failed to scan assigned alert row: %w", err)
+		}
+		i, ok := index[assignedTo]
+		if !ok || IsFinalAlertStatus(alertType, status) {
+			continue
+		}
+		workloads[i].OpenAlerts++
+		workloads[i].ByPriority[models.PriorityLevel(priority).String()]++
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating assigned alert rows: %w", err)
+	}
+
+	return workloads, nil
+}
+
+// queryQueue runs an alert query, drops alerts in final statuses and returns the rest in queue
+// order, up to limit when it is positive.
+func queryQueue(db database.DBTX, query string, args []interface{}, limit int) ([]models.Alert, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert queue: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []models.Alert{}
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		}
+		if !IsFinalAlertStatus(alert.AlertType, alert.Status) {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	if limit > 0 && len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+	return alerts, nil
+}
+
+func activeInvestigator(db database.DBTX, id string) (*models.Investigator, error) {
+	investigator, err := GetInvestigator(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if !investigator.Active {
+		return nil, fmt.Errorf("%w: investigator %s is inactive", ErrInvalidInvestigator, id)
+	}
+	return investigator, nil
+}
+
+// setAlertAssignee changes an alert's assignee provided it still has the assignee it was loaded
+// with, and records the change in the assignment log.
+func setAlertAssignee(db database.DBTX, alert *models.Alert, assignee, action, actor, strategy string, now time.Time) error {
+	res, err := db.Exec(`UPDATE alerts SET assigned_to = ? WHERE id = ? AND assigned_to = ?`, assignee, alert.ID, alert.AssignedTo)
+	if err != nil {
+		return fmt.Errorf("failed to assign alert %s: %w", alert.ID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to assign alert %s: %w", alert.ID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: alert %s was reassigned concurrently", ErrAlertAlreadyAssigned, alert.ID)
+	}
+
+	investigatorID := assignee
+	if action == models.AssignmentActionReleased {
+		investigatorID = alert.AssignedTo
+	} else if _, err := db.Exec(`UPDATE investigators SET last_assigned_at = ? WHERE id = ?`, now.UTC(), assignee); err != nil {
+		return fmt.Errorf("failed to update investigator %s: %w", assignee, err)
+	}
+
+	query := `
+		INSERT INTO alert_assignments (id, alert_id, investigator_id, action, actor, strategy, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	if _, err := db.Exec(query, uuid.New().String(), alert.ID, investigatorID, action, actor, strategy, now.UTC()); err != nil {
+		return fmt.Errorf("failed to record assignment of alert %s: %w", alert.ID, err)
+	}
+	alert.AssignedTo = assignee
+	return nil
+}
+
+func scanInvestigator(row rowScanner) (*models.Investigator, error) {
+	var investigator models.Investigator
+	var teamID sql.NullString
+	var lastAssignedAt sql.NullTime
+	err := row.Scan(&investigator.ID, &investigator.Name, &teamID, &investigator.Skills,
+		&investigator.MaxOpenAlerts, &investigator.Active, &lastAssignedAt, &investigator.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	investigator.TeamID = teamID.String
+	if lastAssignedAt.Valid {
+		investigator.LastAssignedAt = lastAssignedAt.Time
+	}
+	return &investigator, nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+func TestAlertAssignment(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := base.Add(24 * time.Hour)
+
+	addInvestigator := func(t *testing.T, db database.DBTX, inv models.Investigator) {
+		t.Helper()
+		inv.Active = true
+		if err := CreateInvestigator(db, &inv, base); err != nil {
+			t.Fatalf("CreateInvestigator failed: %v", err)
+		}
+	}
+
+	// Test Case 1: Each strategy picks the expected investigator
+	t.Run("strategies", func(t *testing.T) {
+		alert := &models.Alert{AlertType: AlertTypeStructuringPattern}
+		candidates := []models.InvestigatorWorkload{
+			{Investigator: models.Investigator{ID: "inv-a", LastAssignedAt: base.Add(time.Hour)}, OpenAlerts: 1},
+			{Investigator: models.Investigator{ID: "inv-b", LastAssignedAt: base.Add(2 * time.Hour), Skills: models.StringList{AlertTypeStructuringPattern}}, OpenAlerts: 3},
+			{Investigator: models.Investigator{ID: "inv-c"}, OpenAlerts: 2},
+		}
+		tests := []struct {
+			strategy string
+			want     string
+		}{
+			{config.AssignmentRoundRobin, "inv-c"},
+			{config.AssignmentLeastLoaded, "inv-a"},
+			{config.AssignmentSkillBased, "inv-b"},
+		}
+		for _, tt := range tests {
+			s, err := NewAssignmentStrategy(tt.strategy)
+			if err != nil {
+				t.Fatalf("NewAssignmentStrategy failed: %v", err)
+			}
+			if got := s.Choose(alert, candidates); got == nil || got.ID != tt.want {
+				t.Errorf("Expected %s to choose %s, got %+v", tt.strategy, tt.want, got)
+			}
+		}
+		if got := (skillBasedStrategy{}).Choose(&models.Alert{AlertType: AlertTypeVelocityAnomaly}, candidates); got != nil {
+			t.Errorf("Expected no skilled investigator, got %+v", got)
+		}
+	})
+
+	// Test Case 2: Automatic assignment respects teams, activity and workload caps
+	t.Run("auto_assign", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		team, err := CreateTeam(db, "Structuring", []string{AlertTypeStructuringPattern}, base)
+		if err != nil {
+			t.Fatalf("CreateTeam failed: %v", err)
+		}
+		addInvestigator(t, db, models.Investigator{ID: "inv-1", Name: "One", TeamID: team.ID, MaxOpenAlerts: 1})
+		addInvestigator(t, db, models.Investigator{ID: "inv-2", Name: "Two"})
+
+		assigner, err := NewAssigner(config.AssignmentLeastLoaded)
+		if err != nil {
+			t.Fatalf("NewAssigner failed: %v", err)
+		}
+		// alert-0 is a threshold alert, which the structuring team does not handle.
+		alert, err := assigner.Assign(db, "alert-0", "supervisor-1", now)
+		if err != nil {
+			t.Fatalf("Assign failed: %v", err)
+		}
+		if alert.AssignedTo != "inv-2" {
+			t.Errorf("Expected alert-0 assigned to inv-2, got %q", alert.AssignedTo)
+		}
+		// alert-1 is structuring; both are eligible and inv-1 has less work.
+		if alert, err = assigner.Assign(db, "alert-1", "supervisor-1", now); err != nil || alert.AssignedTo != "inv-1" {
+			t.Errorf("Expected alert-1 assigned to inv-1, got %+v, %v", alert, err)
+		}
+		// inv-1 is at capacity, so the next structuring alert goes to inv-2.
+		if alert, err = assigner.Assign(db, "alert-3", "supervisor-1", now); err != nil || alert.AssignedTo != "inv-2" {
+			t.Errorf("Expected alert-3 assigned to inv-2, got %+v, %v", alert, err)
+		}
+		if _, err := assigner.Assign(db, "alert-3", "supervisor-1", now); !errors.Is(err, ErrAlertAlreadyAssigned) {
+			t.Errorf("Expected ErrAlertAlreadyAssigned, got %v", err)
+		}
+
+		workloads, err := ListWorkloads(db)
+		if err != nil {
+			t.Fatalf("ListWorkloads failed: %v", err)
+		}
+		if len(workloads) != 2 || workloads[0].OpenAlerts != 1 || workloads[1].OpenAlerts != 2 {
+			t.Errorf("Unexpected workloads: %+v", workloads)
+		}
+		if workloads[1].ByPriority[models.Critical.String()] != 1 {
+			t.Errorf("Expected inv-2 to hold 1 critical alert, got %+v", workloads[1].ByPriority)
+		}
+
+		assignments, err := ListAlertAssignments(db, "alert-1")
+		if err != nil {
+			t.Fatalf("ListAlertAssignments failed: %v", err)
+		}
+		if len(assignments) != 1 || assignments[0].Strategy != config.AssignmentLeastLoaded || assignments[0].Actor != "supervisor-1" {
+			t.Errorf("Unexpected assignment log: %+v", assignments)
+		}
+	})
+
+	// Test Case 3: Investigators claim and release alerts from a priority-ordered queue
+	t.Run("claim_and_release", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		team, err := CreateTeam(db, "Structuring", []string{AlertTypeStructuringPattern}, base)
+		if err != nil {
+			t.Fatalf("CreateTeam failed: %v", err)
+		}
+		addInvestigator(t, db, models.Investigator{ID: "inv-1", Name: "One", TeamID: team.ID})
+		addInvestigator(t, db, models.Investigator{ID: "inv-2", Name: "Two", TeamID: team.ID})
+
+		queue, err := TeamQueue(db, team.ID, 0)
+		if err != nil {
+			t.Fatalf("TeamQueue failed: %v", err)
+		}
+		// alert-1 and alert-3 are both critical; alert-3 has the higher score.
+		if len(queue) != 2 || queue[0].ID != "alert-3" || queue[1].ID != "alert-1" {
+			t.Fatalf("Expected queue [alert-3 alert-1], got %+v", queue)
+		}
+
+		if _, err := ClaimAlert(db, "alert-3", "inv-1", now); err != nil {
+			t.Fatalf("ClaimAlert failed: %v", err)
+		}
+		if _, err := ClaimAlert(db, "alert-3", "inv-2", now); !errors.Is(err, ErrAlertAlreadyAssigned) {
+			t.Errorf("Expected ErrAlertAlreadyAssigned, got %v", err)
+		}
+		if _, err := ClaimAlert(db, "alert-3", "nobody", now); !errors.Is(err, ErrInvestigatorNotFound) {
+			t.Errorf("Expected ErrInvestigatorNotFound, got %v", err)
+		}
+		if queue, _ := TeamQueue(db, team.ID, 0); len(queue) != 1 {
+			t.Errorf("Expected claimed alert to leave the team queue, got %+v", queue)
+		}
+		if mine, _ := InvestigatorQueue(db, "inv-1"); len(mine) != 1 || mine[0].ID != "alert-3" {
+			t.Errorf("Expected alert-3 in inv-1's queue, got %+v", mine)
+		}
+
+		if _, err := ReleaseAlert(db, "alert-3", "inv-2", now); !errors.Is(err, ErrAlertNotAssigned) {
+			t.Errorf("Expected ErrAlertNotAssigned, got %v", err)
+		}
+		released, err := ReleaseAlert(db, "alert-3", "inv-1", now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("ReleaseAlert failed: %v", err)
+		}
+		if released.AssignedTo != "" {
+			t.Errorf("Expected released alert to be unassigned, got %q", released.AssignedTo)
+		}
+		assignments, err := ListAlertAssignments(db, "alert-3")
+		if err != nil {
+			t.Fatalf("ListAlertAssignments failed: %v", err)
+		}
+		if len(assignments) != 2 || assignments[1].Action != models.AssignmentActionReleased || assignments[1].InvestigatorID != "inv-1" {
+			t.Errorf("Unexpected assignment log: %+v", assignments)
+		}
+	})
+
+	// Test Case 4: New alerts are assigned as they are raised
+	t.Run("processor", func(t *testing.T) {
+		db := newTestDB(t)
+		addInvestigator(t, db, models.Investigator{ID: "inv-1", Name: "One"})
+		assigner, err := NewAssigner(config.AssignmentRoundRobin)
+		if err != nil {
+			t.Fatalf("NewAssigner failed: %v", err)
+		}
+		p := &TransactionProcessor{
+			Orchestrator: NewOrchestrator([]Detector{stubDetector{id: "stub", findings: []Finding{{DetectorID: "stub", AlertType: AlertTypeThresholdViolation}}}}),
+			Detection:    DetectionContext{Clock: fixedClock(now)},
+			Assigner:     assigner,
+		}
+		result, err := p.Process(db, models.Transaction{TransactionID: "tx-1", AccountID: "acc-1", Amount: 500, Timestamp: now})
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		stored, err := GetAlert(db, result.Alerts[0].ID)
+		if err != nil {
+			t.Fatalf("GetAlert failed: %v", err)
+		}
+		if stored.AssignedTo != "inv-1" {
+			t.Errorf("Expected new alert assigned to inv-1, got %q", stored.AssignedTo)
+		}
+	})
+}
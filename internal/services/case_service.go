@@ -0,0 +1,546 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+var (
+	// ErrCaseNotFound is returned when no case exists with the requested ID.
+	ErrCaseNotFound = fmt.Errorf("case not found")
+	// ErrCaseNotOpen is returned when a change is requested on a closed or merged case.
+	ErrCaseNotOpen = fmt.Errorf("case is not open")
+	// ErrInvalidCase is returned when a case request is incomplete or inconsistent.
+	ErrInvalidCase = fmt.Errorf("invalid case request")
+)
+
+// caseColumns is the column list matching scanCase.
+const caseColumns = `id, subject_account_id, title, status, assigned_to, created_by, created_at, updated_at, closed_at, close_reason, merged_into`
+
+// CaseFilter narrows a case listing. Zero values leave a field unfiltered.
+type CaseFilter struct {
+	Status           string
+	SubjectAccountID string
+	AssignedTo       string
+}
+
+// CaseDetail is a case together with everything attached to it.
+type CaseDetail struct {
+	Case        *models.Case            `json:"case"`
+	Alerts      []models.Alert          `json:"alerts"`
+	Notes       []models.CaseNote       `json:"notes"`
+	Attachments []models.CaseAttachment `json:"attachments"`
+}
+
+// CreateCase opens a case for a subject account and attaches the given alerts, which must all
+// belong to that account and not already be in an open case.
+func CreateCase(db database.DBTX, subjectAccountID, title, assignedTo string, alertIDs []string, actor string, now time.Time) (*models.Case, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if subjectAccountID == "" {
+		return nil, fmt.Errorf("%w: subject_account_id is required", ErrInvalidCase)
+	}
+	if strings.TrimSpace(title) == "" {
+		title = "Investigation of account " + subjectAccountID
+	}
+
+	c := &models.Case{
+		ID:               uuid.New().String(),
+		SubjectAccountID: subjectAccountID,
+		Title:            title,
+		Status:           models.CaseStatusOpen,
+		AssignedTo:       assignedTo,
+		CreatedBy:        actor,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	query := `
+		INSERT INTO cases (id, subject_account_id, title, status, assigned_to, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, c.ID, c.SubjectAccountID, c.Title, c.Status, c.AssignedTo, c.CreatedBy, c.CreatedAt.UTC(), c.UpdatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert case: %w", err)
+	}
+
+	if len(alertIDs) > 0 {
+		if err := AttachAlertsToCase(db, c.ID, alertIDs, actor, now); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// GetCase fetches a single case by ID.
+func GetCase(db database.DBTX, id string) (*models.Case, error) {
+	query := `SELECT ` + caseColumns + ` FROM cases WHERE id = ?`
+	c, err := scanCase(db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrCaseNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query case %s: %w", id, err)
+	}
+	return c, nil
+}
+
+// GetCaseDetail fetches a case with its alerts, notes and attachments.
+func GetCaseDetail(db database.DBTX, id string) (*CaseDetail, error) {
+	c, err := GetCase(db, id)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := ListCaseAlerts(db, id)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := ListCaseNotes(db, id)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := ListCaseAttachments(db, id)
+	if err != nil {
+		return nil, err
+	}
+	return &CaseDetail{Case: c, Alerts: alerts, Notes: notes, Attachments: attachments}, nil
+}
+
+// ListCases returns cases matching the filter, most recently updated first.
+func ListCases(db database.DBTX, filter CaseFilter) ([]models.Case, error) {
+	query := `SELECT ` + caseColumns + ` FROM cases`
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.SubjectAccountID != "" {
+		conditions = append(conditions, "subject_account_id = ?")
+		args = append(args, filter.SubjectAccountID)
+	}
+	if filter.AssignedTo != "" {
+		conditions = append(conditions, "assigned_to = ?")
+		args = append(args, filter.AssignedTo)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY updated_at DESC, id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cases: %w", err)
+	}
+	defer rows.Close()
+
+	cases := []models.Case{}
+	for rows.Next() {
+		c, err := scanCase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan case row: %w", err)
+		}
+		cases = append(cases, *c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating case rows: %w", err)
+	}
+
+	return cases, nil
+}
+
+// ListCaseAlerts returns the alerts attached to a case, oldest first.
+func ListCaseAlerts(db database.DBTX, caseID string) ([]models.Alert, error) {
+	query := `SELECT ` + alertSelectColumns + ` FROM alerts WHERE case_id = ? ORDER BY created_at ASC, id ASC`
+	rows, err := db.Query(query, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts for case %s: %w", caseID, err)
+	}
+	defer rows.Close()
+
+	alerts := []models.Alert{}
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// FindOpenCase returns the most recently updated open case for a subject account, or nil.
+func FindOpenCase(db database.DBTX, subjectAccountID string) (*models.Case, error) {
+	query := `
+		SELECT ` + caseColumns + `
+		FROM cases
+		WHERE subject_account_id = ? AND status = ?
+		ORDER BY updated_at DESC, id DESC
+		LIMIT 1
+	`
+	c, err := scanCase(db.QueryRow(query, subjectAccountID, models.CaseStatusOpen))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open case for %s: %w", subjectAccountID, err)
+	}
+	return c, nil
+}
+
+// AttachAlertsToCase moves alerts into an open case. Every alert must belong to the case's
+// subject account; alerts already in another open case are rejected.
+func AttachAlertsToCase(db database.DBTX, caseID string, alertIDs []string, actor string, now time.Time) error {
+	c, err := getOpenCase(db, caseID)
+	if err != nil {
+		return err
+	}
+	for _, id := range alertIDs {
+		alert, err := GetAlert(db, id)
+		if err != nil {
+			return err
+		}
+		if alert.AccountID != c.SubjectAccountID {
+			return fmt.Errorf("%w: alert %s belongs to account %s, not %s", ErrInvalidCase, id, alert.AccountID, c.SubjectAccountID)
+		}
+		if alert.CaseID != "" && alert.CaseID != caseID {
+			current, err := GetCase(db, alert.CaseID)
+			if err != nil {
+				return err
+			}
+			if current.Status == models.CaseStatusOpen {
+				return fmt.Errorf("%w: alert %s is already in open case %s", ErrInvalidCase, id, alert.CaseID)
+			}
+		}
+		if err := setAlertCase(db, id, caseID); err != nil {
+			return err
+		}
+	}
+	_, err = AddCaseNote(db, caseID, actor, fmt.Sprintf("Attached alert(s) %s", strings.Join(alertIDs, ", ")), now)
+	return err
+}
+
+// AttachToOpenCase adds a newly raised alert to the open case for its account, if there is one,
+// and returns that case.
+func AttachToOpenCase(db database.DBTX, alert *models.Alert, now time.Time) (*models.Case, error) {
+	c, err := FindOpenCase(db, alert.AccountID)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	if err := setAlertCase(db, alert.ID, c.ID); err != nil {
+		return nil, err
+	}
+	if err := touchCase(db, c.ID, now); err != nil {
+		return nil, err
+	}
+	alert.CaseID = c.ID
+	return c, nil
+}
+
+// AssignCase changes a case's assignee.
+func AssignCase(db database.DBTX, caseID, assignedTo, actor string, now time.Time) (*models.Case, error) {
+	c, err := getOpenCase(db, caseID)
+	if err != nil {
+		return nil, err
+	}
+	c.AssignedTo = assignedTo
+	c.UpdatedAt = now
+	if _, err := db.Exec(`UPDATE cases SET assigned_to = ?, updated_at = ? WHERE id = ?`, c.AssignedTo, c.UpdatedAt.UTC(), c.ID); err != nil {
+		return nil, fmt.Errorf("failed to assign case %s: %w", caseID, err)
+	}
+	if _, err := AddCaseNote(db, caseID, actor, fmt.Sprintf("Assigned to %s", displayAssignee(assignedTo)), now); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AddCaseNote appends a note to a case.
+func AddCaseNote(db database.DBTX, caseID, author, body string, now time.Time) (*models.CaseNote, error) {
+	if strings.TrimSpace(author) == "" {
+		return nil, ErrActorRequired
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("%w: note body is required", ErrInvalidCase)
+	}
+	note := &models.CaseNote{
+		ID:        uuid.New().String(),
+		CaseID:    caseID,
+		Author:    author,
+		Body:      body,
+		CreatedAt: now,
+	}
+	query := `INSERT INTO case_notes (id, case_id, author, body, created_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := db.Exec(query, note.ID, note.CaseID, note.Author, note.Body, note.CreatedAt.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to add note to case %s: %w", caseID, err)
+	}
+	if err := touchCase(db, caseID, now); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// ListCaseNotes returns a case's notes, oldest first.
+func ListCaseNotes(db database.DBTX, caseID string) ([]models.CaseNote, error) {
+	query := `SELECT id, case_id, author, body, created_at FROM case_notes WHERE case_id = ? ORDER BY created_at ASC, id ASC`
+	rows, err := db.Query(query, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes for case %s: %w", caseID, err)
+	}
+	defer rows.Close()
+
+	notes := []models.CaseNote{}
+	for rows.Next() {
+		var n models.CaseNote
+		if err := rows.Scan(&n.ID, &n.CaseID, &n.Author, &n.Body, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan case note row: %w", err)
+		}
+		notes = append(notes, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating case note rows: %w", err)
+	}
+
+	return notes, nil
+}
+
+// AddCaseAttachment records a document attached to a case.
+func AddCaseAttachment(db database.DBTX, attachment *models.CaseAttachment, now time.Time) error {
+	if strings.TrimSpace(attachment.UploadedBy) == "" {
+		return ErrActorRequired
+	}
+	if attachment.FileName == "" || attachment.URI == "" {
+		return fmt.Errorf("%w: file_name and uri are required", ErrInvalidCase)
+	}
+	if _, err := GetCase(db, attachment.CaseID); err != nil {
+		return err
+	}
+	attachment.ID = uuid.New().String()
+	attachment.UploadedAt = now
+	query := `
+		INSERT INTO case_attachments (id, case_id, file_name, content_type, uri, size_bytes, sha256, uploaded_by, uploaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, attachment.ID, attachment.CaseID, attachment.FileName, attachment.ContentType, attachment.URI,
+		attachment.SizeBytes, attachment.SHA256, attachment.UploadedBy, attachment.UploadedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add attachment to case %s: %w", attachment.CaseID, err)
+	}
+	return touchCase(db, attachment.CaseID, now)
+}
+
+// ListCaseAttachments returns a case's attachments, oldest first.
+func ListCaseAttachments(db database.DBTX, caseID string) ([]models.CaseAttachment, error) {
+	query := `
+		SELECT id, case_id, file_name, content_type, uri, size_bytes, sha256, uploaded_by, uploaded_at
+		FROM case_attachments
+		WHERE case_id = ?
+		ORDER BY uploaded_at ASC, id ASC
+	`
+	rows, err := db.Query(query, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments for case %s: %w", caseID, err)
+	}
+	defer rows.Close()
+
+	attachments := []models.CaseAttachment{}
+	for rows.Next() {
+		var a models.CaseAttachment
+		err := rows.Scan(&a.ID, &a.CaseID, &a.FileName, &a.ContentType, &a.URI, &a.SizeBytes, &a.SHA256, &a.UploadedBy, &a.UploadedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan case attachment row: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating case attachment rows: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// MergeCases moves every alert from the source cases into the target case and marks the sources
+// as merged. All cases must be open and share the target's subject account.
+func MergeCases(db database.DBTX, targetID string, sourceIDs []string, actor string, now time.Time) (*models.Case, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if len(sourceIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one source case is required", ErrInvalidCase)
+	}
+	target, err := getOpenCase(db, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			return nil, fmt.Errorf("%w: cannot merge case %s into itself", ErrInvalidCase, sourceID)
+		}
+		source, err := getOpenCase(db, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		if source.SubjectAccountID != target.SubjectAccountID {
+			return nil, fmt.Errorf("%w: case %s has subject %s, not %s", ErrInvalidCase, sourceID, source.SubjectAccountID, target.SubjectAccountID)
+		}
+
+		if _, err := db.Exec(`UPDATE alerts SET case_id = ? WHERE case_id = ?`, targetID, sourceID); err != nil {
+			return nil, fmt.Errorf("failed to move alerts from case %s: %w", sourceID, err)
+		}
+		query := `UPDATE cases SET status = ?, merged_into = ?, closed_at = ?, updated_at = ? WHERE id = ?`
+		if _, err := db.Exec(query, models.CaseStatusMerged, targetID, now.UTC(), now.UTC(), sourceID); err != nil {
+			return nil, fmt.Errorf("failed to mark case %s as merged: %w", sourceID, err)
+		}
+		if _, err := AddCaseNote(db, sourceID, actor, fmt.Sprintf("Merged into case %s", targetID), now); err != nil {
+			return nil, err
+		}
+		if _, err := AddCaseNote(db, targetID, actor, fmt.Sprintf("Merged case %s into this case", sourceID), now); err != nil {
+			return nil, err
+		}
+	}
+
+	target.UpdatedAt = now
+	return target, nil
+}
+
+// SplitCase moves the given alerts out of a case into a new case for the same subject.
+func SplitCase(db database.DBTX, caseID string, alertIDs []string, title, assignedTo, actor string, now time.Time) (*models.Case, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if len(alertIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one alert is required to split a case", ErrInvalidCase)
+	}
+	source, err := getOpenCase(db, caseID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range alertIDs {
+		alert, err := GetAlert(db, id)
+		if err != nil {
+			return nil, err
+		}
+		if alert.CaseID != caseID {
+			return nil, fmt.Errorf("%w: alert %s is not in case %s", ErrInvalidCase, id, caseID)
+		}
+	}
+
+	split, err := CreateCase(db, source.SubjectAccountID, title, assignedTo, nil, actor, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range alertIDs {
+		if err := setAlertCase(db, id, split.ID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := AddCaseNote(db, caseID, actor, fmt.Sprintf("Split %d alert(s) into case %s", len(alertIDs), split.ID), now); err != nil {
+		return nil, err
+	}
+	if _, err := AddCaseNote(db, split.ID, actor, fmt.Sprintf("Split from case %s", caseID), now); err != nil {
+		return nil, err
+	}
+	return split, nil
+}
+
+// CloseCase closes an open case with a reason.
+func CloseCase(db database.DBTX, caseID, reason, actor string, now time.Time) (*models.Case, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrCommentRequired
+	}
+	c, err := getOpenCase(db, caseID)
+	if err != nil {
+		return nil, err
+	}
+	c.Status = models.CaseStatusClosed
+	c.ClosedAt = now
+	c.CloseReason = reason
+	c.UpdatedAt = now
+	query := `UPDATE cases SET status = ?, closed_at = ?, close_reason = ?, updated_at = ? WHERE id = ?`
+	if _, err := db.Exec(query, c.Status, c.ClosedAt.UTC(), c.CloseReason, c.UpdatedAt.UTC(), c.ID); err != nil {
+		return nil, fmt.Errorf("failed to close case %s: %w", caseID, err)
+	}
+	if _, err := AddCaseNote(db, caseID, actor, "Closed: "+reason, now); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// caseAlertIDs returns the IDs of the alerts attached to a case.
+func caseAlertIDs(db database.DBTX, caseID string) ([]string, error) {
+	alerts, err := ListCaseAlerts(db, caseID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(alerts))
+	for i, alert := range alerts {
+		ids[i] = alert.ID
+	}
+	return ids, nil
+}
+
+func getOpenCase(db database.DBTX, caseID string) (*models.Case, error) {
+	c, err := GetCase(db, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != models.CaseStatusOpen {
+		return nil, fmt.Errorf("%w: case %s is %s", ErrCaseNotOpen, caseID, c.Status)
+	}
+	return c, nil
+}
+
+func setAlertCase(db database.DBTX, alertID, caseID string) error {
+	if _, err := db.Exec(`UPDATE alerts SET case_id = ? WHERE id = ?`, caseID, alertID); err != nil {
+		return fmt.Errorf("failed to attach alert %s to case %s: %w", alertID, caseID, err)
+	}
+	return nil
+}
+
+func touchCase(db database.DBTX, caseID string, now time.Time) error {
+	if _, err := db.Exec(`UPDATE cases SET updated_at = ? WHERE id = ?`, now.UTC(), caseID); err != nil {
+		return fmt.Errorf("failed to update case %s: %w", caseID, err)
+	}
+	return nil
+}
+
+func displayAssignee(assignedTo string) string {
+	if assignedTo == "" {
+		return "nobody"
+	}
+	return assignedTo
+}
+
+func scanCase(row rowScanner) (*models.Case, error) {
+	var c models.Case
+	var closedAt sql.NullTime
+	var mergedInto sql.NullString
+	err := row.Scan(&c.ID, &c.SubjectAccountID, &c.Title, &c.Status, &c.AssignedTo, &c.CreatedBy,
+		&c.CreatedAt, &c.UpdatedAt, &closedAt, &c.CloseReason, &mergedInto)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		c.ClosedAt = closedAt.Time
+	}
+	c.MergedInto = mergedInto.String
+	return &c, nil
+}
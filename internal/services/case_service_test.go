@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"AML/internal/models"
+)
+
+func TestCaseService(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := base.Add(24 * time.Hour)
+
+	// Test Case 1: A case groups alerts for its subject and rejects alerts for other accounts
+	t.Run("create_and_attach", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+
+		c, err := CreateCase(db, "acc-1", "", "inv-1", []string{"alert-0", "alert-2"}, "analyst-1", now)
+		if err != nil {
+			t.Fatalf("CreateCase failed: %v", err)
+		}
+		if c.Status != models.CaseStatusOpen || c.Title == "" {
+			t.Errorf("Unexpected case: %+v", c)
+		}
+
+		if err := AttachAlertsToCase(db, c.ID, []string{"alert-1"}, "analyst-1", now); !errors.Is(err, ErrInvalidCase) {
+			t.Errorf("Expected ErrInvalidCase for another account's alert, got %v", err)
+		}
+		if _, err := CreateCase(db, "acc-1", "Second", "", []string{"alert-0"}, "analyst-1", now); !errors.Is(err, ErrInvalidCase) {
+			t.Errorf("Expected ErrInvalidCase for an alert already in an open case, got %v", err)
+		}
+		if _, err := CreateCase(db, "acc-1", "", "", nil, "", now); !errors.Is(err, ErrActorRequired) {
+			t.Errorf("Expected ErrActorRequired, got %v", err)
+		}
+
+		detail, err := GetCaseDetail(db, c.ID)
+		if err != nil {
+			t.Fatalf("GetCaseDetail failed: %v", err)
+		}
+		if len(detail.Alerts) != 2 || detail.Alerts[0].ID != "alert-0" || detail.Alerts[1].ID != "alert-2" {
+			t.Errorf("Expected alerts alert-0 and alert-2, got %+v", detail.Alerts)
+		}
+		if len(detail.Notes) != 1 {
+			t.Errorf("Expected 1 note recording the attachment, got %d", len(detail.Notes))
+		}
+
+		page, err := ListAlerts(db, AlertFilter{CaseID: c.ID})
+		if err != nil {
+			t.Fatalf("ListAlerts failed: %v", err)
+		}
+		if len(page.Alerts) != 2 {
+			t.Errorf("Expected 2 alerts filtered by case, got %d", len(page.Alerts))
+		}
+	})
+
+	// Test Case 2: New alerts for an account join its open case
+	t.Run("auto_attach", func(t *testing.T) {
+		db := newTestDB(t)
+		c, err := CreateCase(db, "acc-1", "Watch", "", nil, "analyst-1", base)
+		if err != nil {
+			t.Fatalf("CreateCase failed: %v", err)
+		}
+
+		p := &TransactionProcessor{
+			Orchestrator:  NewOrchestrator([]Detector{stubDetector{id: "stub", findings: []Finding{{DetectorID: "stub", AlertType: AlertTypeThresholdViolation}}}}),
+			Detection:     DetectionContext{Clock: fixedClock(now)},
+			AttachToCases: true,
+		}
+		result, err := p.Process(db, models.Transaction{TransactionID: "tx-1", AccountID: "acc-1", Amount: 500, Timestamp: now})
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if len(result.Alerts) != 1 || result.Alerts[0].CaseID != c.ID {
+			t.Fatalf("Expected the new alert to join case %s, got %+v", c.ID, result.Alerts)
+		}
+		stored, err := GetAlert(db, result.Alerts[0].ID)
+		if err != nil {
+			t.Fatalf("GetAlert failed: %v", err)
+		}
+		if stored.CaseID != c.ID {
+			t.Errorf("Expected stored alert in case %s, got %q", c.ID, stored.CaseID)
+		}
+	})
+
+	// Test Case 3: Merging moves alerts and marks the source merged; splitting moves them back out
+	t.Run("merge_and_split", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		target, err := CreateCase(db, "acc-1", "Target", "", []string{"alert-0"}, "analyst-1", now)
+		if err != nil {
+			t.Fatalf("CreateCase failed: %v", err)
+		}
+		source, err := CreateCase(db, "acc-1", "Source", "", []string{"alert-2", "alert-4"}, "analyst-1", now)
+		if err != nil {
+			t.Fatalf("CreateCase failed: %v", err)
+		}
+		other, err := CreateCase(db, "acc-2", "Other", "", nil, "analyst-1", now)
+		if err != nil {
+			t.Fatalf("CreateCase failed: %v", err)
+		}
+
+		if _, err := MergeCases(db, target.ID, []string{other.ID}, "analyst-1", now); !errors.Is(err, ErrInvalidCase) {
+			t.Errorf("Expected ErrInvalidCase merging another subject's case, got %v", err)
+		}
+		if _, err := MergeCases(db, target.ID, []string{source.ID}, "analyst-1", now); err != nil {
+			t.Fatalf("MergeCases failed: %v", err)
+		}
+		merged, err := GetCase(db, source.ID)
+		if err != nil {
+			t.Fatalf("GetCase failed: %v", err)
+		}
+		if merged.Status != models.CaseStatusMerged || merged.MergedInto != target.ID {
+			t.Errorf("Expected source merged into %s, got %+v", target.ID, merged)
+		}
+		if ids, _ := caseAlertIDs(db, target.ID); len(ids) != 3 {
+			t.Errorf("Expected 3 alerts in the target case, got %v", ids)
+		}
+
+		split, err := SplitCase(db, target.ID, []string{"alert-4"}, "Split", "inv-2", "analyst-1", now)
+		if err != nil {
+			t.Fatalf("SplitCase failed: %v", err)
+		}
+		if ids, _ := caseAlertIDs(db, split.ID); len(ids) != 1 || ids[0] != "alert-4" {
+			t.Errorf("Expected alert-4 in the split case, got %v", ids)
+		}
+		if ids, _ := caseAlertIDs(db, target.ID); len(ids) != 2 {
+			t.Errorf("Expected 2 alerts left in the target case, got %v", ids)
+		}
+		if _, err := SplitCase(db, target.ID, []string{"alert-4"}, "", "", "analyst-1", now); !errors.Is(err, ErrInvalidCase) {
+			t.Errorf("Expected ErrInvalidCase splitting an alert not in the case, got %v", err)
+		}
+	})
+
+	// Test Case 4: Closed cases need a reason and accept no further changes
+	t.Run("close", func(t *testing.T) {
+		db := newTestDB(t)
+		c, err := CreateCase(db, "acc-1", "Close me", "", nil, "analyst-1", now)
+		if err != nil {
+			t.Fatalf("CreateCase failed: %v", err)
+		}
+		if _, err := CloseCase(db, c.ID, " ", "analyst-1", now); !errors.Is(err, ErrCommentRequired) {
+			t.Errorf("Expected ErrCommentRequired, got %v", err)
+		}
+		closed, err := CloseCase(db, c.ID, "No suspicious activity", "analyst-1", now)
+		if err != nil {
+			t.Fatalf("CloseCase failed: %v", err)
+		}
+		if closed.Status != models.CaseStatusClosed || !closed.ClosedAt.Equal(now) {
+			t.Errorf("Unexpected closed case: %+v", closed)
+		}
+		if _, err := AssignCase(db, c.ID, "inv-1", "analyst-1", now); !errors.Is(err, ErrCaseNotOpen) {
+			t.Errorf("Expected ErrCaseNotOpen, got %v", err)
+		}
+		if found, err := FindOpenCase(db, "acc-1"); err != nil || found != nil {
+			t.Errorf("Expected no open case, got %+v, %v", found, err)
+		}
+	})
+
+	// Test Case 5: A SAR can be generated from every alert in a case
+	t.Run("case_sar", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		if _, err := db.Exec(`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('acc-1', 'Jane Doe', '1 Main St', '1980-01-01')`); err != nil {
+			t.Fatalf("Failed to insert account: %v", err)
+		}
+		for i, id := range []string{"tx-0", "tx-2"} {
+			_, err := db.Exec(`INSERT INTO transactions (transaction_id, account_id, amount, currency, timestamp, source_country, destination_country, transaction_type, status)
+				VALUES (?, 'acc-1', ?, 'USD', ?, 'US', 'US', 'transfer', 'completed')`, id, 1000*(i+1), base.Add(time.Duration(i)*time.Hour))
+			if err != nil {
+				t.Fatalf("Failed to insert transaction: %v", err)
+			}
+		}
+		c, err := CreateCase(db, "acc-1", "", "", []string{"alert-0", "alert-2"}, "analyst-1", now)
+		if err != nil {
+			t.Fatalf("CreateCase failed: %v", err)
+		}
+
+		report, err := GenerateCaseSARData(c.ID, db)
+		if err != nil {
+			t.Fatalf("GenerateCaseSARData failed: %v", err)
+		}
+		if report == nil || report.SubjectName != "Jane Doe" || report.TotalTransactionCount != 2 || report.TotalSuspiciousAmount != 3000 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+		if _, err := GenerateCaseSARData("missing", db); !errors.Is(err, ErrCaseNotFound) {
+			t.Errorf("Expected ErrCaseNotFound, got %v", err)
+		}
+	})
+}
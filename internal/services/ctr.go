@@ -0,0 +1,237 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// ctrDay is one person's cash activity on one business day.
+type ctrDay struct {
+	date     string
+	holder   models.Account
+	accounts map[string]bool
+	cashIn   float64
+	cashOut  float64
+	txs      []models.Transaction
+}
+
+// ctrCalendar assigns transactions to business days: a day ends at the cutoff in the business
+// time zone, and activity on closed weekdays and holidays rolls forward to the next business day.
+type ctrCalendar struct {
+	loc *time.Location
+	// cutoffHour and cutoffMinute are the local time the business day ends, midnight when both
+	// are zero.
+	cutoffHour   int
+	cutoffMinute int
+	closed       map[time.Weekday]bool
+	holidays     map[string]bool
+}
+
+func newCTRCalendar(cfg config.CTRConfig) (*ctrCalendar, error) {
+	loc, err := cfg.Location()
+	if err != nil {
+		return nil, err
+	}
+	hour, minute, err := cfg.CutoffTime()
+	if err != nil {
+		return nil, err
+	}
+	closed, err := cfg.ClosedWeekdaySet()
+	if err != nil {
+		return nil, err
+	}
+	cal := &ctrCalendar{loc: loc, cutoffHour: hour, cutoffMinute: minute, closed: closed, holidays: make(map[string]bool)}
+	for _, h := range cfg.Holidays {
+		cal.holidays[h] = true
+	}
+	return cal, nil
+}
+
+// open reports whether the local midnight day is a business day.
+func (c *ctrCalendar) open(day time.Time) bool {
+	return !c.closed[day.Weekday()] && !c.holidays[day.Format("2006-01-02")]
+}
+
+// close returns when the local midnight day's business day ends. The cutoff is a wall-clock time,
+// so it stays put on days when daylight saving time starts or ends.
+func (c *ctrCalendar) close(day time.Time) time.Time {
+	if c.cutoffHour == 0 && c.cutoffMinute == 0 {
+		return day.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), c.cutoffHour, c.cutoffMinute, 0, 0, c.loc)
+}
+
+// businessDate returns the business day a transaction at t counts towards, as YYYY-MM-DD.
+func (c *ctrCalendar) businessDate(t time.Time) string {
+	local := t.In(c.loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.loc)
+	if !local.Before(c.close(day)) {
+		day = day.AddDate(0, 0, 1)
+	}
+	for !c.open(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.Format("2006-01-02")
+}
+
+// GenerateCTRs returns the Currency Transaction Reports due for the business days from from to
+// through, inclusive; only the dates of from and through are used. Cash transactions are grouped
+// per person across all of their accounts, matching holders on name and date of birth, and per
+// business day of the configured calendar. A person is reported for a day when their cash in or
+// their cash out, each converted to the report currency, exceeds the threshold. Reports are
+// ordered by date and then by name.
+func GenerateCTRs(db database.DBTX, cfg config.CTRConfig, from, through time.Time) ([]models.CTR, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cal, err := newCTRCalendar(cfg)
+	if err != nil {
+		return nil, err
+	}
+	first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, cal.loc)
+	last := time.Date(through.Year(), through.Month(), through.Day(), 0, 0, 0, 0, cal.loc)
+	if last.Before(first) {
+		return nil, fmt.Errorf("CTR period ends before it starts")
+	}
+	firstDate, lastDate := first.Format("2006-01-02"), last.Format("2006-01-02")
+
+	// The first business day also takes the activity since the close of the business day before
+	// it, including any closed days in between.
+	previous := first.AddDate(0, 0, -1)
+	for !cal.open(previous) {
+		previous = previous.AddDate(0, 0, -1)
+	}
+	start, end := cal.close(previous), cal.close(last)
+
+	cashIn := make(map[string]bool)
+	for _, t := range cfg.CashInTypes {
+		cashIn[t] = true
+	}
+	types := append(append([]string{}, cfg.CashInTypes...), cfg.CashOutTypes...)
+	args := make([]interface{}, 0, len(types)+len(cfg.Statuses)+2)
+	for _, t := range types {
+		args = append(args, t)
+	}
+	for _, status := range cfg.Statuses {
+		args = append(args, status)
+	}
+	args = append(args, start.UTC(), end.UTC())
+
+	query := `
+		SELECT t.transaction_id, t.account_id, t.amount, t.currency, t.timestamp,
+			t.source_country, t.destination_country, t.transaction_type, t.status, COALESCE(t.counterparty_id, ''),
+			COALESCE(a.holder_name, ''), COALESCE(a.address, ''), COALESCE(a.date_of_birth, '')
+		FROM transactions t
+		LEFT JOIN accounts a ON a.account_id = t.account_id
+		WHERE t.transaction_type IN ` + database.Placeholders(len(types)) + `
+			AND t.status IN ` + database.Placeholders(len(cfg.Statuses)) + `
+			AND t.timestamp >= ? AND t.timestamp < ?
+		ORDER BY t.timestamp ASC, t.transaction_id ASC
+	`
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cash transactions: %w", err)
+	}
+	defer rows.Close()
+
+	days := make(map[string]*ctrDay)
+	for rows.Next() {
+		var tx models.Transaction
+		var holder models.Account
+		err := rows.Scan(
+			&tx.TransactionID, &tx.AccountID, &tx.Amount, &tx.Currency, &tx.Timestamp,
+			&tx.SourceCountry, &tx.DestinationCountry, &tx.TransactionType, &tx.Status, &tx.CounterpartyID,
+			&holder.HolderName, &holder.Address, &holder.DateOfBirth,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
+		}
+		date := cal.businessDate(tx.Timestamp)
+		if date < firstDate || date > lastDate {
+			continue
+		}
+		amount, err := ctrAmount(cfg, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Accounts without a holder on record can't be matched to a person, so each is its own.
+		person := "account|" + tx.AccountID
+		if strings.TrimSpace(holder.HolderName) != "" {
+			person = accountHolderKey(holder)
+		}
+		day, ok := days[date+"|"+person]
+		if !ok {
+			day = &ctrDay{date: date, holder: holder, accounts: make(map[string]bool)}
+			days[date+"|"+person] = day
+		}
+		day.accounts[tx.AccountID] = true
+		if cashIn[tx.TransactionType] {
+			day.cashIn += amount
+		} else {
+			day.cashOut += amount
+		}
+		day.txs = append(day.txs, tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
+	}
+
+	var reportable []*ctrDay
+	for _, day := range days {
+		if day.cashIn > cfg.Threshold || day.cashOut > cfg.Threshold {
+			reportable = append(reportable, day)
+		}
+	}
+	sort.Slice(reportable, func(i, j int) bool {
+		a, b := reportable[i], reportable[j]
+		if a.date != b.date {
+			return a.date < b.date
+		}
+		if a.holder.HolderName != b.holder.HolderName {
+			return a.holder.HolderName < b.holder.HolderName
+		}
+		return a.txs[0].AccountID < b.txs[0].AccountID
+	})
+
+	ctrs := make([]models.CTR, 0, len(reportable))
+	seq := make(map[string]int)
+	for _, day := range reportable {
+		seq[day.date]++
+		ctr := models.CTR{
+			ID:                fmt.Sprintf("CTR-%s-%03d", strings.ReplaceAll(day.date, "-", ""), seq[day.date]),
+			BusinessDate:      day.date,
+			PersonName:        day.holder.HolderName,
+			PersonAddress:     day.holder.Address,
+			PersonDateOfBirth: day.holder.DateOfBirth,
+			CashIn:            day.cashIn,
+			CashOut:           day.cashOut,
+			Currency:          cfg.Currency,
+			Transactions:      day.txs,
+		}
+		for id := range day.accounts {
+			ctr.AccountIDs = append(ctr.AccountIDs, id)
+		}
+		sort.Strings(ctr.AccountIDs)
+		ctrs = append(ctrs, ctr)
+	}
+	return ctrs, nil
+}
+
+// ctrAmount converts a transaction's amount to the report currency.
+func ctrAmount(cfg config.CTRConfig, tx models.Transaction) (float64, error) {
+	if tx.Currency == cfg.Currency {
+		return tx.Amount, nil
+	}
+	rate, ok := cfg.ExchangeRates[tx.Currency]
+	if !ok {
+		return 0, fmt.Errorf("transaction %s: no exchange rate from %s to %s", tx.TransactionID, tx.Currency, cfg.Currency)
+	}
+	return tx.Amount * rate, nil
+}
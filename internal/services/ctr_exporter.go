@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"AML/internal/models"
+)
+
+// ctrCSVHeader is the header row of a CTR CSV batch.
+var ctrCSVHeader = []string{
+	"id", "business_date", "person_name", "person_address", "person_date_of_birth", "account_ids",
+	"cash_in", "cash_out", "currency", "transaction_count", "transaction_ids",
+}
+
+// ExportCTRsToJSON writes a batch of CTRs to a JSON file. As in SAR exports, account numbers are
+// masked and timestamps are ISO 8601.
+func ExportCTRsToJSON(ctrs []models.CTR, filepath string) error {
+	type TransactionJSON struct {
+		TransactionID      string  `json:"transaction_id"`
+		AccountID          string  `json:"account_id"` // Masked
+		Amount             float64 `json:"amount"`
+		Currency           string  `json:"currency"`
+		Timestamp          string  `json:"timestamp"` // Will be ISO 8601
+		SourceCountry      string  `json:"source_country"`
+		DestinationCountry string  `json:"destination_country"`
+		TransactionType    string  `json:"transaction_type"`
+		Status             string  `json:"status"`
+	}
+
+	type CTRJSON struct {
+		ID                string            `json:"id"`
+		BusinessDate      string            `json:"business_date"`
+		PersonName        string            `json:"person_name"`
+		PersonAddress     string            `json:"person_address"`
+		PersonDateOfBirth string            `json:"person_date_of_birth"`
+		AccountIDs        []string          `json:"account_ids"` // Masked
+		CashIn            float64           `json:"cash_in"`
+		CashOut           float64           `json:"cash_out"`
+		Currency          string            `json:"currency"`
+		Transactions      []TransactionJSON `json:"transactions"`
+	}
+
+	type BatchJSON struct {
+		ReportCount int       `json:"report_count"`
+		Reports     []CTRJSON `json:"reports"`
+	}
+
+	batch := BatchJSON{ReportCount: len(ctrs), Reports: make([]CTRJSON, len(ctrs))}
+	for i, ctr := range ctrs {
+		ctrJSON := CTRJSON{
+			ID:                ctr.ID,
+			BusinessDate:      ctr.BusinessDate,
+			PersonName:        ctr.PersonName,
+			PersonAddress:     ctr.PersonAddress,
+			PersonDateOfBirth: ctr.PersonDateOfBirth,
+			AccountIDs:        maskAccountNumbers(ctr.AccountIDs),
+			CashIn:            ctr.CashIn,
+			CashOut:           ctr.CashOut,
+			Currency:          ctr.Currency,
+			Transactions:      make([]TransactionJSON, len(ctr.Transactions)),
+		}
+		for j, transaction := range ctr.Transactions {
+			ctrJSON.Transactions[j] = TransactionJSON{
+				TransactionID:      transaction.TransactionID,
+				AccountID:          MaskAccountNumber(transaction.AccountID),
+				Amount:             transaction.Amount,
+				Currency:           transaction.Currency,
+				Timestamp:          transaction.Timestamp.Format(time.RFC3339),
+				SourceCountry:      transaction.SourceCountry,
+				DestinationCountry: transaction.DestinationCountry,
+				TransactionType:    transaction.TransactionType,
+				Status:             transaction.Status,
+			}
+		}
+		batch.Reports[i] = ctrJSON
+	}
+
+	jsonData, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal CTR batch to JSON: %w", err)
+	}
+	if err := os.WriteFile(filepath, jsonData, 0600); err != nil {
+		return fmt.Errorf("failed to write CTR batch to file: %w", err)
+	}
+	return nil
+}
+
+// ExportCTRsToCSV writes a batch of CTRs to a CSV file, one row per report. Account numbers are
+// masked and joined with semicolons, as are the transaction IDs.
+func ExportCTRsToCSV(ctrs []models.CTR, filepath string) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(ctrCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CTR CSV header: %w", err)
+	}
+	for _, ctr := range ctrs {
+		txIDs := make([]string, len(ctr.Transactions))
+		for i, tx := range ctr.Transactions {
+			txIDs[i] = tx.TransactionID
+		}
+		record := []string{
+			ctr.ID,
+			ctr.BusinessDate,
+			ctr.PersonName,
+			ctr.PersonAddress,
+			ctr.PersonDateOfBirth,
+			strings.Join(maskAccountNumbers(ctr.AccountIDs), ";"),
+			strconv.FormatFloat(ctr.CashIn, 'f', 2, 64),
+			strconv.FormatFloat(ctr.CashOut, 'f', 2, 64),
+			ctr.Currency,
+			strconv.Itoa(len(ctr.Transactions)),
+			strings.Join(txIDs, ";"),
+		}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("failed to write CTR %s: %w", ctr.ID, err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CTR CSV: %w", err)
+	}
+	if err := os.WriteFile(filepath, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write CTR batch to file: %w", err)
+	}
+	return nil
+}
+
+// maskAccountNumbers masks each account number in a list.
+func maskAccountNumbers(accounts []string) []string {
+	masked := make([]string, len(accounts))
+	for i, account := range accounts {
+		masked[i] = MaskAccountNumber(account)
+	}
+	return masked
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+)
+
+// seedCashActivity adds Jane Q Doe with two accounts and John Roe with one, and their cash and
+// wire transactions around Monday 3 to Wednesday 5 June 2024. Business days end at midnight in
+// New York (UTC-4).
+func seedCashActivity(t *testing.T, db database.DBTX) {
+	t.Helper()
+	accounts := []string{
+		`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('ACCT1000000001', 'Jane Q Doe', '1 Main St', '1980-05-20')`,
+		`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('ACCT1000000002', 'JANE Q DOE', '1 Main St', '1980-05-20')`,
+		`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('ACCT2000000001', 'John Roe', '2 High St', '1975-01-02')`,
+	}
+	for _, stmt := range accounts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to insert account: %v", err)
+		}
+	}
+	txs := []struct {
+		id, account, currency, txType, status string
+		amount                                float64
+		at                                    time.Time
+	}{
+		{"c-1", "ACCT1000000001", "USD", "CASH_DEPOSIT", "COMPLETED", 6000, time.Date(2024, 6, 3, 14, 0, 0, 0, time.UTC)},
+		{"c-2", "ACCT1000000001", "USD", "CASH_WITHDRAWAL", "COMPLETED", 3000, time.Date(2024, 6, 3, 15, 0, 0, 0, time.UTC)},
+		// 22:00 on 3 June in New York, on Jane's second account.
+		{"c-3", "ACCT1000000002", "USD", "CASH", "COMPLETED", 4500, time.Date(2024, 6, 4, 2, 0, 0, 0, time.UTC)},
+		{"c-4", "ACCT1000000001", "USD", "CASH_DEPOSIT", "FAILED", 20000, time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)},
+		{"c-5", "ACCT2000000001", "USD", "CASH_DEPOSIT", "COMPLETED", 9000, time.Date(2024, 6, 3, 14, 0, 0, 0, time.UTC)},
+		{"c-6", "ACCT2000000001", "USD", "WIRE", "COMPLETED", 5000, time.Date(2024, 6, 3, 15, 0, 0, 0, time.UTC)},
+		{"c-7", "ACCT2000000001", "EUR", "CASH_DEPOSIT", "COMPLETED", 9500, time.Date(2024, 6, 4, 16, 0, 0, 0, time.UTC)},
+		{"c-8", "ACCT1000000002", "USD", "CASH_DEPOSIT", "COMPLETED", 10000, time.Date(2024, 6, 5, 16, 0, 0, 0, time.UTC)},
+	}
+	for _, tx := range txs {
+		_, err := db.Exec(`
+			INSERT INTO transactions (transaction_id, account_id, amount, currency, timestamp, source_country,
+				destination_country, transaction_type, status)
+			VALUES (?, ?, ?, ?, ?, 'US', 'US', ?, ?)`,
+			tx.id, tx.account, tx.amount, tx.currency, tx.at, tx.txType, tx.status)
+		if err != nil {
+			t.Fatalf("failed to insert transaction: %v", err)
+		}
+	}
+}
+
+func TestCTRs(t *testing.T) {
+	cfg := config.DefaultCTRConfig()
+	cfg.ExchangeRates = map[string]float64{"EUR": 1.08}
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	// Test Case 1: Cash is totalled per person across accounts and per business day
+	t.Run("aggregate", func(t *testing.T) {
+		db := newTestDB(t)
+		seedCashActivity(t, db)
+		ctrs, err := GenerateCTRs(db, cfg, day(3), day(5))
+		if err != nil {
+			t.Fatalf("GenerateCTRs failed: %v", err)
+		}
+		if len(ctrs) != 2 {
+			t.Fatalf("Expected 2 CTRs, got %+v", ctrs)
+		}
+
+		jane := ctrs[0]
+		if jane.ID != "CTR-20240603-001" || jane.BusinessDate != "2024-06-03" || jane.PersonName != "Jane Q Doe" {
+			t.Errorf("Unexpected first CTR: %+v", jane)
+		}
+		if strings.Join(jane.AccountIDs, ",") != "ACCT1000000001,ACCT1000000002" {
+			t.Errorf("Expected both of Jane's accounts, got %v", jane.AccountIDs)
+		}
+		if jane.CashIn != 10500 || jane.CashOut != 3000 || len(jane.Transactions) != 3 {
+			t.Errorf("Expected 10500 in and 3000 out over 3 transactions, got %.2f in, %.2f out over %d",
+				jane.CashIn, jane.CashOut, len(jane.Transactions))
+		}
+
+		// 9,500 EUR is 10,260 USD; John's 9,000 USD on 3 June and his wire are not reportable.
+		john := ctrs[1]
+		if john.ID != "CTR-20240604-001" || john.PersonName != "John Roe" || john.CashIn != 10260 || john.Currency != "USD" {
+			t.Errorf("Unexpected second CTR: %+v", john)
+		}
+	})
+
+	// Test Case 2: Transactions count towards the business day they fall on locally
+	t.Run("business_day", func(t *testing.T) {
+		db := newTestDB(t)
+		seedCashActivity(t, db)
+		ctrs, err := GenerateCTRs(db, cfg, day(4), day(4))
+		if err != nil {
+			t.Fatalf("GenerateCTRs failed: %v", err)
+		}
+		if len(ctrs) != 1 || ctrs[0].PersonName != "John Roe" {
+			t.Errorf("Expected only John's CTR on 4 June, got %+v", ctrs)
+		}
+	})
+
+	// Test Case 3: Foreign currencies without an exchange rate are an error
+	t.Run("missing_rate", func(t *testing.T) {
+		db := newTestDB(t)
+		seedCashActivity(t, db)
+		noRates := cfg
+		noRates.ExchangeRates = nil
+		if _, err := GenerateCTRs(db, noRates, day(3), day(5)); err == nil || !strings.Contains(err.Error(), "no exchange rate from EUR to USD") {
+			t.Errorf("Expected a missing exchange rate error, got %v", err)
+		}
+	})
+
+	// Test Case 4: Batches are written as JSON and CSV with masked account numbers
+	t.Run("export", func(t *testing.T) {
+		db := newTestDB(t)
+		seedCashActivity(t, db)
+		ctrs, err := GenerateCTRs(db, cfg, day(3), day(5))
+		if err != nil {
+			t.Fatalf("GenerateCTRs failed: %v", err)
+		}
+		dir := t.TempDir()
+
+		jsonPath := filepath.Join(dir, "ctr.json")
+		if err := ExportCTRsToJSON(ctrs, jsonPath); err != nil {
+			t.Fatalf("ExportCTRsToJSON failed: %v", err)
+		}
+		data, err := os.ReadFile(jsonPath)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		if strings.Contains(string(data), "ACCT1000000001") {
+			t.Errorf("Expected account numbers to be masked")
+		}
+		var batch struct {
+			ReportCount int `json:"report_count"`
+			Reports     []struct {
+				AccountIDs   []string `json:"account_ids"`
+				Transactions []struct {
+					AccountID string `json:"account_id"`
+					Timestamp string `json:"timestamp"`
+				} `json:"transactions"`
+			} `json:"reports"`
+		}
+		if err := json.Unmarshal(data, &batch); err != nil {
+			t.Fatalf("failed to unmarshal exported JSON: %v", err)
+		}
+		if batch.ReportCount != 2 || strings.Join(batch.Reports[0].AccountIDs, ",") != "XXXX-XXXX-XXXX-0001,XXXX-XXXX-XXXX-0002" {
+			t.Errorf("Unexpected JSON batch: %+v", batch)
+		}
+		if tx := batch.Reports[0].Transactions[0]; tx.AccountID != "XXXX-XXXX-XXXX-0001" || tx.Timestamp != "2024-06-03T14:00:00Z" {
+			t.Errorf("Expected a masked account and ISO 8601 timestamp, got %+v", tx)
+		}
+		if info, err := os.Stat(jsonPath); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("Expected file permissions 0600")
+		}
+
+		csvPath := filepath.Join(dir, "ctr.csv")
+		if err := ExportCTRsToCSV(ctrs, csvPath); err != nil {
+			t.Fatalf("ExportCTRsToCSV failed: %v", err)
+		}
+		f, err := os.Open(csvPath)
+		if err != nil {
+			t.Fatalf("failed to open file: %v", err)
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to read CSV: %v", err)
+		}
+		if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(ctrCSVHeader, ",") {
+			t.Fatalf("Expected a header and 2 rows, got %v", records)
+		}
+		want := "CTR-20240603-001,2024-06-03,Jane Q Doe,1 Main St,1980-05-20,XXXX-XXXX-XXXX-0001;XXXX-XXXX-XXXX-0002,10500.00,3000.00,USD,3,c-1;c-2;c-3"
+		if got := strings.Join(records[1], ","); got != want {
+			t.Errorf("Expected row %q, got %q", want, got)
+		}
+	})
+
+	// Test Case 5: Activity after the cutoff or on closed days counts towards the next business day
+	t.Run("calendar", func(t *testing.T) {
+		db := newTestDB(t)
+		seedCashActivity(t, db)
+		// Saturday 1 June, 11:00 in New York.
+		_, err := db.Exec(`
+			INSERT INTO transactions (transaction_id, account_id, amount, currency, timestamp, source_country,
+				destination_country, transaction_type, status)
+			VALUES ('c-9', 'ACCT2000000001', 2000, 'USD', ?, 'US', 'US', 'CASH_DEPOSIT', 'COMPLETED')`,
+			time.Date(2024, 6, 1, 15, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("failed to insert transaction: %v", err)
+		}
+
+		weekend, err := GenerateCTRs(db, cfg, day(1), day(2))
+		if err != nil {
+			t.Fatalf("GenerateCTRs failed: %v", err)
+		}
+		if len(weekend) != 0 {
+			t.Errorf("Expected no CTRs for a weekend, got %+v", weekend)
+		}
+		monday, err := GenerateCTRs(db, cfg, day(3), day(3))
+		if err != nil {
+			t.Fatalf("GenerateCTRs failed: %v", err)
+		}
+		if len(monday) != 2 || monday[1].PersonName != "John Roe" || monday[1].CashIn != 11000 {
+			t.Errorf("Expected John's Saturday deposit on Monday's CTR, got %+v", monday)
+		}
+
+		// With a 15:00 cutoff Jane's 22:00 deposit moves to 4 June, and the 4 June holiday moves it
+		// and John's EUR deposit on to 5 June.
+		calendar := cfg
+		calendar.Cutoff = "15:00"
+		calendar.Holidays = []string{"2024-06-04"}
+		ctrs, err := GenerateCTRs(db, calendar, day(3), day(5))
+		if err != nil {
+			t.Fatalf("GenerateCTRs failed: %v", err)
+		}
+		var got []string
+		for _, ctr := range ctrs {
+			got = append(got, fmt.Sprintf("%s %s %d", ctr.BusinessDate, ctr.PersonName, len(ctr.Transactions)))
+		}
+		want := []string{"2024-06-03 John Roe 2", "2024-06-05 JANE Q DOE 2", "2024-06-05 John Roe 1"}
+		if strings.Join(got, ", ") != strings.Join(want, ", ") {
+			t.Errorf("Expected CTRs %v, got %v", want, got)
+		}
+	})
+
+	// Test Case 6: The cutoff is kept in local time on the days daylight saving time changes
+	t.Run("dst_cutoff", func(t *testing.T) {
+		calendar := cfg
+		calendar.Cutoff = "15:00"
+		calendar.ClosedWeekdays = nil
+		cal, err := newCTRCalendar(calendar)
+		if err != nil {
+			t.Fatalf("newCTRCalendar failed: %v", err)
+		}
+		// New York moves to EDT on 10 March 2024 and back to EST on 3 November 2024.
+		cases := []struct {
+			at   time.Time
+			want string
+		}{
+			{time.Date(2024, 3, 10, 14, 59, 0, 0, cal.loc), "2024-03-10"},
+			{time.Date(2024, 3, 10, 15, 30, 0, 0, cal.loc), "2024-03-11"},
+			{time.Date(2024, 11, 3, 14, 30, 0, 0, cal.loc), "2024-11-03"},
+			{time.Date(2024, 11, 3, 15, 0, 0, 0, cal.loc), "2024-11-04"},
+		}
+		for _, c := range cases {
+			if got := cal.businessDate(c.at); got != c.want {
+				t.Errorf("Expected %s to count towards %s, got %s", c.at, c.want, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"AML/internal/models"
+)
+
+// Orchestrator runs a set of detectors over a transaction and turns their findings into alerts.
+type Orchestrator struct {
+	detectors []Detector
+}
+
+// NewOrchestrator creates an orchestrator for the given detectors, run in order.
+func NewOrchestrator(detectors []Detector) *Orchestrator {
+	return &Orchestrator{detectors: detectors}
+}
+
+// Detectors returns the detectors the orchestrator runs.
+func (o *Orchestrator) Detectors() []Detector {
+	return o.detectors
+}
+
+// Detect runs every detector and collects their findings. A detector that lacks history is
+// skipped; other detector failures are reported together after all detectors have run.
+func (o *Orchestrator) Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error) {
+	var findings []Finding
+	var errs []error
+	for _, detector := range o.detectors {
+		found, err := detector.Detect(tx, dctx)
+		if err != nil {
+			if !isInsufficientHistory(err) {
+				errs = append(errs, fmt.Errorf("detector %s: %w", detector.ID(), err))
+			}
+			continue
+		}
+		findings = append(findings, found...)
+	}
+	return findings, errors.Join(errs...)
+}
+
+// Run detects findings for a transaction and generates one alert per finding. Alerts from
+// detectors that succeeded are returned even when another detector failed.
+func (o *Orchestrator) Run(tx models.Transaction, dctx *DetectionContext) ([]*models.Alert, error) {
+	findings, detectErr := o.Detect(tx, dctx)
+
+	var alerts []*models.Alert
+	for _, finding := range findings {
+		alert, err := GenerateAlert(tx, finding.AlertType, findingDetails(finding))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate alert for detector %s: %w", finding.DetectorID, err)
+		}
+		alert.CreatedAt = dctx.now()
+		alert.DedupKey = dedupKey(finding)
+		alerts = append(alerts, alert)
+	}
+	return alerts, detectErr
+}
+
+// findingDetails returns the finding's details tagged with where they came from.
+func findingDetails(finding Finding) map[string]interface{} {
+	details := make(map[string]interface{}, len(finding.Details)+2)
+	for k, v := range finding.Details {
+		details[k] = v
+	}
+	details["detector_id"] = finding.DetectorID
+	if finding.RuleID != "" {
+		details["rule_id"] = finding.RuleID
+	}
+	return details
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+var (
+	// ErrInsufficientHistory is returned when a detector lacks the history it needs to decide.
+	ErrInsufficientHistory = fmt.Errorf("insufficient transaction history")
+)
+
+// Finding is one suspicious signal raised by a detector for a transaction.
+type Finding struct {
+	DetectorID    string                 `json:"detector_id"`
+	AlertType     string                 `json:"alert_type"`
+	RuleID        string                 `json:"rule_id,omitempty"`
+	TransactionID string                 `json:"transaction_id"`
+	AccountID     string                 `json:"account_id"`
+	Details       map[string]interface{} `json:"details"`
+}
+
+// Detector inspects a transaction and reports any findings.
+type Detector interface {
+	// ID returns the identifier used to enable the detector in configuration.
+	ID() string
+	// Detect evaluates tx. The context's profile must not yet include tx.
+	Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error)
+}
+
+// Clock supplies the current time so detection can be replayed deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock backed by the system time.
+type SystemClock struct{}
+
+// Now returns the current system time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// HistoryAccessor loads an account's transactions on demand, so detectors only fetch the
+// windows they need.
+type HistoryAccessor interface {
+	// TransactionsBetween returns the account's transactions with from <= timestamp < to, oldest first.
+	TransactionsBetween(accountID string, from, to time.Time) ([]models.Transaction, error)
+}
+
+// DetectionContext carries everything a detector may need besides the transaction itself.
+type DetectionContext struct {
+	History     HistoryAccessor
+	Profile     *models.AccountProfile
+	Clock       Clock
+	Rules       []config.Rule
+	CountryRisk config.CountryRisk
+}
+
+// now returns the context clock's time, defaulting to the system clock.
+func (dctx *DetectionContext) now() time.Time {
+	if dctx.Clock == nil {
+		return time.Now()
+	}
+	return dctx.Clock.Now()
+}
+
+// historyBefore returns the account's transactions in the window before tx.
+func (dctx *DetectionContext) historyBefore(tx models.Transaction, window time.Duration) ([]models.Transaction, error) {
+	if dctx.History == nil {
+		return nil, nil
+	}
+	return dctx.History.TransactionsBetween(tx.AccountID, tx.Timestamp.Add(-window), tx.Timestamp)
+}
+
+// SliceHistory is an in-memory HistoryAccessor over a fixed set of transactions.
+type SliceHistory []models.Transaction
+
+// TransactionsBetween returns the matching transactions, oldest first.
+func (h SliceHistory) TransactionsBetween(accountID string, from, to time.Time) ([]models.Transaction, error) {
+	var matching []models.Transaction
+	for _, tx := range h {
+		if tx.AccountID == accountID && !tx.Timestamp.Before(from) && tx.Timestamp.Before(to) {
+			matching = append(matching, tx)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].Timestamp.Before(matching[j].Timestamp)
+	})
+	return matching, nil
+}
+
+// SQLHistory is a HistoryAccessor reading the transactions table.
+type SQLHistory struct {
+	DB database.DBTX
+}
+
+// TransactionsBetween returns the matching transactions, oldest first.
+func (h SQLHistory) TransactionsBetween(accountID string, from, to time.Time) ([]models.Transaction, error) {
+	query := `
+		SELECT transaction_id, account_id, amount, currency, timestamp,
+			source_country, destination_country, transaction_type, status, COALESCE(counterparty_id, '')
+		FROM transactions
+		WHERE account_id = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC
+	`
+	rows, err := h.DB.Query(query, accountID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction history: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var tx models.Transaction
+		err := rows.Scan(
+			&tx.TransactionID, &tx.AccountID, &tx.Amount, &tx.Currency, &tx.Timestamp,
+			&tx.SourceCountry, &tx.DestinationCountry, &tx.TransactionType, &tx.Status, &tx.CounterpartyID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+// Built-in detector IDs.
+const (
+	DetectorThresholdRules  = "threshold_rules"
+	DetectorBehavioralRules = "behavioral_rules"
+	DetectorStructuring     = "structuring"
+	DetectorAmountAnomaly   = "amount_anomaly"
+	DetectorVelocity        = "velocity"
+	DetectorIsolationForest = "isolation_forest"
+)
+
+// DetectorFactory builds a detector from its configuration parameters, which may be empty.
+type DetectorFactory func(params json.RawMessage) (Detector, error)
+
+var detectorFactories = map[string]DetectorFactory{
+	DetectorThresholdRules:  newThresholdRuleDetector,
+	DetectorBehavioralRules: newBehavioralRuleDetector,
+	DetectorStructuring:     newStructuringDetector,
+	DetectorAmountAnomaly:   newAmountAnomalyDetector,
+	DetectorVelocity:        newVelocityDetector,
+	DetectorIsolationForest: newIsolationForestDetector,
+}
+
+// RegisterDetector makes a detector available to BuildDetectors under the given ID.
+func RegisterDetector(id string, factory DetectorFactory) error {
+	if _, exists := detectorFactories[id]; exists {
+		return fmt.Errorf("detector already registered: %s", id)
+	}
+	detectorFactories[id] = factory
+	return nil
+}
+
+// BuildDetectors instantiates every enabled detector in configuration order.
+func BuildDetectors(cfgs []config.DetectorConfig) ([]Detector, error) {
+	var detectors []Detector
+	for _, cfg := range cfgs {
+		if !cfg.Enabled {
+			continue
+		}
+		factory, ok := detectorFactories[cfg.DetectorID]
+		if !ok {
+			return nil, fmt.Errorf("unknown detector: %s", cfg.DetectorID)
+		}
+		detector, err := factory(cfg.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build detector %s: %w", cfg.DetectorID, err)
+		}
+		detectors = append(detectors, detector)
+	}
+	return detectors, nil
+}
+
+// decodeParams overlays JSON parameters onto defaults already held in target.
+func decodeParams(params json.RawMessage, target interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, target); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
+
+// thresholdRuleDetector adapts EvaluateRules.
+type thresholdRuleDetector struct{}
+
+func newThresholdRuleDetector(params json.RawMessage) (Detector, error) {
+	return thresholdRuleDetector{}, nil
+}
+
+func (thresholdRuleDetector) ID() string { return DetectorThresholdRules }
+
+func (d thresholdRuleDetector) Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error) {
+	var longest time.Duration
+	for _, rule := range dctx.Rules {
+		if window, err := rule.GetTimeWindow(); err == nil && window > longest {
+			longest = window
+		}
+	}
+	history, err := dctx.historyBefore(tx, longest)
+	if err != nil {
+		return nil, err
+	}
+
+	violations, err := EvaluateRules(tx, dctx.Rules, history)
+	if err != nil {
+		return nil, err
+	}
+	return violationFindings(d.ID(), AlertTypeThresholdViolation, tx, violations), nil
+}
+
+// behavioralRuleDetector adapts EvaluateProfileRules.
+type behavioralRuleDetector struct{}
+
+func newBehavioralRuleDetector(params json.RawMessage) (Detector, error) {
+	return behavioralRuleDetector{}, nil
+}
+
+func (behavioralRuleDetector) ID() string { return DetectorBehavioralRules }
+
+func (d behavioralRuleDetector) Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error) {
+	violations := EvaluateProfileRules(tx, dctx.Rules, dctx.Profile)
+	return violationFindings(d.ID(), AlertTypeBehavioralDeviation, tx, violations), nil
+}
+
+func violationFindings(detectorID, alertType string, tx models.Transaction, violations []RuleViolation) []Finding {
+	var findings []Finding
+	for _, v := range violations {
+		details := map[string]interface{}{
+			"rule_id":         v.RuleID,
+			"actual_value":    v.ActualValue,
+			"threshold_value": v.ThresholdValue,
+		}
+		for k, val := range v.Details {
+			details[k] = val
+		}
+		findings = append(findings, Finding{
+			DetectorID:    detectorID,
+			AlertType:     alertType,
+			RuleID:        v.RuleID,
+			TransactionID: tx.TransactionID,
+			AccountID:     tx.AccountID,
+			Details:       details,
+		})
+	}
+	return findings
+}
+
+// structuringDetector adapts DetectStructuring.
+type structuringDetector struct {
+	cfg    config.StructuringConfig
+	window time.Duration
+}
+
+func newStructuringDetector(params json.RawMessage) (Detector, error) {
+	cfg := config.DefaultStructuringConfig()
+	if err := decodeParams(params, &cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	window, _ := cfg.GetTimeWindow()
+	return &structuringDetector{cfg: cfg, window: window}, nil
+}
+
+func (d *structuringDetector) ID() string { return DetectorStructuring }
+
+func (d *structuringDetector) Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error) {
+	history, err := dctx.historyBefore(tx, d.window)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := append(history, tx)
+	detected, matching := DetectStructuring(tx.AccountID, candidates, tx.Timestamp, d.window, d.cfg.ThresholdLow, d.cfg.ThresholdHigh, d.cfg.MinCount)
+	if !detected {
+		return nil, nil
+	}
+
+	var total float64
+	for _, m := range matching {
+		total += m.Amount
+	}
+	return []Finding{{
+		DetectorID:    d.ID(),
+		AlertType:     AlertTypeStructuringPattern,
+		TransactionID: tx.TransactionID,
+		AccountID:     tx.AccountID,
+		Details: map[string]interface{}{
+			"matching_transactions": matching,
+			"total_amount":          total,
+			"time_window":           d.cfg.TimeWindow,
+			"threshold_low":         d.cfg.ThresholdLow,
+			"threshold_high":        d.cfg.ThresholdHigh,
+		},
+	}}, nil
+}
+
+// amountAnomalyParams extends the anomaly configuration with how much raw history to load
+// when the account profile cannot be used.
+type amountAnomalyParams struct {
+	config.AnomalyConfig
+	HistoryWindow string `json:"history_window"`
+}
+
+// amountAnomalyDetector adapts DetectAmountAnomalyFromProfile and DetectAmountAnomalyWithConfig.
+type amountAnomalyDetector struct {
+	cfg           config.AnomalyConfig
+	historyWindow time.Duration
+}
+
+func newAmountAnomalyDetector(params json.RawMessage) (Detector, error) {
+	p := amountAnomalyParams{AnomalyConfig: config.DefaultAnomalyConfig(), HistoryWindow: "2160h"}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if err := p.AnomalyConfig.Validate(); err != nil {
+		return nil, err
+	}
+	window, err := time.ParseDuration(p.HistoryWindow)
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid history_window '%s'", p.HistoryWindow)
+	}
+	return &amountAnomalyDetector{cfg: p.AnomalyConfig, historyWindow: window}, nil
+}
+
+func (d *amountAnomalyDetector) ID() string { return DetectorAmountAnomaly }
+
+func (d *amountAnomalyDetector) Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error) {
+	var result *AnomalyResult
+	var err error
+	if d.cfg.Method != config.AnomalyMethodMAD && len(d.cfg.SegmentBy) == 0 && dctx.Profile != nil {
+		result, err = DetectAmountAnomalyFromProfile(tx, dctx.Profile, d.cfg)
+	} else {
+		var history []models.Transaction
+		history, err = dctx.historyBefore(tx, d.historyWindow)
+		if err != nil {
+			return nil, err
+		}
+		result, err = DetectAmountAnomalyWithConfig(tx, history, d.cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !result.IsAnomaly {
+		return nil, nil
+	}
+	return []Finding{{
+		DetectorID:    d.ID(),
+		AlertType:     AlertTypeAnomalyDetected,
+		TransactionID: tx.TransactionID,
+		AccountID:     tx.AccountID,
+		Details:       result.Details(),
+	}}, nil
+}
+
+// velocityDetector adapts DetectVelocityAnomaly.
+type velocityDetector struct {
+	cfg config.VelocityConfig
+}
+
+func newVelocityDetector(params json.RawMessage) (Detector, error) {
+	cfg := config.DefaultVelocityConfig()
+	if err := decodeParams(params, &cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &velocityDetector{cfg: cfg}, nil
+}
+
+func (d *velocityDetector) ID() string { return DetectorVelocity }
+
+func (d *velocityDetector) Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error) {
+	if dctx.Profile == nil {
+		return nil, fmt.Errorf("%w: velocity detection requires an account profile", ErrInsufficientHistory)
+	}
+	recent, err := dctx.historyBefore(tx, d.cfg.Lookback())
+	if err != nil {
+		return nil, err
+	}
+	result, err := DetectVelocityAnomaly(tx, recent, dctx.Profile, d.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	if result.IsBurst {
+		findings = append(findings, Finding{
+			DetectorID:    d.ID(),
+			AlertType:     AlertTypeVelocityAnomaly,
+			TransactionID: tx.TransactionID,
+			AccountID:     tx.AccountID,
+			Details:       result.Details(),
+		})
+	}
+	if result.DormantReactivation {
+		findings = append(findings, Finding{
+			DetectorID:    d.ID(),
+			AlertType:     AlertTypeDormantReactivation,
+			TransactionID: tx.TransactionID,
+			AccountID:     tx.AccountID,
+			Details:       result.Details(),
+		})
+	}
+	return findings, nil
+}
+
+// isolationForestDetector adapts AnomalyModel.
+type isolationForestDetector struct {
+	model *AnomalyModel
+}
+
+func newIsolationForestDetector(params json.RawMessage) (Detector, error) {
+	p := struct {
+		ModelPath string `json:"model_path"`
+	}{ModelPath: "anomaly_model.json"}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	model, err := LoadAnomalyModel(p.ModelPath)
+	if err != nil {
+		return nil, err
+	}
+	return &isolationForestDetector{model: model}, nil
+}
+
+func (d *isolationForestDetector) ID() string { return DetectorIsolationForest }
+
+func (d *isolationForestDetector) Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error) {
+	history, err := dctx.historyBefore(tx, featureWindows[len(featureWindows)-1])
+	if err != nil {
+		return nil, err
+	}
+	score, err := d.model.Score(tx, history, dctx.CountryRisk)
+	if err != nil {
+		return nil, err
+	}
+	if !score.IsAnomaly {
+		return nil, nil
+	}
+	return []Finding{{
+		DetectorID:    d.ID(),
+		AlertType:     AlertTypeAnomalyDetected,
+		TransactionID: tx.TransactionID,
+		AccountID:     tx.AccountID,
+		Details:       score.Details(),
+	}}, nil
+}
+
+// isInsufficientHistory reports whether err only means a detector could not decide yet.
+func isInsufficientHistory(err error) bool {
+	return errors.Is(err, ErrInsufficientHistory)
+}
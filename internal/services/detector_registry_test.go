@@ -0,0 +1,193 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+// fixedClock is a Clock that always returns the same instant.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// stubDetector returns canned findings or an error.
+type stubDetector struct {
+	id       string
+	findings []Finding
+	err      error
+}
+
+func (d stubDetector) ID() string { return d.id }
+
+func (d stubDetector) Detect(tx models.Transaction, dctx *DetectionContext) ([]Finding, error) {
+	return d.findings, d.err
+}
+
+func TestBuildDetectors(t *testing.T) {
+	// Test Case 1: Enabled detectors are built in order, disabled ones skipped
+	t.Run("enabled_detectors", func(t *testing.T) {
+		cfgs := []config.DetectorConfig{
+			{DetectorID: DetectorThresholdRules, Enabled: true},
+			{DetectorID: DetectorStructuring, Enabled: true, Params: json.RawMessage(`{"min_count": 4}`)},
+			{DetectorID: DetectorVelocity, Enabled: false},
+			{DetectorID: DetectorAmountAnomaly, Enabled: true, Params: json.RawMessage(`{"method": "mad"}`)},
+		}
+		detectors, err := BuildDetectors(cfgs)
+		if err != nil {
+			t.Fatalf("BuildDetectors failed: %v", err)
+		}
+		if len(detectors) != 3 {
+			t.Fatalf("Expected 3 detectors, got %d", len(detectors))
+		}
+		if detectors[1].(*structuringDetector).cfg.MinCount != 4 {
+			t.Errorf("Expected params to override min_count")
+		}
+		if detectors[1].(*structuringDetector).cfg.ThresholdLow != 8000 {
+			t.Errorf("Expected defaults to fill unspecified params")
+		}
+		if detectors[2].ID() != DetectorAmountAnomaly {
+			t.Errorf("Expected amount_anomaly third, got %s", detectors[2].ID())
+		}
+	})
+
+	// Test Case 2: Unknown detectors and bad params are rejected
+	t.Run("invalid_configs", func(t *testing.T) {
+		if _, err := BuildDetectors([]config.DetectorConfig{{DetectorID: "nope", Enabled: true}}); err == nil {
+			t.Errorf("Expected error for unknown detector")
+		}
+		bad := []config.DetectorConfig{{DetectorID: DetectorVelocity, Enabled: true, Params: json.RawMessage(`{"alpha": 2}`)}}
+		if _, err := BuildDetectors(bad); err == nil {
+			t.Errorf("Expected error for invalid velocity params")
+		}
+	})
+
+	// Test Case 3: Custom detectors can be registered
+	t.Run("register_custom_detector", func(t *testing.T) {
+		err := RegisterDetector("custom_test", func(params json.RawMessage) (Detector, error) {
+			return stubDetector{id: "custom_test"}, nil
+		})
+		if err != nil {
+			t.Fatalf("RegisterDetector failed: %v", err)
+		}
+		defer delete(detectorFactories, "custom_test")
+
+		if err := RegisterDetector(DetectorVelocity, nil); err == nil {
+			t.Errorf("Expected error when registering a duplicate ID")
+		}
+		detectors, err := BuildDetectors([]config.DetectorConfig{{DetectorID: "custom_test", Enabled: true}})
+		if err != nil || len(detectors) != 1 {
+			t.Fatalf("Expected custom detector to build, got %v %v", detectors, err)
+		}
+	})
+}
+
+func TestOrchestrator(t *testing.T) {
+	now := time.Now()
+	rules := []config.Rule{
+		{RuleID: "single_transaction_exceeds_10000", ThresholdValue: 10000, TimeWindow: "0h", Enabled: true},
+		{RuleID: "daily_cumulative_exceeds_50000", ThresholdValue: 50000, TimeWindow: "24h", Enabled: true},
+	}
+	history := SliceHistory{
+		{TransactionID: "h1", AccountID: "acc-1", Amount: 9000, Timestamp: now.Add(-3 * time.Hour)},
+		{TransactionID: "h2", AccountID: "acc-1", Amount: 9500, Timestamp: now.Add(-2 * time.Hour)},
+		{TransactionID: "h3", AccountID: "acc-2", Amount: 9500, Timestamp: now.Add(-2 * time.Hour)},
+	}
+
+	detectors, err := BuildDetectors([]config.DetectorConfig{
+		{DetectorID: DetectorThresholdRules, Enabled: true},
+		{DetectorID: DetectorStructuring, Enabled: true},
+		{DetectorID: DetectorAmountAnomaly, Enabled: true},
+		{DetectorID: DetectorVelocity, Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("BuildDetectors failed: %v", err)
+	}
+	orchestrator := NewOrchestrator(detectors)
+
+	// Test Case 1: Findings from several detectors become alerts; detectors without history are skipped
+	t.Run("findings_to_alerts", func(t *testing.T) {
+		tx := models.Transaction{TransactionID: "tx-1", AccountID: "acc-1", Amount: 9900, Timestamp: now.Add(-time.Minute)}
+		dctx := &DetectionContext{History: history, Rules: rules, Clock: fixedClock(now), Profile: NewAccountProfile("acc-1")}
+
+		alerts, err := orchestrator.Run(tx, dctx)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(alerts) != 1 {
+			t.Fatalf("Expected 1 alert, got %d", len(alerts))
+		}
+		alert := alerts[0]
+		if alert.AlertType != AlertTypeStructuringPattern {
+			t.Errorf("Expected structuring alert, got %s", alert.AlertType)
+		}
+		if alert.RuleDetails["detector_id"] != DetectorStructuring {
+			t.Errorf("Expected detector_id in rule details, got %v", alert.RuleDetails)
+		}
+		if matching := alert.RuleDetails["matching_transactions"].([]models.Transaction); len(matching) != 3 {
+			t.Errorf("Expected 3 matching transactions, got %d", len(matching))
+		}
+		if !alert.CreatedAt.Equal(now) {
+			t.Errorf("Expected alert to use the context clock")
+		}
+	})
+
+	// Test Case 2: Rule violations carry their rule ID
+	t.Run("rule_findings", func(t *testing.T) {
+		tx := models.Transaction{TransactionID: "tx-2", AccountID: "acc-3", Amount: 60000, Timestamp: now.Add(-time.Minute)}
+		findings, err := orchestrator.Detect(tx, &DetectionContext{History: history, Rules: rules})
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if len(findings) != 2 {
+			t.Fatalf("Expected 2 findings, got %d", len(findings))
+		}
+		for _, f := range findings {
+			if f.AlertType != AlertTypeThresholdViolation || f.RuleID == "" || f.AccountID != "acc-3" {
+				t.Errorf("Unexpected finding %+v", f)
+			}
+		}
+	})
+
+	// Test Case 3: A failing detector does not stop the others
+	t.Run("detector_errors_are_collected", func(t *testing.T) {
+		o := NewOrchestrator([]Detector{
+			stubDetector{id: "broken", err: errors.New("boom")},
+			stubDetector{id: "ok", findings: []Finding{{DetectorID: "ok", AlertType: AlertTypeGeographicRisk}}},
+		})
+		alerts, err := o.Run(models.Transaction{TransactionID: "tx-3"}, &DetectionContext{})
+		if err == nil {
+			t.Errorf("Expected the broken detector's error")
+		}
+		if len(alerts) != 1 || alerts[0].AlertType != AlertTypeGeographicRisk {
+			t.Errorf("Expected the healthy detector's alert, got %v", alerts)
+		}
+	})
+}
+
+func TestSQLHistory(t *testing.T) {
+	db := newTestDB(t)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{-48 * time.Hour, -2 * time.Hour, -time.Hour, 0} {
+		_, err := db.Exec(`INSERT INTO transactions VALUES (?, 'acc-1', 100, 'USD', ?, 'US', 'US', 'transfer', 'completed', NULL)`,
+			"tx-"+string(rune('a'+i)), base.Add(offset))
+		if err != nil {
+			t.Fatalf("failed to insert transaction: %v", err)
+		}
+	}
+
+	history, err := SQLHistory{DB: db}.TransactionsBetween("acc-1", base.Add(-24*time.Hour), base)
+	if err != nil {
+		t.Fatalf("TransactionsBetween failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 transactions in window, got %d", len(history))
+	}
+	if history[0].TransactionID != "tx-b" || history[1].TransactionID != "tx-c" {
+		t.Errorf("Expected oldest first, got %s, %s", history[0].TransactionID, history[1].TransactionID)
+	}
+}
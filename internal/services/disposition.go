@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+// ErrInvalidDisposition is returned when a disposition code is unknown or does not fit the status
+// an alert is moved to.
+var ErrInvalidDisposition = fmt.Errorf("invalid disposition code")
+
+// dispositionCodeField is the transition field carrying the disposition code.
+const dispositionCodeField = "disposition_code"
+
+// dispositionCatalog indexes the configured disposition codes.
+type dispositionCatalog struct {
+	codes  []config.DispositionCode
+	byCode map[string]config.DispositionCode
+}
+
+// dispositions is the active catalogue. It starts as the built-in default and is replaced once at
+// startup by ConfigureDispositionCodes.
+var dispositions = mustCompileDispositions(config.DefaultDispositionConfig())
+
+// ConfigureDispositionCodes validates and installs the disposition codes. It must be called before
+// alerts are closed, as the active catalogue is not guarded for concurrent replacement.
+func ConfigureDispositionCodes(cfg config.DispositionConfig) error {
+	catalog, err := compileDispositions(cfg)
+	if err != nil {
+		return err
+	}
+	dispositions = catalog
+	return nil
+}
+
+func compileDispositions(cfg config.DispositionConfig) (*dispositionCatalog, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid disposition config: %w", err)
+	}
+	catalog := &dispositionCatalog{codes: cfg.Codes, byCode: make(map[string]config.DispositionCode, len(cfg.Codes))}
+	for _, d := range cfg.Codes {
+		catalog.byCode[d.Code] = d
+	}
+	return catalog, nil
+}
+
+func mustCompileDispositions(cfg config.DispositionConfig) *dispositionCatalog {
+	catalog, err := compileDispositions(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// DispositionCodes returns the configured disposition codes.
+func DispositionCodes() []config.DispositionCode {
+	return dispositions.codes
+}
+
+// DispositionOutcome returns whether a disposition code marks a false or true positive.
+func DispositionOutcome(code string) (string, bool) {
+	d, ok := dispositions.byCode[code]
+	return d.Outcome, ok
+}
+
+// checkDisposition verifies that a code may close an alert to the given final status.
+func checkDisposition(code, status string, final bool) error {
+	d, ok := dispositions.byCode[code]
+	if !ok {
+		return fmt.Errorf("%w: unknown code %s", ErrInvalidDisposition, code)
+	}
+	if !final {
+		return fmt.Errorf("%w: %s is not a final status", ErrInvalidDisposition, status)
+	}
+	if len(d.Statuses) > 0 && !models.StringList(d.Statuses).Contains(status) {
+		return fmt.Errorf("%w: %s cannot close an alert as %s", ErrInvalidDisposition, code, status)
+	}
+	return nil
+}
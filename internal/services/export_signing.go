@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"AML/internal/config"
+)
+
+// ErrExportInvalid is returned when an export file does not match its manifest or signature.
+var ErrExportInvalid = fmt.Errorf("export failed verification")
+
+const (
+	exportArtifactVersion     = 1
+	exportEncryptionAlgorithm = "AES-256-GCM"
+)
+
+// ExportManifest describes an export file as written: its SHA-256 digest and size, how it was
+// encrypted, and the key that signed the manifest. The manifest is written beside the export and
+// signed with a detached Ed25519 signature, so any change to either is detected.
+type ExportManifest struct {
+	Version int `json:"version"`
+	// File is the export's base name, so a manifest cannot be reused for another file.
+	File      string    `json:"file"`
+	Format    string    `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	// Encryption is set when the file is an encrypted envelope rather than the export itself.
+	Encryption   *ExportEncryption `json:"encryption,omitempty"`
+	SigningKeyID string            `json:"signing_key_id"`
+}
+
+// ExportEncryption records how an export was encrypted and the digest of its plaintext.
+type ExportEncryption struct {
+	Algorithm       string `json:"algorithm"`
+	KeyID           string `json:"key_id"`
+	PlaintextSHA256 string `json:"plaintext_sha256"`
+}
+
+// exportEnvelope is the file written for an encrypted export. The export is sealed with a fresh
+// data key, and the data key is sealed with the keyring's key-encryption key.
+type exportEnvelope struct {
+	Version    int    `json:"version"`
+	Algorithm  string `json:"algorithm"`
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	WrapNonce  []byte `json:"wrap_nonce"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// ExportManifestPath returns where the manifest of the export at path is written.
+func ExportManifestPath(path string) string {
+	return path + ".manifest.json"
+}
+
+// ExportSignaturePath returns where the signature of the export at path is written.
+func ExportSignaturePath(path string) string {
+	return path + ".sig"
+}
+
+// WriteSignedExport writes an export to path, as an encrypted envelope when encrypt is set,
+// followed by its manifest and the manifest's signature. It signs with the keyring's signing key
+// and encrypts with its encryption key. Every file is written with 0600 permissions.
+func WriteSignedExport(keyring config.KeyringConfig, path, format string, data []byte, encrypt bool, now time.Time) (*ExportManifest, error) {
+	signer, err := keyring.SigningKey(keyring.SigningKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ExportManifest{
+		Version:      exportArtifactVersion,
+		File:         filepath.Base(path),
+		Format:       format,
+		CreatedAt:    now.UTC(),
+		SigningKeyID: keyring.SigningKeyID,
+	}
+	if encrypt {
+		kek, err := keyring.EncryptionKey(keyring.EncryptionKeyID)
+		if err != nil {
+			return nil, err
+		}
+		envelope, err := sealExport(kek, keyring.EncryptionKeyID, data)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Encryption = &ExportEncryption{
+			Algorithm:       exportEncryptionAlgorithm,
+			KeyID:           keyring.EncryptionKeyID,
+			PlaintextSHA256: sha256Hex(data),
+		}
+		data = envelope
+	}
+	manifest.Size = int64(len(data))
+	manifest.SHA256 = sha256Hex(data)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal export manifest: %w", err)
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(signer, manifestData)) + "\n"
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write export to file: %w", err)
+	}
+	if err := os.WriteFile(ExportManifestPath(path), manifestData, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write export manifest: %w", err)
+	}
+	if err := os.WriteFile(ExportSignaturePath(path), []byte(signature), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write export signature: %w", err)
+	}
+	return manifest, nil
+}
+
+// VerifyExport checks the export at path against its manifest and signature: the signature must
+// be valid for the manifest under the signing key it names, and the file must have the manifest's
+// name, size and digest. It returns the manifest and the export's content. Encrypted exports are
+// decrypted and checked against their plaintext digest when the keyring holds their key;
+// otherwise the content returned is nil. Failed checks return an error wrapping
+// ErrExportInvalid.
+func VerifyExport(keyring config.KeyringConfig, path string) (*ExportManifest, []byte, error) {
+	manifestData, err := os.ReadFile(ExportManifestPath(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read export manifest: %w", err)
+	}
+	encodedSignature, err := os.ReadFile(ExportSignaturePath(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read export signature: %w", err)
+	}
+	var manifest ExportManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("%w: manifest is not valid JSON", ErrExportInvalid)
+	}
+	if manifest.Version != exportArtifactVersion {
+		return nil, nil, fmt.Errorf("%w: unsupported manifest version %d", ErrExportInvalid, manifest.Version)
+	}
+
+	publicKey, err := keyring.PublicKey(manifest.SigningKeyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encodedSignature)))
+	if err != nil || !ed25519.Verify(publicKey, manifestData, signature) {
+		return nil, nil, fmt.Errorf("%w: manifest signature is not valid for key %s", ErrExportInvalid, manifest.SigningKeyID)
+	}
+
+	if manifest.File != filepath.Base(path) {
+		return nil, nil, fmt.Errorf("%w: manifest is for %s, not %s", ErrExportInvalid, manifest.File, filepath.Base(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	if int64(len(data)) != manifest.Size || sha256Hex(data) != manifest.SHA256 {
+		return nil, nil, fmt.Errorf("%w: %s does not match its manifest digest", ErrExportInvalid, manifest.File)
+	}
+
+	if manifest.Encryption == nil {
+		return &manifest, data, nil
+	}
+	kek, err := keyring.EncryptionKey(manifest.Encryption.KeyID)
+	if err != nil {
+		return &manifest, nil, nil
+	}
+	plaintext, err := openExport(kek, manifest.Encryption.KeyID, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sha256Hex(plaintext) != manifest.Encryption.PlaintextSHA256 {
+		return nil, nil, fmt.Errorf("%w: decrypted content does not match its manifest digest", ErrExportInvalid)
+	}
+	return &manifest, plaintext, nil
+}
+
+// NewKeyring returns a keyring with a fresh signing key and encryption key under the given IDs.
+func NewKeyring(signingKeyID, encryptionKeyID string) (config.KeyringConfig, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	kek := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return config.KeyringConfig{}, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	if _, err := rand.Read(kek); err != nil {
+		return config.KeyringConfig{}, fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	return config.KeyringConfig{
+		SigningKeyID:    signingKeyID,
+		SigningKeys:     map[string]string{signingKeyID: base64.StdEncoding.EncodeToString(seed)},
+		EncryptionKeyID: encryptionKeyID,
+		EncryptionKeys:  map[string]string{encryptionKeyID: base64.StdEncoding.EncodeToString(kek)},
+	}, nil
+}
+
+// sealExport encrypts data under a fresh data key and wraps the data key with kek. The key ID is
+// bound into both seals so an envelope can't be relabelled with another key.
+func sealExport(kek []byte, keyID string, data []byte) ([]byte, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	envelope := exportEnvelope{Version: exportArtifactVersion, Algorithm: exportEncryptionAlgorithm, KeyID: keyID}
+	var err error
+	if envelope.Nonce, envelope.Ciphertext, err = gcmSeal(dataKey, data, exportAAD(keyID)); err != nil {
+		return nil, err
+	}
+	if envelope.WrapNonce, envelope.WrappedKey, err = gcmSeal(kek, dataKey, exportAAD(keyID)); err != nil {
+		return nil, err
+	}
+	sealed, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal export envelope: %w", err)
+	}
+	return sealed, nil
+}
+
+// openExport unwraps an envelope's data key with kek and decrypts the export.
+func openExport(kek []byte, keyID string, sealed []byte) ([]byte, error) {
+	var envelope exportEnvelope
+	if err := json.Unmarshal(sealed, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: encrypted export is not a valid envelope", ErrExportInvalid)
+	}
+	if envelope.Algorithm != exportEncryptionAlgorithm || envelope.KeyID != keyID {
+		return nil, fmt.Errorf("%w: envelope is not %s under key %s", ErrExportInvalid, exportEncryptionAlgorithm, keyID)
+	}
+	dataKey, err := gcmOpen(kek, envelope.WrapNonce, envelope.WrappedKey, exportAAD(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: data key does not unwrap with key %s", ErrExportInvalid, keyID)
+	}
+	plaintext, err := gcmOpen(dataKey, envelope.Nonce, envelope.Ciphertext, exportAAD(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: export does not decrypt", ErrExportInvalid)
+	}
+	return plaintext, nil
+}
+
+func exportAAD(keyID string) []byte {
+	return []byte(fmt.Sprintf("aml-export/v%d/%s", exportArtifactVersion, keyID))
+}
+
+func gcmSeal(key, plaintext, aad []byte) (nonce, ciphertext []byte, err error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+func gcmOpen(key, nonce, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size")
+	}
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
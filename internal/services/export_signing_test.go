@@ -0,0 +1,155 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedExports(t *testing.T) {
+	keyring, err := NewKeyring("sign-1", "enc-1")
+	if err != nil {
+		t.Fatalf("NewKeyring failed: %v", err)
+	}
+	data, err := MarshalSARJSON(testSARReport())
+	if err != nil {
+		t.Fatalf("MarshalSARJSON failed: %v", err)
+	}
+	now := time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)
+
+	// Test Case 1: a plain export is written as is with a manifest and signature that verify
+	t.Run("signed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sar.json")
+		manifest, err := WriteSignedExport(keyring, path, "json", data, false, now)
+		if err != nil {
+			t.Fatalf("WriteSignedExport failed: %v", err)
+		}
+		written, _ := os.ReadFile(path)
+		if !bytes.Equal(written, data) {
+			t.Errorf("Expected the export to be written unencrypted")
+		}
+		if manifest.File != "sar.json" || manifest.Size != int64(len(data)) || manifest.SHA256 != sha256Hex(data) || manifest.Encryption != nil {
+			t.Errorf("Unexpected manifest: %+v", manifest)
+		}
+		for _, p := range []string{path, ExportManifestPath(path), ExportSignaturePath(path)} {
+			info, err := os.Stat(p)
+			if err != nil {
+				t.Fatalf("Expected %s to be written: %v", p, err)
+			}
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("Expected %s to be written with 0600, got %o", p, info.Mode().Perm())
+			}
+		}
+
+		verified, content, err := VerifyExport(keyring, path)
+		if err != nil {
+			t.Fatalf("VerifyExport failed: %v", err)
+		}
+		if verified.SHA256 != manifest.SHA256 || !bytes.Equal(content, data) {
+			t.Errorf("Expected the verified manifest and content to match what was written")
+		}
+	})
+
+	// Test Case 2: an encrypted export holds no plaintext and decrypts on verification
+	t.Run("encrypted", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sar.json")
+		manifest, err := WriteSignedExport(keyring, path, "json", data, true, now)
+		if err != nil {
+			t.Fatalf("WriteSignedExport failed: %v", err)
+		}
+		written, _ := os.ReadFile(path)
+		if bytes.Contains(written, []byte("Jane Q Doe")) || bytes.Contains(written, []byte("subject")) {
+			t.Errorf("Expected no plaintext in the encrypted export")
+		}
+		if manifest.Encryption == nil || manifest.Encryption.KeyID != "enc-1" || manifest.Encryption.PlaintextSHA256 != sha256Hex(data) {
+			t.Errorf("Unexpected encryption in manifest: %+v", manifest.Encryption)
+		}
+
+		_, content, err := VerifyExport(keyring, path)
+		if err != nil {
+			t.Fatalf("VerifyExport failed: %v", err)
+		}
+		if !bytes.Equal(content, data) {
+			t.Errorf("Expected the export to decrypt to the original")
+		}
+
+		// A verifier holding only the public key checks integrity but can't read the content.
+		public := keyring
+		public.SigningKeys = nil
+		public.EncryptionKeys = nil
+		signer, _ := keyring.PublicKey("sign-1")
+		public.PublicKeys = map[string]string{"sign-1": base64.StdEncoding.EncodeToString(signer)}
+		_, content, err = VerifyExport(public, path)
+		if err != nil || content != nil {
+			t.Errorf("Expected verification without decryption, got content %d bytes, err %v", len(content), err)
+		}
+
+		// A different key of the same ID doesn't unwrap the data key.
+		other, _ := NewKeyring("sign-1", "enc-1")
+		other.SigningKeys = keyring.SigningKeys
+		if _, _, err := VerifyExport(other, path); !errors.Is(err, ErrExportInvalid) {
+			t.Errorf("Expected ErrExportInvalid with the wrong encryption key, got %v", err)
+		}
+	})
+
+	// Test Case 3: changes to the export, its manifest or its signature fail verification
+	t.Run("tampered", func(t *testing.T) {
+		tamper := map[string]func(path string){
+			"export": func(path string) {
+				os.WriteFile(path, bytes.Replace(data, []byte("Jane Q Doe"), []byte("Jane R Doe"), 1), 0600)
+			},
+			"manifest": func(path string) {
+				m, _ := os.ReadFile(ExportManifestPath(path))
+				os.WriteFile(ExportManifestPath(path), bytes.Replace(m, []byte(`"json"`), []byte(`"html"`), 1), 0600)
+			},
+			"signature": func(path string) {
+				other, _ := NewKeyring("sign-1", "enc-1")
+				otherPath := filepath.Join(filepath.Dir(path), "other", "sar.json")
+				os.Mkdir(filepath.Dir(otherPath), 0700)
+				WriteSignedExport(other, otherPath, "json", data, false, now)
+				sig, _ := os.ReadFile(ExportSignaturePath(otherPath))
+				os.WriteFile(ExportSignaturePath(path), sig, 0600)
+			},
+			"renamed": func(path string) {
+				for _, p := range []string{"", ".manifest.json", ".sig"} {
+					os.Rename(path+p, filepath.Join(filepath.Dir(path), "renamed.json"+p))
+				}
+			},
+		}
+		for name, change := range tamper {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "sar.json")
+			if _, err := WriteSignedExport(keyring, path, "json", data, false, now); err != nil {
+				t.Fatalf("WriteSignedExport failed: %v", err)
+			}
+			change(path)
+			if name == "renamed" {
+				path = filepath.Join(dir, "renamed.json")
+			}
+			if _, _, err := VerifyExport(keyring, path); !errors.Is(err, ErrExportInvalid) {
+				t.Errorf("%s: expected ErrExportInvalid, got %v", name, err)
+			}
+		}
+
+		// The encrypted envelope is covered by the manifest too.
+		path := filepath.Join(t.TempDir(), "sar.json")
+		if _, err := WriteSignedExport(keyring, path, "json", data, true, now); err != nil {
+			t.Fatalf("WriteSignedExport failed: %v", err)
+		}
+		var envelope map[string]interface{}
+		written, _ := os.ReadFile(path)
+		json.Unmarshal(written, &envelope)
+		envelope["key_id"] = "enc-2"
+		written, _ = json.Marshal(envelope)
+		os.WriteFile(path, written, 0600)
+		if _, _, err := VerifyExport(keyring, path); err == nil || !strings.Contains(err.Error(), "digest") {
+			t.Errorf("Expected a digest mismatch for a changed envelope, got %v", err)
+		}
+	})
+}
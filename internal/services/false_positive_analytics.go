@@ -0,0 +1,337 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// ErrInvalidReportPeriod is returned for a reporting period other than day, week or month.
+var ErrInvalidReportPeriod = fmt.Errorf("invalid report period")
+
+// DispositionAnalytics reports false-positive rates from alert dispositions and recommends rule
+// thresholds from them.
+type DispositionAnalytics struct {
+	cfg config.DispositionConfig
+}
+
+// NewDispositionAnalytics builds the analytics for a validated configuration.
+func NewDispositionAnalytics(cfg config.DispositionConfig) (*DispositionAnalytics, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &DispositionAnalytics{cfg: cfg}, nil
+}
+
+// dispositionedFinding is one finding of a closed alert with the outcome it was closed with.
+type dispositionedFinding struct {
+	ruleKey       string
+	detectorID    string
+	details       models.JSONMap
+	falsePositive bool
+	closedAt      time.Time
+}
+
+// FalsePositiveReport returns the false-positive rate per rule, per detector and per threshold
+// band for alerts dispositioned between from and to, broken down by period when one is given.
+// Each finding of a composite alert counts separately. Zero times leave the range open.
+func (a *DispositionAnalytics) FalsePositiveReport(db database.DBTX, from, to time.Time, period string) (*models.FalsePositiveReport, error) {
+	switch period {
+	case "", models.ReportPeriodDay, models.ReportPeriodWeek, models.ReportPeriodMonth:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReportPeriod, period)
+	}
+
+	findings, err := loadDispositionedFindings(db, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byRule := make(map[string]*models.FalsePositiveRate)
+	byDetector := make(map[string]*models.FalsePositiveRate)
+	byBand := make(map[string]*models.FalsePositiveRate)
+	for _, f := range findings {
+		p := reportPeriod(f.closedAt, period)
+		tallyFalsePositive(byRule, models.FalsePositiveRate{Key: f.ruleKey, Period: p}, f.falsePositive)
+		tallyFalsePositive(byDetector, models.FalsePositiveRate{Key: f.detectorID, Period: p}, f.falsePositive)
+		if band, ok := a.thresholdBand(f.details); ok {
+			tallyFalsePositive(byBand, models.FalsePositiveRate{Key: f.ruleKey, Band: band, Period: p}, f.falsePositive)
+		}
+	}
+
+	return &models.FalsePositiveReport{
+		From:            from,
+		To:              to,
+		Period:          period,
+		ByRule:          sortedFalsePositiveRates(byRule),
+		ByDetector:      sortedFalsePositiveRates(byDetector),
+		ByThresholdBand: sortedFalsePositiveRates(byBand),
+	}, nil
+}
+
+// RecommendThresholds suggests, for each enabled rule that fires above its threshold, the
+// configured threshold step that would have removed the most false positives among the alerts
+// dispositioned between from and to without dropping more than the allowed share of true
+// positives. Rules for which no step removes a false positive are left out.
+func (a *DispositionAnalytics) RecommendThresholds(db database.DBTX, rules []config.Rule, from, to time.Time) ([]models.ThresholdRecommendation, error) {
+	findings, err := loadDispositionedFindings(db, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := []models.ThresholdRecommendation{}
+	for _, rule := range rules {
+		if !rule.Enabled || rule.ThresholdValue <= 0 {
+			continue
+		}
+
+		var fpValues, tpValues []float64
+		for _, f := range findings {
+			if f.ruleKey != rule.RuleID {
+				continue
+			}
+			actual, ok := numericDetail(f.details, "actual_value")
+			if !ok || actual <= rule.ThresholdValue {
+				continue
+			}
+			if f.falsePositive {
+				fpValues = append(fpValues, actual)
+			} else {
+				tpValues = append(tpValues, actual)
+			}
+		}
+		if len(fpValues) == 0 {
+			continue
+		}
+
+		var best *models.ThresholdRecommendation
+		for _, step := range a.cfg.TuningSteps {
+			candidate := round2(rule.ThresholdValue * step)
+			removedFP, removedTP := countAtOrBelow(fpValues, candidate), countAtOrBelow(tpValues, candidate)
+			tpShare := 0.0
+			if len(tpValues) > 0 {
+				tpShare = float64(removedTP) / float64(len(tpValues))
+			}
+			if removedFP == 0 || tpShare > a.cfg.MaxTruePositiveLoss {
+				continue
+			}
+			if best != nil && removedFP <= best.RemovedFalsePositives {
+				continue
+			}
+			best = &models.ThresholdRecommendation{
+				RuleID:                    rule.RuleID,
+				CurrentThreshold:          rule.ThresholdValue,
+				SuggestedThreshold:        candidate,
+				FalsePositives:            len(fpValues),
+				TruePositives:             len(tpValues),
+				RemovedFalsePositives:     removedFP,
+				RemovedTruePositives:      removedTP,
+				RemovedFalsePositiveShare: round4(float64(removedFP) / float64(len(fpValues))),
+				RemovedTruePositiveShare:  round4(tpShare),
+			}
+		}
+		if best == nil {
+			continue
+		}
+		best.Summary = fmt.Sprintf("raising %s to %s would remove %.0f%% of FPs and %.0f%% of true positives",
+			rule.RuleID, formatThreshold(best.SuggestedThreshold), best.RemovedFalsePositiveShare*100, best.RemovedTruePositiveShare*100)
+		recommendations = append(recommendations, *best)
+	}
+	return recommendations, nil
+}
+
+// thresholdBand names the band of how far a finding's observed value exceeded its rule threshold,
+// e.g. "1.10-1.25x". Findings without both values, or below their threshold, have no band.
+func (a *DispositionAnalytics) thresholdBand(details models.JSONMap) (string, bool) {
+	actual, ok := numericDetail(details, "actual_value")
+	if !ok {
+		return "", false
+	}
+	threshold, ok := numericDetail(details, "threshold_value")
+	if !ok || threshold <= 0 || actual <= threshold {
+		return "", false
+	}
+
+	ratio := actual / threshold
+	lower := 1.0
+	for _, edge := range a.cfg.ThresholdBands {
+		if ratio <= edge {
+			return fmt.Sprintf("%.2f-%.2fx", lower, edge), true
+		}
+		lower = edge
+	}
+	return fmt.Sprintf("%.2fx+", lower), true
+}
+
+// loadDispositionedFindings returns the findings of alerts closed with a known disposition code
+// between from and to, in the order they were closed.
+func loadDispositionedFindings(db database.DBTX, from, to time.Time) ([]dispositionedFinding, error) {
+	query := `SELECT ` + alertSelectColumns + ` FROM alerts WHERE disposition_code != ''`
+	var args []interface{}
+	if !from.IsZero() {
+		query += ` AND transition_at >= ?`
+		args = append(args, from.UTC())
+	}
+	if !to.IsZero() {
+		query += ` AND transition_at < ?`
+		args = append(args, to.UTC())
+	}
+	query += ` ORDER BY transition_at ASC, id ASC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dispositioned alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []dispositionedFinding
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		}
+		outcome, ok := DispositionOutcome(alert.DispositionCode)
+		if !ok {
+			continue // The code has since been retired from the catalogue
+		}
+
+		alertFindings := alert.Findings
+		if len(alertFindings) == 0 {
+			alertFindings = models.AlertFindings{{AlertType: alert.AlertType, DedupKey: alert.DedupKey, RuleDetails: alert.RuleDetails}}
+		}
+		for _, f := range alertFindings {
+			findings = append(findings, dispositionedFinding{
+				ruleKey:       findingRuleKey(f),
+				detectorID:    findingDetectorID(f),
+				details:       f.RuleDetails,
+				falsePositive: outcome == config.DispositionFalsePositive,
+				closedAt:      alert.TransitionAt,
+			})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+	}
+
+	return findings, nil
+}
+
+// findingRuleKey identifies the rule behind a finding: its rule ID, or its dedup key for detectors
+// without rules. Alerts raised before either was recorded fall back to their type.
+func findingRuleKey(f models.AlertFinding) string {
+	if id, ok := f.RuleDetails["rule_id"].(string); ok && id != "" {
+		return id
+	}
+	if f.DedupKey != "" {
+		return f.DedupKey
+	}
+	return f.AlertType
+}
+
+func findingDetectorID(f models.AlertFinding) string {
+	if id, ok := f.RuleDetails["detector_id"].(string); ok && id != "" {
+		return id
+	}
+	return "unknown"
+}
+
+func tallyFalsePositive(rates map[string]*models.FalsePositiveRate, key models.FalsePositiveRate, falsePositive bool) {
+	k := key.Key + "\x00" + key.Band + "\x00" + key.Period
+	r, ok := rates[k]
+	if !ok {
+		r = &key
+		rates[k] = r
+	}
+	r.Dispositioned++
+	if falsePositive {
+		r.FalsePositives++
+	}
+	r.Rate = round4(float64(r.FalsePositives) / float64(r.Dispositioned))
+}
+
+func sortedFalsePositiveRates(rates map[string]*models.FalsePositiveRate) []models.FalsePositiveRate {
+	sorted := make([]models.FalsePositiveRate, 0, len(rates))
+	for _, r := range rates {
+		sorted = append(sorted, *r)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Key != sorted[j].Key {
+			return sorted[i].Key < sorted[j].Key
+		}
+		if sorted[i].Band != sorted[j].Band {
+			return sorted[i].Band < sorted[j].Band
+		}
+		return sorted[i].Period < sorted[j].Period
+	})
+	return sorted
+}
+
+// reportPeriod labels the day, ISO week or month t falls in, in UTC.
+func reportPeriod(t time.Time, period string) string {
+	t = t.UTC()
+	switch period {
+	case models.ReportPeriodDay:
+		return t.Format("2006-01-02")
+	case models.ReportPeriodWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case models.ReportPeriodMonth:
+		return t.Format("2006-01")
+	}
+	return ""
+}
+
+// numericDetail reads a number from rule details, which hold float64 values when built in memory
+// and json.Number or float64 values when loaded.
+func numericDetail(details models.JSONMap, key string) (float64, bool) {
+	switch v := details[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func countAtOrBelow(values []float64, limit float64) int {
+	n := 0
+	for _, v := range values {
+		if v <= limit {
+			n++
+		}
+	}
+	return n
+}
+
+// formatThreshold writes a threshold with thousands separators, e.g. 12,000 or 1,250.5.
+func formatThreshold(v float64) string {
+	return groupThousands(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// groupThousands inserts thousands separators into a formatted decimal number.
+func groupThousands(s string) string {
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i:]
+	}
+	for i := len(whole) - 3; i > 0 && whole[i-1] != '-'; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return whole + frac
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+func TestDispositionAnalytics(t *testing.T) {
+	may := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	const ruleID = "single_transaction_exceeds_10000"
+	rules := []config.Rule{{RuleID: ruleID, ThresholdValue: 10000, TimeWindow: "0h", Enabled: true}}
+
+	ruleDetails := func(actual float64) models.JSONMap {
+		return models.JSONMap{"rule_id": ruleID, "detector_id": DetectorThresholdRules, "actual_value": actual, "threshold_value": 10000.0}
+	}
+	seed := func(t *testing.T) (*sql.DB, *DispositionAnalytics) {
+		db := newTestDB(t)
+		closed := []struct {
+			actual float64
+			code   string
+			at     time.Time
+		}{
+			{10500, "FP_KNOWN_CUSTOMER", may},
+			{11000, "FP_KNOWN_CUSTOMER", may},
+			{11500, "FP_RULE_TOO_SENSITIVE", may},
+			{11800, "FP_RULE_TOO_SENSITIVE", june},
+			{15000, "FP_KNOWN_CUSTOMER", june},
+			{14000, "TP_SAR_FILED", may},
+			{20000, "TP_SAR_FILED", june},
+			{25000, "TP_NO_SAR", june},
+			{30000, "TP_SAR_FILED", june},
+			{40000, "TP_SAR_FILED", june},
+		}
+		for i, c := range closed {
+			alert := &models.Alert{
+				ID: fmt.Sprintf("alert-%d", i), TransactionID: fmt.Sprintf("tx-%d", i), AccountID: "acc-1",
+				AlertType: AlertTypeThresholdViolation, Status: models.StatusClosed, DedupKey: ruleID,
+				RuleDetails: ruleDetails(c.actual), DispositionCode: c.code,
+				CreatedAt: c.at.Add(-time.Hour), TransitionAt: c.at.Add(time.Duration(i) * time.Minute),
+			}
+			if err := SaveAlert(db, alert); err != nil {
+				t.Fatalf("SaveAlert failed: %v", err)
+			}
+		}
+
+		// A composite false positive counts once per finding.
+		composite := &models.Alert{
+			ID: "alert-composite", TransactionID: "tx-c", AccountID: "acc-1", AlertType: AlertTypeStructuringPattern,
+			Status: models.StatusFalsePositive, DispositionCode: "FP_DATA_ERROR", CreatedAt: june, TransitionAt: june.Add(time.Hour),
+			Findings: models.AlertFindings{
+				{AlertType: AlertTypeThresholdViolation, DedupKey: ruleID, RuleDetails: ruleDetails(10200)},
+				{AlertType: AlertTypeStructuringPattern, DedupKey: "structuring/STRUCTURING_PATTERN", RuleDetails: models.JSONMap{"detector_id": "structuring"}},
+			},
+		}
+		if err := SaveAlert(db, composite); err != nil {
+			t.Fatalf("SaveAlert failed: %v", err)
+		}
+		// Open alerts have no disposition and are left out.
+		if err := SaveAlert(db, &models.Alert{ID: "alert-open", TransactionID: "tx-o", AccountID: "acc-1", AlertType: AlertTypeThresholdViolation, Status: models.StatusOpen, DedupKey: ruleID, RuleDetails: ruleDetails(10100), CreatedAt: june, TransitionAt: june}); err != nil {
+			t.Fatalf("SaveAlert failed: %v", err)
+		}
+
+		analytics, err := NewDispositionAnalytics(config.DefaultDispositionConfig())
+		if err != nil {
+			t.Fatalf("NewDispositionAnalytics failed: %v", err)
+		}
+		return db, analytics
+	}
+
+	// Test Case 1: Disposition codes are checked against the catalogue and the target status
+	t.Run("disposition_codes", func(t *testing.T) {
+		workflows, err := config.LoadWorkflowConfig("../../workflows.json")
+		if err != nil {
+			t.Fatalf("LoadWorkflowConfig failed: %v", err)
+		}
+		if err := ConfigureAlertWorkflows(workflows); err != nil {
+			t.Fatalf("ConfigureAlertWorkflows failed: %v", err)
+		}
+		defer ConfigureAlertWorkflows(config.DefaultWorkflowConfig())
+
+		db := newTestDB(t)
+		seedAlerts(t, db, may)
+		investigate := AlertTransitionRequest{AlertID: "alert-0", NewStatus: models.StatusInvestigating, Actor: "analyst-1", Comment: "Looking"}
+		if _, _, err := ApplyAlertTransition(db, investigate); err != nil {
+			t.Fatalf("ApplyAlertTransition failed: %v", err)
+		}
+
+		tests := []struct {
+			name string
+			code string
+		}{
+			{"unknown_code", "FP_BECAUSE"},
+			{"true_positive_code", "TP_SAR_FILED"},
+		}
+		for _, tt := range tests {
+			_, _, err := ApplyAlertTransition(db, AlertTransitionRequest{
+				AlertID: "alert-0", NewStatus: models.StatusFalsePositive, Actor: "analyst-1", Comment: "Benign",
+				Fields: map[string]string{"disposition_code": tt.code},
+			})
+			if !errors.Is(err, ErrInvalidDisposition) {
+				t.Errorf("%s: expected ErrInvalidDisposition, got %v", tt.name, err)
+			}
+		}
+
+		alert, _, err := ApplyAlertTransition(db, AlertTransitionRequest{
+			AlertID: "alert-0", NewStatus: models.StatusFalsePositive, Actor: "analyst-1", Comment: "Benign",
+			Fields: map[string]string{"disposition_code": "FP_KNOWN_CUSTOMER"},
+		})
+		if err != nil {
+			t.Fatalf("ApplyAlertTransition failed: %v", err)
+		}
+		if stored, _ := GetAlert(db, alert.ID); stored.DispositionCode != "FP_KNOWN_CUSTOMER" {
+			t.Errorf("Expected the disposition code to be stored, got %q", stored.DispositionCode)
+		}
+
+		_, _, err = ApplyAlertTransition(db, AlertTransitionRequest{AlertID: "alert-0", NewStatus: models.StatusOpen, Actor: "supervisor-1", Comment: "Reopened", Roles: []string{"supervisor"}})
+		if err != nil {
+			t.Fatalf("ApplyAlertTransition failed: %v", err)
+		}
+		if stored, _ := GetAlert(db, "alert-0"); stored.DispositionCode != "" {
+			t.Errorf("Expected reopening to clear the disposition code, got %q", stored.DispositionCode)
+		}
+	})
+
+	// Test Case 2: False-positive rates are reported per rule, detector, threshold band and period
+	t.Run("report", func(t *testing.T) {
+		db, analytics := seed(t)
+		report, err := analytics.FalsePositiveReport(db, time.Time{}, time.Time{}, "")
+		if err != nil {
+			t.Fatalf("FalsePositiveReport failed: %v", err)
+		}
+
+		rates := make(map[string]models.FalsePositiveRate)
+		for _, r := range report.ByRule {
+			rates[r.Key] = r
+		}
+		if r := rates[ruleID]; r.Dispositioned != 11 || r.FalsePositives != 6 {
+			t.Errorf("Expected 6 of 11 findings to be false positives, got %+v", r)
+		}
+		if r := rates["structuring/STRUCTURING_PATTERN"]; r.Dispositioned != 1 || r.Rate != 1 {
+			t.Errorf("Expected the composite's structuring finding to count, got %+v", r)
+		}
+		if len(report.ByDetector) != 2 || report.ByDetector[0].Key != "structuring" {
+			t.Errorf("Expected rates for both detectors, got %+v", report.ByDetector)
+		}
+
+		bands := make(map[string]models.FalsePositiveRate)
+		for _, r := range report.ByThresholdBand {
+			bands[r.Band] = r
+		}
+		if r := bands["1.00-1.10x"]; r.Dispositioned != 3 || r.Rate != 1 {
+			t.Errorf("Expected the band just above the threshold to be all false positives, got %+v", r)
+		}
+		if r := bands["2.00x+"]; r.Dispositioned != 3 || r.FalsePositives != 0 {
+			t.Errorf("Expected the top band to be all true positives, got %+v", r)
+		}
+
+		monthly, err := analytics.FalsePositiveReport(db, time.Time{}, time.Time{}, models.ReportPeriodMonth)
+		if err != nil {
+			t.Fatalf("FalsePositiveReport failed: %v", err)
+		}
+		periods := make(map[string]models.FalsePositiveRate)
+		for _, r := range monthly.ByRule {
+			if r.Key == ruleID {
+				periods[r.Period] = r
+			}
+		}
+		if periods["2024-05"].Dispositioned != 4 || periods["2024-06"].Dispositioned != 7 {
+			t.Errorf("Expected 4 findings in May and 7 in June, got %+v", periods)
+		}
+
+		if _, err := analytics.FalsePositiveReport(db, time.Time{}, time.Time{}, "fortnight"); !errors.Is(err, ErrInvalidReportPeriod) {
+			t.Errorf("Expected ErrInvalidReportPeriod, got %v", err)
+		}
+	})
+
+	// Test Case 3: The recommender picks the step removing the most false positives within the true-positive budget
+	t.Run("recommend", func(t *testing.T) {
+		db, analytics := seed(t)
+		recommendations, err := analytics.RecommendThresholds(db, rules, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("RecommendThresholds failed: %v", err)
+		}
+		if len(recommendations) != 1 {
+			t.Fatalf("Expected 1 recommendation, got %+v", recommendations)
+		}
+		// 15,000 would also remove the 15,000 false positive but drops the 14,000 true positive.
+		r := recommendations[0]
+		if r.SuggestedThreshold != 12000 || r.RemovedFalsePositives != 5 || r.RemovedTruePositives != 0 {
+			t.Errorf("Expected 12000 removing 5 false positives, got %+v", r)
+		}
+		want := "raising single_transaction_exceeds_10000 to 12,000 would remove 83% of FPs and 0% of true positives"
+		if r.Summary != want {
+			t.Errorf("Expected summary %q, got %q", want, r.Summary)
+		}
+
+		// Nothing is recommended for a window without dispositions.
+		none, err := analytics.RecommendThresholds(db, rules, june.AddDate(0, 1, 0), time.Time{})
+		if err != nil || len(none) != 0 {
+			t.Errorf("Expected no recommendations, got %+v, %v", none, err)
+		}
+	})
+}
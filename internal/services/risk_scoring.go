@@ -0,0 +1,184 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// ScoringInput is the evidence the risk scoring model weighs for one alert.
+type ScoringInput struct {
+	Alert       *models.Alert
+	Transaction models.Transaction
+	// Profile is the account's profile before the transaction; it may be nil.
+	Profile     *models.AccountProfile
+	CountryRisk config.CountryRisk
+	// CustomerRiskRating is the account holder's KYC rating; empty when unknown.
+	CustomerRiskRating string
+	// PriorAlerts counts the account's alerts raised within the lookback before this one.
+	PriorAlerts int
+	// RulesFired counts the findings raised for the transaction, this one included.
+	RulesFired int
+}
+
+// RiskScorer computes alert risk scores as a weighted average of factor risks.
+type RiskScorer struct {
+	cfg      config.ScoringConfig
+	lookback time.Duration
+	// factors lists the weighted factors in a fixed order with their normalised weights.
+	factors []weightedFactor
+}
+
+type weightedFactor struct {
+	name   string
+	weight float64
+}
+
+// NewRiskScorer builds a scorer from a validated configuration.
+func NewRiskScorer(cfg config.ScoringConfig) (*RiskScorer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	lookback, _ := cfg.GetPriorAlertsLookback()
+
+	var total float64
+	for _, w := range cfg.Weights {
+		total += w
+	}
+	s := &RiskScorer{cfg: cfg, lookback: lookback}
+	for name, w := range cfg.Weights {
+		if w > 0 {
+			s.factors = append(s.factors, weightedFactor{name: name, weight: w / total})
+		}
+	}
+	sort.Slice(s.factors, func(i, j int) bool {
+		if s.factors[i].weight != s.factors[j].weight {
+			return s.factors[i].weight > s.factors[j].weight
+		}
+		return s.factors[i].name < s.factors[j].name
+	})
+	return s, nil
+}
+
+// Score returns the risk score between 0 and 100 and the contribution of each factor to it.
+func (s *RiskScorer) Score(in ScoringInput) (float64, models.ScoreBreakdown) {
+	var score float64
+	breakdown := make(models.ScoreBreakdown, 0, len(s.factors))
+	for _, f := range s.factors {
+		value, reason := s.factorValue(f.name, in)
+		contribution := f.weight * value * 100
+		score += contribution
+		breakdown = append(breakdown, models.ScoreFactor{
+			Name:         f.name,
+			Weight:       round2(f.weight),
+			Value:        round2(value),
+			Contribution: round2(contribution),
+			Reason:       reason,
+		})
+	}
+	return round2(math.Min(score, 100)), breakdown
+}
+
+func (s *RiskScorer) factorValue(name string, in ScoringInput) (float64, string) {
+	switch name {
+	case config.ScoreFactorAlertType:
+		risk, ok := s.cfg.AlertTypeRisk[in.Alert.AlertType]
+		if !ok {
+			risk = s.cfg.DefaultAlertTypeRisk
+		}
+		return risk, fmt.Sprintf("%s alerts carry risk %.2f", in.Alert.AlertType, risk)
+
+	case config.ScoreFactorAmountVsBaseline:
+		baseline, source := s.cfg.AmountReference, "reference amount"
+		if in.Profile != nil && in.Profile.TransactionCount > 0 && in.Profile.AmountMean > 0 {
+			baseline, source = in.Profile.AmountMean, "account mean"
+		}
+		ratio := in.Transaction.Amount / baseline
+		value := clamp01((ratio - 1) / (s.cfg.AmountRatioCap - 1))
+		return value, fmt.Sprintf("amount %.2f is %.1fx the %s of %.2f", in.Transaction.Amount, ratio, source, baseline)
+
+	case config.ScoreFactorCountryRisk:
+		source := in.CountryRisk.Score(in.Transaction.SourceCountry)
+		destination := in.CountryRisk.Score(in.Transaction.DestinationCountry)
+		if destination > source {
+			return destination, fmt.Sprintf("destination country %s has risk %.2f", in.Transaction.DestinationCountry, destination)
+		}
+		return source, fmt.Sprintf("source country %s has risk %.2f", in.Transaction.SourceCountry, source)
+
+	case config.ScoreFactorCustomerRisk:
+		rating := strings.ToUpper(in.CustomerRiskRating)
+		risk, ok := s.cfg.CustomerRisk[rating]
+		if !ok {
+			return s.cfg.DefaultCustomerRisk, "customer risk rating unknown"
+		}
+		return risk, fmt.Sprintf("customer rated %s", rating)
+
+	case config.ScoreFactorPriorAlerts:
+		value := clamp01(float64(in.PriorAlerts) / float64(s.cfg.PriorAlertsCap))
+		return value, fmt.Sprintf("%d earlier alert(s) on the account in the last %s", in.PriorAlerts, s.cfg.PriorAlertsLookback)
+
+	case config.ScoreFactorRulesFired:
+		value := clamp01(float64(in.RulesFired) / float64(s.cfg.RulesFiredCap))
+		return value, fmt.Sprintf("%d rule(s) fired on the transaction", in.RulesFired)
+	}
+	return 0, ""
+}
+
+// ScoreAlerts rescores the alerts raised for one transaction, gathering the customer rating and
+// the account's earlier alerts from the database.
+func (s *RiskScorer) ScoreAlerts(db database.DBTX, tx models.Transaction, profile *models.AccountProfile, countryRisk config.CountryRisk, alerts []*models.Alert, now time.Time) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	rating, err := GetCustomerRiskRating(db, tx.AccountID)
+	if err != nil {
+		return err
+	}
+	var prior int
+	err = db.QueryRow(`SELECT COUNT(*) FROM alerts WHERE account_id = ? AND created_at >= ?`, tx.AccountID, now.Add(-s.lookback).UTC()).Scan(&prior)
+	if err != nil {
+		return fmt.Errorf("failed to count prior alerts for %s: %w", tx.AccountID, err)
+	}
+
+	for _, alert := range alerts {
+		alert.Score, alert.ScoreBreakdown = s.Score(ScoringInput{
+			Alert:              alert,
+			Transaction:        tx,
+			Profile:            profile,
+			CountryRisk:        countryRisk,
+			CustomerRiskRating: rating,
+			PriorAlerts:        prior,
+			RulesFired:         len(alerts),
+		})
+	}
+	return nil
+}
+
+// GetCustomerRiskRating returns an account holder's KYC risk rating, or an empty string when it
+// is unknown: the account is not on file, has no rating, or there is no accounts table.
+func GetCustomerRiskRating(db database.DBTX, accountID string) (string, error) {
+	var rating sql.NullString
+	err := db.QueryRow(`SELECT risk_rating FROM accounts WHERE account_id = ?`, accountID).Scan(&rating)
+	if err == sql.ErrNoRows || database.IsUndefinedTable(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query risk rating for %s: %w", accountID, err)
+	}
+	return rating.String, nil
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+func TestRiskScorer(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	countryRisk := config.CountryRisk{Default: 0.2, Countries: map[string]float64{"IR": 0.9}}
+	scorer, err := NewRiskScorer(config.DefaultScoringConfig())
+	if err != nil {
+		t.Fatalf("NewRiskScorer failed: %v", err)
+	}
+
+	// Test Case 1: The score is the weighted sum of the factor risks and each factor is explained
+	t.Run("weighted_factors", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input ScoringInput
+			want  float64
+		}{
+			{
+				name: "high_risk",
+				input: ScoringInput{
+					Alert:              &models.Alert{AlertType: AlertTypeStructuringPattern},
+					Transaction:        models.Transaction{Amount: 50000, SourceCountry: "US", DestinationCountry: "IR"},
+					Profile:            &models.AccountProfile{TransactionCount: 10, AmountMean: 5000},
+					CountryRisk:        countryRisk,
+					CustomerRiskRating: "high",
+					PriorAlerts:        5,
+					RulesFired:         3,
+				},
+				// 30 + 20 + 0.15×0.9×100 + 15 + 10 + 10
+				want: 98.5,
+			},
+			{
+				name: "low_risk",
+				input: ScoringInput{
+					Alert:              &models.Alert{AlertType: AlertTypeThresholdViolation},
+					Transaction:        models.Transaction{Amount: 4000, SourceCountry: "US", DestinationCountry: "US"},
+					Profile:            &models.AccountProfile{TransactionCount: 10, AmountMean: 5000},
+					CountryRisk:        countryRisk,
+					CustomerRiskRating: "LOW",
+					RulesFired:         1,
+				},
+				// 15 + 0 + 3 + 1.5 + 0 + 3.33
+				want: 22.83,
+			},
+		}
+		for _, tt := range tests {
+			score, breakdown := scorer.Score(tt.input)
+			if math.Abs(score-tt.want) > 0.01 {
+				t.Errorf("%s: expected score %.2f, got %.2f", tt.name, tt.want, score)
+			}
+			if len(breakdown) != 6 || breakdown[0].Name != config.ScoreFactorAlertType {
+				t.Fatalf("%s: expected 6 factors led by alert_type, got %+v", tt.name, breakdown)
+			}
+			var total float64
+			for _, f := range breakdown {
+				total += f.Contribution
+				if f.Reason == "" {
+					t.Errorf("%s: expected a reason for %s", tt.name, f.Name)
+				}
+			}
+			if math.Abs(total-score) > 0.05 {
+				t.Errorf("%s: expected contributions to sum to %.2f, got %.2f", tt.name, score, total)
+			}
+		}
+	})
+
+	// Test Case 2: Accounts without history are compared with the reference amount
+	t.Run("no_history", func(t *testing.T) {
+		_, breakdown := scorer.Score(ScoringInput{
+			Alert:       &models.Alert{AlertType: AlertTypeThresholdViolation},
+			Transaction: models.Transaction{Amount: 55000},
+			Profile:     &models.AccountProfile{},
+			RulesFired:  1,
+		})
+		for _, f := range breakdown {
+			if f.Name == config.ScoreFactorAmountVsBaseline && f.Value != 0.5 {
+				t.Errorf("Expected amount factor 0.5 against the 10000 reference, got %+v", f)
+			}
+			if f.Name == config.ScoreFactorCustomerRisk && f.Value != 0.5 {
+				t.Errorf("Expected the default customer risk for an unknown rating, got %+v", f)
+			}
+		}
+	})
+
+	// Test Case 3: Processed alerts are stored with the model's score and breakdown
+	t.Run("processor", func(t *testing.T) {
+		db := newTestDB(t)
+		if _, err := db.Exec(`INSERT INTO accounts (account_id, holder_name, risk_rating) VALUES ('acc-1', 'Jane Doe', 'HIGH')`); err != nil {
+			t.Fatalf("Failed to insert account: %v", err)
+		}
+		seedAlerts(t, db, now.Add(-48*time.Hour))
+
+		p := &TransactionProcessor{
+			Orchestrator: NewOrchestrator([]Detector{stubDetector{id: "stub", findings: []Finding{{DetectorID: "stub", AlertType: AlertTypeStructuringPattern}}}}),
+			Detection:    DetectionContext{Clock: fixedClock(now), CountryRisk: countryRisk},
+			Scorer:       scorer,
+		}
+		result, err := p.Process(db, models.Transaction{TransactionID: "tx-new", AccountID: "acc-1", Amount: 500, Timestamp: now})
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		stored, err := GetAlert(db, result.Alerts[0].ID)
+		if err != nil {
+			t.Fatalf("GetAlert failed: %v", err)
+		}
+		if stored.Score != result.Alerts[0].Score || len(stored.ScoreBreakdown) != 6 {
+			t.Fatalf("Expected the scored alert to be stored with its breakdown, got %+v", stored)
+		}
+		for _, f := range stored.ScoreBreakdown {
+			// acc-1 has three earlier seeded alerts.
+			if f.Name == config.ScoreFactorPriorAlerts && f.Value != 0.6 {
+				t.Errorf("Expected prior alerts factor 0.6, got %+v", f)
+			}
+			if f.Name == config.ScoreFactorCustomerRisk && f.Value != 1 {
+				t.Errorf("Expected HIGH customer risk, got %+v", f)
+			}
+		}
+	})
+
+	// Test Case 4: Invalid models are rejected
+	t.Run("validation", func(t *testing.T) {
+		cfg := config.DefaultScoringConfig()
+		cfg.Weights = map[string]float64{"shoe_size": 1}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected an unknown factor to be rejected")
+		}
+		cfg.Weights = map[string]float64{config.ScoreFactorAlertType: 0}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected all-zero weights to be rejected")
+		}
+		loaded, err := config.LoadScoringConfig("../../scoring.json")
+		if err != nil {
+			t.Fatalf("LoadScoringConfig failed: %v", err)
+		}
+		if !loaded.Enabled || len(loaded.Weights) != 6 {
+			t.Errorf("Unexpected scoring config: %+v", loaded)
+		}
+	})
+
+	// Test Case 5: Unrated accounts, unknown accounts and a missing accounts table have no rating
+	t.Run("unknown_rating", func(t *testing.T) {
+		db := newTestDB(t)
+		if _, err := db.Exec(`INSERT INTO accounts (account_id, holder_name) VALUES ('acc-1', 'Jane Doe')`); err != nil {
+			t.Fatalf("Failed to insert account: %v", err)
+		}
+		for _, id := range []string{"acc-1", "acc-2"} {
+			if rating, err := GetCustomerRiskRating(db, id); err != nil || rating != "" {
+				t.Errorf("Expected no rating for %s, got %q, %v", id, rating, err)
+			}
+		}
+		if _, err := db.Exec(`DROP TABLE accounts`); err != nil {
+			t.Fatalf("Failed to drop accounts: %v", err)
+		}
+		if rating, err := GetCustomerRiskRating(db, "acc-1"); err != nil || rating != "" {
+			t.Errorf("Expected no rating without an accounts table, got %q, %v", rating, err)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"AML/internal/config"
 	"AML/internal/models"
+	"strings"
 	"time"
 )
 
@@ -11,9 +12,24 @@ type RuleViolation struct {
 	RuleID         string  `json:"rule_id"`
 	ActualValue    float64 `json:"actual_value"`
 	ThresholdValue float64 `json:"threshold_value"`
+	// Details carries rule-specific context, such as the unfamiliar country or counterparty.
+	Details map[string]interface{} `json:"details,omitempty"`
 }
 
-// EvaluateRules checks a transaction against a set of rules.
+const (
+	// RuleNewDestinationCountry fires when an established account sends to a country it has not used before.
+	RuleNewDestinationCountry = "new_destination_country"
+	// RuleNewCounterparty fires when an established account transacts with an unseen counterparty.
+	RuleNewCounterparty = "new_counterparty"
+	// RuleUnusualHour fires when a transaction falls in an hour the account is rarely active.
+	RuleUnusualHour = "unusual_hour"
+)
+
+// defaultProfileMinHistory is used when a behavioural rule does not set min_history.
+const defaultProfileMinHistory = 10
+
+// EvaluateRules checks a transaction against a set of rules. Windowed rules count the history in
+// the window before the transaction's timestamp.
 func EvaluateRules(tx models.Transaction, rules []config.Rule, history []models.Transaction) ([]RuleViolation, error) {
 	if rules == nil {
 		return nil, nil // No rules to evaluate
@@ -41,7 +57,7 @@ func EvaluateRules(tx models.Transaction, rules []config.Rule, history []models.
 				})
 			}
 		case "daily_cumulative_exceeds_50000":
-			transactionsInWindow := GetTransactionsInWindow(history, timeWindow)
+			transactionsInWindow := GetTransactionsInWindow(history, tx.Timestamp, timeWindow)
 			var totalAmount float64
 			for _, t := range transactionsInWindow {
 				totalAmount += t.Amount
@@ -56,7 +72,7 @@ func EvaluateRules(tx models.Transaction, rules []config.Rule, history []models.
 				})
 			}
 		case "more_than_5_transactions_in_1_hour":
-			transactionsInWindow := GetTransactionsInWindow(history, timeWindow)
+			transactionsInWindow := GetTransactionsInWindow(history, tx.Timestamp, timeWindow)
 			transactionCount := len(transactionsInWindow) + 1 // Include current transaction
 
 			if float64(transactionCount) > rule.ThresholdValue {
@@ -74,18 +90,81 @@ func EvaluateRules(tx models.Transaction, rules []config.Rule, history []models.
 	return violations, nil
 }
 
-// GetTransactionsInWindow filters transactions that fall within a given time window.
-func GetTransactionsInWindow(history []models.Transaction, window time.Duration) []models.Transaction {
+// EvaluateProfileRules checks a transaction against behavioural rules using the account profile
+// instead of raw history. The profile must not yet include the transaction being evaluated.
+// Each rule fires when the observed value is below its threshold_value.
+func EvaluateProfileRules(tx models.Transaction, rules []config.Rule, profile *models.AccountProfile) []RuleViolation {
+	if profile == nil {
+		return nil
+	}
+
+	var violations []RuleViolation
+
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+
+		minHistory := int64(rule.MinHistory)
+		if minHistory == 0 {
+			minHistory = defaultProfileMinHistory
+		}
+		if profile.TransactionCount < minHistory {
+			continue // Not enough behaviour to compare against yet
+		}
+
+		switch rule.RuleID {
+		case RuleNewDestinationCountry:
+			country := strings.ToUpper(tx.DestinationCountry)
+			seen := float64(profile.Countries[country])
+			if country != "" && seen < rule.ThresholdValue {
+				violations = append(violations, RuleViolation{
+					RuleID:         rule.RuleID,
+					ActualValue:    seen,
+					ThresholdValue: rule.ThresholdValue,
+					Details:        map[string]interface{}{"country": country},
+				})
+			}
+		case RuleNewCounterparty:
+			seen := float64(profile.Counterparties[tx.CounterpartyID])
+			if tx.CounterpartyID != "" && seen < rule.ThresholdValue {
+				violations = append(violations, RuleViolation{
+					RuleID:         rule.RuleID,
+					ActualValue:    seen,
+					ThresholdValue: rule.ThresholdValue,
+					Details:        map[string]interface{}{"counterparty_id": tx.CounterpartyID},
+				})
+			}
+		case RuleUnusualHour:
+			hour := tx.Timestamp.UTC().Hour()
+			share := HourShare(profile, hour)
+			if share < rule.ThresholdValue {
+				violations = append(violations, RuleViolation{
+					RuleID:         rule.RuleID,
+					ActualValue:    share,
+					ThresholdValue: rule.ThresholdValue,
+					Details:        map[string]interface{}{"hour_utc": hour},
+				})
+			}
+		default:
+			// Non-behavioural rules are handled by EvaluateRules
+		}
+	}
+
+	return violations
+}
+
+// GetTransactionsInWindow filters transactions that fall within the window ending before asOf.
+func GetTransactionsInWindow(history []models.Transaction, asOf time.Time, window time.Duration) []models.Transaction {
 	if history == nil {
 		return nil
 	}
 
 	var transactionsInWindow []models.Transaction
-	now := time.Now()
-	windowStart := now.Add(-window)
+	windowStart := asOf.Add(-window)
 
 	for _, tx := range history {
-		if tx.Timestamp.After(windowStart) && tx.Timestamp.Before(now) {
+		if tx.Timestamp.After(windowStart) && tx.Timestamp.Before(asOf) {
 			transactionsInWindow = append(transactionsInWindow, tx)
 		}
 	}
@@ -114,3 +114,55 @@ func TestEvaluateRules(t *testing.T) {
 		}
 	})
 }
+
+func TestEvaluateProfileRules(t *testing.T) {
+	rules := []config.Rule{
+		{RuleID: RuleNewDestinationCountry, ThresholdValue: 1, TimeWindow: "0h", Enabled: true, MinHistory: 5},
+		{RuleID: RuleNewCounterparty, ThresholdValue: 1, TimeWindow: "0h", Enabled: true, MinHistory: 5},
+		{RuleID: RuleUnusualHour, ThresholdValue: 0.1, TimeWindow: "0h", Enabled: true, MinHistory: 5},
+	}
+
+	profile := NewAccountProfile("acc-1")
+	for i := 0; i < 10; i++ {
+		UpdateAccountProfile(profile, models.Transaction{
+			Amount: 100, Timestamp: time.Date(2024, 5, 1+i, 10, 0, 0, 0, time.UTC),
+			SourceCountry: "US", DestinationCountry: "US", CounterpartyID: "cp-1",
+		})
+	}
+
+	// Test Case 1: Familiar behaviour
+	t.Run("familiar_behaviour", func(t *testing.T) {
+		tx := models.Transaction{Timestamp: time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC), DestinationCountry: "US", CounterpartyID: "cp-1"}
+		if violations := EvaluateProfileRules(tx, rules, profile); len(violations) != 0 {
+			t.Errorf("Expected 0 violations, got %v", violations)
+		}
+	})
+
+	// Test Case 2: New country, new counterparty, unusual hour
+	t.Run("new_behaviour", func(t *testing.T) {
+		tx := models.Transaction{Timestamp: time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC), DestinationCountry: "kp", CounterpartyID: "cp-2"}
+		violations := EvaluateProfileRules(tx, rules, profile)
+		if len(violations) != 3 {
+			t.Fatalf("Expected 3 violations, got %d", len(violations))
+		}
+		if violations[0].Details["country"] != "KP" {
+			t.Errorf("Expected country detail KP, got %v", violations[0].Details)
+		}
+		if violations[1].Details["counterparty_id"] != "cp-2" {
+			t.Errorf("Expected counterparty detail cp-2, got %v", violations[1].Details)
+		}
+		if violations[2].ActualValue != 0 {
+			t.Errorf("Expected hour share 0, got %f", violations[2].ActualValue)
+		}
+	})
+
+	// Test Case 3: Young profiles are not evaluated
+	t.Run("insufficient_profile_history", func(t *testing.T) {
+		young := NewAccountProfile("acc-2")
+		UpdateAccountProfile(young, models.Transaction{Amount: 100, Timestamp: time.Now(), DestinationCountry: "US"})
+		tx := models.Transaction{Timestamp: time.Now(), DestinationCountry: "KP", CounterpartyID: "cp-2"}
+		if violations := EvaluateProfileRules(tx, rules, young); len(violations) != 0 {
+			t.Errorf("Expected 0 violations for young profile, got %d", len(violations))
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// continuingActivityPattern holds a subject's transactions since the prior report that raised no
+// alert of their own.
+const continuingActivityPattern = "CONTINUING_ACTIVITY"
+
+// Continue drafts a continuing-activity SAR following a filed one. It reports the subject's
+// activity since the end of the prior report and carries the series' cumulative totals. A filed
+// SAR can only be continued once; later activity continues the continuing report.
+func (l *SARLifecycle) Continue(db database.DBTX, priorID, actor string, now time.Time) (*models.SAR, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	prior, err := GetSAR(db, priorID)
+	if err != nil {
+		return nil, err
+	}
+	if prior.Status != models.SARStatusFiled {
+		return nil, fmt.Errorf("%w: only filed SARs can be continued, %s is %s", ErrInvalidSARTransition, priorID, prior.Status)
+	}
+	var existing string
+	err = db.QueryRow(`SELECT id FROM sars WHERE prior_sar_id = ? AND filing_type = ?`, priorID, models.SARFilingContinuing).Scan(&existing)
+	if err == nil {
+		return nil, fmt.Errorf("%w: SAR %s is already continued by %s", ErrInvalidSARTransition, priorID, existing)
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query continuations of SAR %s: %w", priorID, err)
+	}
+
+	report, alertIDs, err := GenerateContinuingSARData(prior, now, db)
+	if err != nil {
+		return nil, err
+	}
+	if l.narratives != nil {
+		if report.Narrative, err = l.narratives.Draft(report); err != nil {
+			return nil, err
+		}
+	}
+
+	sar := &models.SAR{
+		ID:         uuid.New().String(),
+		CaseID:     prior.CaseID,
+		AlertIDs:   alertIDs,
+		Status:     models.SARStatusDraft,
+		FilingType: models.SARFilingContinuing,
+		PriorSARID: prior.ID,
+		Report:     report,
+		CreatedBy:  actor,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := insertSAR(db, sar); err != nil {
+		return nil, err
+	}
+	if sar.CaseID != "" {
+		if _, err := AddCaseNote(db, sar.CaseID, actor, fmt.Sprintf("Drafted continuing SAR %s following %s", sar.ID, prior.ID), now); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := recordSAREvent(db, sar.ID, "", models.SARStatusDraft, actor, "Continues SAR "+prior.ID, now); err != nil {
+		return nil, err
+	}
+	return sar, nil
+}
+
+// GenerateContinuingSARData builds the report for a continuing-activity SAR: the activity on the
+// prior report's accounts after its end date and up to through. Alerts raised on that activity,
+// other than false positives, are grouped by alert type as usual and returned for linking; the
+// subject's remaining transactions are reported under CONTINUING_ACTIVITY.
+func GenerateContinuingSARData(prior *models.SAR, through time.Time, db database.DBTX) (*models.SARReport, []string, error) {
+	accounts := sarAccountIDs(prior.Report)
+	if len(accounts) == 0 {
+		return nil, nil, fmt.Errorf("%w: SAR %s names no accounts", ErrInvalidSAR, prior.ID)
+	}
+	since := prior.Report.EndDate
+
+	alertQuery := `
+		SELECT a.id
+		FROM alerts a
+		JOIN transactions t ON t.transaction_id = a.transaction_id
+		WHERE a.account_id IN %s AND t.timestamp > ? AND t.timestamp <= ? AND a.status != ?
+	`
+	alertIDs := []string{}
+	err := database.QueryIn(db, alertQuery, accounts, func(rows *sql.Rows) error {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan alert row: %w", err)
+		}
+		alertIDs = append(alertIDs, id)
+		return nil
+	}, since.UTC(), through.UTC(), models.StatusFalsePositive)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query alerts since SAR %s: %w", prior.ID, err)
+	}
+	sort.Strings(alertIDs)
+
+	report := &models.SARReport{Patterns: make(models.SARPatterns)}
+	if len(alertIDs) > 0 {
+		generated, err := GenerateSARData(alertIDs, db)
+		if err != nil {
+			return nil, nil, err
+		}
+		if generated != nil {
+			report = generated
+			trimSARReport(report, since, through)
+		}
+	}
+	if report.SubjectName == "" {
+		report.SubjectName = prior.Report.SubjectName
+		report.SubjectAddress = prior.Report.SubjectAddress
+		report.SubjectDateOfBirth = prior.Report.SubjectDateOfBirth
+		report.Subjects = prior.Report.Subjects
+	}
+
+	reported := make(map[string]bool)
+	for _, p := range report.Patterns {
+		for _, tx := range p.Transactions {
+			reported[tx.TransactionID] = true
+		}
+	}
+	txQuery := `
+		SELECT transaction_id, account_id, amount, currency, timestamp,
+			source_country, destination_country, transaction_type, status, COALESCE(counterparty_id, '')
+		FROM transactions
+		WHERE account_id IN %s AND timestamp > ? AND timestamp <= ?
+	`
+	var remaining models.SuspiciousActivityPattern
+	err = database.QueryIn(db, txQuery, accounts, func(rows *sql.Rows) error {
+		var tx models.Transaction
+		err := rows.Scan(
+			&tx.TransactionID, &tx.AccountID, &tx.Amount, &tx.Currency, &tx.Timestamp,
+			&tx.SourceCountry, &tx.DestinationCountry, &tx.TransactionType, &tx.Status, &tx.CounterpartyID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan transaction row: %w", err)
+		}
+		if reported[tx.TransactionID] {
+			return nil
+		}
+		remaining.Transactions = append(remaining.Transactions, tx)
+		remaining.TotalAmount += tx.Amount
+		remaining.TransactionCount++
+		return nil
+	}, since.UTC(), through.UTC())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query transactions since SAR %s: %w", prior.ID, err)
+	}
+	sortTransactions(remaining.Transactions)
+
+	if remaining.TransactionCount > 0 {
+		remaining.PatternDescription = "Continuing activity"
+		report.Patterns[continuingActivityPattern] = remaining
+		first, last := remaining.Transactions[0].Timestamp, remaining.Transactions[len(remaining.Transactions)-1].Timestamp
+		if report.TotalTransactionCount == 0 || first.Before(report.StartDate) {
+			report.StartDate = first
+		}
+		if last.After(report.EndDate) {
+			report.EndDate = last
+		}
+		report.TotalSuspiciousAmount += remaining.TotalAmount
+		report.TotalTransactionCount += remaining.TransactionCount
+	}
+	if report.TotalTransactionCount == 0 {
+		return nil, nil, fmt.Errorf("%w: no activity on the subject's accounts since %s", ErrInvalidSAR, since.UTC().Format("2006-01-02"))
+	}
+
+	continuation := &models.SARContinuation{
+		PriorSARID:                 prior.ID,
+		PriorFilingReference:       prior.FilingReference,
+		ReportNumber:               2,
+		SeriesStartDate:            prior.Report.StartDate,
+		CumulativeAmount:           prior.Report.TotalSuspiciousAmount,
+		CumulativeTransactionCount: prior.Report.TotalTransactionCount,
+	}
+	if previous := prior.Report.Continuation; previous != nil {
+		continuation.ReportNumber = previous.ReportNumber + 1
+		continuation.SeriesStartDate = previous.SeriesStartDate
+		continuation.CumulativeAmount = previous.CumulativeAmount
+		continuation.CumulativeTransactionCount = previous.CumulativeTransactionCount
+	}
+	continuation.CumulativeAmount += report.TotalSuspiciousAmount
+	continuation.CumulativeTransactionCount += report.TotalTransactionCount
+	report.Continuation = continuation
+	return report, alertIDs, nil
+}
+
+// trimSARReport drops pattern transactions outside (since, through] and recomputes the pattern
+// and report totals and dates. Alerts raised after since may still report earlier transactions,
+// such as the look-back window of a structuring pattern, which the prior report already covers.
+func trimSARReport(report *models.SARReport, since, through time.Time) {
+	report.TotalSuspiciousAmount, report.TotalTransactionCount = 0, 0
+	report.StartDate, report.EndDate = time.Time{}, time.Time{}
+	for key, p := range report.Patterns {
+		kept := p.Transactions[:0]
+		p.TotalAmount, p.TransactionCount = 0, 0
+		for _, tx := range p.Transactions {
+			if !tx.Timestamp.After(since) || tx.Timestamp.After(through) {
+				continue
+			}
+			kept = append(kept, tx)
+			p.TotalAmount += tx.Amount
+			p.TransactionCount++
+			if report.StartDate.IsZero() || tx.Timestamp.Before(report.StartDate) {
+				report.StartDate = tx.Timestamp
+			}
+			if tx.Timestamp.After(report.EndDate) {
+				report.EndDate = tx.Timestamp
+			}
+		}
+		if len(kept) == 0 {
+			delete(report.Patterns, key)
+			continue
+		}
+		p.Transactions = kept
+		report.Patterns[key] = p
+		report.TotalSuspiciousAmount += p.TotalAmount
+		report.TotalTransactionCount += p.TransactionCount
+	}
+}
+
+// ListDueContinuingReviews returns the filed SARs whose continuing-activity review is due by now
+// and which have not been continued, earliest due first.
+func ListDueContinuingReviews(db database.DBTX, now time.Time) ([]models.SAR, error) {
+	query := `SELECT ` + sarColumns + ` FROM sars
+		WHERE status = ? AND review_due_at IS NOT NULL AND review_due_at <= ?
+			AND NOT EXISTS (SELECT 1 FROM sars c WHERE c.prior_sar_id = sars.id AND c.filing_type = ?)
+		ORDER BY review_due_at ASC, id ASC`
+	return querySARs(db, query, models.SARStatusFiled, now.UTC(), models.SARFilingContinuing)
+}
+
+// CloseContinuingReview records that a filed SAR's review found no continuing activity to report
+// and clears its reminder. It needs an approver role and a comment.
+func (l *SARLifecycle) CloseContinuingReview(db database.DBTX, id, actor string, roles []string, comment string, now time.Time) (*models.SAR, error) {
+	if strings.TrimSpace(comment) == "" {
+		return nil, ErrCommentRequired
+	}
+	sar, err := l.reviewable(db, id, actor, roles)
+	if err != nil {
+		return nil, err
+	}
+	if sar.Status != models.SARStatusFiled || sar.ReviewDueAt.IsZero() {
+		return nil, fmt.Errorf("%w: SAR %s has no continuing-activity review scheduled", ErrInvalidSARTransition, id)
+	}
+
+	sar.ReviewDueAt = time.Time{}
+	sar.UpdatedAt = now
+	query := `UPDATE sars SET review_due_at = NULL, updated_at = ? WHERE id = ? AND status = ?`
+	if _, err := db.Exec(query, now.UTC(), id, models.SARStatusFiled); err != nil {
+		return nil, fmt.Errorf("failed to update SAR %s: %w", id, err)
+	}
+	if _, err := recordSAREvent(db, id, sar.Status, sar.Status, actor, "Continuing-activity review closed: "+comment, now); err != nil {
+		return nil, err
+	}
+	return sar, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// fileTestSAR takes a draft SAR through review and files it at the given time.
+func fileTestSAR(t *testing.T, lifecycle *SARLifecycle, db database.DBTX, id, reference string, at time.Time) {
+	t.Helper()
+	if _, err := lifecycle.Submit(db, id, "analyst-1", "", at); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if _, err := lifecycle.Approve(db, id, "supervisor-1", []string{"supervisor"}, "", at); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if _, err := lifecycle.File(db, id, "mlro-1", []string{"mlro"}, reference, at); err != nil {
+		t.Fatalf("File failed: %v", err)
+	}
+}
+
+func TestContinuingSARs(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	filedAt := base.AddDate(0, 0, 5)
+	lifecycle, err := NewSARLifecycle(config.DefaultSARConfig(), nil)
+	if err != nil {
+		t.Fatalf("NewSARLifecycle failed: %v", err)
+	}
+
+	// setup files a SAR on acc-1's first two transactions (tx-0 and tx-2) and adds a later
+	// transaction on acc-1 that raised no alert.
+	setup := func(t *testing.T) (*models.SAR, database.DBTX) {
+		db := newTestDB(t)
+		seedSARActivity(t, db, base)
+		_, err := db.Exec(`
+			INSERT INTO transactions (transaction_id, account_id, amount, currency, timestamp, source_country,
+				destination_country, transaction_type, status)
+			VALUES ('tx-5', 'acc-1', 4000, 'USD', ?, 'US', 'US', 'CASH', 'COMPLETED')`, base.AddDate(0, 0, 30))
+		if err != nil {
+			t.Fatalf("failed to insert transaction: %v", err)
+		}
+		prior, err := lifecycle.Create(db, "", []string{"alert-0", "alert-2"}, "analyst-1", base)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		fileTestSAR(t, lifecycle, db, prior.ID, "31000000000001", filedAt)
+		prior, err = GetSAR(db, prior.ID)
+		if err != nil {
+			t.Fatalf("GetSAR failed: %v", err)
+		}
+		return prior, db
+	}
+
+	// Test Case 1: A continuing SAR reports the subject's activity since the prior report
+	t.Run("continue", func(t *testing.T) {
+		prior, db := setup(t)
+		now := filedAt.AddDate(0, 0, 90)
+		sar, err := lifecycle.Continue(db, prior.ID, "analyst-1", now)
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		if sar.FilingType != models.SARFilingContinuing || sar.PriorSARID != prior.ID || sar.Status != models.SARStatusDraft {
+			t.Errorf("Expected a continuing draft following %s, got %+v", prior.ID, sar)
+		}
+		if strings.Join(sar.AlertIDs, ",") != "alert-4" {
+			t.Errorf("Expected the alert raised since the prior report, got %v", sar.AlertIDs)
+		}
+
+		report := sar.Report
+		if report.TotalTransactionCount != 2 || report.TotalSuspiciousAmount != 13400 {
+			t.Errorf("Expected tx-4 and tx-5 totalling 13400, got %d totalling %.2f", report.TotalTransactionCount, report.TotalSuspiciousAmount)
+		}
+		if p := report.Patterns[continuingActivityPattern]; p.TransactionCount != 1 || p.Transactions[0].TransactionID != "tx-5" {
+			t.Errorf("Expected tx-5 under %s, got %+v", continuingActivityPattern, p)
+		}
+		if !report.StartDate.Equal(base.Add(4*time.Hour)) || !report.EndDate.Equal(base.AddDate(0, 0, 30)) {
+			t.Errorf("Expected the period to run from tx-4 to tx-5, got %v to %v", report.StartDate, report.EndDate)
+		}
+
+		c := report.Continuation
+		if c == nil {
+			t.Fatalf("Expected continuation details")
+		}
+		if c.PriorFilingReference != "31000000000001" || c.ReportNumber != 2 || !c.SeriesStartDate.Equal(base) {
+			t.Errorf("Unexpected continuation: %+v", c)
+		}
+		if c.CumulativeAmount != 31600 || c.CumulativeTransactionCount != 4 {
+			t.Errorf("Expected cumulative 4 transactions totalling 31600, got %d totalling %.2f", c.CumulativeTransactionCount, c.CumulativeAmount)
+		}
+
+		if _, err := lifecycle.Continue(db, prior.ID, "analyst-1", now); !errors.Is(err, ErrInvalidSARTransition) {
+			t.Errorf("Expected ErrInvalidSARTransition continuing a SAR twice, got %v", err)
+		}
+		if _, err := lifecycle.Continue(db, sar.ID, "analyst-1", now); !errors.Is(err, ErrInvalidSARTransition) {
+			t.Errorf("Expected ErrInvalidSARTransition continuing an unfiled SAR, got %v", err)
+		}
+	})
+
+	// Test Case 2: Cumulative totals run across the whole series
+	t.Run("series", func(t *testing.T) {
+		prior, db := setup(t)
+		second, err := lifecycle.Continue(db, prior.ID, "analyst-1", filedAt.AddDate(0, 0, 90))
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		fileTestSAR(t, lifecycle, db, second.ID, "31000000000002", filedAt.AddDate(0, 0, 100))
+		_, err = db.Exec(`
+			INSERT INTO transactions (transaction_id, account_id, amount, currency, timestamp, source_country,
+				destination_country, transaction_type, status)
+			VALUES ('tx-6', 'acc-1', 2500, 'USD', ?, 'US', 'US', 'CASH', 'COMPLETED')`, base.AddDate(0, 0, 120))
+		if err != nil {
+			t.Fatalf("failed to insert transaction: %v", err)
+		}
+
+		third, err := lifecycle.Continue(db, second.ID, "analyst-1", filedAt.AddDate(0, 0, 190))
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		c := third.Report.Continuation
+		if c.ReportNumber != 3 || c.PriorFilingReference != "31000000000002" || !c.SeriesStartDate.Equal(base) {
+			t.Errorf("Unexpected continuation: %+v", c)
+		}
+		if third.Report.TotalTransactionCount != 1 || c.CumulativeAmount != 34100 || c.CumulativeTransactionCount != 5 {
+			t.Errorf("Expected tx-6 on top of 31600 over 4 transactions, got %+v", c)
+		}
+		if _, err := lifecycle.Continue(db, third.ID, "analyst-1", filedAt.AddDate(0, 0, 190)); !errors.Is(err, ErrInvalidSARTransition) {
+			t.Errorf("Expected ErrInvalidSARTransition for a draft, got %v", err)
+		}
+	})
+
+	// Test Case 3: Filed SARs are due a review 90 days after filing until continued or closed
+	t.Run("reviews", func(t *testing.T) {
+		prior, db := setup(t)
+		if want := filedAt.AddDate(0, 0, 90); !prior.ReviewDueAt.Equal(want) {
+			t.Errorf("Expected review due %v, got %v", want, prior.ReviewDueAt)
+		}
+		due, err := ListDueContinuingReviews(db, filedAt.AddDate(0, 0, 89))
+		if err != nil {
+			t.Fatalf("ListDueContinuingReviews failed: %v", err)
+		}
+		if len(due) != 0 {
+			t.Errorf("Expected no reviews due before 90 days, got %d", len(due))
+		}
+		due, err = ListDueContinuingReviews(db, filedAt.AddDate(0, 0, 90))
+		if err != nil {
+			t.Fatalf("ListDueContinuingReviews failed: %v", err)
+		}
+		if len(due) != 1 || due[0].ID != prior.ID {
+			t.Fatalf("Expected the filed SAR to be due review, got %v", due)
+		}
+
+		if _, err := lifecycle.CloseContinuingReview(db, prior.ID, "analyst-1", []string{"analyst"}, "Activity stopped", filedAt.AddDate(0, 0, 91)); !errors.Is(err, ErrTransitionForbidden) {
+			t.Errorf("Expected ErrTransitionForbidden without an approver role, got %v", err)
+		}
+		if _, err := lifecycle.CloseContinuingReview(db, prior.ID, "supervisor-1", []string{"supervisor"}, "", filedAt.AddDate(0, 0, 91)); !errors.Is(err, ErrCommentRequired) {
+			t.Errorf("Expected ErrCommentRequired, got %v", err)
+		}
+		if _, err := lifecycle.CloseContinuingReview(db, prior.ID, "supervisor-1", []string{"supervisor"}, "Account closed", filedAt.AddDate(0, 0, 91)); err != nil {
+			t.Fatalf("CloseContinuingReview failed: %v", err)
+		}
+		due, err = ListDueContinuingReviews(db, filedAt.AddDate(0, 0, 200))
+		if err != nil {
+			t.Fatalf("ListDueContinuingReviews failed: %v", err)
+		}
+		if len(due) != 0 {
+			t.Errorf("Expected the closed review to drop off, got %d", len(due))
+		}
+
+		other, db := setup(t)
+		if _, err := lifecycle.Continue(db, other.ID, "analyst-1", filedAt.AddDate(0, 0, 95)); err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		due, err = ListDueContinuingReviews(db, filedAt.AddDate(0, 0, 95))
+		if err != nil {
+			t.Fatalf("ListDueContinuingReviews failed: %v", err)
+		}
+		if len(due) != 0 {
+			t.Errorf("Expected a continued SAR to drop off, got %d", len(due))
+		}
+	})
+
+	// Test Case 4: Pattern transactions the prior report already covered are left out
+	t.Run("straddling_pattern", func(t *testing.T) {
+		prior, db := setup(t)
+		// A structuring alert just after the prior report's end whose window reaches back to tx-2.
+		tx7 := models.Transaction{TransactionID: "tx-7", AccountID: "acc-1", Amount: 9300, Currency: "USD", Timestamp: base.Add(5 * time.Hour)}
+		_, err := db.Exec(`
+			INSERT INTO transactions (transaction_id, account_id, amount, currency, timestamp, source_country,
+				destination_country, transaction_type, status)
+			VALUES ('tx-7', 'acc-1', 9300, 'USD', ?, 'US', 'US', 'CASH', 'COMPLETED')`, tx7.Timestamp)
+		if err != nil {
+			t.Fatalf("failed to insert transaction: %v", err)
+		}
+		tx2 := models.Transaction{TransactionID: "tx-2", AccountID: "acc-1", Amount: 9200, Currency: "USD", Timestamp: base.Add(2 * time.Hour)}
+		alert := &models.Alert{
+			ID: "alert-7", TransactionID: "tx-7", AccountID: "acc-1", AlertType: AlertTypeStructuringPattern,
+			Priority: models.Critical, CreatedAt: tx7.Timestamp, Status: models.StatusOpen,
+			RuleDetails: models.JSONMap{"matching_transactions": []models.Transaction{tx2, tx7}},
+		}
+		if err := SaveAlert(db, alert); err != nil {
+			t.Fatalf("SaveAlert failed: %v", err)
+		}
+
+		sar, err := lifecycle.Continue(db, prior.ID, "analyst-1", filedAt.AddDate(0, 0, 90))
+		if err != nil {
+			t.Fatalf("Continue failed: %v", err)
+		}
+		report := sar.Report
+		if p := report.Patterns[AlertTypeStructuringPattern]; p.TransactionCount != 1 || p.TotalAmount != 9300 || p.Transactions[0].TransactionID != "tx-7" {
+			t.Errorf("Expected only tx-7 in the structuring pattern, got %+v", p)
+		}
+		// tx-4, tx-7 and tx-5.
+		if report.TotalTransactionCount != 3 || report.TotalSuspiciousAmount != 22700 {
+			t.Errorf("Expected 3 transactions totalling 22700, got %d totalling %.2f", report.TotalTransactionCount, report.TotalSuspiciousAmount)
+		}
+		if !report.StartDate.Equal(base.Add(4 * time.Hour)) {
+			t.Errorf("Expected the period to start after the prior report, at tx-4, got %v", report.StartDate)
+		}
+		if c := report.Continuation; c.CumulativeAmount != 40900 || c.CumulativeTransactionCount != 5 {
+			t.Errorf("Expected cumulative 5 transactions totalling 40900, got %d totalling %.2f", c.CumulativeTransactionCount, c.CumulativeAmount)
+		}
+	})
+}
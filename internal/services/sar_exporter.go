@@ -4,11 +4,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"AML/internal/models"
 )
 
+// ErrSARInvalid is returned when a SAR is missing mandatory data or fails its format's schema
+// check. The returned error is a *SARValidationError listing the problems.
+var ErrSARInvalid = fmt.Errorf("SAR failed validation")
+
+// SARValidationError lists every problem that stopped a SAR from being exported.
+type SARValidationError struct {
+	Problems []string
+}
+
+func (e *SARValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrSARInvalid, strings.Join(e.Problems, "; "))
+}
+
+// Unwrap lets callers match the error with errors.Is(err, ErrSARInvalid).
+func (e *SARValidationError) Unwrap() error {
+	return ErrSARInvalid
+}
+
 // MaskAccountNumber masks the account number, showing only the last 4 digits.
 func MaskAccountNumber(accountNum string) string {
 	if len(accountNum) <= 4 {
@@ -19,6 +38,23 @@ func MaskAccountNumber(accountNum string) string {
 
 // ExportSARToJSON exports a SARReport to a JSON file with specified formatting and masking.
 func ExportSARToJSON(report *models.SARReport, filepath string) error {
+	jsonData, err := MarshalSARJSON(report)
+	if err != nil {
+		return err
+	}
+
+	// Write to file with 0600 permissions
+	err = os.WriteFile(filepath, jsonData, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write SAR report to file: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalSARJSON formats a SARReport as indented JSON with account numbers masked and ISO 8601
+// timestamps, as written by ExportSARToJSON.
+func MarshalSARJSON(report *models.SARReport) ([]byte, error) {
 	// Custom type to handle JSON marshaling for SARReport, applying masking and ISO 8601
 
 	type TransactionJSON struct {
@@ -40,15 +76,25 @@ func ExportSARToJSON(report *models.SARReport, filepath string) error {
 		TransactionCount   int               `json:"transaction_count"`
 	}
 
+	type SubjectJSON struct {
+		Role        string   `json:"role"`
+		Name        string   `json:"name"`
+		Address     string   `json:"address"`
+		DateOfBirth string   `json:"date_of_birth"`
+		AccountIDs  []string `json:"account_ids"` // Masked
+	}
+
 	type SARReportJSON struct {
 		SubjectName           string                 `json:"subject_name"`
 		SubjectAddress        string                 `json:"subject_address"`
 		SubjectDateOfBirth    string                 `json:"subject_date_of_birth"`
+		Subjects              []SubjectJSON          `json:"subjects"`
 		StartDate             string                 `json:"start_date"` // Will be ISO 8601
 		EndDate               string                 `json:"end_date"`   // Will be ISO 8601
 		TotalSuspiciousAmount float64                `json:"total_suspicious_amount"`
 		TotalTransactionCount int                    `json:"total_transaction_count"`
 		Patterns              map[string]PatternJSON `json:"patterns"`
+		Narrative             string                 `json:"narrative,omitempty"`
 	}
 
 	sarReportJSON := SARReportJSON{
@@ -60,6 +106,18 @@ func ExportSARToJSON(report *models.SARReport, filepath string) error {
 		TotalSuspiciousAmount: report.TotalSuspiciousAmount,
 		TotalTransactionCount: report.TotalTransactionCount,
 		Patterns:              make(map[string]PatternJSON),
+		Narrative:             report.Narrative,
+	}
+
+	for _, subject := range sarSubjects(report) {
+		subjectJSON := SubjectJSON{
+			Role:        subject.Role,
+			Name:        subject.Name,
+			Address:     subject.Address,
+			DateOfBirth: subject.DateOfBirth,
+			AccountIDs:  maskAccountNumbers(subject.AccountIDs),
+		}
+		sarReportJSON.Subjects = append(sarReportJSON.Subjects, subjectJSON)
 	}
 
 	for key, pattern := range report.Patterns {
@@ -88,14 +146,7 @@ func ExportSARToJSON(report *models.SARReport, filepath string) error {
 	// Marshal to JSON with indentation
 	jsonData, err := json.MarshalIndent(sarReportJSON, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal SAR report to JSON: %w", err)
+		return nil, fmt.Errorf("failed to marshal SAR report to JSON: %w", err)
 	}
-
-	// Write to file with 0600 permissions
-	err = os.WriteFile(filepath, jsonData, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to write SAR report to file: %w", err)
-	}
-
-	return nil
+	return jsonData, nil
 }
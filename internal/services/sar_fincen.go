@@ -0,0 +1,501 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+const (
+	fincenNamespace    = "www.fincen.gov/base"
+	fincenFormTypeCode = "SARX"
+	fincenDateFormat   = "20060102"
+)
+
+// A SAR narrative is filed in up to fincenNarrativeBlocks blocks of fincenNarrativeLength
+// characters each.
+const (
+	fincenNarrativeBlocks = 5
+	fincenNarrativeLength = 17000
+)
+
+// FinCEN activity party type codes.
+const (
+	fincenPartyTransmitter         = "35"
+	fincenPartyTransmitterContact  = "37"
+	fincenPartyFilingInstitution   = "30"
+	fincenPartyContactOffice       = "8"
+	fincenPartyActivityInstitution = "34"
+	fincenPartySubject             = "33"
+)
+
+// FinCEN party identification type codes.
+const (
+	fincenIdentificationEIN = "2"
+	fincenIdentificationTCC = "4"
+)
+
+// fincenSARSchema is the subset of the FinCEN SAR XML batch schema the exporter produces, derived
+// from the element declarations of the published XSD: occurrence bounds, lengths and formats.
+var fincenSARSchema = xmlSchema{
+	root: "EFilingBatchXML",
+	rules: []xmlElementRule{
+		{Path: "FormTypeCode", MinOccurs: 1, MaxOccurs: 1, Enum: []string{fincenFormTypeCode}},
+		{Path: "Activity", MinOccurs: 1, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/EFilingPriorDocumentNumber", MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{14}$`)},
+		{Path: "Activity/FilingDateText", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{8}$`)},
+		{Path: "Activity/ActivityAssociation", MinOccurs: 1, MaxOccurs: 1, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/ActivityAssociation/ContinuingActivityReportIndicator", MaxOccurs: 1, Enum: []string{"Y"}},
+		{Path: "Activity/ActivityAssociation/CorrectsAmendsPriorReportIndicator", MaxOccurs: 1, Enum: []string{"Y"}},
+		{Path: "Activity/ActivityAssociation/InitialReportIndicator", MaxOccurs: 1, Enum: []string{"Y"}},
+		{Path: "Activity/Party", MinOccurs: 6, MaxOccurs: 1005, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/Party/ActivityPartyTypeCode", MinOccurs: 1, MaxOccurs: 1, Enum: []string{
+			fincenPartyTransmitter, fincenPartyTransmitterContact, fincenPartyFilingInstitution,
+			fincenPartyContactOffice, fincenPartyActivityInstitution, fincenPartySubject,
+		}},
+		{Path: "Activity/Party/PayeeReceiverIndicator", MaxOccurs: 1, Enum: []string{"Y"}},
+		{Path: "Activity/Party/PurchaserSenderIndicator", MaxOccurs: 1, Enum: []string{"Y"}},
+		{Path: "Activity/Party/PrimaryRegulatorTypeCode", MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{1,2}$`)},
+		{Path: "Activity/Party/PartyName", MinOccurs: 1, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/Party/PartyName/PartyNameTypeCode", MinOccurs: 1, MaxOccurs: 1, Enum: []string{"L"}},
+		{Path: "Activity/Party/PartyName/RawPartyFullName", MaxOccurs: 1, MaxLength: 150, Pattern: regexp.MustCompile(`\S`)},
+		{Path: "Activity/Party/PartyName/RawEntityIndividualLastName", MaxOccurs: 1, MaxLength: 150, Pattern: regexp.MustCompile(`\S`)},
+		{Path: "Activity/Party/PartyName/RawIndividualFirstName", MaxOccurs: 1, MaxLength: 35},
+		{Path: "Activity/Party/Address", MaxOccurs: 1, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/Party/Address/RawStreetAddress1Text", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100},
+		{Path: "Activity/Party/Address/RawCityText", MaxOccurs: 1, MaxLength: 50},
+		{Path: "Activity/Party/Address/RawStateCodeText", MaxOccurs: 1, MaxLength: 3},
+		{Path: "Activity/Party/Address/RawZIPCode", MaxOccurs: 1, MaxLength: 9},
+		{Path: "Activity/Party/Address/RawCountryCodeText", MaxOccurs: 1, Pattern: regexp.MustCompile(`^[A-Z]{2}$`)},
+		{Path: "Activity/Party/PhoneNumber", MaxOccurs: 1, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/Party/PhoneNumber/PhoneNumberText", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{1,16}$`)},
+		{Path: "Activity/Party/IndividualBirthDateText", MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{8}$`)},
+		{Path: "Activity/Party/PartyIdentification", Attrs: []string{"SeqNum"}},
+		{Path: "Activity/Party/PartyIdentification/PartyIdentificationNumberText", MinOccurs: 1, MaxOccurs: 1, MaxLength: 25, Pattern: regexp.MustCompile(`\S`)},
+		{Path: "Activity/Party/PartyIdentification/PartyIdentificationTypeCode", MinOccurs: 1, MaxOccurs: 1, Enum: []string{fincenIdentificationEIN, fincenIdentificationTCC}},
+		{Path: "Activity/Party/PartyAccountAssociation", Attrs: []string{"SeqNum"}},
+		{Path: "Activity/Party/PartyAccountAssociation/AccountNumberText", MinOccurs: 1, MaxOccurs: 1, MaxLength: 40},
+		{Path: "Activity/SuspiciousActivity", MinOccurs: 1, MaxOccurs: 1, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/SuspiciousActivity/CumulativeTotalViolationAmountText", MaxOccurs: 1, Pattern: regexp.MustCompile(`^[1-9]\d{0,14}$`)},
+		{Path: "Activity/SuspiciousActivity/SuspiciousActivityFromDateText", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{8}$`)},
+		{Path: "Activity/SuspiciousActivity/SuspiciousActivityToDateText", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{8}$`)},
+		{Path: "Activity/SuspiciousActivity/TotalSuspiciousAmountText", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^[1-9]\d{0,14}$`)},
+		{Path: "Activity/SuspiciousActivity/SuspiciousActivityClassification", MinOccurs: 1, MaxOccurs: 99, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/SuspiciousActivity/SuspiciousActivityClassification/SuspiciousActivityTypeID", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{1,3}$`)},
+		{Path: "Activity/SuspiciousActivity/SuspiciousActivityClassification/SuspiciousActivitySubtypeID", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{1,4}$`)},
+		{Path: "Activity/SuspiciousActivity/SuspiciousActivityClassification/OtherSuspiciousActivityTypeText", MaxOccurs: 1, MaxLength: 50},
+		{Path: "Activity/ActivityNarrativeInformation", MinOccurs: 1, MaxOccurs: fincenNarrativeBlocks, Attrs: []string{"SeqNum"}},
+		{Path: "Activity/ActivityNarrativeInformation/ActivityNarrativeSequenceNumber", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^[1-5]$`)},
+		{Path: "Activity/ActivityNarrativeInformation/ActivityNarrativeText", MinOccurs: 1, MaxOccurs: 1, MaxLength: fincenNarrativeLength, Pattern: regexp.MustCompile(`\S`)},
+	},
+}
+
+type fincenBatch struct {
+	XMLName                 xml.Name         `xml:"fc2:EFilingBatchXML"`
+	Namespace               string           `xml:"xmlns:fc2,attr"`
+	ActivityCount           int              `xml:"ActivityCount,attr"`
+	TotalAmount             int64            `xml:"TotalAmount,attr"`
+	PartyCount              int              `xml:"PartyCount,attr"`
+	ActivityAttachmentCount int              `xml:"ActivityAttachmentCount,attr"`
+	FormTypeCode            string           `xml:"fc2:FormTypeCode"`
+	Activities              []fincenActivity `xml:"fc2:Activity"`
+}
+
+type fincenActivity struct {
+	SeqNum              int                       `xml:"SeqNum,attr"`
+	PriorDocumentNumber string                    `xml:"fc2:EFilingPriorDocumentNumber,omitempty"`
+	FilingDateText      string                    `xml:"fc2:FilingDateText"`
+	ActivityAssociation fincenActivityAssociation `xml:"fc2:ActivityAssociation"`
+	Parties             []fincenParty             `xml:"fc2:Party"`
+	SuspiciousActivity  fincenSuspiciousActivity  `xml:"fc2:SuspiciousActivity"`
+	Narratives          []fincenNarrative         `xml:"fc2:ActivityNarrativeInformation"`
+}
+
+type fincenActivityAssociation struct {
+	SeqNum                    int    `xml:"SeqNum,attr"`
+	ContinuingReportIndicator string `xml:"fc2:ContinuingActivityReportIndicator,omitempty"`
+	CorrectsAmendsIndicator   string `xml:"fc2:CorrectsAmendsPriorReportIndicator,omitempty"`
+	InitialReportIndicator    string `xml:"fc2:InitialReportIndicator,omitempty"`
+}
+
+type fincenParty struct {
+	SeqNum                   int                        `xml:"SeqNum,attr"`
+	ActivityPartyTypeCode    string                     `xml:"fc2:ActivityPartyTypeCode"`
+	PayeeReceiverIndicator   string                     `xml:"fc2:PayeeReceiverIndicator,omitempty"`
+	PurchaserSenderIndicator string                     `xml:"fc2:PurchaserSenderIndicator,omitempty"`
+	PrimaryRegulatorTypeCode string                     `xml:"fc2:PrimaryRegulatorTypeCode,omitempty"`
+	Names                    []fincenPartyName          `xml:"fc2:PartyName"`
+	Address                  *fincenAddress             `xml:"fc2:Address,omitempty"`
+	Phone                    *fincenPhone               `xml:"fc2:PhoneNumber,omitempty"`
+	BirthDate                string                     `xml:"fc2:IndividualBirthDateText,omitempty"`
+	Identifications          []fincenIdentification     `xml:"fc2:PartyIdentification"`
+	Accounts                 []fincenAccountAssociation `xml:"fc2:PartyAccountAssociation"`
+}
+
+type fincenPartyName struct {
+	SeqNum            int    `xml:"SeqNum,attr"`
+	PartyNameTypeCode string `xml:"fc2:PartyNameTypeCode"`
+	FullName          string `xml:"fc2:RawPartyFullName,omitempty"`
+	LastName          string `xml:"fc2:RawEntityIndividualLastName,omitempty"`
+	FirstName         string `xml:"fc2:RawIndividualFirstName,omitempty"`
+}
+
+type fincenAddress struct {
+	SeqNum  int    `xml:"SeqNum,attr"`
+	City    string `xml:"fc2:RawCityText,omitempty"`
+	Country string `xml:"fc2:RawCountryCodeText,omitempty"`
+	State   string `xml:"fc2:RawStateCodeText,omitempty"`
+	Street  string `xml:"fc2:RawStreetAddress1Text"`
+	ZIP     string `xml:"fc2:RawZIPCode,omitempty"`
+}
+
+type fincenPhone struct {
+	SeqNum int    `xml:"SeqNum,attr"`
+	Number string `xml:"fc2:PhoneNumberText"`
+}
+
+type fincenIdentification struct {
+	SeqNum   int    `xml:"SeqNum,attr"`
+	Number   string `xml:"fc2:PartyIdentificationNumberText"`
+	TypeCode string `xml:"fc2:PartyIdentificationTypeCode"`
+}
+
+type fincenAccountAssociation struct {
+	SeqNum        int    `xml:"SeqNum,attr"`
+	AccountNumber string `xml:"fc2:AccountNumberText"`
+}
+
+type fincenSuspiciousActivity struct {
+	SeqNum           int                    `xml:"SeqNum,attr"`
+	CumulativeAmount string                 `xml:"fc2:CumulativeTotalViolationAmountText,omitempty"`
+	FromDate         string                 `xml:"fc2:SuspiciousActivityFromDateText"`
+	ToDate           string                 `xml:"fc2:SuspiciousActivityToDateText"`
+	TotalAmount      string                 `xml:"fc2:TotalSuspiciousAmountText"`
+	Classifications  []fincenClassification `xml:"fc2:SuspiciousActivityClassification"`
+}
+
+type fincenClassification struct {
+	SeqNum    int    `xml:"SeqNum,attr"`
+	TypeID    int    `xml:"fc2:SuspiciousActivityTypeID"`
+	SubtypeID int    `xml:"fc2:SuspiciousActivitySubtypeID"`
+	OtherText string `xml:"fc2:OtherSuspiciousActivityTypeText,omitempty"`
+}
+
+type fincenNarrative struct {
+	SeqNum         int    `xml:"SeqNum,attr"`
+	SequenceNumber int    `xml:"fc2:ActivityNarrativeSequenceNumber"`
+	Text           string `xml:"fc2:ActivityNarrativeText"`
+}
+
+// fincenSequence hands out the SeqNum attributes, which must be unique within a batch.
+type fincenSequence int
+
+func (s *fincenSequence) next() int {
+	*s++
+	return int(*s)
+}
+
+// FinCENSARExporter writes SARs as a FinCEN BSA SAR XML batch.
+type FinCENSARExporter struct {
+	cfg config.FinCENConfig
+}
+
+// NewFinCENSARExporter builds an exporter for a validated filer configuration.
+func NewFinCENSARExporter(cfg config.FinCENConfig) (*FinCENSARExporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &FinCENSARExporter{cfg: cfg}, nil
+}
+
+// BuildBatch maps each report to a batch activity, numbering every element, and checks the
+// result against the FinCEN schema. Missing mandatory report fields and schema violations are
+// returned together as a *SARValidationError. Subject account numbers are reported unmasked, as
+// the regulator requires.
+func (e *FinCENSARExporter) BuildBatch(reports []*models.SARReport, filedAt time.Time) ([]byte, error) {
+	var problems []string
+	for i, report := range reports {
+		for _, p := range checkSARReport(report) {
+			problems = append(problems, fmt.Sprintf("SAR %d: %s", i+1, p))
+		}
+		if n := len(splitNarrative(sarNarrative(report), fincenNarrativeLength)); n > fincenNarrativeBlocks {
+			problems = append(problems, fmt.Sprintf("SAR %d: narrative too long: it needs %d blocks of %d characters and FinCEN allows %d",
+				i+1, n, fincenNarrativeLength, fincenNarrativeBlocks))
+		}
+	}
+	if len(reports) == 0 {
+		problems = append(problems, "batch has no SARs")
+	}
+	if len(problems) > 0 {
+		return nil, &SARValidationError{Problems: problems}
+	}
+
+	batch := fincenBatch{
+		Namespace:    fincenNamespace,
+		FormTypeCode: fincenFormTypeCode,
+	}
+	var seq fincenSequence
+	for _, report := range reports {
+		activity := e.activity(report, filedAt, &seq)
+		batch.Activities = append(batch.Activities, activity)
+		batch.PartyCount += len(activity.Parties)
+		amount, _ := strconv.ParseInt(activity.SuspiciousActivity.TotalAmount, 10, 64)
+		batch.TotalAmount += amount
+	}
+	batch.ActivityCount = len(batch.Activities)
+
+	data, err := xml.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal FinCEN SAR batch: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+
+	if problems := fincenSARSchema.validate(data); len(problems) > 0 {
+		return nil, &SARValidationError{Problems: problems}
+	}
+	return data, nil
+}
+
+// ExportBatch builds a FinCEN SAR batch and writes it to path. Nothing is written if the batch
+// fails validation.
+func (e *FinCENSARExporter) ExportBatch(reports []*models.SARReport, filedAt time.Time, path string) error {
+	data, err := e.BuildBatch(reports, filedAt)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write FinCEN SAR batch to file: %w", err)
+	}
+	return nil
+}
+
+func (e *FinCENSARExporter) activity(report *models.SARReport, filedAt time.Time, seq *fincenSequence) fincenActivity {
+	filer := e.cfg.Filer
+	activity := fincenActivity{
+		SeqNum:              seq.next(),
+		FilingDateText:      filedAt.UTC().Format(fincenDateFormat),
+		ActivityAssociation: fincenActivityAssociation{SeqNum: seq.next(), InitialReportIndicator: "Y"},
+	}
+
+	institution := func(typeCode string, regulator bool, idType, id string) fincenParty {
+		party := fincenParty{SeqNum: seq.next(), ActivityPartyTypeCode: typeCode}
+		if regulator {
+			party.PrimaryRegulatorTypeCode = filer.RegulatorCode
+		}
+		party.Names = []fincenPartyName{{SeqNum: seq.next(), PartyNameTypeCode: "L", FullName: filer.Name}}
+		party.Address = &fincenAddress{
+			SeqNum: seq.next(), City: filer.City, Country: filer.Country, State: filer.State, Street: filer.Address, ZIP: filer.ZIP,
+		}
+		party.Identifications = []fincenIdentification{{SeqNum: seq.next(), Number: id, TypeCode: idType}}
+		return party
+	}
+
+	activity.Parties = append(activity.Parties,
+		institution(fincenPartyTransmitter, false, fincenIdentificationTCC, e.cfg.TransmitterControlCode),
+		fincenParty{
+			SeqNum: seq.next(), ActivityPartyTypeCode: fincenPartyTransmitterContact,
+			Names: []fincenPartyName{{SeqNum: seq.next(), PartyNameTypeCode: "L", FullName: e.cfg.ContactOffice}},
+		},
+		institution(fincenPartyFilingInstitution, true, fincenIdentificationEIN, filer.TIN),
+		fincenParty{
+			SeqNum: seq.next(), ActivityPartyTypeCode: fincenPartyContactOffice,
+			Names: []fincenPartyName{{SeqNum: seq.next(), PartyNameTypeCode: "L", FullName: e.cfg.ContactOffice}},
+			Phone: &fincenPhone{SeqNum: seq.next(), Number: e.cfg.ContactPhone},
+		},
+		institution(fincenPartyActivityInstitution, true, fincenIdentificationEIN, filer.TIN),
+	)
+	activity.Parties = append(activity.Parties, e.subjects(report, seq)...)
+
+	if c := report.Continuation; c != nil {
+		activity.PriorDocumentNumber = c.PriorFilingReference
+		activity.ActivityAssociation.ContinuingReportIndicator = "Y"
+		activity.ActivityAssociation.InitialReportIndicator = ""
+	}
+	// An amendment refers to the report it corrects, which takes the place of the prior report
+	// of a continuing series.
+	if a := report.Amendment; a != nil {
+		activity.PriorDocumentNumber = a.PriorFilingReference
+		activity.ActivityAssociation.CorrectsAmendsIndicator = "Y"
+		activity.ActivityAssociation.InitialReportIndicator = ""
+	}
+
+	patternKeys := sortedPatternKeys(report)
+	suspicious := fincenSuspiciousActivity{
+		SeqNum:      seq.next(),
+		FromDate:    report.StartDate.UTC().Format(fincenDateFormat),
+		ToDate:      report.EndDate.UTC().Format(fincenDateFormat),
+		TotalAmount: strconv.FormatInt(int64(math.Round(report.TotalSuspiciousAmount)), 10),
+	}
+	if c := report.Continuation; c != nil {
+		suspicious.CumulativeAmount = strconv.FormatInt(int64(math.Round(c.CumulativeAmount)), 10)
+	}
+	seen := make(map[config.FinCENClassification]bool)
+	for _, key := range patternKeys {
+		cl := e.cfg.ClassificationFor(key)
+		if seen[cl] {
+			continue
+		}
+		seen[cl] = true
+		suspicious.Classifications = append(suspicious.Classifications, fincenClassification{
+			SeqNum: seq.next(), TypeID: cl.TypeID, SubtypeID: cl.SubtypeID, OtherText: cl.OtherText,
+		})
+	}
+	activity.SuspiciousActivity = suspicious
+
+	for i, text := range splitNarrative(sarNarrative(report), fincenNarrativeLength) {
+		activity.Narratives = append(activity.Narratives, fincenNarrative{SeqNum: seq.next(), SequenceNumber: i + 1, Text: text})
+	}
+	return activity
+}
+
+// subjects maps each of a report's subjects to a subject party with its accounts. Beneficiaries
+// are marked as payees; the other subjects as senders.
+func (e *FinCENSARExporter) subjects(report *models.SARReport, seq *fincenSequence) []fincenParty {
+	var parties []fincenParty
+	for _, subject := range sarSubjects(report) {
+		party := fincenParty{SeqNum: seq.next(), ActivityPartyTypeCode: fincenPartySubject}
+		if subject.Role == models.SARSubjectBeneficiary {
+			party.PayeeReceiverIndicator = "Y"
+		} else {
+			party.PurchaserSenderIndicator = "Y"
+		}
+		first, last := splitPersonName(subject.Name)
+		party.Names = []fincenPartyName{{SeqNum: seq.next(), PartyNameTypeCode: "L", LastName: last, FirstName: first}}
+		if subject.Address != "" {
+			party.Address = &fincenAddress{SeqNum: seq.next(), Street: subject.Address}
+		}
+		if dob, err := time.Parse("2006-01-02", subject.DateOfBirth); err == nil {
+			party.BirthDate = dob.Format(fincenDateFormat)
+		}
+		for _, account := range subject.AccountIDs {
+			party.Accounts = append(party.Accounts, fincenAccountAssociation{SeqNum: seq.next(), AccountNumber: account})
+		}
+		parties = append(parties, party)
+	}
+	return parties
+}
+
+// checkSARReport lists the mandatory fields a report is missing for regulatory filing.
+func checkSARReport(report *models.SARReport) []string {
+	if report == nil {
+		return []string{"report is empty"}
+	}
+	var problems []string
+	for i, subject := range sarSubjects(report) {
+		label := "subject"
+		if i > 0 {
+			label = fmt.Sprintf("subject %d", i+1)
+		}
+		if i == 0 && subject.Role != models.SARSubjectPrimary {
+			problems = append(problems, "primary subject is missing")
+		}
+		if strings.TrimSpace(subject.Name) == "" {
+			problems = append(problems, label+" name is missing")
+		}
+		if subject.DateOfBirth != "" {
+			if _, err := time.Parse("2006-01-02", subject.DateOfBirth); err != nil {
+				problems = append(problems, fmt.Sprintf("%s date of birth %q is not YYYY-MM-DD", label, subject.DateOfBirth))
+			}
+		}
+	}
+	if report.StartDate.IsZero() || report.EndDate.IsZero() {
+		problems = append(problems, "activity dates are missing")
+	} else if report.EndDate.Before(report.StartDate) {
+		problems = append(problems, "activity end date is before its start date")
+	}
+	if len(report.Patterns) == 0 {
+		problems = append(problems, "no suspicious activity patterns")
+	}
+	if report.TotalSuspiciousAmount <= 0 {
+		problems = append(problems, "total suspicious amount is missing")
+	}
+	if c := report.Continuation; c != nil && strings.TrimSpace(c.PriorFilingReference) == "" {
+		problems = append(problems, "prior report reference is missing for continuing activity")
+	}
+	if a := report.Amendment; a != nil && strings.TrimSpace(a.PriorFilingReference) == "" {
+		problems = append(problems, "prior report reference is missing for the amendment")
+	}
+	return problems
+}
+
+// sarNarrative returns the report's reviewed narrative, or a plain summary of its activity when
+// no narrative has been written. The summary gives counts and totals per pattern; the
+// transactions themselves are listed elsewhere in each filing format.
+func sarNarrative(report *models.SARReport) string {
+	if narrative := strings.TrimSpace(report.Narrative); narrative != "" {
+		return narrative
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Between %s and %s, %d transaction(s) totalling %.2f were identified as suspicious for %s.",
+		report.StartDate.UTC().Format("2006-01-02"), report.EndDate.UTC().Format("2006-01-02"),
+		report.TotalTransactionCount, report.TotalSuspiciousAmount, report.SubjectName)
+	for _, key := range sortedPatternKeys(report) {
+		p := report.Patterns[key]
+		fmt.Fprintf(&b, " %s: %d transaction(s) totalling %.2f.", p.PatternDescription, p.TransactionCount, p.TotalAmount)
+	}
+	return b.String()
+}
+
+// splitNarrative splits a narrative into blocks of at most size characters, breaking at the last
+// whitespace before the limit where there is one.
+func splitNarrative(text string, size int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var blocks []string
+	for len(runes) > size {
+		cut := size
+		for i := size; i > size/2; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+		blocks = append(blocks, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimLeftFunc(string(runes[cut:]), unicode.IsSpace))
+	}
+	return append(blocks, string(runes))
+}
+
+// sortedPatternKeys returns a report's pattern keys in a stable order.
+func sortedPatternKeys(report *models.SARReport) []string {
+	keys := make([]string, 0, len(report.Patterns))
+	for key := range report.Patterns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sarAccountIDs returns the distinct accounts behind a report's transactions, sorted.
+func sarAccountIDs(report *models.SARReport) []string {
+	seen := make(map[string]bool)
+	var accounts []string
+	for _, p := range report.Patterns {
+		for _, tx := range p.Transactions {
+			if tx.AccountID != "" && !seen[tx.AccountID] {
+				seen[tx.AccountID] = true
+				accounts = append(accounts, tx.AccountID)
+			}
+		}
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// splitPersonName splits a full name into first and last names at the last space.
+func splitPersonName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		return strings.TrimSpace(name[:i]), name[i+1:]
+	}
+	return "", name
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+// testSARReport returns a report with a structuring and a threshold pattern on one account.
+func testSARReport() *models.SARReport {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	tx := func(id string, amount float64, at time.Time, destination string) models.Transaction {
+		return models.Transaction{
+			TransactionID: id, AccountID: "ACCT9876543210", Amount: amount, Currency: "USD", Timestamp: at,
+			SourceCountry: "US", DestinationCountry: destination, TransactionType: "WIRE", Status: "COMPLETED",
+		}
+	}
+	structuring := []models.Transaction{tx("tx-1", 9500, start, "US"), tx("tx-2", 9400, start.Add(24*time.Hour), "US")}
+	threshold := []models.Transaction{tx("tx-3", 25000, start.Add(72*time.Hour), "GE")}
+	return &models.SARReport{
+		SubjectName:           "Jane Q Doe",
+		SubjectAddress:        "42 Elm Street, Springfield",
+		SubjectDateOfBirth:    "1980-05-20",
+		StartDate:             start,
+		EndDate:               start.Add(72 * time.Hour),
+		TotalSuspiciousAmount: 43900,
+		TotalTransactionCount: 3,
+		Patterns: models.SARPatterns{
+			AlertTypeStructuringPattern: {PatternDescription: AlertTypeStructuringPattern, Transactions: structuring, TotalAmount: 18900, TransactionCount: 2},
+			AlertTypeThresholdViolation: {PatternDescription: AlertTypeThresholdViolation, Transactions: threshold, TotalAmount: 25000, TransactionCount: 1},
+		},
+	}
+}
+
+func TestFinCENSARExporter(t *testing.T) {
+	filedAt := time.Date(2024, 6, 3, 15, 0, 0, 0, time.UTC)
+	cfg, err := config.LoadFinCENConfig("../../fincen.json")
+	if err != nil {
+		t.Fatalf("LoadFinCENConfig failed: %v", err)
+	}
+	exporter, err := NewFinCENSARExporter(cfg)
+	if err != nil {
+		t.Fatalf("NewFinCENSARExporter failed: %v", err)
+	}
+
+	// Test Case 1: Reports map to a numbered batch that passes the schema check
+	t.Run("batch", func(t *testing.T) {
+		data, err := exporter.BuildBatch([]*models.SARReport{testSARReport(), testSARReport()}, filedAt)
+		if err != nil {
+			t.Fatalf("BuildBatch failed: %v", err)
+		}
+		root, err := parseXMLTree(data)
+		if err != nil {
+			t.Fatalf("parseXMLTree failed: %v", err)
+		}
+		if root.attrs["ActivityCount"] != "2" || root.attrs["TotalAmount"] != "87800" || root.attrs["PartyCount"] != "12" {
+			t.Errorf("Unexpected batch totals: %v", root.attrs)
+		}
+
+		seqNums := make(map[string]bool)
+		dec := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				break
+			}
+			start, ok := tok.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			for _, attr := range start.Attr {
+				if attr.Name.Local != "SeqNum" {
+					continue
+				}
+				if seqNums[attr.Value] {
+					t.Errorf("Duplicate SeqNum %s on %s", attr.Value, start.Name.Local)
+				}
+				seqNums[attr.Value] = true
+			}
+		}
+
+		activity := root.find("Activity")[0]
+		if got := activity.find("FilingDateText")[0].text; got != "20240603" {
+			t.Errorf("Expected filing date 20240603, got %s", got)
+		}
+		var subject *xmlNode
+		for _, party := range activity.find("Party") {
+			if party.find("ActivityPartyTypeCode")[0].text == fincenPartySubject {
+				subject = party
+			}
+		}
+		if subject == nil {
+			t.Fatalf("Expected a subject party")
+		}
+		if subject.find("PartyName/RawEntityIndividualLastName")[0].text != "Doe" || subject.find("IndividualBirthDateText")[0].text != "19800520" {
+			t.Errorf("Unexpected subject: %+v", subject)
+		}
+		if subject.find("PartyAccountAssociation/AccountNumberText")[0].text != "ACCT9876543210" {
+			t.Errorf("Expected the unmasked subject account")
+		}
+
+		suspicious := activity.find("SuspiciousActivity")[0]
+		if suspicious.find("SuspiciousActivityFromDateText")[0].text != "20240501" || suspicious.find("TotalSuspiciousAmountText")[0].text != "43900" {
+			t.Errorf("Unexpected suspicious activity: %+v", suspicious)
+		}
+		if n := len(suspicious.find("SuspiciousActivityClassification")); n != 2 {
+			t.Errorf("Expected a classification per pattern, got %d", n)
+		}
+		narrative := activity.find("ActivityNarrativeInformation/ActivityNarrativeText")[0].text
+		if !strings.Contains(narrative, "THRESHOLD_VIOLATION: 1 transaction(s) totalling 25000.00") || strings.Contains(narrative, "tx-3") {
+			t.Errorf("Expected the narrative to summarise each pattern without listing transactions, got %q", narrative)
+		}
+	})
+
+	// Test Case 2: Missing mandatory fields are all reported and nothing is written
+	t.Run("missing_fields", func(t *testing.T) {
+		report := testSARReport()
+		report.SubjectName = ""
+		report.StartDate = time.Time{}
+		report.SubjectDateOfBirth = "20/05/1980"
+		path := filepath.Join(t.TempDir(), "sar.xml")
+
+		err := exporter.ExportBatch([]*models.SARReport{report}, filedAt, path)
+		var verr *SARValidationError
+		if !errors.Is(err, ErrSARInvalid) || !errors.As(err, &verr) {
+			t.Fatalf("Expected a SARValidationError, got %v", err)
+		}
+		if len(verr.Problems) != 3 || !strings.Contains(verr.Problems[0], "SAR 1: subject name") {
+			t.Errorf("Expected three problems for SAR 1, got %v", verr.Problems)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected no file to be written, got %v", err)
+		}
+	})
+
+	// Test Case 3: The schema check catches values the report checks cannot
+	t.Run("schema", func(t *testing.T) {
+		report := testSARReport()
+		report.SubjectName = "Jane " + strings.Repeat("x", 151)
+		_, err := exporter.BuildBatch([]*models.SARReport{report}, filedAt)
+		if err == nil || !strings.Contains(err.Error(), "RawEntityIndividualLastName is longer than 150 characters") {
+			t.Errorf("Expected the long last name to fail the schema check, got %v", err)
+		}
+
+		problems := fincenSARSchema.validate([]byte(`<EFilingBatchXML><FormTypeCode>CTRX</FormTypeCode></EFilingBatchXML>`))
+		if len(problems) != 2 {
+			t.Errorf("Expected a wrong form type and a missing activity, got %v", problems)
+		}
+	})
+
+	// Test Case 4: Valid batches are written with restricted permissions
+	t.Run("export", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sar.xml")
+		if err := exporter.ExportBatch([]*models.SARReport{testSARReport()}, filedAt, path); err != nil {
+			t.Fatalf("ExportBatch failed: %v", err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("failed to stat file: %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("file permissions are %o, want 0600", info.Mode().Perm())
+		}
+	})
+
+	// Test Case 5: Continuing reports cite the prior report and the series' cumulative amount
+	t.Run("continuing", func(t *testing.T) {
+		report := testSARReport()
+		report.Continuation = &models.SARContinuation{
+			PriorFilingReference:       "31000123456789",
+			ReportNumber:               2,
+			SeriesStartDate:            report.StartDate.AddDate(0, -4, 0),
+			CumulativeAmount:           101250.4,
+			CumulativeTransactionCount: 9,
+		}
+		data, err := exporter.BuildBatch([]*models.SARReport{report}, filedAt)
+		if err != nil {
+			t.Fatalf("BuildBatch failed: %v", err)
+		}
+		root, err := parseXMLTree(data)
+		if err != nil {
+			t.Fatalf("parseXMLTree failed: %v", err)
+		}
+		activity := root.find("Activity")[0]
+		if got := activity.find("EFilingPriorDocumentNumber"); len(got) != 1 || got[0].text != "31000123456789" {
+			t.Errorf("Expected the prior document number, got %v", got)
+		}
+		if len(activity.find("ActivityAssociation/ContinuingActivityReportIndicator")) != 1 || len(activity.find("ActivityAssociation/InitialReportIndicator")) != 0 {
+			t.Errorf("Expected only the continuing activity indicator")
+		}
+		if got := activity.find("SuspiciousActivity/CumulativeTotalViolationAmountText"); len(got) != 1 || got[0].text != "101250" {
+			t.Errorf("Expected cumulative amount 101250, got %v", got)
+		}
+
+		report.Continuation.PriorFilingReference = ""
+		if _, err := exporter.BuildBatch([]*models.SARReport{report}, filedAt); err == nil || !strings.Contains(err.Error(), "prior report reference is missing") {
+			t.Errorf("Expected a missing prior reference to fail, got %v", err)
+		}
+	})
+
+	// Test Case 6: Each subject is a party with their own accounts; beneficiaries are payees
+	t.Run("subjects", func(t *testing.T) {
+		data, err := exporter.BuildBatch([]*models.SARReport{testMultiSubjectReport()}, filedAt)
+		if err != nil {
+			t.Fatalf("BuildBatch failed: %v", err)
+		}
+		root, err := parseXMLTree(data)
+		if err != nil {
+			t.Fatalf("parseXMLTree failed: %v", err)
+		}
+		var subjects []*xmlNode
+		for _, party := range root.find("Activity/Party") {
+			if party.find("ActivityPartyTypeCode")[0].text == fincenPartySubject {
+				subjects = append(subjects, party)
+			}
+		}
+		if len(subjects) != 3 {
+			t.Fatalf("Expected 3 subject parties, got %d", len(subjects))
+		}
+		want := []struct{ last, account, indicator string }{
+			{"Doe", "ACCT9876543210", "PurchaserSenderIndicator"},
+			{"Roe", "ACCT1111222233", "PurchaserSenderIndicator"},
+			{"Lee", "ACCT5555666677", "PayeeReceiverIndicator"},
+		}
+		for i, w := range want {
+			party := subjects[i]
+			if got := party.find("PartyName/RawEntityIndividualLastName")[0].text; got != w.last {
+				t.Errorf("Subject %d: expected %s, got %s", i, w.last, got)
+			}
+			if got := party.find("PartyAccountAssociation/AccountNumberText"); len(got) != 1 || got[0].text != w.account {
+				t.Errorf("Subject %d: expected account %s, got %v", i, w.account, got)
+			}
+			if len(party.find(w.indicator)) != 1 {
+				t.Errorf("Subject %d: expected %s", i, w.indicator)
+			}
+		}
+
+		report := testMultiSubjectReport()
+		report.Subjects[0].Role = models.SARSubjectCoConspirator
+		if _, err := exporter.BuildBatch([]*models.SARReport{report}, filedAt); err == nil || !strings.Contains(err.Error(), "primary subject is missing") {
+			t.Errorf("Expected a missing primary subject to fail, got %v", err)
+		}
+	})
+
+	// Test Case 7: Long narratives are split across blocks, up to the number FinCEN allows
+	t.Run("narrative_blocks", func(t *testing.T) {
+		report := testSARReport()
+		report.Narrative = strings.Repeat("The subject made another deposit. ", 1500)
+		data, err := exporter.BuildBatch([]*models.SARReport{report}, filedAt)
+		if err != nil {
+			t.Fatalf("BuildBatch failed: %v", err)
+		}
+		root, err := parseXMLTree(data)
+		if err != nil {
+			t.Fatalf("parseXMLTree failed: %v", err)
+		}
+		blocks := root.find("Activity/ActivityNarrativeInformation")
+		if len(blocks) != 3 {
+			t.Fatalf("Expected 51000 characters in 3 narrative blocks, got %d", len(blocks))
+		}
+		var joined []string
+		for i, block := range blocks {
+			if block.find("ActivityNarrativeSequenceNumber")[0].text != strconv.Itoa(i+1) {
+				t.Errorf("Expected block %d to be numbered %d", i, i+1)
+			}
+			joined = append(joined, block.find("ActivityNarrativeText")[0].text)
+		}
+		if strings.Join(joined, " ") != strings.TrimSpace(report.Narrative) {
+			t.Errorf("Expected the blocks to split the narrative at spaces")
+		}
+
+		report.Narrative = strings.Repeat("x ", 45000)
+		_, err = exporter.BuildBatch([]*models.SARReport{report}, filedAt)
+		if err == nil || !strings.Contains(err.Error(), "SAR 1: narrative too long") {
+			t.Errorf("Expected a narrative over 5 blocks to be rejected, got %v", err)
+		}
+	})
+
+	// Test Case 8: Reports with hundreds of transactions and no reviewed narrative still file
+	t.Run("many_transactions", func(t *testing.T) {
+		report := testSARReport()
+		pattern := report.Patterns[AlertTypeStructuringPattern]
+		for i := 0; i < 600; i++ {
+			tx := pattern.Transactions[0]
+			tx.TransactionID = fmt.Sprintf("tx-bulk-%04d-%s", i, strings.Repeat("0", 30))
+			pattern.Transactions = append(pattern.Transactions, tx)
+		}
+		pattern.TransactionCount = len(pattern.Transactions)
+		report.Patterns[AlertTypeStructuringPattern] = pattern
+		if _, err := exporter.BuildBatch([]*models.SARReport{report}, filedAt); err != nil {
+			t.Errorf("BuildBatch failed: %v", err)
+		}
+	})
+
+	// Test Case 9: Amendments cite the report they correct instead of being initial reports
+	t.Run("amendment", func(t *testing.T) {
+		report := testSARReport()
+		report.Amendment = &models.SARAmendment{PriorSARID: "sar-1", PriorFilingReference: "31000123456789"}
+		data, err := exporter.BuildBatch([]*models.SARReport{report}, filedAt)
+		if err != nil {
+			t.Fatalf("BuildBatch failed: %v", err)
+		}
+		root, err := parseXMLTree(data)
+		if err != nil {
+			t.Fatalf("parseXMLTree failed: %v", err)
+		}
+		activity := root.find("Activity")[0]
+		if got := activity.find("EFilingPriorDocumentNumber"); len(got) != 1 || got[0].text != "31000123456789" {
+			t.Errorf("Expected the corrected report's document number, got %v", got)
+		}
+		if len(activity.find("ActivityAssociation/CorrectsAmendsPriorReportIndicator")) != 1 || len(activity.find("ActivityAssociation/InitialReportIndicator")) != 0 {
+			t.Errorf("Expected only the corrects/amends indicator")
+		}
+
+		report.Amendment.PriorFilingReference = ""
+		if _, err := exporter.BuildBatch([]*models.SARReport{report}, filedAt); err == nil || !strings.Contains(err.Error(), "prior report reference is missing for the amendment") {
+			t.Errorf("Expected a missing prior reference to fail, got %v", err)
+		}
+	})
+}
@@ -4,285 +4,259 @@ import (
 	"database/sql"
 	"encoding/json" // Added
 	"fmt"           // Added
-	"strings"
+	"sort"
 	"time"
 
+	"AML/internal/database"
 	"AML/internal/models"
 )
 
 // ensure time is imported and used to avoid linter errors when only type declarations use it.
 var _ = time.Now
 
+// GenerateCaseSARData builds a Suspicious Activity Report from every alert in a case.
+func GenerateCaseSARData(caseID string, db database.DBTX) (*models.SARReport, error) {
+	if _, err := GetCase(db, caseID); err != nil {
+		return nil, err
+	}
+	alertIDs, err := caseAlertIDs(db, caseID)
+	if err != nil {
+		return nil, err
+	}
+	return GenerateSARData(alertIDs, db)
+}
+
 // GenerateSARData aggregates data from multiple alerts into a single Suspicious Activity Report (SAR).
-func GenerateSARData(alertIDs []string, db *sql.DB) (*models.SARReport, error) {
+// Alerts, transactions and accounts are read in chunks and folded into the report as they stream
+// in, so large alert sets neither overflow IN lists nor hold every row at once. Each pattern lists
+// its transactions in time order.
+func GenerateSARData(alertIDs []string, db database.DBTX) (*models.SARReport, error) {
 	if len(alertIDs) == 0 {
 		// Return nil, nil if no alert IDs are provided, as there's nothing to report.
 		return nil, nil
 	}
 
-	queryArgs := make([]interface{}, len(alertIDs))
-	for i, id := range alertIDs {
-		queryArgs[i] = id
+	report := &models.SARReport{
+		Patterns: make(map[string]models.SuspiciousActivityPattern),
+	}
+	var minStartDate time.Time = time.Now().Add(100 * 365 * 24 * time.Hour) // Far future
+	var maxEndDate time.Time
+	addTransaction := func(alertType string, tx models.Transaction) {
+		pattern := report.Patterns[alertType]
+		pattern.Transactions = append(pattern.Transactions, tx)
+		pattern.TotalAmount += tx.Amount
+		pattern.TransactionCount++
+		report.Patterns[alertType] = pattern
+
+		if tx.Timestamp.Before(minStartDate) {
+			minStartDate = tx.Timestamp
+		}
+		if tx.Timestamp.After(maxEndDate) {
+			maxEndDate = tx.Timestamp
+		}
+		report.TotalSuspiciousAmount += tx.Amount
+		report.TotalTransactionCount++
 	}
 
-	// 1. Fetch Alerts based on alertIDs
+	// 1. Stream the alerts, reporting the transactions recorded in their rule details. Alerts
+	// without them report their own transaction, which is read in step 2.
+	alertTxIDs := make(map[string]string)
+	var relatedTxIDs []string
+	seenTxIDs := make(map[string]bool)
+	addRelated := func(txID string) {
+		if !seenTxIDs[txID] {
+			seenTxIDs[txID] = true
+			relatedTxIDs = append(relatedTxIDs, txID)
+		}
+	}
+	pendingAlertTypes := make(map[string][]string) // transaction ID -> alert types reporting it
 	alertQuery := `
 		SELECT id, alert_type, transaction_id, rule_details
 		FROM alerts
-		WHERE id IN (?` + strings.Repeat(",?", len(alertIDs)-1) + `)
+		WHERE id IN %s
 	`
-	alertRows, err := db.Query(alertQuery, queryArgs...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query alerts: %w", err)
-	}
-	defer alertRows.Close()
-
-	var alerts []models.Alert
-	var allRelatedTxIDs []string
-	alertIDToDetails := make(map[string]models.Alert)
-
-	for alertRows.Next() {
+	err := database.QueryIn(db, alertQuery, distinctIDs(alertIDs), func(rows *sql.Rows) error {
 		var alert models.Alert
-		err := alertRows.Scan(&alert.ID, &alert.AlertType, &alert.TransactionID, &alert.RuleDetails)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		if err := rows.Scan(&alert.ID, &alert.AlertType, &alert.TransactionID, &alert.RuleDetails); err != nil {
+			return fmt.Errorf("failed to scan alert row: %w", err)
 		}
+		alertTxIDs[alert.ID] = alert.TransactionID
 
-		// `alert.RuleDetails` is now directly populated by its Scan method.
-		// We can directly use alert.RuleDetails now.
-		ruleDetailsMap := alert.RuleDetails
+		pattern := report.Patterns[alert.AlertType]
+		pattern.PatternDescription = alert.AlertType
+		report.Patterns[alert.AlertType] = pattern
 
-		if rawMatchingTxs, ok := ruleDetailsMap["matching_transactions"]; ok {
-			if list, isList := rawMatchingTxs.([]interface{}); isList {
-				for _, item := range list {
-					txBytes, err := json.Marshal(item)
-					if err != nil {
-						return nil, fmt.Errorf("failed to marshal transaction item from rule_details for alert %s: %w", alert.ID, err)
-					}
-					var tx models.Transaction
-					err = json.Unmarshal(txBytes, &tx)
-					if err != nil {
-						return nil, fmt.Errorf("failed to unmarshal transaction item to models.Transaction for alert %s: %w", alert.ID, err)
-					}
-					allRelatedTxIDs = append(allRelatedTxIDs, tx.TransactionID)
-				}
-			} else {
-				// Fallback for single transaction if not a list
-				txBytes, err := json.Marshal(rawMatchingTxs)
-				if err != nil {
-					return nil, fmt.Errorf("failed to marshal single transaction from rule_details for alert %s: %w", alert.ID, err)
-				}
-				var tx models.Transaction
-				err = json.Unmarshal(txBytes, &tx)
-				if err != nil {
-					return nil, fmt.Errorf("failed to unmarshal single transaction to models.Transaction for alert %s: %w", alert.ID, err)
-				}
-				allRelatedTxIDs = append(allRelatedTxIDs, tx.TransactionID)
-			}
-		} else {
-			// If no matching_transactions in rule_details, fall back to alert's TransactionID
-			allRelatedTxIDs = append(allRelatedTxIDs, alert.TransactionID)
+		matching, ok, err := alertMatchingTransactions(alert)
+		if err != nil {
+			return err
 		}
-
-		alertIDToDetails[alert.ID] = alert
-		alerts = append(alerts, alert)
-	}
-
-	if err = alertRows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+		if !ok {
+			// Fallback: If no 'matching_transactions' in RuleDetails, just use the alert's primary transaction
+			pendingAlertTypes[alert.TransactionID] = append(pendingAlertTypes[alert.TransactionID], alert.AlertType)
+			addRelated(alert.TransactionID)
+			return nil
+		}
+		for _, tx := range matching {
+			addTransaction(alert.AlertType, tx)
+			addRelated(tx.TransactionID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
 	}
 
-	if len(allRelatedTxIDs) == 0 {
+	if len(relatedTxIDs) == 0 {
 		return nil, nil // No related transactions found
 	}
 
-	// 2. Fetch all related transactions
-	uniqueRelatedTxIDs := make(map[string]bool)
-	for _, id := range allRelatedTxIDs {
-		uniqueRelatedTxIDs[id] = true
-	}
-	var finalTxIDs []string
-	for id := range uniqueRelatedTxIDs {
-		finalTxIDs = append(finalTxIDs, id)
-	}
-
-	txQueryArgs := make([]interface{}, len(finalTxIDs))
-	for i, id := range finalTxIDs {
-		txQueryArgs[i] = id
-	}
-
+	// 2. Stream the related transactions, noting the accounts and counterparties involved
+	txAccounts := make(map[string]string)
+	var accountIDs []string
+	uniqueAccountIDs := make(map[string]bool)
+	var counterpartyIDs []string
+	seenCounterparties := make(map[string]bool)
+	var earliest time.Time
+	var earliestAccountID string
 	txQuery := `
 		SELECT transaction_id, account_id, amount, currency, timestamp,
-			source_country, destination_country, transaction_type, status
+			source_country, destination_country, transaction_type, status, COALESCE(counterparty_id, '')
 		FROM transactions
-		WHERE transaction_id IN (?` + strings.Repeat(",?", len(finalTxIDs)-1) + `)
-		ORDER BY timestamp ASC
+		WHERE transaction_id IN %s
 	`
-	txRows, err := db.Query(txQuery, txQueryArgs...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions: %w", err)
-	}
-	defer txRows.Close()
-
-	transactionsMap := make(map[string]models.Transaction)
-	var accountIDs []string // To collect account IDs for fetching account details
-	uniqueAccountIDs := make(map[string]bool)
-
-	for txRows.Next() {
+	err = database.QueryIn(db, txQuery, relatedTxIDs, func(rows *sql.Rows) error {
 		var tx models.Transaction
-		err := txRows.Scan(
+		err := rows.Scan(
 			&tx.TransactionID, &tx.AccountID, &tx.Amount, &tx.Currency, &tx.Timestamp,
-			&tx.SourceCountry, &tx.DestinationCountry, &tx.TransactionType, &tx.Status,
+			&tx.SourceCountry, &tx.DestinationCountry, &tx.TransactionType, &tx.Status, &tx.CounterpartyID,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
+			return fmt.Errorf("failed to scan transaction row: %w", err)
 		}
-		transactionsMap[tx.TransactionID] = tx
-		if _, exists := uniqueAccountIDs[tx.AccountID]; !exists {
+		txAccounts[tx.TransactionID] = tx.AccountID
+		if !uniqueAccountIDs[tx.AccountID] {
 			uniqueAccountIDs[tx.AccountID] = true
 			accountIDs = append(accountIDs, tx.AccountID)
 		}
-	}
-
-	if err = txRows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
+		if tx.CounterpartyID != "" && !seenCounterparties[tx.CounterpartyID] {
+			seenCounterparties[tx.CounterpartyID] = true
+			counterpartyIDs = append(counterpartyIDs, tx.CounterpartyID)
+		}
+		if earliestAccountID == "" || tx.Timestamp.Before(earliest) {
+			earliest, earliestAccountID = tx.Timestamp, tx.AccountID
+		}
+		for _, alertType := range pendingAlertTypes[tx.TransactionID] {
+			addTransaction(alertType, tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
 	}
 
 	if len(accountIDs) == 0 {
 		return nil, fmt.Errorf("no account IDs found for related transactions")
 	}
 
-	// 3. Fetch account details
-	accountQueryArgs := make([]interface{}, len(accountIDs))
-	for i, id := range accountIDs {
-		accountQueryArgs[i] = id
+	// 3. Fetch account details, including counterparties that hold accounts with us
+	lookupIDs := append([]string(nil), accountIDs...)
+	beneficiaryIDs := counterpartyIDs[:0]
+	for _, id := range counterpartyIDs {
+		if !uniqueAccountIDs[id] {
+			beneficiaryIDs = append(beneficiaryIDs, id)
+			lookupIDs = append(lookupIDs, id)
+		}
 	}
-
+	accountsMap := make(map[string]models.Account)
 	accountQuery := `
 		SELECT account_id, holder_name, address, date_of_birth
 		FROM accounts
-		WHERE account_id IN (?` + strings.Repeat(",?", len(accountIDs)-1) + `)
+		WHERE account_id IN %s
 	`
-	accRows, err := db.Query(accountQuery, accountQueryArgs...)
+	err = database.QueryIn(db, accountQuery, lookupIDs, func(rows *sql.Rows) error {
+		var account models.Account
+		if err := rows.Scan(&account.AccountID, &account.HolderName, &account.Address, &account.DateOfBirth); err != nil {
+			return fmt.Errorf("failed to scan account row: %w", err)
+		}
+		accountsMap[account.AccountID] = account
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to query accounts: %w", err)
 	}
-	defer accRows.Close()
 
-	accountsMap := make(map[string]models.Account)
-	for accRows.Next() {
-		var account models.Account
-		err := accRows.Scan(&account.AccountID, &account.HolderName, &account.Address, &account.DateOfBirth)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan account row: %w", err)
+	// 4. Name the subjects and finish the report. The primary subject holds the account of the
+	// first requested alert's transaction, or of the earliest related transaction.
+	primaryAccountID := earliestAccountID
+	for _, id := range alertIDs {
+		if accountID, ok := txAccounts[alertTxIDs[id]]; ok {
+			primaryAccountID = accountID
+			break
 		}
-		accountsMap[account.AccountID] = account
 	}
-
-	if err = accRows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating account rows: %w", err)
+	report.Subjects = buildSARSubjects(primaryAccountID, accountIDs, beneficiaryIDs, accountsMap)
+	if len(report.Subjects) > 0 && report.Subjects[0].Role == models.SARSubjectPrimary {
+		report.SubjectName = report.Subjects[0].Name
+		report.SubjectAddress = report.Subjects[0].Address
+		report.SubjectDateOfBirth = report.Subjects[0].DateOfBirth
 	}
 
-	// 4. Aggregate data into SARReport
-	report := &models.SARReport{
-		Patterns: make(map[string]models.SuspiciousActivityPattern),
+	for key, pattern := range report.Patterns {
+		sortTransactions(pattern.Transactions)
+		report.Patterns[key] = pattern
 	}
-	isFirstRow := true // To set subject details and initial start date
+	report.StartDate = minStartDate
+	report.EndDate = maxEndDate
 
-	var minStartDate time.Time = time.Now().Add(100 * 365 * 24 * time.Hour) // Far future
-	var maxEndDate time.Time
-
-	for _, alert := range alerts {
-		// Get account info for the primary account associated with the alert (or first transaction)
-		var associatedAccount models.Account
-		if len(allRelatedTxIDs) > 0 {
-			if tx, ok := transactionsMap[alert.TransactionID]; ok { // Use alert's primary transaction ID
-				associatedAccount = accountsMap[tx.AccountID]
-			} else { // Fallback to any related transaction's account if alert.TransactionID isn't a direct match in map
-				for _, txID := range allRelatedTxIDs {
-					if tx, ok := transactionsMap[txID]; ok {
-						associatedAccount = accountsMap[tx.AccountID]
-						break
-					}
-				}
-			}
-		}
-
-		if isFirstRow && associatedAccount.AccountID != "" {
-			report.SubjectName = associatedAccount.HolderName
-			report.SubjectAddress = associatedAccount.Address
-			report.SubjectDateOfBirth = associatedAccount.DateOfBirth
-			isFirstRow = false
-		}
-
-		pattern := report.Patterns[alert.AlertType]
-		pattern.PatternDescription = alert.AlertType
-
-		// Extract transactions for this specific alert from RuleDetails
-		var alertRuleDetails map[string]interface{}
-		if len(alert.RuleDetails) > 0 { // Check if RuleDetails has content
-			// Since RuleDetails is now JSONMap, we need to unmarshal it from []byte if it was stored as text
-			// For in-memory GORM setup, it might be directly available as map[string]interface{}
-			// Assuming alert.RuleDetails is already unmarshalled to map[string]interface{} by models.JSONMap Scan method
-			alertRuleDetails = alert.RuleDetails
-		}
+	return report, nil
+}
 
-		var currentAlertMatchingTxs []models.Transaction
-		if rawMatchingTxs, ok := alertRuleDetails["matching_transactions"]; ok {
-			if list, isList := rawMatchingTxs.([]interface{}); isList {
-				for _, item := range list {
-					txBytes, err := json.Marshal(item)
-					if err != nil {
-						return nil, fmt.Errorf("failed to marshal transaction item from alert rule_details for report: %w", err)
-					}
-					var tx models.Transaction
-					err = json.Unmarshal(txBytes, &tx)
-					if err != nil {
-						return nil, fmt.Errorf("failed to unmarshal transaction item to models.Transaction from alert rule_details for report: %w", err)
-					}
-					currentAlertMatchingTxs = append(currentAlertMatchingTxs, tx)
-				}
-			} else {
-				// Fallback for single transaction if not a list
-				txBytes, err := json.Marshal(rawMatchingTxs)
-				if err != nil {
-					return nil, fmt.Errorf("failed to marshal single transaction from alert rule_details for report: %w", err)
-				}
-				var tx models.Transaction
-				err = json.Unmarshal(txBytes, &tx)
-				if err != nil {
-					return nil, fmt.Errorf("failed to unmarshal single transaction to models.Transaction from alert rule_details for report: %w", err)
-				}
-				currentAlertMatchingTxs = append(currentAlertMatchingTxs, tx)
-			}
-		} else {
-			// Fallback: If no 'matching_transactions' in RuleDetails, just use the alert's primary transaction
-			if tx, ok := transactionsMap[alert.TransactionID]; ok {
-				currentAlertMatchingTxs = append(currentAlertMatchingTxs, tx)
-			}
+// alertMatchingTransactions returns the transactions recorded in an alert's rule details, and
+// false if it has none recorded.
+func alertMatchingTransactions(alert models.Alert) ([]models.Transaction, bool, error) {
+	rawMatchingTxs, ok := alert.RuleDetails["matching_transactions"]
+	if !ok {
+		return nil, false, nil
+	}
+	items, isList := rawMatchingTxs.([]interface{})
+	if !isList {
+		// Fallback for single transaction if not a list
+		items = []interface{}{rawMatchingTxs}
+	}
+	matching := make([]models.Transaction, 0, len(items))
+	for _, item := range items {
+		txBytes, err := json.Marshal(item)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to marshal transaction item from rule_details for alert %s: %w", alert.ID, err)
 		}
-
-		for _, tx := range currentAlertMatchingTxs {
-			pattern.Transactions = append(pattern.Transactions, tx)
-			pattern.TotalAmount += tx.Amount
-			pattern.TransactionCount++
-
-			if tx.Timestamp.Before(minStartDate) {
-				minStartDate = tx.Timestamp
-			}
-			if tx.Timestamp.After(maxEndDate) {
-				maxEndDate = tx.Timestamp
-			}
-			report.TotalSuspiciousAmount += tx.Amount
-			report.TotalTransactionCount++
+		var tx models.Transaction
+		if err := json.Unmarshal(txBytes, &tx); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal transaction item to models.Transaction for alert %s: %w", alert.ID, err)
 		}
-		report.Patterns[alert.AlertType] = pattern
+		matching = append(matching, tx)
 	}
+	return matching, true, nil
+}
 
-	if len(alerts) > 0 {
-		report.StartDate = minStartDate
-		report.EndDate = maxEndDate
+// distinctIDs returns ids without repeats, in their first order.
+func distinctIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	distinct := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, id)
+		}
 	}
+	return distinct
+}
 
-	return report, nil
+// sortTransactions puts transactions in time order, breaking ties by ID.
+func sortTransactions(txs []models.Transaction) {
+	sort.SliceStable(txs, func(i, j int) bool {
+		if !txs[i].Timestamp.Equal(txs[j].Timestamp) {
+			return txs[i].Timestamp.Before(txs[j].Timestamp)
+		}
+		return txs[i].TransactionID < txs[j].TransactionID
+	})
 }
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
+
+	"AML/internal/database"
 )
 
 // ExampleGenerateSARData demonstrates how to use the GenerateSARData function.
@@ -31,10 +33,10 @@ func ExampleGenerateSARData() {
 	CREATE TABLE transactions (
 		transaction_id TEXT PRIMARY KEY, account_id TEXT, amount REAL, currency TEXT, 
 		timestamp DATETIME, source_country TEXT, destination_country TEXT, 
-		transaction_type TEXT, status TEXT
+		transaction_type TEXT, status TEXT, counterparty_id TEXT
 	);
 	CREATE TABLE alerts (
-		id TEXT PRIMARY KEY, transaction_id TEXT, alert_type TEXT, status TEXT, created_at DATETIME
+		id TEXT PRIMARY KEY, transaction_id TEXT, alert_type TEXT, status TEXT, created_at DATETIME, rule_details TEXT
 	);
 	`
 	if _, err := db.Exec(schema); err != nil {
@@ -58,7 +60,7 @@ func ExampleGenerateSARData() {
 		{"tx003", "acc001", 8500.00, time.Now().Add(-24 * time.Hour)},
 	}
 	for _, tx := range txs {
-		if _, err := db.Exec(`INSERT INTO transactions VALUES (?, ?, ?, 'USD', ?, 'US', 'GE', 'WIRE', 'COMPLETED')`,
+		if _, err := db.Exec(`INSERT INTO transactions VALUES (?, ?, ?, 'USD', ?, 'US', 'GE', 'WIRE', 'COMPLETED', NULL)`,
 			tx.id, tx.accID, tx.amount, tx.ts); err != nil {
 			log.Fatalf("Failed to insert transaction %s: %v", tx.id, err)
 		}
@@ -73,7 +75,7 @@ func ExampleGenerateSARData() {
 	alertIDs := make([]string, len(alerts))
 	for i, a := range alerts {
 		alertIDs[i] = a.id
-		if _, err := db.Exec(`INSERT INTO alerts VALUES (?, ?, ?, 'OPEN', ?)`, a.id, a.txID, a.alertType, time.Now()); err != nil {
+		if _, err := db.Exec(`INSERT INTO alerts (id, transaction_id, alert_type, status, created_at) VALUES (?, ?, ?, 'OPEN', ?)`, a.id, a.txID, a.alertType, time.Now()); err != nil {
 			log.Fatalf("Failed to insert alert %s: %v", a.id, err)
 		}
 	}
@@ -102,6 +104,54 @@ func TestMain(m *testing.M) {
 	// The example function is self-contained and demonstrates the functionality.
 	// We'll call it directly to ensure its output is captured.
 	ExampleGenerateSARData()
-	// os.Exit(m.Run()) // We can skip running other tests for this specific request.
-	os.Exit(0)
+	os.Exit(m.Run())
+}
+
+func TestGenerateSARDataLargeAlertSet(t *testing.T) {
+	db := newTestDB(t)
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := db.Exec(`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('acc-1', 'Jane Q Doe', '1 Main St', '1980-05-20')`); err != nil {
+		t.Fatalf("failed to insert account: %v", err)
+	}
+
+	// More alerts than fit in one IN list, newest first, each on its own transaction.
+	n := 2*database.MaxInParams + 50
+	alertIDs := make([]string, n)
+	total := 0.0
+	for i := 0; i < n; i++ {
+		txID := fmt.Sprintf("tx-%04d", i)
+		alertIDs[n-1-i] = fmt.Sprintf("alert-%04d", i)
+		amount := 100.0 + float64(i)
+		total += amount
+		_, err := db.Exec(`
+			INSERT INTO transactions (transaction_id, account_id, amount, currency, timestamp, source_country,
+				destination_country, transaction_type, status)
+			VALUES (?, 'acc-1', ?, 'USD', ?, 'US', 'US', 'CASH', 'COMPLETED')`, txID, amount, base.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatalf("failed to insert transaction: %v", err)
+		}
+		_, err = db.Exec(`INSERT INTO alerts (id, transaction_id, account_id, alert_type, status, created_at) VALUES (?, ?, 'acc-1', ?, 'OPEN', ?)`,
+			alertIDs[n-1-i], txID, AlertTypeStructuringPattern, base)
+		if err != nil {
+			t.Fatalf("failed to insert alert: %v", err)
+		}
+	}
+
+	// Postgres-style numbered placeholders reach the driver; SQLite accepts them too.
+	report, err := GenerateSARData(alertIDs, database.Wrap(db, database.Dollar))
+	if err != nil {
+		t.Fatalf("GenerateSARData failed: %v", err)
+	}
+	if report.TotalTransactionCount != n || report.TotalSuspiciousAmount != total {
+		t.Errorf("Expected %d transactions totalling %.2f, got %d totalling %.2f", n, total, report.TotalTransactionCount, report.TotalSuspiciousAmount)
+	}
+	txs := report.Patterns[AlertTypeStructuringPattern].Transactions
+	for i := 1; i < len(txs); i++ {
+		if txs[i].Timestamp.Before(txs[i-1].Timestamp) {
+			t.Fatalf("Expected the pattern's transactions in time order")
+		}
+	}
+	if !report.StartDate.Equal(base) || report.SubjectName != "Jane Q Doe" {
+		t.Errorf("Unexpected report period or subject: %v, %s", report.StartDate, report.SubjectName)
+	}
 }
@@ -0,0 +1,398 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+const (
+	goamlDateTimeFormat       = "2006-01-02T15:04:05"
+	goamlSubmissionElectronic = "E"
+	goamlUnknownCounterparty  = "UNKNOWN"
+	// goamlReasonLength is the most characters the narrative filed as the report's reason can have.
+	goamlReasonLength = 4000
+)
+
+var (
+	goamlDateTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`)
+	goamlAmountPattern   = regexp.MustCompile(`^\d{1,15}\.\d{2}$`)
+	goamlCurrencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+	goamlCountryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	goamlNonEmptyPattern = regexp.MustCompile(`\S`)
+)
+
+// goamlReportSchema is the subset of the goAML report schema the exporter produces, derived from
+// the element declarations of the UNODC XSD: occurrence bounds, lengths and formats.
+var goamlReportSchema = xmlSchema{
+	root: "report",
+	rules: []xmlElementRule{
+		{Path: "rentity_id", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d{1,10}$`)},
+		{Path: "rentity_branch", MaxOccurs: 1, MaxLength: 255},
+		{Path: "submission_code", MinOccurs: 1, MaxOccurs: 1, Enum: []string{goamlSubmissionElectronic, "M"}},
+		{Path: "report_code", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^[A-Z]{2,5}$`)},
+		{Path: "entity_reference", MaxOccurs: 1, MaxLength: 255},
+		{Path: "fiu_ref_number", MaxOccurs: 1, MaxLength: 255},
+		{Path: "submission_date", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlDateTimePattern},
+		{Path: "currency_code_local", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlCurrencyPattern},
+		{Path: "reporting_person", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "reporting_person/first_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "reporting_person/last_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "reporting_person/email", MaxOccurs: 1, MaxLength: 255},
+		{Path: "reporting_person/occupation", MaxOccurs: 1, MaxLength: 255},
+		{Path: "location", MaxOccurs: 1},
+		{Path: "location/address_type", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "location/address", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100},
+		{Path: "location/city", MinOccurs: 1, MaxOccurs: 1, MaxLength: 255},
+		{Path: "location/country_code", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlCountryPattern},
+		{Path: "reason", MinOccurs: 1, MaxOccurs: 1, MaxLength: goamlReasonLength, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction", MinOccurs: 1, Choices: [][]string{{"t_from_my_client", "t_from"}, {"t_to_my_client", "t_to"}}},
+		{Path: "transaction/transactionnumber", MinOccurs: 1, MaxOccurs: 1, MaxLength: 50, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/transaction_description", MaxOccurs: 1, MaxLength: 4000},
+		{Path: "transaction/date_transaction", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlDateTimePattern},
+		{Path: "transaction/transmode_code", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "transaction/amount_local", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlAmountPattern},
+		{Path: "transaction/t_from_my_client", MaxOccurs: 1},
+		{Path: "transaction/t_from_my_client/from_funds_code", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "transaction/t_from_my_client/from_foreign_currency", MaxOccurs: 1},
+		{Path: "transaction/t_from_my_client/from_foreign_currency/foreign_currency_code", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlCurrencyPattern},
+		{Path: "transaction/t_from_my_client/from_foreign_currency/foreign_amount", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlAmountPattern},
+		{Path: "transaction/t_from_my_client/from_foreign_currency/foreign_exchange_rate", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d+(\.\d+)?$`)},
+		{Path: "transaction/t_from_my_client/from_account", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "transaction/t_from_my_client/from_account/institution_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 255},
+		{Path: "transaction/t_from_my_client/from_account/swift", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)},
+		{Path: "transaction/t_from_my_client/from_account/account", MinOccurs: 1, MaxOccurs: 1, MaxLength: 50, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_from_my_client/from_account/currency_code", MaxOccurs: 1, Pattern: goamlCurrencyPattern},
+		{Path: "transaction/t_from_my_client/from_account/account_name", MaxOccurs: 1, MaxLength: 255},
+		{Path: "transaction/t_from_my_client/from_account/signatory/t_person/first_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_from_my_client/from_account/signatory/t_person/last_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_from_my_client/from_account/signatory/t_person/birthdate", MaxOccurs: 1, Pattern: goamlDateTimePattern},
+		{Path: "transaction/t_from_my_client/from_country", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlCountryPattern},
+		{Path: "transaction/t_from", MaxOccurs: 1},
+		{Path: "transaction/t_from/from_funds_code", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "transaction/t_from/from_entity", MaxOccurs: 1},
+		{Path: "transaction/t_from/from_entity/name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 255, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_from/from_person", MaxOccurs: 1},
+		{Path: "transaction/t_from/from_person/first_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_from/from_person/last_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_from/from_person/birthdate", MaxOccurs: 1, Pattern: goamlDateTimePattern},
+		{Path: "transaction/t_from/from_country", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlCountryPattern},
+		{Path: "transaction/t_to_my_client", MaxOccurs: 1},
+		{Path: "transaction/t_to_my_client/to_funds_code", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "transaction/t_to_my_client/to_foreign_currency", MaxOccurs: 1},
+		{Path: "transaction/t_to_my_client/to_foreign_currency/foreign_currency_code", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlCurrencyPattern},
+		{Path: "transaction/t_to_my_client/to_foreign_currency/foreign_amount", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlAmountPattern},
+		{Path: "transaction/t_to_my_client/to_foreign_currency/foreign_exchange_rate", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^\d+(\.\d+)?$`)},
+		{Path: "transaction/t_to_my_client/to_account", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "transaction/t_to_my_client/to_account/institution_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 255},
+		{Path: "transaction/t_to_my_client/to_account/swift", MinOccurs: 1, MaxOccurs: 1, Pattern: regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)},
+		{Path: "transaction/t_to_my_client/to_account/account", MinOccurs: 1, MaxOccurs: 1, MaxLength: 50, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_to_my_client/to_account/currency_code", MaxOccurs: 1, Pattern: goamlCurrencyPattern},
+		{Path: "transaction/t_to_my_client/to_account/account_name", MaxOccurs: 1, MaxLength: 255},
+		{Path: "transaction/t_to_my_client/to_account/signatory/t_person/first_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_to_my_client/to_account/signatory/t_person/last_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_to_my_client/to_account/signatory/t_person/birthdate", MaxOccurs: 1, Pattern: goamlDateTimePattern},
+		{Path: "transaction/t_to_my_client/to_country", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlCountryPattern},
+		{Path: "transaction/t_to", MaxOccurs: 1},
+		{Path: "transaction/t_to/to_funds_code", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "transaction/t_to/to_entity", MaxOccurs: 1},
+		{Path: "transaction/t_to/to_entity/name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 255, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_to/to_person", MaxOccurs: 1},
+		{Path: "transaction/t_to/to_person/first_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_to/to_person/last_name", MinOccurs: 1, MaxOccurs: 1, MaxLength: 100, Pattern: goamlNonEmptyPattern},
+		{Path: "transaction/t_to/to_person/birthdate", MaxOccurs: 1, Pattern: goamlDateTimePattern},
+		{Path: "transaction/t_to/to_country", MinOccurs: 1, MaxOccurs: 1, Pattern: goamlCountryPattern},
+		{Path: "report_indicators", MinOccurs: 1, MaxOccurs: 1},
+		{Path: "report_indicators/indicator", MinOccurs: 1, MaxOccurs: 25, MaxLength: 25},
+	},
+}
+
+type goamlReport struct {
+	XMLName           xml.Name           `xml:"report"`
+	RentityID         int                `xml:"rentity_id"`
+	RentityBranch     string             `xml:"rentity_branch,omitempty"`
+	SubmissionCode    string             `xml:"submission_code"`
+	ReportCode        string             `xml:"report_code"`
+	EntityReference   string             `xml:"entity_reference,omitempty"`
+	FIURefNumber      string             `xml:"fiu_ref_number,omitempty"`
+	SubmissionDate    string             `xml:"submission_date"`
+	CurrencyCodeLocal string             `xml:"currency_code_local"`
+	ReportingPerson   goamlPerson        `xml:"reporting_person"`
+	Location          *goamlLocation     `xml:"location,omitempty"`
+	Reason            string             `xml:"reason"`
+	Transactions      []goamlTransaction `xml:"transaction"`
+	Indicators        []string           `xml:"report_indicators>indicator"`
+}
+
+type goamlPerson struct {
+	FirstName  string `xml:"first_name"`
+	LastName   string `xml:"last_name"`
+	Birthdate  string `xml:"birthdate,omitempty"`
+	Email      string `xml:"email,omitempty"`
+	Occupation string `xml:"occupation,omitempty"`
+}
+
+type goamlLocation struct {
+	AddressType string `xml:"address_type"`
+	Address     string `xml:"address"`
+	City        string `xml:"city"`
+	CountryCode string `xml:"country_code"`
+}
+
+type goamlTransaction struct {
+	Number      string `xml:"transactionnumber"`
+	Description string `xml:"transaction_description,omitempty"`
+	Date        string `xml:"date_transaction"`
+	Transmode   string `xml:"transmode_code"`
+	AmountLocal string `xml:"amount_local"`
+	// Funds leave the subject's account for a counterparty (FromMyClient and To) or arrive in
+	// it from one (From and ToMyClient).
+	FromMyClient *goamlFromClient `xml:"t_from_my_client,omitempty"`
+	From         *goamlFrom       `xml:"t_from,omitempty"`
+	ToMyClient   *goamlToClient   `xml:"t_to_my_client,omitempty"`
+	To           *goamlTo         `xml:"t_to,omitempty"`
+}
+
+type goamlFromClient struct {
+	FundsCode       string                `xml:"from_funds_code"`
+	ForeignCurrency *goamlForeignCurrency `xml:"from_foreign_currency,omitempty"`
+	Account         goamlAccount          `xml:"from_account"`
+	Country         string                `xml:"from_country"`
+}
+
+type goamlFrom struct {
+	FundsCode string       `xml:"from_funds_code"`
+	Entity    *goamlEntity `xml:"from_entity,omitempty"`
+	Person    *goamlPerson `xml:"from_person,omitempty"`
+	Country   string       `xml:"from_country"`
+}
+
+type goamlToClient struct {
+	FundsCode       string                `xml:"to_funds_code"`
+	ForeignCurrency *goamlForeignCurrency `xml:"to_foreign_currency,omitempty"`
+	Account         goamlAccount          `xml:"to_account"`
+	Country         string                `xml:"to_country"`
+}
+
+type goamlForeignCurrency struct {
+	CurrencyCode string `xml:"foreign_currency_code"`
+	Amount       string `xml:"foreign_amount"`
+	ExchangeRate string `xml:"foreign_exchange_rate"`
+}
+
+type goamlAccount struct {
+	InstitutionName string         `xml:"institution_name"`
+	SWIFT           string         `xml:"swift"`
+	Account         string         `xml:"account"`
+	CurrencyCode    string         `xml:"currency_code,omitempty"`
+	AccountName     string         `xml:"account_name,omitempty"`
+	Signatory       goamlSignatory `xml:"signatory"`
+}
+
+type goamlSignatory struct {
+	IsPrimary bool        `xml:"is_primary"`
+	Person    goamlPerson `xml:"t_person"`
+}
+
+// goamlTo names the counterparty as a person when they are a subject of the report, and as an
+// entity otherwise.
+type goamlTo struct {
+	FundsCode string       `xml:"to_funds_code"`
+	Entity    *goamlEntity `xml:"to_entity,omitempty"`
+	Person    *goamlPerson `xml:"to_person,omitempty"`
+	Country   string       `xml:"to_country"`
+}
+
+type goamlEntity struct {
+	Name string `xml:"name"`
+}
+
+// GoAMLExporter writes SARs as goAML reports for financial intelligence units outside the US.
+type GoAMLExporter struct {
+	cfg config.GoAMLConfig
+}
+
+// NewGoAMLExporter builds an exporter for a validated reporting-entity profile.
+func NewGoAMLExporter(cfg config.GoAMLConfig) (*GoAMLExporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &GoAMLExporter{cfg: cfg}, nil
+}
+
+// BuildReport maps a report to a goAML <report> document and checks it against the goAML schema.
+// Each suspicious transaction is reported between the subject's account and its counterparty, in
+// the direction the funds moved, with foreign-currency amounts converted at the profile's exchange rates. Missing mandatory fields,
+// unconvertible currencies and schema violations are returned together as a *SARValidationError.
+func (e *GoAMLExporter) BuildReport(report *models.SARReport, submittedAt time.Time) ([]byte, error) {
+	problems := checkSARReport(report)
+	reason := sarNarrative(report)
+	if n := len([]rune(reason)); n > goamlReasonLength {
+		problems = append(problems, fmt.Sprintf("narrative too long: %d characters and goAML allows %d", n, goamlReasonLength))
+	}
+	if len(problems) > 0 {
+		return nil, &SARValidationError{Problems: problems}
+	}
+
+	doc := goamlReport{
+		RentityID:         e.cfg.RentityID,
+		RentityBranch:     e.cfg.RentityBranch,
+		SubmissionCode:    goamlSubmissionElectronic,
+		ReportCode:        e.cfg.ReportCode,
+		EntityReference:   e.cfg.EntityReferencePrefix + submittedAt.UTC().Format("20060102150405"),
+		SubmissionDate:    submittedAt.UTC().Format(goamlDateTimeFormat),
+		CurrencyCodeLocal: e.cfg.CurrencyCodeLocal,
+		ReportingPerson: goamlPerson{
+			FirstName:  e.cfg.ReportingPerson.FirstName,
+			LastName:   e.cfg.ReportingPerson.LastName,
+			Email:      e.cfg.ReportingPerson.Email,
+			Occupation: e.cfg.ReportingPerson.Occupation,
+		},
+		Reason: reason,
+	}
+	// A continuing-activity report refers to the FIU's reference for the report it follows, and an
+	// amendment to the reference of the report it corrects.
+	if report.Continuation != nil {
+		doc.FIURefNumber = report.Continuation.PriorFilingReference
+	}
+	if report.Amendment != nil {
+		doc.FIURefNumber = report.Amendment.PriorFilingReference
+	}
+	if e.cfg.Location.Address != "" {
+		doc.Location = &goamlLocation{
+			AddressType: e.cfg.Location.AddressType,
+			Address:     e.cfg.Location.Address,
+			City:        e.cfg.Location.City,
+			CountryCode: e.cfg.Location.CountryCode,
+		}
+	}
+
+	holders := sarAccountHolders(report)
+	seen := make(map[string]bool)
+	indicators := make(map[string]bool)
+	for _, key := range sortedPatternKeys(report) {
+		for _, tx := range report.Patterns[key].Transactions {
+			if seen[tx.TransactionID] {
+				continue
+			}
+			seen[tx.TransactionID] = true
+			transaction, err := e.transaction(report, holders, tx)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			doc.Transactions = append(doc.Transactions, transaction)
+		}
+
+		for _, indicator := range e.cfg.IndicatorsFor(key) {
+			if !indicators[indicator] {
+				indicators[indicator] = true
+				doc.Indicators = append(doc.Indicators, indicator)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &SARValidationError{Problems: problems}
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal goAML report: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+
+	if problems := goamlReportSchema.validate(data); len(problems) > 0 {
+		return nil, &SARValidationError{Problems: problems}
+	}
+	return data, nil
+}
+
+// ExportReport builds a goAML report and writes it to path. Nothing is written if the report
+// fails validation.
+func (e *GoAMLExporter) ExportReport(report *models.SARReport, submittedAt time.Time, path string) error {
+	data, err := e.BuildReport(report, submittedAt)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write goAML report to file: %w", err)
+	}
+	return nil
+}
+
+// transaction converts one reported transaction. Inbound types are reported from the counterparty
+// to the subject's account and everything else the other way round. The account's signatory is
+// the subject holding it, falling back to the primary subject, and a counterparty account held by
+// a subject is reported as that person.
+func (e *GoAMLExporter) transaction(report *models.SARReport, holders map[string]models.SARSubject, tx models.Transaction) (goamlTransaction, error) {
+	amountLocal := tx.Amount
+	var foreign *goamlForeignCurrency
+	if tx.Currency != "" && tx.Currency != e.cfg.CurrencyCodeLocal {
+		rate, ok := e.cfg.ExchangeRates[tx.Currency]
+		if !ok {
+			return goamlTransaction{}, fmt.Errorf("transaction %s: no exchange rate from %s to %s", tx.TransactionID, tx.Currency, e.cfg.CurrencyCodeLocal)
+		}
+		amountLocal = tx.Amount * rate
+		foreign = &goamlForeignCurrency{
+			CurrencyCode: tx.Currency,
+			Amount:       fmt.Sprintf("%.2f", tx.Amount),
+			ExchangeRate: fmt.Sprintf("%g", rate),
+		}
+	}
+
+	holder, ok := holders[tx.AccountID]
+	if !ok {
+		holder = sarSubjects(report)[0]
+	}
+	account := goamlAccount{
+		InstitutionName: e.cfg.InstitutionName,
+		SWIFT:           e.cfg.SWIFT,
+		Account:         tx.AccountID,
+		CurrencyCode:    tx.Currency,
+		AccountName:     holder.Name,
+		Signatory:       goamlSignatory{IsPrimary: true, Person: goamlSubjectPerson(holder)},
+	}
+	var entity *goamlEntity
+	var person *goamlPerson
+	if counterparty, ok := holders[tx.CounterpartyID]; ok && tx.CounterpartyID != "" {
+		p := goamlSubjectPerson(counterparty)
+		person = &p
+	} else {
+		name := tx.CounterpartyID
+		if name == "" {
+			name = goamlUnknownCounterparty
+		}
+		entity = &goamlEntity{Name: name}
+	}
+
+	transaction := goamlTransaction{
+		Number:      tx.TransactionID,
+		Description: fmt.Sprintf("%s %s", tx.TransactionType, tx.Status),
+		Date:        tx.Timestamp.UTC().Format(goamlDateTimeFormat),
+		Transmode:   e.cfg.TransmodeCodeFor(tx.TransactionType),
+		AmountLocal: fmt.Sprintf("%.2f", amountLocal),
+	}
+	if e.cfg.IsInbound(tx.TransactionType) {
+		transaction.From = &goamlFrom{FundsCode: e.cfg.FundsCode, Entity: entity, Person: person, Country: tx.SourceCountry}
+		transaction.ToMyClient = &goamlToClient{FundsCode: e.cfg.FundsCode, ForeignCurrency: foreign, Account: account, Country: tx.DestinationCountry}
+	} else {
+		transaction.FromMyClient = &goamlFromClient{FundsCode: e.cfg.FundsCode, ForeignCurrency: foreign, Account: account, Country: tx.SourceCountry}
+		transaction.To = &goamlTo{FundsCode: e.cfg.FundsCode, Entity: entity, Person: person, Country: tx.DestinationCountry}
+	}
+	return transaction, nil
+}
+
+// goamlSubjectPerson converts a report subject to a goAML person.
+func goamlSubjectPerson(subject models.SARSubject) goamlPerson {
+	first, last := splitPersonName(subject.Name)
+	person := goamlPerson{FirstName: first, LastName: last}
+	if dob, err := time.Parse("2006-01-02", subject.DateOfBirth); err == nil {
+		person.Birthdate = dob.Format(goamlDateTimeFormat)
+	}
+	return person
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+func TestGoAMLExporter(t *testing.T) {
+	submittedAt := time.Date(2024, 6, 3, 15, 0, 0, 0, time.UTC)
+	cfg, err := config.LoadGoAMLConfig("../../goaml.json")
+	if err != nil {
+		t.Fatalf("LoadGoAMLConfig failed: %v", err)
+	}
+	exporter, err := NewGoAMLExporter(cfg)
+	if err != nil {
+		t.Fatalf("NewGoAMLExporter failed: %v", err)
+	}
+
+	// Test Case 1: A report maps to a goAML document with the entity profile, transactions and indicators
+	t.Run("report", func(t *testing.T) {
+		report := testSARReport()
+		threshold := report.Patterns[AlertTypeThresholdViolation]
+		threshold.Transactions[0].CounterpartyID = "cp-tbilisi"
+		report.Patterns[AlertTypeThresholdViolation] = threshold
+
+		data, err := exporter.BuildReport(report, submittedAt)
+		if err != nil {
+			t.Fatalf("BuildReport failed: %v", err)
+		}
+		root, err := parseXMLTree(data)
+		if err != nil {
+			t.Fatalf("parseXMLTree failed: %v", err)
+		}
+		if root.find("rentity_id")[0].text != "1001" || root.find("report_code")[0].text != "STR" {
+			t.Errorf("Unexpected reporting entity: %+v", root)
+		}
+		if got := root.find("entity_reference")[0].text; got != "AML-STR-20240603150000" {
+			t.Errorf("Expected entity reference AML-STR-20240603150000, got %s", got)
+		}
+		if got := root.find("reporting_person/last_name")[0].text; got != "Morgan" {
+			t.Errorf("Expected reporting person Morgan, got %s", got)
+		}
+
+		transactions := root.find("transaction")
+		if len(transactions) != 3 {
+			t.Fatalf("Expected 3 transactions, got %d", len(transactions))
+		}
+		first := transactions[0]
+		if first.find("transactionnumber")[0].text != "tx-1" || first.find("transmode_code")[0].text != "K" {
+			t.Errorf("Unexpected first transaction: %+v", first)
+		}
+		if got := first.find("amount_local")[0].text; got != "8740.00" {
+			t.Errorf("Expected 9500 USD as 8740.00 EUR, got %s", got)
+		}
+		if got := first.find("t_from_my_client/from_foreign_currency/foreign_amount")[0].text; got != "9500.00" {
+			t.Errorf("Expected foreign amount 9500.00, got %s", got)
+		}
+		if got := first.find("t_from_my_client/from_account/signatory/t_person/birthdate")[0].text; got != "1980-05-20T00:00:00" {
+			t.Errorf("Expected subject birthdate, got %s", got)
+		}
+		if got := first.find("t_to/to_entity/name")[0].text; got != goamlUnknownCounterparty {
+			t.Errorf("Expected an unknown counterparty, got %s", got)
+		}
+
+		last := transactions[2]
+		if last.find("t_to/to_entity/name")[0].text != "cp-tbilisi" || last.find("t_to/to_country")[0].text != "GE" {
+			t.Errorf("Expected the counterparty and destination country, got %+v", last.find("t_to")[0])
+		}
+
+		var indicators []string
+		for _, n := range root.find("report_indicators/indicator") {
+			indicators = append(indicators, n.text)
+		}
+		if strings.Join(indicators, ",") != "STRUCT,LARGE" {
+			t.Errorf("Expected indicators STRUCT,LARGE, got %v", indicators)
+		}
+	})
+
+	// Test Case 2: Each transaction's signatory is its account holder and subjects receive as persons
+	t.Run("subjects", func(t *testing.T) {
+		data, err := exporter.BuildReport(testMultiSubjectReport(), submittedAt)
+		if err != nil {
+			t.Fatalf("BuildReport failed: %v", err)
+		}
+		root, err := parseXMLTree(data)
+		if err != nil {
+			t.Fatalf("parseXMLTree failed: %v", err)
+		}
+		transactions := root.find("transaction")
+		if len(transactions) != 3 {
+			t.Fatalf("Expected 3 transactions, got %d", len(transactions))
+		}
+		for i, want := range []string{"Doe", "Roe", "Doe"} {
+			if got := transactions[i].find("t_from_my_client/from_account/signatory/t_person/last_name")[0].text; got != want {
+				t.Errorf("Transaction %d: expected signatory %s, got %s", i, want, got)
+			}
+		}
+		to := transactions[2].find("t_to")[0]
+		if len(to.find("to_entity")) != 0 || len(to.find("to_person/last_name")) != 1 || to.find("to_person/last_name")[0].text != "Lee" {
+			t.Errorf("Expected the beneficiary as the receiving person, got %+v", to)
+		}
+	})
+
+	// Test Case 3: Currencies without an exchange rate are reported and nothing is written
+	t.Run("missing_rate", func(t *testing.T) {
+		report := testSARReport()
+		structuring := report.Patterns[AlertTypeStructuringPattern]
+		structuring.Transactions[1].Currency = "JPY"
+		report.Patterns[AlertTypeStructuringPattern] = structuring
+		path := filepath.Join(t.TempDir(), "str.xml")
+
+		err := exporter.ExportReport(report, submittedAt, path)
+		var verr *SARValidationError
+		if !errors.Is(err, ErrSARInvalid) || !errors.As(err, &verr) {
+			t.Fatalf("Expected a SARValidationError, got %v", err)
+		}
+		if len(verr.Problems) != 1 || !strings.Contains(verr.Problems[0], "tx-2: no exchange rate from JPY") {
+			t.Errorf("Expected a missing rate problem, got %v", verr.Problems)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected no file to be written, got %v", err)
+		}
+	})
+
+	// Test Case 4: The schema check catches values the report checks cannot
+	t.Run("schema", func(t *testing.T) {
+		report := testSARReport()
+		report.SubjectName = "Doe"
+		_, err := exporter.BuildReport(report, submittedAt)
+		if err == nil || !strings.Contains(err.Error(), "t_person/first_name value \"\" does not match") {
+			t.Errorf("Expected the missing first name to fail the schema check, got %v", err)
+		}
+
+		problems := goamlReportSchema.validate([]byte(`<report><rentity_id>x</rentity_id></report>`))
+		if len(problems) == 0 || !strings.Contains(problems[0], "rentity_id value \"x\"") {
+			t.Errorf("Expected an invalid rentity_id, got %v", problems)
+		}
+	})
+
+	// Test Case 5: Reports without patterns fail before mapping
+	t.Run("missing_fields", func(t *testing.T) {
+		report := testSARReport()
+		report.Patterns = models.SARPatterns{}
+		_, err := exporter.BuildReport(report, submittedAt)
+		if !errors.Is(err, ErrSARInvalid) || !strings.Contains(err.Error(), "no suspicious activity patterns") {
+			t.Errorf("Expected a missing patterns problem, got %v", err)
+		}
+	})
+
+	// Test Case 6: Narratives over the reason limit are rejected rather than cut
+	t.Run("narrative_too_long", func(t *testing.T) {
+		report := testSARReport()
+		report.Narrative = strings.Repeat("x", 4001)
+		_, err := exporter.BuildReport(report, submittedAt)
+		if !errors.Is(err, ErrSARInvalid) || !strings.Contains(err.Error(), "narrative too long: 4001 characters") {
+			t.Errorf("Expected a narrative too long problem, got %v", err)
+		}
+
+		// Without a reviewed narrative, the summary stays short however many transactions there are.
+		report.Narrative = ""
+		pattern := report.Patterns[AlertTypeStructuringPattern]
+		for i := 0; i < 150; i++ {
+			tx := pattern.Transactions[0]
+			tx.TransactionID = fmt.Sprintf("tx-bulk-%04d-%s", i, strings.Repeat("0", 30))
+			pattern.Transactions = append(pattern.Transactions, tx)
+		}
+		pattern.TransactionCount = len(pattern.Transactions)
+		report.Patterns[AlertTypeStructuringPattern] = pattern
+		if _, err := exporter.BuildReport(report, submittedAt); err != nil {
+			t.Errorf("BuildReport failed: %v", err)
+		}
+	})
+
+	// Test Case 7: Inbound transactions are reported from the counterparty to the subject's account
+	t.Run("inbound", func(t *testing.T) {
+		report := testSARReport()
+		structuring := report.Patterns[AlertTypeStructuringPattern]
+		structuring.Transactions[0].TransactionType = "CASH_DEPOSIT"
+		structuring.Transactions[0].CounterpartyID = "depositor-1"
+		report.Patterns[AlertTypeStructuringPattern] = structuring
+
+		data, err := exporter.BuildReport(report, submittedAt)
+		if err != nil {
+			t.Fatalf("BuildReport failed: %v", err)
+		}
+		root, err := parseXMLTree(data)
+		if err != nil {
+			t.Fatalf("parseXMLTree failed: %v", err)
+		}
+		var deposit *xmlNode
+		for _, tx := range root.find("transaction") {
+			if tx.find("transactionnumber")[0].text == structuring.Transactions[0].TransactionID {
+				deposit = tx
+			}
+		}
+		if deposit == nil {
+			t.Fatalf("Expected the deposit in the report")
+		}
+		if len(deposit.find("t_from_my_client")) != 0 || len(deposit.find("t_to")) != 0 {
+			t.Errorf("Expected the deposit not to leave the subject's account, got %+v", deposit)
+		}
+		if got := deposit.find("t_from/from_entity/name"); len(got) != 1 || got[0].text != "depositor-1" {
+			t.Errorf("Expected the depositor as the sender, got %+v", got)
+		}
+		if got := deposit.find("t_to_my_client/to_account/account"); len(got) != 1 || got[0].text != structuring.Transactions[0].AccountID {
+			t.Errorf("Expected the subject's account as the receiver, got %+v", got)
+		}
+		if got := deposit.find("transmode_code")[0].text; got != "A" {
+			t.Errorf("Expected transmode A for a cash deposit, got %s", got)
+		}
+
+		problems := goamlReportSchema.validate([]byte(`<report><transaction><t_from/><t_to/><t_to_my_client/></transaction></report>`))
+		if !strings.Contains(strings.Join(problems, "; "), "transaction must contain exactly one of t_to_my_client, t_to") {
+			t.Errorf("Expected a transaction with two receivers to fail the schema check, got %v", problems)
+		}
+	})
+}
@@ -0,0 +1,637 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+var (
+	// ErrSARNotFound is returned when no SAR exists with the requested ID.
+	ErrSARNotFound = fmt.Errorf("SAR not found")
+	// ErrInvalidSAR is returned when a SAR request is incomplete or inconsistent.
+	ErrInvalidSAR = fmt.Errorf("invalid SAR request")
+	// ErrSARLocked is returned when a SAR is edited after it has left draft.
+	ErrSARLocked = fmt.Errorf("SAR is not editable")
+	// ErrInvalidSARTransition is returned when a lifecycle step does not apply to the SAR's
+	// current status.
+	ErrInvalidSARTransition = fmt.Errorf("invalid SAR status transition")
+	// ErrFourEyes is returned when someone who prepared a SAR tries to approve it.
+	ErrFourEyes = fmt.Errorf("SAR must be approved by someone other than its preparers")
+	// ErrSARSnapshotMismatch is returned when a filed snapshot no longer matches its checksum.
+	ErrSARSnapshotMismatch = fmt.Errorf("SAR snapshot does not match its checksum")
+	// ErrSARNotApproved is returned when a SAR is exported for filing before it is approved.
+	ErrSARNotApproved = fmt.Errorf("SAR is not approved for filing")
+)
+
+// sarTransitions lists the statuses each SAR status can move to.
+var sarTransitions = map[string][]string{
+	models.SARStatusDraft:       {models.SARStatusUnderReview},
+	models.SARStatusUnderReview: {models.SARStatusApproved, models.SARStatusDraft},
+	models.SARStatusApproved:    {models.SARStatusFiled},
+	models.SARStatusFiled:       {models.SARStatusAmended},
+}
+
+// sarColumns is the column list matching scanSAR.
+const sarColumns = `id, case_id, status, filing_type, prior_sar_id, report, created_by, created_at, updated_at,
+	submitted_by, submitted_at, approved_by, approved_at, filed_by, filed_at, filing_reference, review_due_at`
+
+// SARFilter narrows a SAR listing. Zero values leave a field unfiltered.
+type SARFilter struct {
+	Status  string
+	CaseID  string
+	AlertID string
+}
+
+// SARDetail is a SAR together with its lifecycle history.
+type SARDetail struct {
+	SAR    *models.SAR       `json:"sar"`
+	Events []models.SAREvent `json:"events"`
+}
+
+// SARLifecycle stores SARs and moves them from draft through four-eyes review to filing.
+type SARLifecycle struct {
+	cfg config.SARConfig
+	// narratives drafts the narrative of new SARs; nil leaves it empty.
+	narratives *NarrativeGenerator
+}
+
+// NewSARLifecycle builds a lifecycle for a validated configuration. narratives may be nil.
+func NewSARLifecycle(cfg config.SARConfig, narratives *NarrativeGenerator) (*SARLifecycle, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &SARLifecycle{cfg: cfg, narratives: narratives}, nil
+}
+
+// Create drafts a SAR from every alert in a case, or from a list of alerts, and links it back to
+// them. Exactly one of caseID and alertIDs must be given.
+func (l *SARLifecycle) Create(db database.DBTX, caseID string, alertIDs []string, actor string, now time.Time) (*models.SAR, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if (caseID == "") == (len(alertIDs) == 0) {
+		return nil, fmt.Errorf("%w: exactly one of case_id or alert_ids is required", ErrInvalidSAR)
+	}
+
+	if caseID != "" {
+		if _, err := GetCase(db, caseID); err != nil {
+			return nil, err
+		}
+		ids, err := caseAlertIDs(db, caseID)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("%w: case %s has no alerts", ErrInvalidSAR, caseID)
+		}
+		alertIDs = ids
+	} else {
+		for _, id := range alertIDs {
+			if _, err := GetAlert(db, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	report, err := GenerateSARData(alertIDs, db)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, fmt.Errorf("%w: no suspicious transactions found to report", ErrInvalidSAR)
+	}
+	if l.narratives != nil {
+		if report.Narrative, err = l.narratives.Draft(report); err != nil {
+			return nil, err
+		}
+	}
+
+	sar := &models.SAR{
+		ID:         uuid.New().String(),
+		CaseID:     caseID,
+		AlertIDs:   alertIDs,
+		Status:     models.SARStatusDraft,
+		FilingType: models.SARFilingInitial,
+		Report:     report,
+		CreatedBy:  actor,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := insertSAR(db, sar); err != nil {
+		return nil, err
+	}
+
+	comment := fmt.Sprintf("Drafted from %d alert(s)", len(alertIDs))
+	if caseID != "" {
+		comment = "Drafted from case " + caseID
+		if _, err := AddCaseNote(db, caseID, actor, "Drafted SAR "+sar.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := recordSAREvent(db, sar.ID, "", models.SARStatusDraft, actor, comment, now); err != nil {
+		return nil, err
+	}
+	return sar, nil
+}
+
+// UpdateNarrative replaces the narrative of a draft SAR.
+func (l *SARLifecycle) UpdateNarrative(db database.DBTX, id, narrative, actor string, now time.Time) (*models.SAR, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	sar, err := GetSAR(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if sar.Status != models.SARStatusDraft {
+		return nil, fmt.Errorf("%w: SAR %s is %s", ErrSARLocked, id, sar.Status)
+	}
+
+	sar.Report.Narrative = strings.TrimSpace(narrative)
+	sar.UpdatedAt = now
+	report, err := json.Marshal(sar.Report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SAR report: %w", err)
+	}
+	query := `UPDATE sars SET report = ?, updated_at = ? WHERE id = ? AND status = ?`
+	if _, err := db.Exec(query, string(report), sar.UpdatedAt.UTC(), id, models.SARStatusDraft); err != nil {
+		return nil, fmt.Errorf("failed to update SAR %s: %w", id, err)
+	}
+	if _, err := recordSAREvent(db, id, sar.Status, sar.Status, actor, "Narrative edited", now); err != nil {
+		return nil, err
+	}
+	return sar, nil
+}
+
+// Submit sends a draft SAR for review. The report must have every field filing requires.
+func (l *SARLifecycle) Submit(db database.DBTX, id, actor, comment string, now time.Time) (*models.SAR, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	sar, err := GetSAR(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if sar.Status != models.SARStatusDraft {
+		return nil, fmt.Errorf("%w: SAR %s is %s", ErrInvalidSARTransition, id, sar.Status)
+	}
+	if problems := checkSARReport(sar.Report); len(problems) > 0 {
+		return nil, &SARValidationError{Problems: problems}
+	}
+	sar.SubmittedBy = actor
+	sar.SubmittedAt = now
+	return sar, advanceSAR(db, sar, models.SARStatusUnderReview, actor, comment, now)
+}
+
+// Approve approves a SAR under review. The approver needs an approver role and must not have
+// created, edited or submitted the SAR.
+func (l *SARLifecycle) Approve(db database.DBTX, id, actor string, roles []string, comment string, now time.Time) (*models.SAR, error) {
+	sar, err := l.reviewable(db, id, actor, roles)
+	if err != nil {
+		return nil, err
+	}
+	preparers, err := sarPreparers(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if preparers[actor] {
+		return nil, fmt.Errorf("%w: %s prepared SAR %s", ErrFourEyes, actor, id)
+	}
+	sar.ApprovedBy = actor
+	sar.ApprovedAt = now
+	return sar, advanceSAR(db, sar, models.SARStatusApproved, actor, comment, now)
+}
+
+// Reject returns a SAR under review to draft with the reviewer's comments.
+func (l *SARLifecycle) Reject(db database.DBTX, id, actor string, roles []string, comment string, now time.Time) (*models.SAR, error) {
+	if strings.TrimSpace(comment) == "" {
+		return nil, ErrCommentRequired
+	}
+	sar, err := l.reviewable(db, id, actor, roles)
+	if err != nil {
+		return nil, err
+	}
+	sar.SubmittedBy = ""
+	sar.SubmittedAt = time.Time{}
+	return sar, advanceSAR(db, sar, models.SARStatusDraft, actor, comment, now)
+}
+
+// File records an approved SAR as filed under the regulator's reference, freezes its report in a
+// snapshot and schedules the review for continuing activity.
+func (l *SARLifecycle) File(db database.DBTX, id, actor string, roles []string, filingReference string, now time.Time) (*models.SAR, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if !hasAnyRole(roles, l.cfg.FilerRoles) {
+		return nil, fmt.Errorf("%w: filing a SAR requires one of %v", ErrTransitionForbidden, l.cfg.FilerRoles)
+	}
+	if strings.TrimSpace(filingReference) == "" {
+		return nil, fmt.Errorf("%w: filing_reference is required", ErrInvalidSAR)
+	}
+	sar, err := GetSAR(db, id)
+	if err != nil {
+		return nil, err
+	}
+	sar.FiledBy = actor
+	sar.FiledAt = now
+	sar.FilingReference = filingReference
+	sar.ReviewDueAt = now.AddDate(0, 0, l.cfg.ContinuingReviewDays)
+	if err := advanceSAR(db, sar, models.SARStatusFiled, actor, "Filed as "+filingReference, now); err != nil {
+		return nil, err
+	}
+
+	report, err := json.Marshal(sar.Report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SAR report: %w", err)
+	}
+	sum := sha256.Sum256(report)
+	query := `INSERT INTO sar_snapshots (sar_id, report, sha256, created_by, created_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := db.Exec(query, id, string(report), hex.EncodeToString(sum[:]), actor, now.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to snapshot SAR %s: %w", id, err)
+	}
+	return sar, nil
+}
+
+// Amend marks a filed SAR as amended and drafts its amendment from the report as filed, linked
+// to the same case and alerts. A reason is required.
+func (l *SARLifecycle) Amend(db database.DBTX, id, actor, reason string, now time.Time) (*models.SAR, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrCommentRequired
+	}
+	original, err := GetSAR(db, id)
+	if err != nil {
+		return nil, err
+	}
+	original.ReviewDueAt = time.Time{}
+	if err := advanceSAR(db, original, models.SARStatusAmended, actor, reason, now); err != nil {
+		return nil, err
+	}
+	snapshot, err := GetSARSnapshot(db, id)
+	if err != nil {
+		return nil, err
+	}
+	var report models.SARReport
+	if err := json.Unmarshal(snapshot.Report, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot of SAR %s: %w", id, err)
+	}
+	report.Amendment = &models.SARAmendment{PriorSARID: original.ID, PriorFilingReference: original.FilingReference}
+
+	amendment := &models.SAR{
+		ID:         uuid.New().String(),
+		CaseID:     original.CaseID,
+		AlertIDs:   original.AlertIDs,
+		Status:     models.SARStatusDraft,
+		FilingType: models.SARFilingAmendment,
+		PriorSARID: original.ID,
+		Report:     &report,
+		CreatedBy:  actor,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := insertSAR(db, amendment); err != nil {
+		return nil, err
+	}
+	if _, err := recordSAREvent(db, amendment.ID, "", models.SARStatusDraft, actor, fmt.Sprintf("Amends SAR %s: %s", id, reason), now); err != nil {
+		return nil, err
+	}
+	return amendment, nil
+}
+
+// LoadSARForFiling returns an approved or filed SAR for export in a filing format. A filed SAR's
+// report is its snapshot, exactly as filed. Amendments drafted before their report recorded the
+// SAR they correct are linked to it here.
+func LoadSARForFiling(db database.DBTX, id string) (*models.SAR, error) {
+	sar, err := GetSAR(db, id)
+	if err != nil {
+		return nil, err
+	}
+	switch sar.Status {
+	case models.SARStatusApproved:
+	case models.SARStatusFiled:
+		snapshot, err := GetSARSnapshot(db, id)
+		if err != nil {
+			return nil, err
+		}
+		var report models.SARReport
+		if err := json.Unmarshal(snapshot.Report, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot of SAR %s: %w", id, err)
+		}
+		sar.Report = &report
+	default:
+		return nil, fmt.Errorf("%w: SAR %s is %s", ErrSARNotApproved, id, sar.Status)
+	}
+
+	if sar.FilingType == models.SARFilingAmendment && sar.Report.Amendment == nil {
+		prior, err := GetSAR(db, sar.PriorSARID)
+		if err != nil {
+			return nil, err
+		}
+		sar.Report.Amendment = &models.SARAmendment{PriorSARID: prior.ID, PriorFilingReference: prior.FilingReference}
+	}
+	return sar, nil
+}
+
+// reviewable loads a SAR for a reviewer holding one of the approver roles.
+func (l *SARLifecycle) reviewable(db database.DBTX, id, actor string, roles []string) (*models.SAR, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if !hasAnyRole(roles, l.cfg.ApproverRoles) {
+		return nil, fmt.Errorf("%w: reviewing a SAR requires one of %v", ErrTransitionForbidden, l.cfg.ApproverRoles)
+	}
+	return GetSAR(db, id)
+}
+
+// GetSAR fetches a single SAR with the alerts it reports on.
+func GetSAR(db database.DBTX, id string) (*models.SAR, error) {
+	query := `SELECT ` + sarColumns + ` FROM sars WHERE id = ?`
+	sar, err := scanSAR(db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrSARNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SAR %s: %w", id, err)
+	}
+	if sar.AlertIDs, err = sarAlertIDs(db, id); err != nil {
+		return nil, err
+	}
+	return sar, nil
+}
+
+// GetSARDetail fetches a SAR with its lifecycle history.
+func GetSARDetail(db database.DBTX, id string) (*SARDetail, error) {
+	sar, err := GetSAR(db, id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := ListSAREvents(db, id)
+	if err != nil {
+		return nil, err
+	}
+	return &SARDetail{SAR: sar, Events: events}, nil
+}
+
+// ListSARs returns SARs matching the filter, most recently updated first.
+func ListSARs(db database.DBTX, filter SARFilter) ([]models.SAR, error) {
+	query := `SELECT ` + sarColumns + ` FROM sars`
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.CaseID != "" {
+		conditions = append(conditions, "case_id = ?")
+		args = append(args, filter.CaseID)
+	}
+	if filter.AlertID != "" {
+		conditions = append(conditions, "id IN (SELECT sar_id FROM sar_alerts WHERE alert_id = ?)")
+		args = append(args, filter.AlertID)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY updated_at DESC, id DESC"
+	return querySARs(db, query, args...)
+}
+
+// querySARs runs a query selecting sarColumns and loads each SAR's alerts.
+func querySARs(db database.DBTX, query string, args ...interface{}) ([]models.SAR, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SARs: %w", err)
+	}
+	defer rows.Close()
+
+	sars := []models.SAR{}
+	for rows.Next() {
+		sar, err := scanSAR(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan SAR row: %w", err)
+		}
+		sars = append(sars, *sar)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SAR rows: %w", err)
+	}
+
+	for i := range sars {
+		if sars[i].AlertIDs, err = sarAlertIDs(db, sars[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return sars, nil
+}
+
+// ListSAREvents returns a SAR's lifecycle history, oldest first.
+func ListSAREvents(db database.DBTX, sarID string) ([]models.SAREvent, error) {
+	query := `
+		SELECT id, sar_id, from_status, to_status, actor, comment, created_at
+		FROM sar_events
+		WHERE sar_id = ?
+		ORDER BY created_at ASC, id ASC
+	`
+	rows, err := db.Query(query, sarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events for SAR %s: %w", sarID, err)
+	}
+	defer rows.Close()
+
+	events := []models.SAREvent{}
+	for rows.Next() {
+		var e models.SAREvent
+		if err := rows.Scan(&e.ID, &e.SARID, &e.FromStatus, &e.ToStatus, &e.Actor, &e.Comment, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan SAR event row: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SAR event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetSARSnapshot returns the report of a filed SAR exactly as filed, after checking it against
+// its checksum.
+func GetSARSnapshot(db database.DBTX, sarID string) (*models.SARSnapshot, error) {
+	var s models.SARSnapshot
+	var report string
+	query := `SELECT sar_id, report, sha256, created_by, created_at FROM sar_snapshots WHERE sar_id = ?`
+	err := db.QueryRow(query, sarID).Scan(&s.SARID, &report, &s.SHA256, &s.CreatedBy, &s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: no filed snapshot for %s", ErrSARNotFound, sarID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snapshot of SAR %s: %w", sarID, err)
+	}
+	s.Report = json.RawMessage(report)
+	sum := sha256.Sum256(s.Report)
+	if hex.EncodeToString(sum[:]) != s.SHA256 {
+		return nil, fmt.Errorf("%w: %s", ErrSARSnapshotMismatch, sarID)
+	}
+	return &s, nil
+}
+
+// advanceSAR moves a SAR to a new status, saving its review and filing fields and recording the
+// step. The update only applies if the SAR is still in the status it was loaded in.
+func advanceSAR(db database.DBTX, sar *models.SAR, to, actor, comment string, now time.Time) error {
+	from := sar.Status
+	allowed := false
+	for _, next := range sarTransitions[from] {
+		if next == to {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: cannot move SAR %s from %s to %s", ErrInvalidSARTransition, sar.ID, from, to)
+	}
+
+	sar.Status = to
+	sar.UpdatedAt = now
+	query := `
+		UPDATE sars SET status = ?, updated_at = ?, submitted_by = ?, submitted_at = ?, approved_by = ?, approved_at = ?,
+			filed_by = ?, filed_at = ?, filing_reference = ?, review_due_at = ?
+		WHERE id = ? AND status = ?
+	`
+	result, err := db.Exec(query, sar.Status, sar.UpdatedAt.UTC(), sar.SubmittedBy, nullableTime(sar.SubmittedAt),
+		sar.ApprovedBy, nullableTime(sar.ApprovedAt), sar.FiledBy, nullableTime(sar.FiledAt), sar.FilingReference,
+		nullableTime(sar.ReviewDueAt), sar.ID, from)
+	if err != nil {
+		return fmt.Errorf("failed to update SAR %s: %w", sar.ID, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: SAR %s changed concurrently", ErrInvalidSARTransition, sar.ID)
+	}
+	_, err = recordSAREvent(db, sar.ID, from, to, actor, comment, now)
+	return err
+}
+
+// sarPreparers returns everyone who created, edited or submitted a SAR.
+func sarPreparers(db database.DBTX, sarID string) (map[string]bool, error) {
+	events, err := ListSAREvents(db, sarID)
+	if err != nil {
+		return nil, err
+	}
+	preparers := make(map[string]bool)
+	for _, e := range events {
+		if e.FromStatus == "" || e.FromStatus == models.SARStatusDraft {
+			preparers[e.Actor] = true
+		}
+	}
+	return preparers, nil
+}
+
+func insertSAR(db database.DBTX, sar *models.SAR) error {
+	report, err := json.Marshal(sar.Report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal SAR report: %w", err)
+	}
+	query := `
+		INSERT INTO sars (id, case_id, status, filing_type, prior_sar_id, report, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = db.Exec(query, sar.ID, nullableString(sar.CaseID), sar.Status, sar.FilingType, nullableString(sar.PriorSARID),
+		string(report), sar.CreatedBy, sar.CreatedAt.UTC(), sar.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to insert SAR: %w", err)
+	}
+	for _, alertID := range sar.AlertIDs {
+		if _, err := db.Exec(`INSERT INTO sar_alerts (sar_id, alert_id) VALUES (?, ?)`, sar.ID, alertID); err != nil {
+			return fmt.Errorf("failed to link SAR %s to alert %s: %w", sar.ID, alertID, err)
+		}
+	}
+	return nil
+}
+
+func recordSAREvent(db database.DBTX, sarID, from, to, actor, comment string, now time.Time) (*models.SAREvent, error) {
+	event := &models.SAREvent{
+		ID:         uuid.New().String(),
+		SARID:      sarID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		Comment:    comment,
+		CreatedAt:  now,
+	}
+	query := `INSERT INTO sar_events (id, sar_id, from_status, to_status, actor, comment, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.Exec(query, event.ID, event.SARID, event.FromStatus, event.ToStatus, event.Actor, event.Comment, event.CreatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to record event for SAR %s: %w", sarID, err)
+	}
+	return event, nil
+}
+
+func sarAlertIDs(db database.DBTX, sarID string) ([]string, error) {
+	rows, err := db.Query(`SELECT alert_id FROM sar_alerts WHERE sar_id = ? ORDER BY alert_id`, sarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts for SAR %s: %w", sarID, err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan SAR alert row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanSAR(row rowScanner) (*models.SAR, error) {
+	var sar models.SAR
+	var caseID, priorSARID sql.NullString
+	var report string
+	var submittedAt, approvedAt, filedAt, reviewDueAt sql.NullTime
+	err := row.Scan(&sar.ID, &caseID, &sar.Status, &sar.FilingType, &priorSARID, &report, &sar.CreatedBy,
+		&sar.CreatedAt, &sar.UpdatedAt, &sar.SubmittedBy, &submittedAt, &sar.ApprovedBy, &approvedAt,
+		&sar.FiledBy, &filedAt, &sar.FilingReference, &reviewDueAt)
+	if err != nil {
+		return nil, err
+	}
+	sar.CaseID = caseID.String
+	sar.PriorSARID = priorSARID.String
+	sar.SubmittedAt = submittedAt.Time
+	sar.ApprovedAt = approvedAt.Time
+	sar.FiledAt = filedAt.Time
+	sar.ReviewDueAt = reviewDueAt.Time
+	sar.Report = &models.SARReport{}
+	if err := json.Unmarshal([]byte(report), sar.Report); err != nil {
+		return nil, fmt.Errorf("failed to parse report of SAR %s: %w", sar.ID, err)
+	}
+	return &sar, nil
+}
+
+// nullableString stores an empty string as NULL, for optional references.
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullableTime stores a zero time as NULL.
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// seedSARActivity adds the alerts from seedAlerts together with their transactions and the two
+// account holders, so SARs can be generated from them.
+func seedSARActivity(t *testing.T, db database.DBTX, base time.Time) {
+	t.Helper()
+	seedAlerts(t, db, base)
+	accounts := []string{
+		`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('acc-1', 'Jane Q Doe', '1 Main St', '1980-05-20')`,
+		`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('acc-2', 'John Roe', '2 High St', '1975-01-02')`,
+	}
+	for _, stmt := range accounts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to insert account: %v", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		_, err := db.Exec(`
+			INSERT INTO transactions (transaction_id, account_id, amount, currency, timestamp, source_country,
+				destination_country, transaction_type, status)
+			VALUES (?, ?, ?, 'USD', ?, 'US', 'US', 'WIRE', 'COMPLETED')`,
+			fmt.Sprintf("tx-%d", i), []string{"acc-1", "acc-2"}[i%2], 9000.0+float64(i)*100, base.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatalf("failed to insert transaction: %v", err)
+		}
+	}
+}
+
+func TestSARLifecycle(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	lifecycle, err := NewSARLifecycle(config.DefaultSARConfig(), nil)
+	if err != nil {
+		t.Fatalf("NewSARLifecycle failed: %v", err)
+	}
+	supervisor := []string{"supervisor"}
+	mlro := []string{"mlro"}
+
+	// Test Case 1: A SAR drafted from a case links back to the case and its alerts
+	t.Run("create_from_case", func(t *testing.T) {
+		db := newTestDB(t)
+		seedSARActivity(t, db, base)
+		c, err := CreateCase(db, "acc-1", "", "", []string{"alert-0", "alert-2"}, "analyst-1", base)
+		if err != nil {
+			t.Fatalf("CreateCase failed: %v", err)
+		}
+
+		sar, err := lifecycle.Create(db, c.ID, nil, "analyst-1", base)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if sar.Status != models.SARStatusDraft || sar.FilingType != models.SARFilingInitial {
+			t.Errorf("Expected an initial draft, got %s %s", sar.FilingType, sar.Status)
+		}
+		if sar.Report.SubjectName != "Jane Q Doe" || sar.Report.TotalTransactionCount != 2 {
+			t.Errorf("Expected 2 transactions for Jane Q Doe, got %d for %q", sar.Report.TotalTransactionCount, sar.Report.SubjectName)
+		}
+
+		stored, err := GetSAR(db, sar.ID)
+		if err != nil {
+			t.Fatalf("GetSAR failed: %v", err)
+		}
+		if stored.CaseID != c.ID || strings.Join(stored.AlertIDs, ",") != "alert-0,alert-2" {
+			t.Errorf("Expected links to case %s and alert-0,alert-2, got %s and %v", c.ID, stored.CaseID, stored.AlertIDs)
+		}
+		byAlert, err := ListSARs(db, SARFilter{AlertID: "alert-2"})
+		if err != nil {
+			t.Fatalf("ListSARs failed: %v", err)
+		}
+		if len(byAlert) != 1 || byAlert[0].ID != sar.ID {
+			t.Errorf("Expected the SAR to be listed under alert-2, got %v", byAlert)
+		}
+		notes, err := ListCaseNotes(db, c.ID)
+		if err != nil {
+			t.Fatalf("ListCaseNotes failed: %v", err)
+		}
+		noted := false
+		for _, note := range notes {
+			noted = noted || strings.Contains(note.Body, sar.ID)
+		}
+		if !noted {
+			t.Errorf("Expected a case note naming the SAR, got %v", notes)
+		}
+
+		if _, err := lifecycle.Create(db, c.ID, []string{"alert-1"}, "analyst-1", base); !errors.Is(err, ErrInvalidSAR) {
+			t.Errorf("Expected ErrInvalidSAR for a case and alerts together, got %v", err)
+		}
+	})
+
+	// Test Case 2: A SAR moves through review to filing and its filed report is frozen
+	t.Run("review_and_file", func(t *testing.T) {
+		db := newTestDB(t)
+		seedSARActivity(t, db, base)
+		sar, err := lifecycle.Create(db, "", []string{"alert-1", "alert-3"}, "analyst-1", base)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := lifecycle.UpdateNarrative(db, sar.ID, "Reviewed narrative.", "analyst-1", base.Add(time.Hour)); err != nil {
+			t.Fatalf("UpdateNarrative failed: %v", err)
+		}
+		if _, err := lifecycle.Submit(db, sar.ID, "analyst-1", "", base.Add(2*time.Hour)); err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		if _, err := lifecycle.UpdateNarrative(db, sar.ID, "Too late.", "analyst-1", base.Add(3*time.Hour)); !errors.Is(err, ErrSARLocked) {
+			t.Errorf("Expected ErrSARLocked editing a SAR under review, got %v", err)
+		}
+		if _, err := lifecycle.File(db, sar.ID, "mlro-1", mlro, "BSA-1", base.Add(3*time.Hour)); !errors.Is(err, ErrInvalidSARTransition) {
+			t.Errorf("Expected ErrInvalidSARTransition filing an unapproved SAR, got %v", err)
+		}
+		if _, err := lifecycle.Approve(db, sar.ID, "supervisor-1", supervisor, "Agreed", base.Add(4*time.Hour)); err != nil {
+			t.Fatalf("Approve failed: %v", err)
+		}
+		if _, err := lifecycle.File(db, sar.ID, "supervisor-1", supervisor, "BSA-1", base.Add(5*time.Hour)); !errors.Is(err, ErrTransitionForbidden) {
+			t.Errorf("Expected ErrTransitionForbidden filing without a filer role, got %v", err)
+		}
+		filed, err := lifecycle.File(db, sar.ID, "mlro-1", mlro, "BSA-1", base.Add(5*time.Hour))
+		if err != nil {
+			t.Fatalf("File failed: %v", err)
+		}
+
+		detail, err := GetSARDetail(db, sar.ID)
+		if err != nil {
+			t.Fatalf("GetSARDetail failed: %v", err)
+		}
+		got := detail.SAR
+		if got.Status != models.SARStatusFiled || got.FilingReference != "BSA-1" || got.ApprovedBy != "supervisor-1" || got.SubmittedBy != "analyst-1" {
+			t.Errorf("Expected a filed SAR with its reviewers recorded, got %+v", got)
+		}
+		if !got.FiledAt.Equal(filed.FiledAt) {
+			t.Errorf("Expected filed_at %v, got %v", filed.FiledAt, got.FiledAt)
+		}
+		var steps []string
+		for _, e := range detail.Events {
+			steps = append(steps, e.FromStatus+">"+e.ToStatus)
+		}
+		if want := ">DRAFT,DRAFT>DRAFT,DRAFT>UNDER_REVIEW,UNDER_REVIEW>APPROVED,APPROVED>FILED"; strings.Join(steps, ",") != want {
+			t.Errorf("Expected history %s, got %s", want, strings.Join(steps, ","))
+		}
+
+		snapshot, err := GetSARSnapshot(db, sar.ID)
+		if err != nil {
+			t.Fatalf("GetSARSnapshot failed: %v", err)
+		}
+		if !strings.Contains(string(snapshot.Report), "Reviewed narrative.") {
+			t.Errorf("Expected the snapshot to hold the reviewed narrative, got %s", snapshot.Report)
+		}
+		if _, err := db.Exec(`UPDATE sar_snapshots SET report = '{}' WHERE sar_id = ?`, sar.ID); err != nil {
+			t.Fatalf("failed to tamper with snapshot: %v", err)
+		}
+		if _, err := GetSARSnapshot(db, sar.ID); !errors.Is(err, ErrSARSnapshotMismatch) {
+			t.Errorf("Expected ErrSARSnapshotMismatch for an altered snapshot, got %v", err)
+		}
+	})
+
+	// Test Case 3: Preparers cannot approve their own SAR, and rejection returns it to draft
+	t.Run("four_eyes", func(t *testing.T) {
+		db := newTestDB(t)
+		seedSARActivity(t, db, base)
+		sar, err := lifecycle.Create(db, "", []string{"alert-0"}, "supervisor-1", base)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := lifecycle.Submit(db, sar.ID, "analyst-1", "", base.Add(time.Hour)); err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		if _, err := lifecycle.Approve(db, sar.ID, "supervisor-1", supervisor, "", base.Add(2*time.Hour)); !errors.Is(err, ErrFourEyes) {
+			t.Errorf("Expected ErrFourEyes for the preparer, got %v", err)
+		}
+		if _, err := lifecycle.Approve(db, sar.ID, "analyst-1", supervisor, "", base.Add(2*time.Hour)); !errors.Is(err, ErrFourEyes) {
+			t.Errorf("Expected ErrFourEyes for the submitter, got %v", err)
+		}
+		if _, err := lifecycle.Approve(db, sar.ID, "analyst-2", []string{"analyst"}, "", base.Add(2*time.Hour)); !errors.Is(err, ErrTransitionForbidden) {
+			t.Errorf("Expected ErrTransitionForbidden without an approver role, got %v", err)
+		}
+		if _, err := lifecycle.Reject(db, sar.ID, "supervisor-2", supervisor, "", base.Add(2*time.Hour)); !errors.Is(err, ErrCommentRequired) {
+			t.Errorf("Expected ErrCommentRequired rejecting without a comment, got %v", err)
+		}
+		rejected, err := lifecycle.Reject(db, sar.ID, "supervisor-2", supervisor, "Add the source of funds", base.Add(2*time.Hour))
+		if err != nil {
+			t.Fatalf("Reject failed: %v", err)
+		}
+		if rejected.Status != models.SARStatusDraft || rejected.SubmittedBy != "" {
+			t.Errorf("Expected an unsubmitted draft, got %s submitted by %q", rejected.Status, rejected.SubmittedBy)
+		}
+		if _, err := lifecycle.UpdateNarrative(db, sar.ID, "Source of funds added.", "analyst-1", base.Add(3*time.Hour)); err != nil {
+			t.Errorf("Expected a rejected SAR to be editable, got %v", err)
+		}
+	})
+
+	// Test Case 4: Amending a filed SAR drafts a linked amendment from the filed report
+	t.Run("amend", func(t *testing.T) {
+		db := newTestDB(t)
+		seedSARActivity(t, db, base)
+		sar, err := lifecycle.Create(db, "", []string{"alert-0", "alert-2"}, "analyst-1", base)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := lifecycle.Amend(db, sar.ID, "mlro-1", "Corrected address", base); !errors.Is(err, ErrInvalidSARTransition) {
+			t.Errorf("Expected ErrInvalidSARTransition amending a draft, got %v", err)
+		}
+		if _, err := lifecycle.UpdateNarrative(db, sar.ID, "Filed narrative.", "analyst-1", base); err != nil {
+			t.Fatalf("UpdateNarrative failed: %v", err)
+		}
+		if _, err := lifecycle.Submit(db, sar.ID, "analyst-1", "", base.Add(time.Hour)); err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		if _, err := lifecycle.Approve(db, sar.ID, "mlro-1", mlro, "", base.Add(2*time.Hour)); err != nil {
+			t.Fatalf("Approve failed: %v", err)
+		}
+		if _, err := lifecycle.File(db, sar.ID, "mlro-1", mlro, "BSA-2", base.Add(3*time.Hour)); err != nil {
+			t.Fatalf("File failed: %v", err)
+		}
+
+		if _, err := lifecycle.Amend(db, sar.ID, "mlro-1", "", base.Add(4*time.Hour)); !errors.Is(err, ErrCommentRequired) {
+			t.Errorf("Expected ErrCommentRequired amending without a reason, got %v", err)
+		}
+		amendment, err := lifecycle.Amend(db, sar.ID, "mlro-1", "Corrected address", base.Add(4*time.Hour))
+		if err != nil {
+			t.Fatalf("Amend failed: %v", err)
+		}
+		if amendment.FilingType != models.SARFilingAmendment || amendment.PriorSARID != sar.ID || amendment.Status != models.SARStatusDraft {
+			t.Errorf("Expected a draft amendment of %s, got %+v", sar.ID, amendment)
+		}
+		if amendment.Report.Narrative != "Filed narrative." || len(amendment.AlertIDs) != 2 {
+			t.Errorf("Expected the amendment to start from the filed report and alerts, got %q and %v", amendment.Report.Narrative, amendment.AlertIDs)
+		}
+		original, err := GetSAR(db, sar.ID)
+		if err != nil {
+			t.Fatalf("GetSAR failed: %v", err)
+		}
+		if original.Status != models.SARStatusAmended {
+			t.Errorf("Expected the original to be AMENDED, got %s", original.Status)
+		}
+		drafts, err := ListSARs(db, SARFilter{Status: models.SARStatusDraft})
+		if err != nil {
+			t.Fatalf("ListSARs failed: %v", err)
+		}
+		if len(drafts) != 1 || drafts[0].ID != amendment.ID {
+			t.Errorf("Expected only the amendment in draft, got %d SARs", len(drafts))
+		}
+	})
+
+	// Test Case 5: Only approved SARs are exported for filing, filed ones from their snapshot
+	t.Run("load_for_filing", func(t *testing.T) {
+		db := newTestDB(t)
+		seedSARActivity(t, db, base)
+		sar, err := lifecycle.Create(db, "", []string{"alert-0", "alert-2"}, "analyst-1", base)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := LoadSARForFiling(db, sar.ID); !errors.Is(err, ErrSARNotApproved) {
+			t.Errorf("Expected ErrSARNotApproved for a draft, got %v", err)
+		}
+		if _, err := lifecycle.Submit(db, sar.ID, "analyst-1", "", base.Add(time.Hour)); err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		if _, err := LoadSARForFiling(db, sar.ID); !errors.Is(err, ErrSARNotApproved) {
+			t.Errorf("Expected ErrSARNotApproved under review, got %v", err)
+		}
+		if _, err := lifecycle.Approve(db, sar.ID, "mlro-1", mlro, "", base.Add(2*time.Hour)); err != nil {
+			t.Fatalf("Approve failed: %v", err)
+		}
+		approved, err := LoadSARForFiling(db, sar.ID)
+		if err != nil || approved.Report == nil || approved.Report.Amendment != nil {
+			t.Fatalf("Expected the approved initial report, got %+v, %v", approved, err)
+		}
+		if _, err := lifecycle.File(db, sar.ID, "mlro-1", mlro, "31000123456789", base.Add(3*time.Hour)); err != nil {
+			t.Fatalf("File failed: %v", err)
+		}
+
+		// The filed report comes from the snapshot, not the working copy.
+		if _, err := db.Exec(`UPDATE sars SET report = ? WHERE id = ?`, `{"subject_name": "Changed"}`, sar.ID); err != nil {
+			t.Fatalf("failed to change SAR: %v", err)
+		}
+		filed, err := LoadSARForFiling(db, sar.ID)
+		if err != nil {
+			t.Fatalf("LoadSARForFiling failed: %v", err)
+		}
+		if filed.Report.SubjectName != "Jane Q Doe" {
+			t.Errorf("Expected the snapshot's subject, got %q", filed.Report.SubjectName)
+		}
+
+		amendment, err := lifecycle.Amend(db, sar.ID, "mlro-1", "Corrected address", base.Add(4*time.Hour))
+		if err != nil {
+			t.Fatalf("Amend failed: %v", err)
+		}
+		if _, err := LoadSARForFiling(db, sar.ID); !errors.Is(err, ErrSARNotApproved) {
+			t.Errorf("Expected ErrSARNotApproved for an amended SAR, got %v", err)
+		}
+		if _, err := lifecycle.Submit(db, amendment.ID, "mlro-1", "", base.Add(5*time.Hour)); err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		if _, err := lifecycle.Approve(db, amendment.ID, "supervisor-1", supervisor, "", base.Add(6*time.Hour)); err != nil {
+			t.Fatalf("Approve failed: %v", err)
+		}
+		loaded, err := LoadSARForFiling(db, amendment.ID)
+		if err != nil {
+			t.Fatalf("LoadSARForFiling failed: %v", err)
+		}
+		if a := loaded.Report.Amendment; a == nil || a.PriorSARID != sar.ID || a.PriorFilingReference != "31000123456789" {
+			t.Errorf("Expected the amendment to cite the filed SAR, got %+v", a)
+		}
+	})
+}
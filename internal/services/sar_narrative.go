@@ -0,0 +1,213 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+// narrativeFacts are the values narrative templates can use. Amounts are formatted with their
+// currency when the transactions share one, and lists are joined in prose, e.g. "US and GE".
+// Subject is the primary subject and Associates the report's other subjects. The prior report and
+// cumulative facts are only set on continuing-activity reports.
+type narrativeFacts struct {
+	Subject      string
+	Associates   string
+	Pattern      string
+	Start        string
+	End          string
+	Count        int
+	Total        string
+	Largest      string
+	Smallest     string
+	Types        string
+	Origins      string
+	Destinations string
+	Threshold    string
+
+	PriorReference  string
+	SeriesStart     string
+	CumulativeCount int
+	CumulativeTotal string
+}
+
+// NarrativeGenerator drafts SAR narratives from per-alert-type templates.
+type NarrativeGenerator struct {
+	introduction *template.Template
+	conclusion   *template.Template
+	fallback     *template.Template
+	templates    map[string]*template.Template
+	threshold    string
+}
+
+// NewNarrativeGenerator parses the configured templates and checks they only use known facts.
+func NewNarrativeGenerator(cfg config.NarrativeConfig) (*NarrativeGenerator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	parse := func(name, src string) (*template.Template, error) {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if err := tmpl.Execute(&strings.Builder{}, narrativeFacts{}); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return tmpl, nil
+	}
+
+	g := &NarrativeGenerator{
+		templates: make(map[string]*template.Template, len(cfg.Templates)),
+		threshold: formatThreshold(cfg.ReportingThreshold),
+	}
+	var err error
+	if g.introduction, err = parse("introduction", cfg.Introduction); err != nil {
+		return nil, err
+	}
+	if g.conclusion, err = parse("conclusion", cfg.Conclusion); err != nil {
+		return nil, err
+	}
+	if g.fallback, err = parse("default_template", cfg.DefaultTemplate); err != nil {
+		return nil, err
+	}
+	for alertType, src := range cfg.Templates {
+		if g.templates[alertType], err = parse("template for "+alertType, src); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Draft writes a narrative for a report: an introduction, a paragraph per pattern from its alert
+// type's template, and a conclusion, separated by blank lines. The draft is meant to be reviewed
+// and edited before it is stored as the report's Narrative.
+func (g *NarrativeGenerator) Draft(report *models.SARReport) (string, error) {
+	var all []models.Transaction
+	for _, key := range sortedPatternKeys(report) {
+		all = append(all, report.Patterns[key].Transactions...)
+	}
+	overall := g.facts(report, "", all)
+	overall.Start = report.StartDate.UTC().Format("2006-01-02")
+	overall.End = report.EndDate.UTC().Format("2006-01-02")
+	overall.Count = report.TotalTransactionCount
+	overall.Total = formatAmount(report.TotalSuspiciousAmount, commonCurrency(all))
+
+	var paragraphs []string
+	add := func(tmpl *template.Template, facts narrativeFacts) error {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, facts); err != nil {
+			return fmt.Errorf("failed to draft SAR narrative: %w", err)
+		}
+		if text := strings.TrimSpace(b.String()); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		return nil
+	}
+
+	if err := add(g.introduction, overall); err != nil {
+		return "", err
+	}
+	for _, key := range sortedPatternKeys(report) {
+		pattern := report.Patterns[key]
+		tmpl, ok := g.templates[key]
+		if !ok {
+			tmpl = g.fallback
+		}
+		description := pattern.PatternDescription
+		if description == "" {
+			description = key
+		}
+		facts := g.facts(report, description, pattern.Transactions)
+		facts.Count = pattern.TransactionCount
+		facts.Total = formatAmount(pattern.TotalAmount, commonCurrency(pattern.Transactions))
+		if err := add(tmpl, facts); err != nil {
+			return "", err
+		}
+	}
+	if err := add(g.conclusion, overall); err != nil {
+		return "", err
+	}
+	return strings.Join(paragraphs, "\n\n"), nil
+}
+
+// facts gathers the dates, amounts, types and countries of a set of transactions.
+func (g *NarrativeGenerator) facts(report *models.SARReport, pattern string, txs []models.Transaction) narrativeFacts {
+	facts := narrativeFacts{
+		Subject:   report.SubjectName,
+		Pattern:   pattern,
+		Count:     len(txs),
+		Threshold: g.threshold,
+	}
+	var associates []string
+	for _, subject := range sarSubjects(report)[1:] {
+		associates = append(associates, subject.Name)
+	}
+	facts.Associates = joinProse(associates)
+	if c := report.Continuation; c != nil {
+		facts.PriorReference = c.PriorFilingReference
+		facts.SeriesStart = c.SeriesStartDate.UTC().Format("2006-01-02")
+		facts.CumulativeCount = c.CumulativeTransactionCount
+		facts.CumulativeTotal = formatAmount(c.CumulativeAmount, "")
+	}
+	if len(txs) == 0 {
+		return facts
+	}
+
+	currency := commonCurrency(txs)
+	first, last := txs[0].Timestamp, txs[0].Timestamp
+	largest, smallest, total := txs[0].Amount, txs[0].Amount, 0.0
+	var types, origins, destinations []string
+	for _, tx := range txs {
+		if tx.Timestamp.Before(first) {
+			first = tx.Timestamp
+		}
+		if tx.Timestamp.After(last) {
+			last = tx.Timestamp
+		}
+		if tx.Amount > largest {
+			largest = tx.Amount
+		}
+		if tx.Amount < smallest {
+			smallest = tx.Amount
+		}
+		total += tx.Amount
+		types = append(types, strings.ToLower(tx.TransactionType))
+		origins = append(origins, tx.SourceCountry)
+		destinations = append(destinations, tx.DestinationCountry)
+	}
+	facts.Start = first.UTC().Format("2006-01-02")
+	facts.End = last.UTC().Format("2006-01-02")
+	facts.Total = formatAmount(total, currency)
+	facts.Largest = formatAmount(largest, currency)
+	facts.Smallest = formatAmount(smallest, currency)
+	facts.Types = joinProse(types)
+	facts.Origins = joinProse(origins)
+	facts.Destinations = joinProse(destinations)
+	return facts
+}
+
+// joinProse joins the distinct non-empty values in sorted order as "a", "a and b" or
+// "a, b and c".
+func joinProse(values []string) string {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			distinct = append(distinct, v)
+		}
+	}
+	sort.Strings(distinct)
+	switch len(distinct) {
+	case 0:
+		return ""
+	case 1:
+		return distinct[0]
+	default:
+		return strings.Join(distinct[:len(distinct)-1], ", ") + " and " + distinct[len(distinct)-1]
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+func TestNarrativeGenerator(t *testing.T) {
+	cfg, err := config.LoadNarrativeConfig("../../narrative.json")
+	if err != nil {
+		t.Fatalf("LoadNarrativeConfig failed: %v", err)
+	}
+	generator, err := NewNarrativeGenerator(cfg)
+	if err != nil {
+		t.Fatalf("NewNarrativeGenerator failed: %v", err)
+	}
+
+	// Test Case 1: Each pattern gets a paragraph from its alert type's template
+	t.Run("draft", func(t *testing.T) {
+		narrative, err := generator.Draft(testSARReport())
+		if err != nil {
+			t.Fatalf("Draft failed: %v", err)
+		}
+		paragraphs := strings.Split(narrative, "\n\n")
+		if len(paragraphs) != 4 {
+			t.Fatalf("Expected introduction, two patterns and conclusion, got %d paragraphs:\n%s", len(paragraphs), narrative)
+		}
+		if want := "This report concerns Jane Q Doe. Between 2024-05-01 and 2024-05-04, 3 transaction(s) totalling 43,900.00 USD were identified as suspicious."; paragraphs[0] != want {
+			t.Errorf("Expected introduction %q, got %q", want, paragraphs[0])
+		}
+		if want := "Between 2024-05-01 and 2024-05-02, Jane Q Doe conducted 2 wire transaction(s) totalling 18,900.00 USD, each below the 10,000 reporting threshold. The largest was 9,500.00 USD."; !strings.HasPrefix(paragraphs[1], want) {
+			t.Errorf("Expected structuring paragraph %q, got %q", want, paragraphs[1])
+		}
+		if !strings.Contains(paragraphs[2], "from US to GE") {
+			t.Errorf("Expected the threshold paragraph to name the countries, got %q", paragraphs[2])
+		}
+
+		narrative, err = generator.Draft(testMultiSubjectReport())
+		if err != nil {
+			t.Fatalf("Draft failed: %v", err)
+		}
+		if want := "This report concerns Jane Q Doe, together with Ann Lee and John Roe."; !strings.HasPrefix(narrative, want) {
+			t.Errorf("Expected the introduction to name the other subjects, got %q", narrative)
+		}
+	})
+
+	// Test Case 2: Alert types without a template use the default template
+	t.Run("default_template", func(t *testing.T) {
+		report := testSARReport()
+		threshold := report.Patterns[AlertTypeThresholdViolation]
+		threshold.PatternDescription = "custom rule"
+		report.Patterns = models.SARPatterns{"CUSTOM_RULE": threshold}
+		narrative, err := generator.Draft(report)
+		if err != nil {
+			t.Fatalf("Draft failed: %v", err)
+		}
+		if !strings.Contains(narrative, "1 wire transaction(s) by Jane Q Doe totalling 25,000.00 USD were flagged as custom rule.") {
+			t.Errorf("Expected the default template, got %q", narrative)
+		}
+	})
+
+	// Test Case 3: Templates using unknown facts are rejected up front
+	t.Run("invalid_template", func(t *testing.T) {
+		bad := config.DefaultNarrativeConfig()
+		bad.Templates = map[string]string{AlertTypeStructuringPattern: "{{.Subjekt}} structured deposits"}
+		if _, err := NewNarrativeGenerator(bad); err == nil || !strings.Contains(err.Error(), "template for STRUCTURING_PATTERN") {
+			t.Errorf("Expected an invalid template error, got %v", err)
+		}
+		bad = config.DefaultNarrativeConfig()
+		bad.Conclusion = "{{.Subject"
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Expected an unparseable template to fail validation")
+		}
+	})
+
+	// Test Case 4: An edited narrative is filed and printed in place of the draft
+	t.Run("edited", func(t *testing.T) {
+		report := testSARReport()
+		report.Narrative = "Reviewed narrative, first paragraph.\n\nSecond paragraph."
+		if got := sarNarrative(report); got != report.Narrative {
+			t.Errorf("Expected the edited narrative to be filed, got %q", got)
+		}
+
+		var buf bytes.Buffer
+		if err := RenderSARHTML(&buf, report, time.Now()); err != nil {
+			t.Fatalf("RenderSARHTML failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "<p>Reviewed narrative, first paragraph.</p>") || !strings.Contains(buf.String(), "<p>Second paragraph.</p>") {
+			t.Errorf("Expected the narrative paragraphs in the HTML summary")
+		}
+	})
+}
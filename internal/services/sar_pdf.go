@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"AML/internal/models"
+)
+
+const (
+	sarPDFMargin     = 50.0
+	sarPDFBodySize   = 10.0
+	sarPDFTableSize  = 8.0
+	sarPDFLineHeight = 13.0
+	sarPDFRowHeight  = 11.0
+)
+
+// sarPDFColumn is one column of a PDF table; amounts are right-aligned.
+type sarPDFColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+var (
+	sarPDFTimelineColumns = []sarPDFColumn{
+		{title: "Date", width: 85}, {title: "Transaction", width: 75}, {title: "Account", width: 100},
+		{title: "Route", width: 50}, {title: "Amount", width: 85, right: true}, {title: "Patterns", width: 100},
+	}
+	sarPDFPatternColumns = []sarPDFColumn{
+		{title: "Date", width: 90}, {title: "Transaction", width: 85}, {title: "Account", width: 105},
+		{title: "Type", width: 60}, {title: "Route", width: 55}, {title: "Amount", width: 100, right: true},
+	}
+)
+
+// sarPDFLayout places content down the page, starting a new page when the next block does not
+// fit.
+type sarPDFLayout struct {
+	doc  pdfDocument
+	page *pdfPage
+	y    float64
+	// title is repeated in the footer of every page.
+	title string
+}
+
+func (l *sarPDFLayout) newPage() {
+	l.page = l.doc.addPage()
+	l.y = pdfPageHeight - sarPDFMargin
+	footer := fmt.Sprintf("%s - page %d", l.title, len(l.doc.pages))
+	l.page.text(sarPDFMargin, sarPDFMargin/2, sarPDFTableSize, false, footer)
+}
+
+// ensure starts a new page unless height points remain above the bottom margin.
+func (l *sarPDFLayout) ensure(height float64) {
+	if l.page == nil || l.y-height < sarPDFMargin {
+		l.newPage()
+	}
+}
+
+func (l *sarPDFLayout) heading(s string, size float64) {
+	l.ensure(size*2 + 3*sarPDFRowHeight)
+	l.y -= size
+	l.page.text(sarPDFMargin, l.y, size, true, s)
+	l.y -= size / 2
+}
+
+// field writes a bold label and its value on one line.
+func (l *sarPDFLayout) field(label, value string) {
+	l.ensure(sarPDFLineHeight)
+	l.y -= sarPDFLineHeight
+	l.page.text(sarPDFMargin, l.y, sarPDFBodySize, true, label)
+	valueX := sarPDFMargin + 130
+	l.page.text(valueX, l.y, sarPDFBodySize, false, pdfFit(value, sarPDFBodySize, pdfPageWidth-sarPDFMargin-valueX))
+}
+
+// paragraph writes text wrapped to the page width, followed by a blank line.
+func (l *sarPDFLayout) paragraph(text string) {
+	for _, line := range pdfWrap(text, sarPDFBodySize, pdfPageWidth-2*sarPDFMargin) {
+		l.ensure(sarPDFLineHeight)
+		l.y -= sarPDFLineHeight
+		l.page.text(sarPDFMargin, l.y, sarPDFBodySize, false, line)
+	}
+	l.y -= sarPDFLineHeight / 2
+}
+
+// table writes rows under a header row, repeating the header on each new page. A non-nil total
+// row is drawn in bold below a rule.
+func (l *sarPDFLayout) table(columns []sarPDFColumn, rows [][]string, total []string) {
+	right := pdfPageWidth - sarPDFMargin
+	header := func() {
+		l.y -= sarPDFRowHeight
+		l.row(columns, headerCells(columns), true)
+		l.page.line(sarPDFMargin, l.y-3, right, l.y-3)
+	}
+	l.ensure(3 * sarPDFRowHeight)
+	header()
+	for _, cells := range rows {
+		if l.y-sarPDFRowHeight < sarPDFMargin {
+			l.newPage()
+			header()
+		}
+		l.y -= sarPDFRowHeight
+		l.row(columns, cells, false)
+	}
+	if total != nil {
+		l.ensure(sarPDFRowHeight + 4)
+		l.page.line(sarPDFMargin, l.y-3, right, l.y-3)
+		l.y -= sarPDFRowHeight + 1
+		l.row(columns, total, true)
+	}
+	l.y -= sarPDFLineHeight
+}
+
+func (l *sarPDFLayout) row(columns []sarPDFColumn, cells []string, bold bool) {
+	x := sarPDFMargin
+	for i, col := range columns {
+		if i < len(cells) && cells[i] != "" {
+			text := pdfFit(cells[i], sarPDFTableSize, col.width-4)
+			tx := x
+			if col.right {
+				tx = x + col.width - 4 - pdfTextWidth(text, sarPDFTableSize)
+			}
+			l.page.text(tx, l.y, sarPDFTableSize, bold && !col.right, text)
+		}
+		x += col.width
+	}
+}
+
+func headerCells(columns []sarPDFColumn) []string {
+	cells := make([]string, len(columns))
+	for i, col := range columns {
+		cells[i] = col.title
+	}
+	return cells
+}
+
+// RenderSARPDF writes a printable PDF case summary of a report for investigator sign-off, with
+// the same content as the HTML summary. Account numbers are masked.
+func RenderSARPDF(w io.Writer, report *models.SARReport, generatedAt time.Time) error {
+	view := newSARView(report, generatedAt)
+	l := &sarPDFLayout{title: "Suspicious Activity Report: " + view.Subjects[0].Name}
+
+	l.heading("Suspicious Activity Report", 18)
+	l.field("Generated", view.GeneratedAt)
+
+	l.heading("Subjects", 13)
+	for _, subject := range view.Subjects {
+		l.heading(subject.Role, 11)
+		l.field("Name", subject.Name)
+		l.field("Address", orDefault(subject.Address, "Not recorded"))
+		l.field("Date of birth", orDefault(subject.DateOfBirth, "Not recorded"))
+		for i, account := range subject.Accounts {
+			label := ""
+			if i == 0 {
+				label = "Accounts"
+			}
+			l.field(label, account)
+		}
+	}
+
+	l.heading("Summary", 13)
+	l.field("Activity period", view.StartDate+" to "+view.EndDate)
+	l.field("Suspicious transactions", strconv.Itoa(view.TransactionCount))
+	l.field("Total suspicious amount", view.TotalAmount)
+	l.field("Patterns", strconv.Itoa(len(view.Patterns)))
+
+	if c := view.Continuation; c != nil {
+		l.heading("Continuing Activity", 13)
+		l.field("Prior report", c.PriorReference)
+		l.field("Report in series", strconv.Itoa(c.ReportNumber))
+		l.field("Series activity since", c.SeriesStartDate)
+		l.field("Cumulative transactions", strconv.Itoa(c.TransactionCount))
+		l.field("Cumulative amount", c.TotalAmount)
+	}
+
+	if len(view.Narrative) > 0 {
+		l.heading("Narrative", 13)
+		for _, paragraph := range view.Narrative {
+			l.paragraph(paragraph)
+		}
+	}
+
+	l.heading("Activity Timeline", 13)
+	var timeline [][]string
+	for _, tx := range view.Timeline {
+		timeline = append(timeline, []string{tx.Time, tx.TransactionID, tx.Account, tx.Route, tx.Amount, tx.Patterns})
+	}
+	l.table(sarPDFTimelineColumns, timeline, nil)
+
+	for _, pattern := range view.Patterns {
+		l.heading("Pattern: "+pattern.Description, 13)
+		var rows [][]string
+		for _, tx := range pattern.Transactions {
+			rows = append(rows, []string{tx.Time, tx.TransactionID, tx.Account, tx.Type, tx.Route, tx.Amount})
+		}
+		total := []string{fmt.Sprintf("Total (%d transactions)", pattern.TransactionCount), "", "", "", "", pattern.TotalAmount}
+		l.table(sarPDFPatternColumns, rows, total)
+	}
+
+	l.ensure(4 * sarPDFLineHeight)
+	l.y -= 3 * sarPDFLineHeight
+	half := (pdfPageWidth - 2*sarPDFMargin) / 2
+	l.page.line(sarPDFMargin, l.y, sarPDFMargin+half-20, l.y)
+	l.page.line(sarPDFMargin+half+20, l.y, pdfPageWidth-sarPDFMargin, l.y)
+	l.y -= sarPDFRowHeight
+	l.page.text(sarPDFMargin, l.y, sarPDFTableSize, false, "Investigator signature and date")
+	l.page.text(sarPDFMargin+half+20, l.y, sarPDFTableSize, false, "Approver signature and date")
+
+	if _, err := w.Write(l.doc.bytes()); err != nil {
+		return fmt.Errorf("failed to write SAR PDF: %w", err)
+	}
+	return nil
+}
+
+// ExportSARToPDF renders a report as PDF and writes it to path.
+func ExportSARToPDF(report *models.SARReport, generatedAt time.Time, path string) error {
+	var buf bytes.Buffer
+	if err := RenderSARPDF(&buf, report, generatedAt); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write SAR PDF to file: %w", err)
+	}
+	return nil
+}
+
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
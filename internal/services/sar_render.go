@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"AML/internal/models"
+)
+
+//go:embed templates/sar_report.html
+var sarTemplateFS embed.FS
+
+var sarHTMLTemplate = template.Must(template.ParseFS(sarTemplateFS, "templates/sar_report.html"))
+
+const sarDisplayTimeFormat = "2006-01-02 15:04 MST"
+
+// sarSubjectRoleLabels are the headings subjects are listed under.
+var sarSubjectRoleLabels = map[string]string{
+	models.SARSubjectPrimary:       "Primary subject",
+	models.SARSubjectCoConspirator: "Co-conspirator",
+	models.SARSubjectBeneficiary:   "Beneficiary",
+}
+
+// sarView is a SAR laid out for people to read: dates and amounts are formatted and every
+// account number is masked.
+type sarView struct {
+	GeneratedAt string
+	// Subjects lists the people the report names, primary subject first.
+	Subjects         []sarSubjectView
+	StartDate        string
+	EndDate          string
+	TransactionCount int
+	TotalAmount      string
+	// Continuation summarises the series a continuing-activity report belongs to.
+	Continuation *sarContinuationView
+	// Narrative holds the report's narrative paragraphs, if one has been written.
+	Narrative []string
+	Timeline  []sarTransactionView
+	Patterns  []sarPatternView
+}
+
+type sarSubjectView struct {
+	Role        string
+	Name        string
+	Address     string
+	DateOfBirth string
+	Accounts    []string
+}
+
+type sarContinuationView struct {
+	PriorReference   string
+	ReportNumber     int
+	SeriesStartDate  string
+	TransactionCount int
+	TotalAmount      string
+}
+
+type sarTransactionView struct {
+	Time          string
+	TransactionID string
+	Account       string
+	Type          string
+	Route         string
+	Amount        string
+	// Patterns lists the patterns the transaction appears in; only set on timeline entries.
+	Patterns string
+}
+
+type sarPatternView struct {
+	Description      string
+	Transactions     []sarTransactionView
+	TransactionCount int
+	TotalAmount      string
+}
+
+// newSARView lays a report out for rendering. The timeline lists each transaction once, in time
+// order, with the patterns it was flagged under; pattern sections follow in a stable order.
+func newSARView(report *models.SARReport, generatedAt time.Time) sarView {
+	view := sarView{
+		GeneratedAt:      generatedAt.UTC().Format(sarDisplayTimeFormat),
+		StartDate:        report.StartDate.UTC().Format("2006-01-02"),
+		EndDate:          report.EndDate.UTC().Format("2006-01-02"),
+		TransactionCount: report.TotalTransactionCount,
+		TotalAmount:      formatAmount(report.TotalSuspiciousAmount, ""),
+	}
+	if c := report.Continuation; c != nil {
+		view.Continuation = &sarContinuationView{
+			PriorReference:   c.PriorFilingReference,
+			ReportNumber:     c.ReportNumber,
+			SeriesStartDate:  c.SeriesStartDate.UTC().Format("2006-01-02"),
+			TransactionCount: c.CumulativeTransactionCount,
+			TotalAmount:      formatAmount(c.CumulativeAmount, ""),
+		}
+	}
+	for _, paragraph := range strings.Split(report.Narrative, "\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			view.Narrative = append(view.Narrative, paragraph)
+		}
+	}
+	for _, subject := range sarSubjects(report) {
+		subjectView := sarSubjectView{
+			Role:        sarSubjectRoleLabels[subject.Role],
+			Name:        subject.Name,
+			Address:     subject.Address,
+			DateOfBirth: subject.DateOfBirth,
+		}
+		for _, account := range subject.AccountIDs {
+			subjectView.Accounts = append(subjectView.Accounts, MaskAccountNumber(account))
+		}
+		view.Subjects = append(view.Subjects, subjectView)
+	}
+
+	byID := make(map[string]models.Transaction)
+	patternsByID := make(map[string][]string)
+	for _, key := range sortedPatternKeys(report) {
+		pattern := report.Patterns[key]
+		description := pattern.PatternDescription
+		if description == "" {
+			description = key
+		}
+		section := sarPatternView{
+			Description:      description,
+			TransactionCount: pattern.TransactionCount,
+			TotalAmount:      formatAmount(pattern.TotalAmount, commonCurrency(pattern.Transactions)),
+		}
+		for _, tx := range pattern.Transactions {
+			section.Transactions = append(section.Transactions, newSARTransactionView(tx))
+			byID[tx.TransactionID] = tx
+			patternsByID[tx.TransactionID] = append(patternsByID[tx.TransactionID], description)
+		}
+		view.Patterns = append(view.Patterns, section)
+	}
+
+	timeline := make([]models.Transaction, 0, len(byID))
+	for _, tx := range byID {
+		timeline = append(timeline, tx)
+	}
+	sort.Slice(timeline, func(i, j int) bool {
+		if !timeline[i].Timestamp.Equal(timeline[j].Timestamp) {
+			return timeline[i].Timestamp.Before(timeline[j].Timestamp)
+		}
+		return timeline[i].TransactionID < timeline[j].TransactionID
+	})
+	for _, tx := range timeline {
+		entry := newSARTransactionView(tx)
+		entry.Patterns = strings.Join(patternsByID[tx.TransactionID], ", ")
+		view.Timeline = append(view.Timeline, entry)
+	}
+	return view
+}
+
+func newSARTransactionView(tx models.Transaction) sarTransactionView {
+	return sarTransactionView{
+		Time:          tx.Timestamp.UTC().Format(sarDisplayTimeFormat),
+		TransactionID: tx.TransactionID,
+		Account:       MaskAccountNumber(tx.AccountID),
+		Type:          tx.TransactionType,
+		Route:         fmt.Sprintf("%s -> %s", tx.SourceCountry, tx.DestinationCountry),
+		Amount:        formatAmount(tx.Amount, tx.Currency),
+	}
+}
+
+// commonCurrency returns the currency shared by all transactions, or "" when they differ.
+func commonCurrency(txs []models.Transaction) string {
+	currency := ""
+	for i, tx := range txs {
+		if i == 0 {
+			currency = tx.Currency
+		} else if tx.Currency != currency {
+			return ""
+		}
+	}
+	return currency
+}
+
+// formatAmount writes an amount to two decimals with thousands separators and an optional
+// currency, e.g. 9,500.00 USD.
+func formatAmount(v float64, currency string) string {
+	s := groupThousands(strconv.FormatFloat(v, 'f', 2, 64))
+	if currency != "" {
+		s += " " + currency
+	}
+	return s
+}
+
+// RenderSARHTML writes a printable HTML case summary of a report for investigator sign-off.
+// Account numbers are masked.
+func RenderSARHTML(w io.Writer, report *models.SARReport, generatedAt time.Time) error {
+	if err := sarHTMLTemplate.Execute(w, newSARView(report, generatedAt)); err != nil {
+		return fmt.Errorf("failed to render SAR HTML: %w", err)
+	}
+	return nil
+}
+
+// ExportSARToHTML renders a report as HTML and writes it to path.
+func ExportSARToHTML(report *models.SARReport, generatedAt time.Time, path string) error {
+	var buf bytes.Buffer
+	if err := RenderSARHTML(&buf, report, generatedAt); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write SAR HTML to file: %w", err)
+	}
+	return nil
+}
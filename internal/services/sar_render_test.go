@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSARRendering(t *testing.T) {
+	generatedAt := time.Date(2024, 6, 3, 15, 0, 0, 0, time.UTC)
+
+	// Test Case 1: The HTML summary has the subject, timeline and pattern totals with masked accounts
+	t.Run("html", func(t *testing.T) {
+		report := testSARReport()
+		report.SubjectAddress = "<b>42 Elm Street</b>"
+		var buf bytes.Buffer
+		if err := RenderSARHTML(&buf, report, generatedAt); err != nil {
+			t.Fatalf("RenderSARHTML failed: %v", err)
+		}
+		html := buf.String()
+
+		for _, want := range []string{
+			"Jane Q Doe", "1980-05-20", "XXXX-XXXX-XXXX-3210", "2024-05-01 to 2024-05-04",
+			"43,900.00", "Pattern: STRUCTURING_PATTERN", "18,900.00 USD", "25,000.00 USD", "US -&gt; GE",
+			"&lt;b&gt;42 Elm Street&lt;/b&gt;",
+		} {
+			if !strings.Contains(html, want) {
+				t.Errorf("Expected the HTML to contain %q", want)
+			}
+		}
+		if strings.Contains(html, "ACCT9876543210") {
+			t.Errorf("Expected account numbers to be masked")
+		}
+
+		first := strings.Index(html, "<td>tx-1</td>")
+		last := strings.Index(html, "<td>tx-3</td>")
+		if first < 0 || last < first {
+			t.Errorf("Expected the timeline in time order")
+		}
+	})
+
+	// Test Case 2: The PDF is well formed, masks accounts and escapes text
+	t.Run("pdf", func(t *testing.T) {
+		report := testSARReport()
+		report.SubjectName = "Jane (Q) Doe"
+		var buf bytes.Buffer
+		if err := RenderSARPDF(&buf, report, generatedAt); err != nil {
+			t.Fatalf("RenderSARPDF failed: %v", err)
+		}
+		pdf := buf.Bytes()
+
+		if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+			t.Fatalf("Expected a PDF header and trailer")
+		}
+		checkPDFXref(t, pdf)
+		for _, want := range []string{"Jane \\(Q\\) Doe", "XXXX-XXXX-XXXX-3210", "18,900.00 USD", "Total \\(2 transactions\\)"} {
+			if !bytes.Contains(pdf, []byte(want)) {
+				t.Errorf("Expected the PDF to contain %q", want)
+			}
+		}
+		if bytes.Contains(pdf, []byte("ACCT9876543210")) {
+			t.Errorf("Expected account numbers to be masked")
+		}
+	})
+
+	// Test Case 3: Long reports flow onto further pages
+	t.Run("pages", func(t *testing.T) {
+		report := testSARReport()
+		pattern := report.Patterns[AlertTypeStructuringPattern]
+		for i := 0; i < 120; i++ {
+			tx := pattern.Transactions[0]
+			tx.TransactionID = fmt.Sprintf("tx-bulk-%d", i)
+			pattern.Transactions = append(pattern.Transactions, tx)
+		}
+		report.Patterns[AlertTypeStructuringPattern] = pattern
+
+		path := filepath.Join(t.TempDir(), "sar.pdf")
+		if err := ExportSARToPDF(report, generatedAt, path); err != nil {
+			t.Fatalf("ExportSARToPDF failed: %v", err)
+		}
+		pdf, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		checkPDFXref(t, pdf)
+		count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(pdf)
+		if pages, _ := strconv.Atoi(string(count[1])); pages < 4 {
+			t.Errorf("Expected at least 4 pages, got %d", pages)
+		}
+		if !bytes.Contains(pdf, []byte("tx-bulk-119")) {
+			t.Errorf("Expected every transaction to be rendered")
+		}
+	})
+
+	// Test Case 4: Every subject is listed under their role with their own masked accounts
+	t.Run("subjects", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := RenderSARHTML(&buf, testMultiSubjectReport(), generatedAt); err != nil {
+			t.Fatalf("RenderSARHTML failed: %v", err)
+		}
+		html := buf.String()
+		roles := []string{"<h3>Primary subject</h3>", "<h3>Co-conspirator</h3>", "<h3>Beneficiary</h3>"}
+		for i := 1; i < len(roles); i++ {
+			if before, at := strings.Index(html, roles[i-1]), strings.Index(html, roles[i]); before < 0 || at < before {
+				t.Errorf("Expected %s after %s", roles[i], roles[i-1])
+			}
+		}
+		coConspirator := html[strings.Index(html, roles[1]):strings.Index(html, roles[2])]
+		if !strings.Contains(coConspirator, "John Roe") || !strings.Contains(coConspirator, "XXXX-XXXX-XXXX-2233") {
+			t.Errorf("Expected the co-conspirator's name and masked account, got %s", coConspirator)
+		}
+	})
+}
+
+// checkPDFXref checks that every cross-reference entry points at its object.
+func checkPDFXref(t *testing.T, pdf []byte) {
+	t.Helper()
+	start := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	if start == nil {
+		t.Fatalf("Expected a startxref")
+	}
+	offset, _ := strconv.Atoi(string(start[1]))
+	if !bytes.HasPrefix(pdf[offset:], []byte("xref\n")) {
+		t.Fatalf("Expected startxref to point at the xref table")
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[offset:], -1)
+	for i, entry := range entries {
+		at, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(pdf[at:], []byte(want)) {
+			t.Errorf("xref entry %d does not point at %q", i+1, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"AML/internal/models"
+)
+
+// sarSubjectRoleOrder lists subjects after the primary subject: co-conspirators, then
+// beneficiaries.
+var sarSubjectRoleOrder = map[string]int{
+	models.SARSubjectPrimary:       0,
+	models.SARSubjectCoConspirator: 1,
+	models.SARSubjectBeneficiary:   2,
+}
+
+// buildSARSubjects groups the holders of a report's accounts into subjects. Accounts held by the
+// same person, matched on name and date of birth, are listed under one subject. The holder of
+// primaryAccountID is the primary subject, the holders of the other transacting accounts are
+// co-conspirators, and holders who only appear as counterparties are beneficiaries. Accounts
+// with no holder on record are left out.
+func buildSARSubjects(primaryAccountID string, transacting, counterparties []string, accounts map[string]models.Account) models.SARSubjects {
+	var subjects models.SARSubjects
+	byHolder := make(map[string]int)
+	add := func(accountID, role string) {
+		account, ok := accounts[accountID]
+		if !ok {
+			return
+		}
+		key := accountHolderKey(account)
+		i, seen := byHolder[key]
+		if !seen {
+			i = len(subjects)
+			byHolder[key] = i
+			subjects = append(subjects, models.SARSubject{
+				Role:        role,
+				Name:        account.HolderName,
+				Address:     account.Address,
+				DateOfBirth: account.DateOfBirth,
+			})
+		}
+		if sarSubjectRoleOrder[role] < sarSubjectRoleOrder[subjects[i].Role] {
+			subjects[i].Role = role
+		}
+		for _, existing := range subjects[i].AccountIDs {
+			if existing == accountID {
+				return
+			}
+		}
+		subjects[i].AccountIDs = append(subjects[i].AccountIDs, accountID)
+	}
+
+	add(primaryAccountID, models.SARSubjectPrimary)
+	for _, id := range transacting {
+		add(id, models.SARSubjectCoConspirator)
+	}
+	for _, id := range counterparties {
+		add(id, models.SARSubjectBeneficiary)
+	}
+
+	for i := range subjects {
+		sort.Strings(subjects[i].AccountIDs)
+	}
+	sort.SliceStable(subjects, func(i, j int) bool {
+		if subjects[i].Role != subjects[j].Role {
+			return sarSubjectRoleOrder[subjects[i].Role] < sarSubjectRoleOrder[subjects[j].Role]
+		}
+		return subjects[i].Name < subjects[j].Name
+	})
+	return subjects
+}
+
+// accountHolderKey identifies the person holding an account by name and date of birth, so that
+// accounts held by the same person can be grouped.
+func accountHolderKey(account models.Account) string {
+	return strings.ToLower(strings.TrimSpace(account.HolderName)) + "|" + account.DateOfBirth
+}
+
+// sarSubjects returns the people a report names, primary subject first. A report without a
+// subject list names only its primary subject, who is taken to hold every account in the report.
+func sarSubjects(report *models.SARReport) []models.SARSubject {
+	if len(report.Subjects) > 0 {
+		return report.Subjects
+	}
+	return []models.SARSubject{{
+		Role:        models.SARSubjectPrimary,
+		Name:        report.SubjectName,
+		Address:     report.SubjectAddress,
+		DateOfBirth: report.SubjectDateOfBirth,
+		AccountIDs:  sarAccountIDs(report),
+	}}
+}
+
+// sarAccountHolders maps each account named in a report to the subject holding it.
+func sarAccountHolders(report *models.SARReport) map[string]models.SARSubject {
+	holders := make(map[string]models.SARSubject)
+	for _, subject := range sarSubjects(report) {
+		for _, id := range subject.AccountIDs {
+			holders[id] = subject
+		}
+	}
+	return holders
+}
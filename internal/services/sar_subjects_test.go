@@ -0,0 +1,85 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"AML/internal/models"
+)
+
+// testMultiSubjectReport returns testSARReport with tx-2 moved to a co-conspirator's account and
+// tx-3 paid to a beneficiary's account.
+func testMultiSubjectReport() *models.SARReport {
+	report := testSARReport()
+	structuring := report.Patterns[AlertTypeStructuringPattern]
+	structuring.Transactions[1].AccountID = "ACCT1111222233"
+	report.Patterns[AlertTypeStructuringPattern] = structuring
+	threshold := report.Patterns[AlertTypeThresholdViolation]
+	threshold.Transactions[0].CounterpartyID = "ACCT5555666677"
+	report.Patterns[AlertTypeThresholdViolation] = threshold
+	report.Subjects = models.SARSubjects{
+		{Role: models.SARSubjectPrimary, Name: "Jane Q Doe", Address: "42 Elm Street, Springfield", DateOfBirth: "1980-05-20", AccountIDs: []string{"ACCT9876543210"}},
+		{Role: models.SARSubjectCoConspirator, Name: "John Roe", Address: "2 High St", DateOfBirth: "1975-01-02", AccountIDs: []string{"ACCT1111222233"}},
+		{Role: models.SARSubjectBeneficiary, Name: "Ann Lee", DateOfBirth: "1990-03-04", AccountIDs: []string{"ACCT5555666677"}},
+	}
+	return report
+}
+
+func TestSARSubjects(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Test Case 1: Every distinct account holder is reported under their role
+	t.Run("generate", func(t *testing.T) {
+		db := newTestDB(t)
+		seedSARActivity(t, db, base)
+		stmts := []string{
+			// acc-3 is a second account held by the primary subject.
+			`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('acc-3', 'jane q doe', '1 Main St', '1980-05-20')`,
+			`INSERT INTO accounts (account_id, holder_name, address, date_of_birth) VALUES ('acc-4', 'Ann Lee', '4 Low Rd', '1990-03-04')`,
+			`UPDATE transactions SET account_id = 'acc-3' WHERE transaction_id = 'tx-2'`,
+			`UPDATE transactions SET counterparty_id = 'acc-4' WHERE transaction_id = 'tx-1'`,
+		}
+		for _, stmt := range stmts {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("failed to seed subjects: %v", err)
+			}
+		}
+
+		report, err := GenerateSARData([]string{"alert-0", "alert-1", "alert-2"}, db)
+		if err != nil {
+			t.Fatalf("GenerateSARData failed: %v", err)
+		}
+		want := []struct {
+			role, name, accounts string
+		}{
+			{models.SARSubjectPrimary, "Jane Q Doe", "acc-1,acc-3"},
+			{models.SARSubjectCoConspirator, "John Roe", "acc-2"},
+			{models.SARSubjectBeneficiary, "Ann Lee", "acc-4"},
+		}
+		if len(report.Subjects) != len(want) {
+			t.Fatalf("Expected %d subjects, got %+v", len(want), report.Subjects)
+		}
+		for i, w := range want {
+			got := report.Subjects[i]
+			if got.Role != w.role || got.Name != w.name || strings.Join(got.AccountIDs, ",") != w.accounts {
+				t.Errorf("Subject %d: expected %s %s on %s, got %+v", i, w.role, w.name, w.accounts, got)
+			}
+		}
+		if report.SubjectName != "Jane Q Doe" || report.SubjectAddress != "1 Main St" || report.SubjectDateOfBirth != "1980-05-20" {
+			t.Errorf("Expected the legacy fields to describe the primary subject, got %s, %s, %s",
+				report.SubjectName, report.SubjectAddress, report.SubjectDateOfBirth)
+		}
+	})
+
+	// Test Case 2: Reports without a subject list name a single primary subject
+	t.Run("legacy", func(t *testing.T) {
+		subjects := sarSubjects(testSARReport())
+		if len(subjects) != 1 || subjects[0].Role != models.SARSubjectPrimary || subjects[0].Name != "Jane Q Doe" {
+			t.Fatalf("Expected a single primary subject, got %+v", subjects)
+		}
+		if strings.Join(subjects[0].AccountIDs, ",") != "ACCT9876543210" {
+			t.Errorf("Expected the primary subject to hold every account, got %v", subjects[0].AccountIDs)
+		}
+	})
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// slaSystemActor is the actor recorded for escalations and reassignments made on SLA breach.
+const slaSystemActor = "sla-monitor"
+
+// slaPolicy is an SLA policy with its durations parsed.
+type slaPolicy struct {
+	config.SLAPolicy
+	within     time.Duration
+	warnBefore time.Duration
+}
+
+// pending reports whether the policy's stage is still outstanding for the alert.
+func (p slaPolicy) pending(alert *models.Alert) bool {
+	if IsFinalAlertStatus(alert.AlertType, alert.Status) {
+		return false
+	}
+	if len(p.PendingStatuses) == 0 {
+		return true
+	}
+	for _, s := range p.PendingStatuses {
+		if s == alert.Status {
+			return true
+		}
+	}
+	return false
+}
+
+// SLAMonitor tracks alert deadlines and acts on breaches.
+type SLAMonitor struct {
+	policies []slaPolicy
+	// Assigner reassigns overdue alerts for policies that ask for it; reassignment is skipped
+	// when nil.
+	Assigner *Assigner
+	// ActorRoles are the roles escalations are made with.
+	ActorRoles []string
+}
+
+// NewSLAMonitor builds a monitor for the configured policies.
+func NewSLAMonitor(cfg config.SLAConfig, assigner *Assigner) (*SLAMonitor, error) {
+	m := &SLAMonitor{Assigner: assigner, ActorRoles: cfg.ActorRoles}
+	for _, p := range cfg.Policies {
+		within, err := p.GetWithin()
+		if err != nil {
+			return nil, fmt.Errorf("policy '%s': %w", p.Name, err)
+		}
+		warnBefore, err := p.GetWarnBefore()
+		if err != nil {
+			return nil, fmt.Errorf("policy '%s': %w", p.Name, err)
+		}
+		m.policies = append(m.policies, slaPolicy{SLAPolicy: p, within: within, warnBefore: warnBefore})
+	}
+	return m, nil
+}
+
+// Deadlines returns the outstanding SLA stages for an alert. Deadlines run from when the alert
+// was raised.
+func (m *SLAMonitor) Deadlines(alert *models.Alert, now time.Time) []models.AlertDeadline {
+	var deadlines []models.AlertDeadline
+	for _, p := range m.policies {
+		if p.Priority != alert.Priority.String() || !p.pending(alert) {
+			continue
+		}
+		deadline := alert.CreatedAt.Add(p.within)
+		state := models.SLAStateOnTrack
+		switch {
+		case !now.Before(deadline):
+			state = models.SLAStateBreached
+		case !now.Before(deadline.Add(-p.warnBefore)):
+			state = models.SLAStateApproaching
+		}
+		deadlines = append(deadlines, models.AlertDeadline{Alert: *alert, Policy: p.Name, Deadline: deadline, State: state})
+	}
+	return deadlines
+}
+
+// ListDeadlines returns the outstanding SLA stages of every open alert in the given state,
+// soonest deadline first. An empty state returns stages approaching or past their deadline.
+func (m *SLAMonitor) ListDeadlines(db database.DBTX, state string, now time.Time) ([]models.AlertDeadline, error) {
+	alerts, err := listOpenAlerts(db)
+	if err != nil {
+		return nil, err
+	}
+
+	deadlines := []models.AlertDeadline{}
+	for i := range alerts {
+		for _, d := range m.Deadlines(&alerts[i], now) {
+			if d.State == state || (state == "" && d.State != models.SLAStateOnTrack) {
+				deadlines = append(deadlines, d)
+			}
+		}
+	}
+	sort.SliceStable(deadlines, func(i, j int) bool {
+		if !deadlines[i].Deadline.Equal(deadlines[j].Deadline) {
+			return deadlines[i].Deadline.Before(deadlines[j].Deadline)
+		}
+		return deadlines[i].Alert.ID < deadlines[j].Alert.ID
+	})
+	return deadlines, nil
+}
+
+// Check records a breach for every outstanding stage past its deadline that has not been
+// recorded yet, escalating or reassigning the alert as its policy asks. Each breach is acted on
+// once, in its own database transaction; actions the workflow or staffing do not allow are
+// recorded as failed. A breach that fails unexpectedly is rolled back and skipped, to be retried
+// on the next check, without holding back the others. Check returns the breaches it recorded,
+// and an error listing those it skipped.
+func (m *SLAMonitor) Check(db *database.DB, now time.Time) ([]models.SLABreach, error) {
+	alerts, err := listOpenAlerts(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var breaches []models.SLABreach
+	var skipped []error
+	for i := range alerts {
+		alert := &alerts[i]
+		for _, p := range m.policies {
+			if p.Priority != alert.Priority.String() || !p.pending(alert) {
+				continue
+			}
+			deadline := alert.CreatedAt.Add(p.within)
+			if now.Before(deadline) {
+				continue
+			}
+			breach, err := m.checkBreach(db, *alert, p, deadline, now)
+			if err != nil {
+				skipped = append(skipped, fmt.Errorf("alert %s, policy %s: %w", alert.ID, p.Name, err))
+				continue
+			}
+			if breach == nil {
+				continue
+			}
+			*alert = breach.alert
+			breaches = append(breaches, breach.SLABreach)
+		}
+	}
+	if len(skipped) > 0 {
+		return breaches, fmt.Errorf("skipped %d SLA breaches: %w", len(skipped), errors.Join(skipped...))
+	}
+	return breaches, nil
+}
+
+// actedBreach is a recorded breach with the alert as it stands after the breach was acted on.
+type actedBreach struct {
+	models.SLABreach
+	alert models.Alert
+}
+
+// checkBreach records and acts on one breach in its own transaction, unless it is already
+// recorded, in which case it returns nil.
+func (m *SLAMonitor) checkBreach(db *database.DB, alert models.Alert, p slaPolicy, deadline, now time.Time) (*actedBreach, error) {
+	dbTx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	recorded, err := slaBreachRecorded(dbTx, alert.ID, p.Name)
+	if err != nil || recorded {
+		return nil, err
+	}
+	breach := models.SLABreach{
+		ID:         uuid.New().String(),
+		AlertID:    alert.ID,
+		Policy:     p.Name,
+		Priority:   p.Priority,
+		Deadline:   deadline,
+		DetectedAt: now,
+		Actions:    models.StringList{},
+	}
+	if err := m.act(dbTx, &alert, p, &breach, now); err != nil {
+		return nil, err
+	}
+	if err := recordSLABreach(dbTx, &breach); err != nil {
+		return nil, err
+	}
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &actedBreach{SLABreach: breach, alert: alert}, nil
+}
+
+// act escalates and reassigns an overdue alert as the policy asks, noting the outcomes on the
+// breach. Only unexpected errors are returned.
+func (m *SLAMonitor) act(db database.DBTX, alert *models.Alert, p slaPolicy, breach *models.SLABreach, now time.Time) error {
+	var details []string
+	if p.EscalateTo != "" && alert.Status != p.EscalateTo {
+		updated, _, err := ApplyAlertTransition(db, AlertTransitionRequest{
+			AlertID:   alert.ID,
+			NewStatus: p.EscalateTo,
+			Actor:     slaSystemActor,
+			Comment:   fmt.Sprintf("SLA breach: %s deadline %s passed", p.Name, breach.Deadline.UTC().Format(time.RFC3339)),
+			Roles:     m.ActorRoles,
+		})
+		switch {
+		case err == nil:
+			*alert = *updated
+			breach.Actions = append(breach.Actions, models.SLAActionEscalated)
+		case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrTransitionForbidden), errors.Is(err, ErrMissingTransitionField):
+			breach.Actions = append(breach.Actions, models.SLAActionEscalationFailed)
+			details = append(details, err.Error())
+		default:
+			return err
+		}
+	}
+
+	if p.Reassign && m.Assigner != nil {
+		err := m.Assigner.assignExcluding(db, alert, alert.AssignedTo, slaSystemActor, now)
+		switch {
+		case err == nil:
+			breach.Actions = append(breach.Actions, models.SLAActionReassigned)
+		case errors.Is(err, ErrNoEligibleInvestigator), errors.Is(err, ErrAlertClosed):
+			breach.Actions = append(breach.Actions, models.SLAActionReassignFailed)
+			details = append(details, err.Error())
+		default:
+			return err
+		}
+	}
+	breach.Detail = strings.Join(details, "; ")
+	return nil
+}
+
+// ListSLABreaches returns the recorded breaches for an alert, or for every alert when alertID is
+// empty, most recent first.
+func ListSLABreaches(db database.DBTX, alertID string) ([]models.SLABreach, error) {
+	query := `SELECT id, alert_id, policy, priority, deadline, detected_at, actions, detail FROM alert_sla_breaches`
+	var args []interface{}
+	if alertID != "" {
+		query += ` WHERE alert_id = ?`
+		args = append(args, alertID)
+	}
+	query += ` ORDER BY detected_at DESC, id DESC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SLA breaches: %w", err)
+	}
+	defer rows.Close()
+
+	breaches := []models.SLABreach{}
+	for rows.Next() {
+		var b models.SLABreach
+		if err := rows.Scan(&b.ID, &b.AlertID, &b.Policy, &b.Priority, &b.Deadline, &b.DetectedAt, &b.Actions, &b.Detail); err != nil {
+			return nil, fmt.Errorf("failed to scan SLA breach row: %w", err)
+		}
+		breaches = append(breaches, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SLA breach rows: %w", err)
+	}
+
+	return breaches, nil
+}
+
+func slaBreachRecorded(db database.DBTX, alertID, policy string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM alert_sla_breaches WHERE alert_id = ? AND policy = ?`, alertID, policy).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to query SLA breaches for alert %s: %w", alertID, err)
+	}
+	return n > 0, nil
+}
+
+func recordSLABreach(db database.DBTX, b *models.SLABreach) error {
+	query := `
+		INSERT INTO alert_sla_breaches (id, alert_id, policy, priority, deadline, detected_at, actions, detail)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, b.ID, b.AlertID, b.Policy, b.Priority, b.Deadline.UTC(), b.DetectedAt.UTC(), b.Actions, b.Detail)
+	if err != nil {
+		return fmt.Errorf("failed to record SLA breach for alert %s: %w", b.AlertID, err)
+	}
+	return nil
+}
+
+// listOpenAlerts returns every alert not in a final status, oldest first.
+func listOpenAlerts(db database.DBTX) ([]models.Alert, error) {
+	open, args := alertWorkflows.openAlertCondition()
+	rows, err := db.Query(`SELECT `+alertSelectColumns+` FROM alerts WHERE `+open+` ORDER BY created_at ASC, id ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []models.Alert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert row: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rows: %w", err)
+	}
+
+	return alerts, nil
+}
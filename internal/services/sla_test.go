@@ -0,0 +1,211 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+func TestSLAMonitor(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.SLAConfig{
+		CheckInterval: "1h",
+		Policies: []config.SLAPolicy{
+			{Name: "triage", Priority: "CRITICAL", Within: "24h", WarnBefore: "4h", PendingStatuses: []string{models.StatusOpen}, Reassign: true},
+			{Name: "decision", Priority: "CRITICAL", Within: "48h", EscalateTo: models.StatusEscalated},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	// Test Case 1: Stages are reported as on track, approaching or breached
+	t.Run("deadlines", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		monitor, err := NewSLAMonitor(cfg, nil)
+		if err != nil {
+			t.Fatalf("NewSLAMonitor failed: %v", err)
+		}
+
+		// alert-1 and alert-3 are the critical alerts, raised at base+1h and base+3h.
+		deadlines, err := monitor.ListDeadlines(db, "", base.Add(22*time.Hour))
+		if err != nil {
+			t.Fatalf("ListDeadlines failed: %v", err)
+		}
+		if len(deadlines) != 1 || deadlines[0].Alert.ID != "alert-1" || deadlines[0].State != models.SLAStateApproaching {
+			t.Fatalf("Expected alert-1 approaching its triage deadline, got %+v", deadlines)
+		}
+		if !deadlines[0].Deadline.Equal(base.Add(25 * time.Hour)) {
+			t.Errorf("Expected deadline %v, got %v", base.Add(25*time.Hour), deadlines[0].Deadline)
+		}
+
+		breached, err := monitor.ListDeadlines(db, models.SLAStateBreached, base.Add(26*time.Hour))
+		if err != nil {
+			t.Fatalf("ListDeadlines failed: %v", err)
+		}
+		if len(breached) != 1 || breached[0].Alert.ID != "alert-1" || breached[0].Policy != "triage" {
+			t.Errorf("Expected alert-1 past its triage deadline, got %+v", breached)
+		}
+	})
+
+	// Test Case 2: Breaches are recorded once and acted on through assignment and the workflow
+	t.Run("check", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		inv := &models.Investigator{ID: "inv-1", Name: "One", Active: true}
+		if err := CreateInvestigator(db, inv, base); err != nil {
+			t.Fatalf("CreateInvestigator failed: %v", err)
+		}
+		assigner, err := NewAssigner(config.AssignmentLeastLoaded)
+		if err != nil {
+			t.Fatalf("NewAssigner failed: %v", err)
+		}
+		monitor, err := NewSLAMonitor(cfg, assigner)
+		if err != nil {
+			t.Fatalf("NewSLAMonitor failed: %v", err)
+		}
+
+		breaches, err := monitor.Check(database.Wrap(db, database.Dollar), base.Add(26*time.Hour))
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		if len(breaches) != 1 || breaches[0].AlertID != "alert-1" || !breaches[0].Actions.Contains(models.SLAActionReassigned) {
+			t.Fatalf("Expected alert-1 reassigned on triage breach, got %+v", breaches)
+		}
+		if alert, _ := GetAlert(db, "alert-1"); alert.AssignedTo != "inv-1" {
+			t.Errorf("Expected alert-1 assigned to inv-1, got %q", alert.AssignedTo)
+		}
+		if again, err := monitor.Check(database.Wrap(db, database.Dollar), base.Add(26*time.Hour+30*time.Minute)); err != nil || len(again) != 0 {
+			t.Errorf("Expected breaches to be recorded once, got %+v, %v", again, err)
+		}
+
+		_, _, err = ApplyAlertTransition(db, AlertTransitionRequest{AlertID: "alert-3", NewStatus: models.StatusInvestigating, Actor: "inv-1", Comment: "Triaged"})
+		if err != nil {
+			t.Fatalf("ApplyAlertTransition failed: %v", err)
+		}
+		breaches, err = monitor.Check(database.Wrap(db, database.Dollar), base.Add(52*time.Hour))
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		actions := map[string]models.StringList{}
+		for _, b := range breaches {
+			actions[b.AlertID+"/"+b.Policy] = b.Actions
+		}
+		if len(breaches) != 2 || !actions["alert-3/decision"].Contains(models.SLAActionEscalated) {
+			t.Fatalf("Expected alert-3 escalated on decision breach, got %+v", breaches)
+		}
+		// OPEN → ESCALATED is not in the workflow, so alert-1 cannot be escalated.
+		if !actions["alert-1/decision"].Contains(models.SLAActionEscalationFailed) {
+			t.Errorf("Expected alert-1 escalation to fail, got %+v", actions["alert-1/decision"])
+		}
+		if alert, _ := GetAlert(db, "alert-3"); alert.Status != models.StatusEscalated {
+			t.Errorf("Expected alert-3 escalated, got %s", alert.Status)
+		}
+
+		recorded, err := ListSLABreaches(db, "alert-1")
+		if err != nil {
+			t.Fatalf("ListSLABreaches failed: %v", err)
+		}
+		if len(recorded) != 2 || recorded[0].Policy != "decision" || recorded[0].Detail == "" {
+			t.Errorf("Unexpected recorded breaches: %+v", recorded)
+		}
+	})
+
+	// Test Case 3: A breach that fails is skipped without rolling back the others
+	t.Run("skip_failed_breach", func(t *testing.T) {
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		monitor, err := NewSLAMonitor(cfg, nil)
+		if err != nil {
+			t.Fatalf("NewSLAMonitor failed: %v", err)
+		}
+		_, err = db.Exec(`CREATE TRIGGER fail_alert_1 BEFORE INSERT ON alert_sla_breaches
+			WHEN NEW.alert_id = 'alert-1' BEGIN SELECT RAISE(ABORT, 'cannot record'); END`)
+		if err != nil {
+			t.Fatalf("failed to create trigger: %v", err)
+		}
+
+		breaches, err := monitor.Check(database.Wrap(db, database.Dollar), base.Add(52*time.Hour))
+		if err == nil || !strings.Contains(err.Error(), "alert-1") {
+			t.Errorf("Expected the alert-1 breaches to be reported as skipped, got %v", err)
+		}
+		if len(breaches) != 2 || breaches[0].AlertID != "alert-3" || breaches[1].AlertID != "alert-3" {
+			t.Fatalf("Expected both alert-3 breaches recorded, got %+v", breaches)
+		}
+		if recorded, _ := ListSLABreaches(db, ""); len(recorded) != 2 {
+			t.Errorf("Expected 2 breaches committed, got %d", len(recorded))
+		}
+	})
+
+	// Test Case 4: With the shipped policies and workflows, an OPEN alert is escalated on its
+	// decision deadline and closed alerts are left out
+	t.Run("shipped_config_escalates_open_alert", func(t *testing.T) {
+		workflows, err := config.LoadWorkflowConfig("../../workflows.json")
+		if err != nil {
+			t.Fatalf("LoadWorkflowConfig failed: %v", err)
+		}
+		if err := ConfigureAlertWorkflows(workflows); err != nil {
+			t.Fatalf("ConfigureAlertWorkflows failed: %v", err)
+		}
+		defer ConfigureAlertWorkflows(config.DefaultWorkflowConfig())
+		shipped, err := config.LoadSLAConfig("../../sla.json")
+		if err != nil {
+			t.Fatalf("LoadSLAConfig failed: %v", err)
+		}
+		monitor, err := NewSLAMonitor(shipped, nil)
+		if err != nil {
+			t.Fatalf("NewSLAMonitor failed: %v", err)
+		}
+
+		db := newTestDB(t)
+		seedAlerts(t, db, base)
+		if _, err := db.Exec(`UPDATE alerts SET status = ? WHERE id = ?`, models.StatusClosed, "alert-3"); err != nil {
+			t.Fatalf("failed to close alert-3: %v", err)
+		}
+
+		now := base.Add(31 * 24 * time.Hour)
+		open, err := listOpenAlerts(db)
+		if err != nil {
+			t.Fatalf("listOpenAlerts failed: %v", err)
+		}
+		for _, alert := range open {
+			if alert.ID == "alert-3" {
+				t.Errorf("Expected closed alert-3 to be left out of open alerts")
+			}
+		}
+		deadlines, err := monitor.ListDeadlines(db, models.SLAStateBreached, now)
+		if err != nil {
+			t.Fatalf("ListDeadlines failed: %v", err)
+		}
+		for _, d := range deadlines {
+			if d.Alert.ID == "alert-3" {
+				t.Errorf("Expected no deadlines for closed alert-3, got %+v", d)
+			}
+		}
+
+		breaches, err := monitor.Check(database.Wrap(db, database.Dollar), now)
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		var decision *models.SLABreach
+		for i := range breaches {
+			if breaches[i].AlertID == "alert-3" {
+				t.Errorf("Expected no breach for closed alert-3, got %+v", breaches[i])
+			}
+			if breaches[i].AlertID == "alert-1" && breaches[i].Policy == "decision" {
+				decision = &breaches[i]
+			}
+		}
+		if decision == nil || !decision.Actions.Contains(models.SLAActionEscalated) {
+			t.Fatalf("Expected OPEN alert-1 escalated on its decision breach, got %+v", breaches)
+		}
+		if alert, _ := GetAlert(db, "alert-1"); alert.Status != models.StatusEscalated {
+			t.Errorf("Expected alert-1 escalated, got %s", alert.Status)
+		}
+	})
+}
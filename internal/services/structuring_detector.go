@@ -7,16 +7,17 @@ import (
 	"AML/internal/models"
 )
 
-// DetectStructuring identifies a pattern of transactions indicative of smurfing.
-func DetectStructuring(accountID string, transactions []models.Transaction, timeWindow time.Duration, thresholdLow float64, thresholdHigh float64, minCount int) (detected bool, matchingTxs []models.Transaction) {
+// DetectStructuring identifies a pattern of transactions indicative of smurfing in the window
+// ending at asOf, inclusive. Detectors pass the timestamp of the transaction being evaluated, so
+// past activity is judged the same way when it is replayed.
+func DetectStructuring(accountID string, transactions []models.Transaction, asOf time.Time, timeWindow time.Duration, thresholdLow float64, thresholdHigh float64, minCount int) (detected bool, matchingTxs []models.Transaction) {
 	var candidates []models.Transaction
-	now := time.Now()
-	windowStart := now.Add(-timeWindow)
+	windowStart := asOf.Add(-timeWindow)
 
 	for _, tx := range transactions {
 		if tx.AccountID == accountID &&
 			tx.Timestamp.After(windowStart) &&
-			tx.Timestamp.Before(now) &&
+			!tx.Timestamp.After(asOf) &&
 			tx.Amount >= thresholdLow &&
 			tx.Amount <= thresholdHigh {
 			candidates = append(candidates, tx)
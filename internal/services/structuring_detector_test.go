@@ -25,7 +25,7 @@ func TestDetectStructuring(t *testing.T) {
 			{AccountID: "acc-456", Amount: 9000.00, Timestamp: time.Now().Add(-6 * time.Hour)}, // Different account
 		}
 
-		detected, matchingTxs := DetectStructuring(accountID, transactions, timeWindow, thresholdLow, thresholdHigh, minCount)
+		detected, matchingTxs := DetectStructuring(accountID, transactions, time.Now(), timeWindow, thresholdLow, thresholdHigh, minCount)
 
 		if !detected {
 			t.Errorf("Expected structuring pattern to be detected, but it was not")
@@ -47,7 +47,7 @@ func TestDetectStructuring(t *testing.T) {
 			{AccountID: accountID, Amount: 9800.00, Timestamp: time.Now().Add(-30 * time.Hour)},
 		}
 
-		detected, _ := DetectStructuring(accountID, transactions, timeWindow, thresholdLow, thresholdHigh, minCount)
+		detected, _ := DetectStructuring(accountID, transactions, time.Now(), timeWindow, thresholdLow, thresholdHigh, minCount)
 
 		if detected {
 			t.Errorf("Expected structuring pattern to not be detected, but it was")
@@ -62,7 +62,7 @@ func TestDetectStructuring(t *testing.T) {
 			{AccountID: accountID, Amount: 7000.00, Timestamp: time.Now().Add(-3 * time.Hour)},
 		}
 
-		detected, _ := DetectStructuring(accountID, transactions, timeWindow, thresholdLow, thresholdHigh, minCount)
+		detected, _ := DetectStructuring(accountID, transactions, time.Now(), timeWindow, thresholdLow, thresholdHigh, minCount)
 
 		if detected {
 			t.Errorf("Expected structuring pattern to not be detected, but it was")
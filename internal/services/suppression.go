@@ -0,0 +1,221 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// suppressionSystemActor is recorded as the actor when a suppression mutes an alert.
+const suppressionSystemActor = "system"
+
+var (
+	// ErrSuppressionNotFound is returned when no suppression exists with the requested ID.
+	ErrSuppressionNotFound = fmt.Errorf("suppression not found")
+	// ErrInvalidSuppression is returned when a suppression request is incomplete or already expired.
+	ErrInvalidSuppression = fmt.Errorf("invalid suppression")
+)
+
+// suppressionColumns is the column list matching scanSuppression.
+const suppressionColumns = `id, account_id, rule_key, reason, created_by, created_at, expires_at, revoked_at, revoked_by`
+
+// CreateSuppression mutes alerts for an account and rule key until expiresAt and records who did so.
+// The rule key is the alert's dedup key: a rule ID, or detector/alert type for detectors without rules.
+func CreateSuppression(db database.DBTX, accountID, ruleKey, reason, actor string, expiresAt, now time.Time) (*models.Suppression, error) {
+	switch {
+	case accountID == "" || ruleKey == "":
+		return nil, fmt.Errorf("%w: account_id and rule_key are required", ErrInvalidSuppression)
+	case strings.TrimSpace(reason) == "":
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidSuppression)
+	case strings.TrimSpace(actor) == "":
+		return nil, ErrActorRequired
+	case !expiresAt.After(now):
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidSuppression)
+	}
+
+	s := &models.Suppression{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		RuleKey:   ruleKey,
+		Reason:    reason,
+		CreatedBy: actor,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	query := `
+		INSERT INTO alert_suppressions (id, account_id, rule_key, reason, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, s.ID, s.AccountID, s.RuleKey, s.Reason, s.CreatedBy, s.CreatedAt.UTC(), s.ExpiresAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert suppression: %w", err)
+	}
+	if err := recordSuppressionAudit(db, s.ID, models.SuppressionActionCreated, actor, "", reason, now); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// RevokeSuppression ends a suppression early and records who did so and why.
+func RevokeSuppression(db database.DBTX, id, actor, comment string, now time.Time) (*models.Suppression, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, ErrActorRequired
+	}
+	if strings.TrimSpace(comment) == "" {
+		return nil, ErrCommentRequired
+	}
+	s, err := GetSuppression(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.ActiveAt(now) {
+		return nil, fmt.Errorf("%w: suppression %s is no longer active", ErrInvalidSuppression, id)
+	}
+
+	s.RevokedAt = now
+	s.RevokedBy = actor
+	query := `UPDATE alert_suppressions SET revoked_at = ?, revoked_by = ? WHERE id = ?`
+	if _, err := db.Exec(query, s.RevokedAt.UTC(), s.RevokedBy, s.ID); err != nil {
+		return nil, fmt.Errorf("failed to revoke suppression %s: %w", id, err)
+	}
+	if err := recordSuppressionAudit(db, s.ID, models.SuppressionActionRevoked, actor, "", comment, now); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetSuppression fetches a single suppression by ID.
+func GetSuppression(db database.DBTX, id string) (*models.Suppression, error) {
+	query := `SELECT ` + suppressionColumns + ` FROM alert_suppressions WHERE id = ?`
+	s, err := scanSuppression(db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrSuppressionNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suppression %s: %w", id, err)
+	}
+	return s, nil
+}
+
+// ListSuppressions returns suppressions, newest first, optionally for one account and only those
+// active at activeAt when it is non-zero.
+func ListSuppressions(db database.DBTX, accountID string, activeAt time.Time) ([]models.Suppression, error) {
+	query := `SELECT ` + suppressionColumns + ` FROM alert_suppressions`
+	var conditions []string
+	var args []interface{}
+	if accountID != "" {
+		conditions = append(conditions, "account_id = ?")
+		args = append(args, accountID)
+	}
+	if !activeAt.IsZero() {
+		conditions = append(conditions, "revoked_at IS NULL AND expires_at > ?")
+		args = append(args, activeAt.UTC())
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	suppressions := []models.Suppression{}
+	for rows.Next() {
+		s, err := scanSuppression(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan suppression row: %w", err)
+		}
+		suppressions = append(suppressions, *s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating suppression rows: %w", err)
+	}
+
+	return suppressions, nil
+}
+
+// ActiveSuppression returns the suppression muting alerts for the account and rule key at now,
+// or nil when there is none.
+func ActiveSuppression(db database.DBTX, accountID, ruleKey string, now time.Time) (*models.Suppression, error) {
+	query := `
+		SELECT ` + suppressionColumns + `
+		FROM alert_suppressions
+		WHERE account_id = ? AND rule_key = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY expires_at DESC
+		LIMIT 1
+	`
+	s, err := scanSuppression(db.QueryRow(query, accountID, ruleKey, now.UTC()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active suppressions: %w", err)
+	}
+	return s, nil
+}
+
+// ListSuppressionAudit returns a suppression's audit trail, oldest first.
+func ListSuppressionAudit(db database.DBTX, suppressionID string) ([]models.SuppressionAuditEntry, error) {
+	query := `
+		SELECT id, suppression_id, action, actor, transaction_id, comment, created_at
+		FROM alert_suppression_audit
+		WHERE suppression_id = ?
+		ORDER BY created_at ASC, id ASC
+	`
+	rows, err := db.Query(query, suppressionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit for suppression %s: %w", suppressionID, err)
+	}
+	defer rows.Close()
+
+	entries := []models.SuppressionAuditEntry{}
+	for rows.Next() {
+		var e models.SuppressionAuditEntry
+		if err := rows.Scan(&e.ID, &e.SuppressionID, &e.Action, &e.Actor, &e.TransactionID, &e.Comment, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan suppression audit row: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating suppression audit rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// recordSuppressionAudit appends an entry to a suppression's audit trail.
+func recordSuppressionAudit(db database.DBTX, suppressionID, action, actor, transactionID, comment string, at time.Time) error {
+	query := `
+		INSERT INTO alert_suppression_audit (id, suppression_id, action, actor, transaction_id, comment, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.Exec(query, uuid.New().String(), suppressionID, action, actor, transactionID, comment, at.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record audit for suppression %s: %w", suppressionID, err)
+	}
+	return nil
+}
+
+func scanSuppression(row rowScanner) (*models.Suppression, error) {
+	var s models.Suppression
+	var revokedAt sql.NullTime
+	err := row.Scan(&s.ID, &s.AccountID, &s.RuleKey, &s.Reason, &s.CreatedBy, &s.CreatedAt, &s.ExpiresAt, &revokedAt, &s.RevokedBy)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = revokedAt.Time
+	}
+	return &s, nil
+}
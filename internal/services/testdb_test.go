@@ -0,0 +1,106 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// testSchema mirrors the migrations in internal/database/migrations using SQLite types.
+const testSchema = `
+	CREATE TABLE accounts (
+		account_id TEXT PRIMARY KEY, holder_name TEXT, address TEXT, date_of_birth TEXT, risk_rating TEXT
+	);
+	CREATE TABLE transactions (
+		transaction_id TEXT PRIMARY KEY, account_id TEXT, amount REAL, currency TEXT,
+		timestamp DATETIME, source_country TEXT, destination_country TEXT,
+		transaction_type TEXT, status TEXT, counterparty_id TEXT
+	);
+	CREATE TABLE account_profiles (
+		account_id TEXT PRIMARY KEY, transaction_count INTEGER, amount_mean REAL, amount_m2 REAL,
+		log_amount_mean REAL, log_amount_m2 REAL, counterparties TEXT, countries TEXT,
+		transaction_types TEXT, active_hours TEXT, gap_count INTEGER, gap_mean_hours REAL, gap_m2 REAL, first_seen DATETIME, last_seen DATETIME, updated_at DATETIME,
+		version INTEGER NOT NULL DEFAULT 1
+	);
+	CREATE TABLE alerts (
+		id TEXT PRIMARY KEY, transaction_id TEXT, account_id TEXT, alert_type TEXT, priority INTEGER,
+		score REAL, created_at DATETIME, status TEXT, assigned_to TEXT DEFAULT '', rule_details TEXT,
+		transition_at DATETIME, dedup_key TEXT DEFAULT '', score_breakdown TEXT, findings TEXT,
+		disposition_code TEXT DEFAULT '', case_id TEXT
+	);
+	CREATE TABLE cases (
+		id TEXT PRIMARY KEY, subject_account_id TEXT, title TEXT, status TEXT, assigned_to TEXT DEFAULT '',
+		created_by TEXT, created_at DATETIME, updated_at DATETIME, closed_at DATETIME,
+		close_reason TEXT DEFAULT '', merged_into TEXT
+	);
+	CREATE TABLE case_notes (
+		id TEXT PRIMARY KEY, case_id TEXT, author TEXT, body TEXT, created_at DATETIME
+	);
+	CREATE TABLE case_attachments (
+		id TEXT PRIMARY KEY, case_id TEXT, file_name TEXT, content_type TEXT, uri TEXT,
+		size_bytes INTEGER, sha256 TEXT DEFAULT '', uploaded_by TEXT, uploaded_at DATETIME
+	);
+	CREATE TABLE alert_evidence (
+		id TEXT PRIMARY KEY, alert_id TEXT, transaction_id TEXT, details TEXT, observed_at DATETIME
+	);
+	CREATE TABLE alert_suppressions (
+		id TEXT PRIMARY KEY, account_id TEXT, rule_key TEXT, reason TEXT, created_by TEXT,
+		created_at DATETIME, expires_at DATETIME, revoked_at DATETIME, revoked_by TEXT DEFAULT ''
+	);
+	CREATE TABLE alert_suppression_audit (
+		id TEXT PRIMARY KEY, suppression_id TEXT, action TEXT, actor TEXT, transaction_id TEXT DEFAULT '',
+		comment TEXT DEFAULT '', created_at DATETIME
+	);
+	CREATE TABLE teams (
+		id TEXT PRIMARY KEY, name TEXT UNIQUE, alert_types TEXT DEFAULT '[]', created_at DATETIME
+	);
+	CREATE TABLE investigators (
+		id TEXT PRIMARY KEY, name TEXT, team_id TEXT, skills TEXT DEFAULT '[]', max_open_alerts INTEGER DEFAULT 0,
+		active BOOLEAN DEFAULT 1, last_assigned_at DATETIME, created_at DATETIME
+	);
+	CREATE TABLE alert_assignments (
+		id TEXT PRIMARY KEY, alert_id TEXT, investigator_id TEXT, action TEXT, actor TEXT,
+		strategy TEXT DEFAULT '', created_at DATETIME
+	);
+	CREATE TABLE alert_sla_breaches (
+		id TEXT PRIMARY KEY, alert_id TEXT, policy TEXT, priority TEXT, deadline DATETIME,
+		detected_at DATETIME, actions TEXT DEFAULT '[]', detail TEXT DEFAULT '', UNIQUE (alert_id, policy)
+	);
+	CREATE TABLE alert_status_history (
+		id TEXT PRIMARY KEY, alert_id TEXT, from_status TEXT, to_status TEXT, actor TEXT,
+		comment TEXT, fields TEXT, changed_at DATETIME
+	);
+	CREATE TABLE sars (
+		id TEXT PRIMARY KEY, case_id TEXT, status TEXT, filing_type TEXT, prior_sar_id TEXT, report TEXT,
+		created_by TEXT, created_at DATETIME, updated_at DATETIME, submitted_by TEXT DEFAULT '', submitted_at DATETIME,
+		approved_by TEXT DEFAULT '', approved_at DATETIME, filed_by TEXT DEFAULT '', filed_at DATETIME,
+		filing_reference TEXT DEFAULT '', review_due_at DATETIME
+	);
+	CREATE TABLE sar_alerts (
+		sar_id TEXT, alert_id TEXT, PRIMARY KEY (sar_id, alert_id)
+	);
+	CREATE TABLE sar_events (
+		id TEXT PRIMARY KEY, sar_id TEXT, from_status TEXT, to_status TEXT, actor TEXT, comment TEXT, created_at DATETIME
+	);
+	CREATE TABLE sar_snapshots (
+		sar_id TEXT PRIMARY KEY, report TEXT, sha256 TEXT, created_by TEXT, created_at DATETIME
+	);
+`
+
+// newTestDB opens a private in-memory SQLite database with the service schema applied.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	// A single connection keeps every query on the same in-memory database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(testSchema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	return db
+}
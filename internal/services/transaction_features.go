@@ -0,0 +1,85 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+// TransactionFeatureNames lists, in order, the features produced by ExtractTransactionFeatures.
+var TransactionFeatureNames = []string{
+	"amount",
+	"log_amount",
+	"hour_of_day",
+	"country_risk",
+	"count_1h",
+	"count_24h",
+	"count_7d",
+}
+
+// featureWindows are the look-back windows behind the count_* features.
+var featureWindows = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+
+// ExtractTransactionFeatures builds the model feature vector for a transaction. history holds
+// the account's earlier transactions; only those before tx within each window are counted.
+func ExtractTransactionFeatures(tx models.Transaction, history []models.Transaction, risk config.CountryRisk) []float64 {
+	features := staticFeatures(tx, risk)
+	for _, window := range featureWindows {
+		windowStart := tx.Timestamp.Add(-window)
+		count := 0
+		for _, h := range history {
+			if h.AccountID == tx.AccountID && h.TransactionID != tx.TransactionID &&
+				!h.Timestamp.Before(windowStart) && h.Timestamp.Before(tx.Timestamp) {
+				count++
+			}
+		}
+		features = append(features, float64(count))
+	}
+	return features
+}
+
+// extractFeatureMatrix builds feature vectors for every transaction, counting each one's
+// window activity from the same account's earlier transactions in the set.
+func extractFeatureMatrix(transactions []models.Transaction, risk config.CountryRisk) [][]float64 {
+	byAccount := make(map[string][]models.Transaction)
+	for _, tx := range transactions {
+		byAccount[tx.AccountID] = append(byAccount[tx.AccountID], tx)
+	}
+
+	// Visit accounts in a fixed order so training with a given seed is reproducible.
+	accountIDs := make([]string, 0, len(byAccount))
+	for accountID := range byAccount {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+
+	var matrix [][]float64
+	for _, accountID := range accountIDs {
+		txs := byAccount[accountID]
+		sort.SliceStable(txs, func(i, j int) bool { return txs[i].Timestamp.Before(txs[j].Timestamp) })
+		for i, tx := range txs {
+			features := staticFeatures(tx, risk)
+			for _, window := range featureWindows {
+				windowStart := tx.Timestamp.Add(-window)
+				// Earlier transactions are txs[:i]; find the first one inside the window.
+				first := sort.Search(i, func(k int) bool { return !txs[k].Timestamp.Before(windowStart) })
+				features = append(features, float64(i-first))
+			}
+			matrix = append(matrix, features)
+		}
+	}
+	return matrix
+}
+
+// staticFeatures returns the features that depend only on the transaction itself.
+func staticFeatures(tx models.Transaction, risk config.CountryRisk) []float64 {
+	return []float64{
+		tx.Amount,
+		logAmount(tx.Amount),
+		float64(tx.Timestamp.UTC().Hour()),
+		math.Max(risk.Score(tx.SourceCountry), risk.Score(tx.DestinationCountry)),
+	}
+}
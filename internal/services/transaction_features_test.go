@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+func TestExtractTransactionFeatures(t *testing.T) {
+	risk := config.CountryRisk{Default: 0.1, Countries: map[string]float64{"KP": 1.0}}
+	now := time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC)
+	history := []models.Transaction{
+		{TransactionID: "t1", AccountID: "acc-1", Amount: 10, Timestamp: now.Add(-30 * time.Minute)},
+		{TransactionID: "t2", AccountID: "acc-1", Amount: 10, Timestamp: now.Add(-5 * time.Hour)},
+		{TransactionID: "t3", AccountID: "acc-1", Amount: 10, Timestamp: now.Add(-3 * 24 * time.Hour)},
+		{TransactionID: "t4", AccountID: "acc-1", Amount: 10, Timestamp: now.Add(-10 * 24 * time.Hour)},
+		{TransactionID: "t5", AccountID: "acc-2", Amount: 10, Timestamp: now.Add(-10 * time.Minute)},
+	}
+	tx := models.Transaction{TransactionID: "t6", AccountID: "acc-1", Amount: 999, Timestamp: now, SourceCountry: "US", DestinationCountry: "kp"}
+
+	// Test Case 1: Feature vector for a single transaction
+	t.Run("single_transaction", func(t *testing.T) {
+		features := ExtractTransactionFeatures(tx, history, risk)
+		if len(features) != len(TransactionFeatureNames) {
+			t.Fatalf("Expected %d features, got %d", len(TransactionFeatureNames), len(features))
+		}
+		want := []float64{999, logAmount(999), 15, 1.0, 1, 2, 3}
+		for i := range want {
+			if features[i] != want[i] {
+				t.Errorf("Feature %s: expected %f, got %f", TransactionFeatureNames[i], want[i], features[i])
+			}
+		}
+	})
+
+	// Test Case 2: Batch extraction agrees with single extraction
+	t.Run("matrix_matches_single", func(t *testing.T) {
+		all := append(append([]models.Transaction(nil), history...), tx)
+		matrix := extractFeatureMatrix(all, risk)
+		if len(matrix) != len(all) {
+			t.Fatalf("Expected %d rows, got %d", len(all), len(matrix))
+		}
+		single := ExtractTransactionFeatures(tx, history, risk)
+		found := false
+		for _, row := range matrix {
+			if row[0] == 999 {
+				found = true
+				for i := range row {
+					if row[i] != single[i] {
+						t.Errorf("Feature %s: matrix %f != single %f", TransactionFeatureNames[i], row[i], single[i])
+					}
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected the 999 transaction in the matrix")
+		}
+	})
+}
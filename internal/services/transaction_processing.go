@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"AML/internal/database"
+	"AML/internal/models"
+)
+
+// ProcessingResult is the outcome of running detection for one transaction.
+type ProcessingResult struct {
+	// Alerts are the alerts newly raised for the transaction.
+	Alerts []*models.Alert
+	// Deduplicated are the open alerts the transaction's findings were appended to as evidence.
+	Deduplicated []*models.Alert
+	// Suppressed are the alerts muted by an analyst suppression; they are not stored.
+	Suppressed []*models.Alert
+	// DetectionErr reports detectors that failed; alerts from the others are still processed.
+	DetectionErr error
+}
+
+// TransactionProcessor runs detection for stored transactions and files the resulting alerts.
+type TransactionProcessor struct {
+	Orchestrator *Orchestrator
+	// Detection is the base context for every transaction; its profile and, when unset, its
+	// history are filled in per transaction.
+	Detection DetectionContext
+	// DedupWindow is how long after an alert is raised repeat findings are merged into it.
+	// Deduplication is off when zero.
+	DedupWindow time.Duration
+	// AttachToCases adds each new alert to the open case for its account, if there is one.
+	AttachToCases bool
+	// Scorer, when set, replaces each new alert's score with the risk scoring model's score and
+	// breakdown.
+	Scorer *RiskScorer
+	// Assigner, when set, assigns each new alert to an investigator. Alerts nobody is eligible
+	// for stay unassigned in their team queue.
+	Assigner *Assigner
+	// Correlate merges concurrent findings for a transaction into a single composite alert
+	// instead of raising one alert per finding.
+	Correlate bool
+}
+
+// Process runs detection for a stored transaction, files the resulting alerts and then folds the
+// transaction into the account profile. The profile is updated after detection so that detectors
+// compare the transaction against the account's earlier behaviour only. If another transaction for
+// the account updated the profile meanwhile, the update is reapplied to the stored profile rather
+// than overwriting it.
+//
+// Each alert is checked against active suppressions, then merged into an open alert for the same
+// account and rule raised within the dedup window, and only otherwise saved as a new alert,
+// optionally attached to the account's open case and assigned to an investigator. When
+// correlating, findings are checked against suppressions one by one and the rest are combined
+// into a composite alert before deduplication.
+func (p *TransactionProcessor) Process(db database.DBTX, tx models.Transaction) (*ProcessingResult, error) {
+	profile, err := LoadAccountProfile(db, tx.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	dctx := p.Detection
+	dctx.Profile = profile
+	if dctx.History == nil {
+		dctx.History = SQLHistory{DB: db}
+	}
+	now := dctx.now()
+
+	result := &ProcessingResult{}
+	if p.Orchestrator != nil {
+		var alerts []*models.Alert
+		alerts, result.DetectionErr = p.Orchestrator.Run(tx, &dctx)
+		if p.Scorer != nil {
+			if err := p.Scorer.ScoreAlerts(db, tx, profile, dctx.CountryRisk, alerts, now); err != nil {
+				return nil, err
+			}
+		}
+		if p.Correlate && len(alerts) > 1 {
+			var kept []*models.Alert
+			for _, alert := range alerts {
+				suppressed, err := p.suppress(db, alert, now, result)
+				if err != nil {
+					return nil, err
+				}
+				if !suppressed {
+					kept = append(kept, alert)
+				}
+			}
+			alerts = CorrelateAlerts(kept)
+		}
+		for _, alert := range alerts {
+			if err := p.fileAlert(db, alert, now, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := recordInProfile(db, profile, tx); err != nil {
+		return nil, fmt.Errorf("failed to update account profile: %w", err)
+	}
+	return result, nil
+}
+
+// fileAlert suppresses, merges or saves one alert and records the outcome in result.
+func (p *TransactionProcessor) fileAlert(db database.DBTX, alert *models.Alert, now time.Time, result *ProcessingResult) error {
+	suppressed, err := p.suppress(db, alert, now, result)
+	if err != nil || suppressed {
+		return err
+	}
+
+	if p.DedupWindow > 0 {
+		for _, key := range alertDedupKeys(alert) {
+			existing, err := FindOpenDuplicate(db, alert.AccountID, key, now.Add(-p.DedupWindow))
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				if err := mergeDuplicate(db, existing, alert); err != nil {
+					return err
+				}
+				result.Deduplicated = append(result.Deduplicated, existing)
+				return nil
+			}
+		}
+	}
+
+	if err := SaveAlert(db, alert); err != nil {
+		return err
+	}
+	if p.AttachToCases {
+		if _, err := AttachToOpenCase(db, alert, now); err != nil {
+			return err
+		}
+	}
+	if p.Assigner != nil {
+		if err := p.Assigner.assign(db, alert, assignmentSystemActor, now); err != nil && !errors.Is(err, ErrNoEligibleInvestigator) {
+			return err
+		}
+	}
+	for _, details := range alertEvidence(alert) {
+		if _, err := AddAlertEvidence(db, alert.ID, alert.TransactionID, details, alert.CreatedAt); err != nil {
+			return err
+		}
+	}
+	result.Alerts = append(result.Alerts, alert)
+	return nil
+}
+
+// suppress reports whether an active suppression mutes the alert, recording its application and
+// adding the alert to the result's suppressed alerts if so.
+func (p *TransactionProcessor) suppress(db database.DBTX, alert *models.Alert, now time.Time, result *ProcessingResult) (bool, error) {
+	suppression, err := ActiveSuppression(db, alert.AccountID, alert.DedupKey, now)
+	if err != nil {
+		return false, err
+	}
+	if suppression == nil {
+		return false, nil
+	}
+	comment := fmt.Sprintf("suppressed %s alert", alert.AlertType)
+	if err := recordSuppressionAudit(db, suppression.ID, models.SuppressionActionApplied, suppressionSystemActor, alert.TransactionID, comment, now); err != nil {
+		return false, err
+	}
+	result.Suppressed = append(result.Suppressed, alert)
+	return true, nil
+}
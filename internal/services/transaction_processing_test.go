@@ -0,0 +1,183 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"AML/internal/models"
+)
+
+func TestTransactionProcessor(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ruleFinding := Finding{DetectorID: DetectorThresholdRules, AlertType: AlertTypeThresholdViolation, RuleID: "daily_cumulative_exceeds_50000"}
+	newProcessor := func(detectors ...Detector) *TransactionProcessor {
+		return &TransactionProcessor{
+			Orchestrator: NewOrchestrator(detectors),
+			Detection:    DetectionContext{Clock: fixedClock(now)},
+			DedupWindow:  24 * time.Hour,
+		}
+	}
+	tx := func(id string) models.Transaction {
+		return models.Transaction{TransactionID: id, AccountID: "acc-1", Amount: 500, Timestamp: now}
+	}
+
+	// Test Case 1: Alerts are stored, failing detectors reported and the profile updated
+	t.Run("stores_alerts", func(t *testing.T) {
+		db := newTestDB(t)
+		p := newProcessor(
+			stubDetector{id: "stub", findings: []Finding{ruleFinding}},
+			stubDetector{id: "broken", err: errors.New("boom")},
+		)
+
+		result, err := p.Process(db, tx("tx-1"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if result.DetectionErr == nil {
+			t.Errorf("Expected the failing detector to be reported")
+		}
+		if len(result.Alerts) != 1 {
+			t.Fatalf("Expected 1 alert, got %d", len(result.Alerts))
+		}
+
+		stored, err := GetAlert(db, result.Alerts[0].ID)
+		if err != nil {
+			t.Fatalf("Expected alert to be stored: %v", err)
+		}
+		if stored.AccountID != "acc-1" || stored.DedupKey != ruleFinding.RuleID || stored.RuleDetails["rule_id"] != ruleFinding.RuleID {
+			t.Errorf("Unexpected stored alert: %+v", stored)
+		}
+
+		profile, err := LoadAccountProfile(db, "acc-1")
+		if err != nil {
+			t.Fatalf("LoadAccountProfile failed: %v", err)
+		}
+		if profile.TransactionCount != 1 {
+			t.Errorf("Expected profile to include the transaction, got count %d", profile.TransactionCount)
+		}
+	})
+
+	// Test Case 2: Repeat findings for the same account and rule are merged into the open alert
+	t.Run("deduplicates", func(t *testing.T) {
+		db := newTestDB(t)
+		p := newProcessor(stubDetector{id: "stub", findings: []Finding{ruleFinding}})
+
+		first, err := p.Process(db, tx("tx-1"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		for _, id := range []string{"tx-2", "tx-3"} {
+			result, err := p.Process(db, tx(id))
+			if err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+			if len(result.Alerts) != 0 || len(result.Deduplicated) != 1 || result.Deduplicated[0].ID != first.Alerts[0].ID {
+				t.Errorf("Expected %s to be merged into the first alert, got %+v", id, result)
+			}
+		}
+
+		evidence, err := ListAlertEvidence(db, first.Alerts[0].ID)
+		if err != nil {
+			t.Fatalf("ListAlertEvidence failed: %v", err)
+		}
+		seen := make(map[string]bool)
+		for _, e := range evidence {
+			seen[e.TransactionID] = true
+		}
+		if len(evidence) != 3 || !seen["tx-1"] || !seen["tx-2"] || !seen["tx-3"] {
+			t.Errorf("Expected evidence from all three transactions, got %+v", evidence)
+		}
+
+		// Once the alert is closed, a new finding raises a fresh alert.
+		alert := first.Alerts[0]
+		alert.Status = models.StatusClosed
+		if err := UpdateAlert(db, alert); err != nil {
+			t.Fatalf("UpdateAlert failed: %v", err)
+		}
+		result, err := p.Process(db, tx("tx-4"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if len(result.Alerts) != 1 {
+			t.Errorf("Expected a new alert after the duplicate was closed, got %+v", result)
+		}
+	})
+
+	// Test Case 3: Active suppressions mute alerts and are audited; revoked ones do not
+	t.Run("suppresses", func(t *testing.T) {
+		db := newTestDB(t)
+		p := newProcessor(stubDetector{id: "stub", findings: []Finding{ruleFinding}})
+
+		s, err := CreateSuppression(db, "acc-1", ruleFinding.RuleID, "Payroll account", "analyst-1", now.Add(time.Hour), now.Add(-time.Minute))
+		if err != nil {
+			t.Fatalf("CreateSuppression failed: %v", err)
+		}
+		result, err := p.Process(db, tx("tx-1"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if len(result.Alerts) != 0 || len(result.Suppressed) != 1 {
+			t.Errorf("Expected the alert to be suppressed, got %+v", result)
+		}
+
+		if _, err := RevokeSuppression(db, s.ID, "supervisor-1", "No longer benign", now.Add(time.Minute)); err != nil {
+			t.Fatalf("RevokeSuppression failed: %v", err)
+		}
+		p.Detection.Clock = fixedClock(now.Add(2 * time.Minute))
+		result, err = p.Process(db, tx("tx-2"))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if len(result.Alerts) != 1 {
+			t.Errorf("Expected an alert after revocation, got %+v", result)
+		}
+
+		audit, err := ListSuppressionAudit(db, s.ID)
+		if err != nil {
+			t.Fatalf("ListSuppressionAudit failed: %v", err)
+		}
+		actions := make([]string, len(audit))
+		for i, e := range audit {
+			actions[i] = e.Action
+		}
+		want := []string{models.SuppressionActionCreated, models.SuppressionActionApplied, models.SuppressionActionRevoked}
+		if len(actions) != len(want) || actions[0] != want[0] || actions[1] != want[1] || actions[2] != want[2] {
+			t.Errorf("Expected audit actions %v, got %v", want, actions)
+		}
+		if audit[1].TransactionID != "tx-1" {
+			t.Errorf("Expected the applied entry to name the muted transaction, got %+v", audit[1])
+		}
+	})
+}
+
+func TestCreateSuppressionValidation(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := CreateSuppression(db, "acc-1", "rule-1", "", "analyst-1", now.Add(time.Hour), now); !errors.Is(err, ErrInvalidSuppression) {
+		t.Errorf("Expected a missing reason to be rejected, got %v", err)
+	}
+	if _, err := CreateSuppression(db, "acc-1", "rule-1", "Benign", "analyst-1", now, now); !errors.Is(err, ErrInvalidSuppression) {
+		t.Errorf("Expected an expired suppression to be rejected, got %v", err)
+	}
+	if _, err := CreateSuppression(db, "acc-1", "rule-1", "Benign", "", now.Add(time.Hour), now); !errors.Is(err, ErrActorRequired) {
+		t.Errorf("Expected a missing actor to be rejected, got %v", err)
+	}
+
+	s, err := CreateSuppression(db, "acc-1", "rule-1", "Benign", "analyst-1", now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("CreateSuppression failed: %v", err)
+	}
+	active, err := ListSuppressions(db, "acc-1", now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("ListSuppressions failed: %v", err)
+	}
+	if len(active) != 0 {
+		t.Errorf("Expected the suppression to have expired, got %+v", active)
+	}
+	all, _ := ListSuppressions(db, "", time.Time{})
+	if len(all) != 1 || all[0].ID != s.ID {
+		t.Errorf("Expected the suppression in the full listing, got %+v", all)
+	}
+}
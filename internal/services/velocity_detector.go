@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"math"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+// VelocityWindowResult compares the transaction count in one window with the account's usual rate.
+type VelocityWindowResult struct {
+	Window      string  `json:"window"`
+	Observed    int     `json:"observed"`
+	Expected    float64 `json:"expected"`
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+}
+
+// VelocityResult reports frequency anomalies for a transaction.
+type VelocityResult struct {
+	IsBurst bool `json:"is_burst"`
+	// BaselineRatePerHour is the account's historical rate; zero when the baseline is too short.
+	BaselineRatePerHour float64                `json:"baseline_rate_per_hour"`
+	Windows             []VelocityWindowResult `json:"windows"`
+	DormantReactivation bool                   `json:"dormant_reactivation"`
+	DormantHours        float64                `json:"dormant_hours"`
+}
+
+// Details returns the result as alert rule details.
+func (r *VelocityResult) Details() map[string]interface{} {
+	windows := make([]map[string]interface{}, len(r.Windows))
+	for i, w := range r.Windows {
+		windows[i] = map[string]interface{}{
+			"window":      w.Window,
+			"observed":    w.Observed,
+			"expected":    w.Expected,
+			"p_value":     w.PValue,
+			"significant": w.Significant,
+		}
+	}
+	return map[string]interface{}{
+		"baseline_rate_per_hour": r.BaselineRatePerHour,
+		"windows":                windows,
+		"dormant_reactivation":   r.DormantReactivation,
+		"dormant_hours":          r.DormantHours,
+	}
+}
+
+// DetectVelocityAnomaly compares the account's transaction counts in each configured window,
+// including currentTx, with the counts expected from its historical rate. recent must hold the
+// account's transactions covering the longest window; the profile must not yet include currentTx.
+func DetectVelocityAnomaly(currentTx models.Transaction, recent []models.Transaction, profile *models.AccountProfile, cfg config.VelocityConfig) (*VelocityResult, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid velocity config: %w", err)
+	}
+	if profile == nil {
+		return nil, fmt.Errorf("account profile is required for velocity detection")
+	}
+
+	windows, _ := cfg.GetWindows()
+	dormancy, _ := cfg.GetDormancyPeriod()
+	now := currentTx.Timestamp
+	result := &VelocityResult{}
+
+	if profile.TransactionCount > 0 && now.After(profile.LastSeen) {
+		result.DormantHours = now.Sub(profile.LastSeen).Hours()
+		result.DormantReactivation = now.Sub(profile.LastSeen) >= dormancy
+	}
+
+	if profile.GapCount < int64(cfg.MinHistory) {
+		return result, nil
+	}
+	rate := profile.RatePerHour()
+	result.BaselineRatePerHour = rate
+
+	for i, window := range windows {
+		observed := 1 // The current transaction
+		windowStart := now.Add(-window)
+		for _, tx := range recent {
+			if tx.AccountID == currentTx.AccountID && tx.TransactionID != currentTx.TransactionID &&
+				tx.Timestamp.After(windowStart) && !tx.Timestamp.After(now) {
+				observed++
+			}
+		}
+
+		expected := rate * window.Hours()
+		pValue := poissonUpperTail(observed, expected)
+		significant := observed >= cfg.MinCount && pValue < cfg.Alpha
+		result.Windows = append(result.Windows, VelocityWindowResult{
+			Window:      cfg.Windows[i],
+			Observed:    observed,
+			Expected:    expected,
+			PValue:      pValue,
+			Significant: significant,
+		})
+		if significant {
+			result.IsBurst = true
+		}
+	}
+
+	return result, nil
+}
+
+// poissonUpperTail returns P(X >= k) for X ~ Poisson(lambda). Above the mean it sums the tail
+// directly so that very small probabilities keep their precision; at or below the mean the tail
+// holds most of the mass, so it is 1 minus the lower tail.
+func poissonUpperTail(k int, lambda float64) float64 {
+	if k <= 0 {
+		return 1
+	}
+	if lambda <= 0 {
+		return 0
+	}
+
+	if float64(k) <= lambda {
+		var lower float64
+		for i := 0; i < k; i++ {
+			lower += poissonPMF(i, lambda)
+		}
+		return math.Max(1-lower, 0)
+	}
+
+	var sum float64
+	for i := k; ; i++ {
+		term := poissonPMF(i, lambda)
+		sum += term
+		if term <= sum*1e-15 {
+			break
+		}
+	}
+	return math.Min(sum, 1)
+}
+
+// poissonPMF returns P(X = i) for X ~ Poisson(lambda).
+func poissonPMF(i int, lambda float64) float64 {
+	lgamma, _ := math.Lgamma(float64(i + 1))
+	return math.Exp(float64(i)*math.Log(lambda) - lambda - lgamma)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"AML/internal/config"
+	"AML/internal/models"
+)
+
+func TestDetectVelocityAnomaly(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// An account that transacts roughly once a day for 30 days.
+	profile := NewAccountProfile("acc-1")
+	var history []models.Transaction
+	for i := 0; i < 30; i++ {
+		tx := models.Transaction{TransactionID: "h" + string(rune('a'+i)), AccountID: "acc-1", Amount: 50, Timestamp: start.Add(time.Duration(i) * 24 * time.Hour)}
+		history = append(history, tx)
+		UpdateAccountProfile(profile, tx)
+	}
+	last := profile.LastSeen
+	cfg := config.DefaultVelocityConfig()
+
+	// Test Case 1: Normal cadence
+	t.Run("normal_cadence", func(t *testing.T) {
+		currentTx := models.Transaction{TransactionID: "c1", AccountID: "acc-1", Timestamp: last.Add(24 * time.Hour)}
+		result, err := DetectVelocityAnomaly(currentTx, history, profile, cfg)
+		if err != nil {
+			t.Fatalf("DetectVelocityAnomaly failed: %v", err)
+		}
+		if result.IsBurst || result.DormantReactivation {
+			t.Errorf("Expected no velocity anomaly, got %+v", result)
+		}
+		if math.Abs(result.BaselineRatePerHour-1.0/24) > 1e-9 {
+			t.Errorf("Expected baseline rate 1/24, got %f", result.BaselineRatePerHour)
+		}
+		if len(result.Windows) != 3 {
+			t.Errorf("Expected 3 window results, got %d", len(result.Windows))
+		}
+	})
+
+	// Test Case 2: Burst of transactions within an hour
+	t.Run("burst_in_one_hour", func(t *testing.T) {
+		now := last.Add(24 * time.Hour)
+		recent := append([]models.Transaction(nil), history...)
+		for i := 1; i <= 5; i++ {
+			recent = append(recent, models.Transaction{TransactionID: "b" + string(rune('0'+i)), AccountID: "acc-1", Timestamp: now.Add(-time.Duration(i) * 5 * time.Minute)})
+		}
+		currentTx := models.Transaction{TransactionID: "c2", AccountID: "acc-1", Timestamp: now}
+		result, err := DetectVelocityAnomaly(currentTx, recent, profile, cfg)
+		if err != nil {
+			t.Fatalf("DetectVelocityAnomaly failed: %v", err)
+		}
+		if !result.IsBurst {
+			t.Fatalf("Expected burst, got %+v", result)
+		}
+		hourly := result.Windows[0]
+		if hourly.Observed != 6 || !hourly.Significant {
+			t.Errorf("Expected 6 significant transactions in 1h window, got %+v", hourly)
+		}
+	})
+
+	// Test Case 3: Dormant account reactivation
+	t.Run("dormant_reactivation", func(t *testing.T) {
+		currentTx := models.Transaction{TransactionID: "c3", AccountID: "acc-1", Timestamp: last.Add(120 * 24 * time.Hour)}
+		result, err := DetectVelocityAnomaly(currentTx, history, profile, cfg)
+		if err != nil {
+			t.Fatalf("DetectVelocityAnomaly failed: %v", err)
+		}
+		if !result.DormantReactivation {
+			t.Errorf("Expected dormant reactivation after 120 days")
+		}
+		if result.DormantHours != 120*24 {
+			t.Errorf("Expected 2880 dormant hours, got %f", result.DormantHours)
+		}
+	})
+
+	// Test Case 4: Short baseline skips burst testing
+	t.Run("insufficient_baseline", func(t *testing.T) {
+		young := NewAccountProfile("acc-2")
+		UpdateAccountProfile(young, models.Transaction{AccountID: "acc-2", Timestamp: start})
+		currentTx := models.Transaction{TransactionID: "c4", AccountID: "acc-2", Timestamp: start.Add(time.Minute)}
+		result, err := DetectVelocityAnomaly(currentTx, nil, young, cfg)
+		if err != nil {
+			t.Fatalf("DetectVelocityAnomaly failed: %v", err)
+		}
+		if result.IsBurst || len(result.Windows) != 0 {
+			t.Errorf("Expected no burst evaluation for a young account, got %+v", result)
+		}
+	})
+}
+
+func TestPoissonUpperTail(t *testing.T) {
+	if p := poissonUpperTail(0, 2); p != 1 {
+		t.Errorf("Expected P(X>=0) = 1, got %f", p)
+	}
+	// P(X >= 1) = 1 - e^-lambda
+	if p := poissonUpperTail(1, 2); math.Abs(p-(1-math.Exp(-2))) > 1e-12 {
+		t.Errorf("Expected %f, got %f", 1-math.Exp(-2), p)
+	}
+	if p := poissonUpperTail(30, 0.5); p <= 0 || p > 1e-20 {
+		t.Errorf("Expected a tiny but positive tail, got %g", p)
+	}
+	// Far below a large mean the tail is almost certain, not empty.
+	if p := poissonUpperTail(5, 20000); math.Abs(p-1) > 1e-12 {
+		t.Errorf("Expected P(X>=5) ~ 1 for lambda 20000, got %g", p)
+	}
+	// At a large mean the tail is about a half.
+	if p := poissonUpperTail(20000, 20000); p < 0.49 || p > 0.51 {
+		t.Errorf("Expected P(X>=20000) ~ 0.5 for lambda 20000, got %g", p)
+	}
+	if p := poissonUpperTail(21000, 20000); p <= 0 || p > 1e-9 {
+		t.Errorf("Expected a tiny but positive tail seven deviations above a large mean, got %g", p)
+	}
+}
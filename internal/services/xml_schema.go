@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// xmlNode is a parsed XML element, kept by local name so documents can be checked without their
+// namespaces.
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*xmlNode
+}
+
+// parseXMLTree parses a document into its root element.
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				node.attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) == 0 {
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			node.text = strings.TrimSpace(node.text)
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("failed to parse XML: no root element")
+	}
+	return root, nil
+}
+
+// find returns the elements at a slash-separated path of local names below the node.
+func (n *xmlNode) find(path string) []*xmlNode {
+	nodes := []*xmlNode{n}
+	for _, name := range strings.Split(path, "/") {
+		var next []*xmlNode
+		for _, node := range nodes {
+			for _, child := range node.children {
+				if child.name == name {
+					next = append(next, child)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// xmlElementRule constrains the occurrences and content of an element, as an XSD element
+// declaration would.
+type xmlElementRule struct {
+	// Path is the slash-separated path of local names from the root, e.g. "Activity/Party".
+	Path string
+	// MinOccurs and MaxOccurs bound how often the element appears in each parent; MaxOccurs zero
+	// means unbounded.
+	MinOccurs int
+	MaxOccurs int
+	// MaxLength limits the element's text; zero means unlimited.
+	MaxLength int
+	// Pattern, when set, must match the whole text.
+	Pattern *regexp.Regexp
+	// Enum, when set, lists the allowed values.
+	Enum []string
+	// Attrs names attributes the element must carry.
+	Attrs []string
+	// Choices lists groups of child elements of which exactly one must appear, as an XSD choice
+	// would.
+	Choices [][]string
+}
+
+// xmlSchema is a set of element rules for one document type.
+type xmlSchema struct {
+	root  string
+	rules []xmlElementRule
+}
+
+// validate checks a document against the schema and returns every problem found, each naming
+// the offending element.
+func (s xmlSchema) validate(data []byte) []string {
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if root.name != s.root {
+		return []string{fmt.Sprintf("root element is %s, expected %s", root.name, s.root)}
+	}
+
+	var problems []string
+	for _, rule := range s.rules {
+		parents := []*xmlNode{root}
+		name := rule.Path
+		if i := strings.LastIndex(rule.Path, "/"); i >= 0 {
+			parents = root.find(rule.Path[:i])
+			name = rule.Path[i+1:]
+		}
+
+		for _, parent := range parents {
+			elements := parent.find(name)
+			if len(elements) < rule.MinOccurs {
+				if len(elements) == 0 {
+					problems = append(problems, fmt.Sprintf("%s is missing", rule.Path))
+				} else {
+					problems = append(problems, fmt.Sprintf("%s occurs %d times, at least %d required", rule.Path, len(elements), rule.MinOccurs))
+				}
+			}
+			if rule.MaxOccurs > 0 && len(elements) > rule.MaxOccurs {
+				problems = append(problems, fmt.Sprintf("%s occurs %d times, at most %d allowed", rule.Path, len(elements), rule.MaxOccurs))
+			}
+			for _, el := range elements {
+				problems = append(problems, rule.check(el)...)
+			}
+		}
+	}
+	return problems
+}
+
+func (r xmlElementRule) check(el *xmlNode) []string {
+	var problems []string
+	for _, attr := range r.Attrs {
+		if el.attrs[attr] == "" {
+			problems = append(problems, fmt.Sprintf("%s is missing attribute %s", r.Path, attr))
+		}
+	}
+	for _, choice := range r.Choices {
+		n := 0
+		for _, name := range choice {
+			n += len(el.find(name))
+		}
+		if n != 1 {
+			problems = append(problems, fmt.Sprintf("%s must contain exactly one of %s", r.Path, strings.Join(choice, ", ")))
+		}
+	}
+	if r.MaxLength > 0 && len([]rune(el.text)) > r.MaxLength {
+		problems = append(problems, fmt.Sprintf("%s is longer than %d characters", r.Path, r.MaxLength))
+	}
+	if r.Pattern != nil && !r.Pattern.MatchString(el.text) {
+		problems = append(problems, fmt.Sprintf("%s value %q does not match %s", r.Path, el.text, r.Pattern))
+	}
+	if len(r.Enum) > 0 {
+		allowed := false
+		for _, v := range r.Enum {
+			if el.text == v {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			problems = append(problems, fmt.Sprintf("%s value %q is not one of %v", r.Path, el.text, r.Enum))
+		}
+	}
+	return problems
+}